@@ -35,6 +35,27 @@ type ComputeStack struct {
 	Config   bson.M `bson:"config,omitempty"`
 	Meta     bson.M `bson:"meta,omitempty"`
 	Title    string `bson:"title,omitempty"`
+
+	// Drift is a result of the last drift check, which compares
+	// the stack resources with the actual cloud state.
+	Drift *StackDrift `bson:"drift,omitempty"`
+}
+
+// StackDrift describes stack resources, which were changed
+// or removed outside of Koding.
+type StackDrift struct {
+	CheckedAt time.Time         `bson:"checkedAt" json:"checkedAt"`
+	Resources []DriftedResource `bson:"resources,omitempty" json:"resources,omitempty"`
+}
+
+// DriftedResource describes a single drifted machine resource.
+type DriftedResource struct {
+	MachineID bson.ObjectId     `bson:"machineId" json:"machineId"`
+	Label     string            `bson:"label" json:"label"`
+	Provider  string            `bson:"provider" json:"provider"`
+	Resource  string            `bson:"resource" json:"resource"` // e.g. aws_instance.vm
+	Reason    string            `bson:"reason" json:"reason"`     // either "missing" or "changed"
+	Changes   map[string]string `bson:"changes,omitempty" json:"changes,omitempty"`
 }
 
 func (c *ComputeStack) State() stackstate.State {
@@ -0,0 +1,48 @@
+package models
+
+// Quota describes limits of resources team members can create,
+// stored under jGroupData.payload.quota.
+//
+// Zero value of a limit means there is no limit.
+type Quota struct {
+	// MaxMachines limits number of machines a single team
+	// member can own.
+	MaxMachines int `bson:"maxMachines,omitempty" json:"maxMachines,omitempty"`
+
+	// MaxCPU, MaxMemory and MaxDisk limit total resources
+	// of all machines a single team member owns.
+	MaxCPU    int     `bson:"maxCpu,omitempty" json:"maxCpu,omitempty"`       // vCPUs
+	MaxMemory float64 `bson:"maxMemory,omitempty" json:"maxMemory,omitempty"` // in GB
+	MaxDisk   int     `bson:"maxDisk,omitempty" json:"maxDisk,omitempty"`     // in GB
+
+	// InstanceTypes and Regions, when non-empty, are the only
+	// instance types and regions allowed to be used.
+	InstanceTypes []string `bson:"instanceTypes,omitempty" json:"instanceTypes,omitempty"`
+	Regions       []string `bson:"regions,omitempty" json:"regions,omitempty"`
+
+	// Overrides are usernames of team members, which are
+	// not subject to the quota.
+	Overrides []string `bson:"overrides,omitempty" json:"overrides,omitempty"`
+}
+
+// IsOverridden returns true if the quota does not apply
+// to the given user.
+func (q *Quota) IsOverridden(username string) bool {
+	for _, s := range q.Overrides {
+		if s == username {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MachineSpec describes resources of a machine, which are counted
+// against a quota. It is stored under jMachine.meta.spec.
+type MachineSpec struct {
+	InstanceType string  `bson:"instanceType" json:"instanceType"`
+	Region       string  `bson:"region" json:"region"`
+	DiskSize     int     `bson:"diskSize" json:"diskSize"`                 // in GB
+	CPU          int     `bson:"cpu,omitempty" json:"cpu,omitempty"`       // vCPUs, zero if not known
+	Memory       float64 `bson:"memory,omitempty" json:"memory,omitempty"` // in GB, zero if not known
+}
@@ -75,3 +75,16 @@ func NewStackTemplate(provider, identifier string) *StackTemplate {
 	}
 
 }
+
+// StackTemplateRevision is a document from jStackTemplateRevisions
+// collection. It holds a snapshot of jStackTemplate.template
+// content, created each time the content has changed.
+type StackTemplateRevision struct {
+	Id         bson.ObjectId `bson:"_id" json:"-"`
+	TemplateID bson.ObjectId `bson:"templateId"`
+	Revision   int           `bson:"revision"`
+	Sum        string        `bson:"sum"`
+	Content    string        `bson:"content"`
+	RawContent string        `bson:"rawContent"`
+	CreatedAt  time.Time     `bson:"createdAt"`
+}
@@ -98,6 +98,15 @@ func SetStackState(id, reason string, state stackstate.State) error {
 	return Mongo.Run(ComputeStackColl, query)
 }
 
+// SetStackDrift stores result of a drift check for the given stack.
+func SetStackDrift(id bson.ObjectId, drift *models.StackDrift) error {
+	query := func(c *mgo.Collection) error {
+		return c.UpdateId(id, bson.M{"$set": bson.M{"drift": drift}})
+	}
+
+	return Mongo.Run(ComputeStackColl, query)
+}
+
 func CreateComputeStack(stack *models.ComputeStack) error {
 	query := insertQuery(stack)
 	return Mongo.Run(ComputeStackColl, query)
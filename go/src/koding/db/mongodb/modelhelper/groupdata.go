@@ -70,3 +70,111 @@ func FetchCountlyInfo(slug string) (*models.Countly, error) {
 	}
 	return res.Payload.Countly, nil
 }
+
+// GetGroupQuota gets the quota of the given group.
+//
+// If the group has no quota, the function returns nil.
+func GetGroupQuota(slug string) (*models.Quota, error) {
+	var res struct {
+		Payload struct {
+			Quota *models.Quota `bson:"quota"`
+		} `bson:"payload"`
+	}
+
+	switch err := GetGroupDataPath(slug, "quota", &res); err {
+	case nil:
+		return res.Payload.Quota, nil
+	case mgo.ErrNotFound:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// AddQuotaOverride exempts the given user from the group quota.
+func AddQuotaOverride(slug, username string) error {
+	op := func(c *mgo.Collection) error {
+		_, err := c.Upsert(
+			bson.M{"slug": slug},
+			bson.M{"$addToSet": bson.M{"payload.quota.overrides": username}},
+		)
+		return err
+	}
+
+	return Mongo.Run(GroupDataColl, op)
+}
+
+// RemoveQuotaOverride makes the given user subject to the group quota again.
+func RemoveQuotaOverride(slug, username string) error {
+	op := func(c *mgo.Collection) error {
+		err := c.Update(
+			bson.M{"slug": slug},
+			bson.M{"$pull": bson.M{"payload.quota.overrides": username}},
+		)
+		if err == mgo.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	return Mongo.Run(GroupDataColl, op)
+}
+
+// quotaLimits are the payload.quota fields, which limit
+// resources of team members.
+var quotaLimits = []string{
+	"maxMachines",
+	"maxCpu",
+	"maxMemory",
+	"maxDisk",
+	"instanceTypes",
+	"regions",
+}
+
+// SetQuotaLimits replaces the limits of the group quota with
+// the given ones. Zero limits are removed. Quota overrides
+// are left untouched.
+func SetQuotaLimits(slug string, q *models.Quota) error {
+	p, err := bson.Marshal(q)
+	if err != nil {
+		return err
+	}
+
+	var fields bson.M
+	if err := bson.Unmarshal(p, &fields); err != nil {
+		return err
+	}
+
+	set, unset := bson.M{}, bson.M{}
+
+	for _, name := range quotaLimits {
+		if v, ok := fields[name]; ok {
+			set["payload.quota."+name] = v
+		} else {
+			unset["payload.quota."+name] = ""
+		}
+	}
+
+	change := bson.M{}
+
+	if len(set) != 0 {
+		change["$set"] = set
+	}
+
+	if len(unset) != 0 {
+		change["$unset"] = unset
+	}
+
+	op := func(c *mgo.Collection) error {
+		_, err := c.Upsert(bson.M{"slug": slug}, change)
+		return err
+	}
+
+	return Mongo.Run(GroupDataColl, op)
+}
+
+// RemoveQuotaLimits removes all limits of the group quota.
+// Quota overrides are left untouched.
+func RemoveQuotaLimits(slug string) error {
+	return SetQuotaLimits(slug, &models.Quota{})
+}
@@ -4,6 +4,7 @@ import (
 	"koding/db/models"
 	"koding/db/mongodb/modelhelper"
 	"koding/db/mongodb/modelhelper/modeltesthelper"
+	"reflect"
 	"testing"
 
 	"gopkg.in/mgo.v2/bson"
@@ -70,3 +71,45 @@ func TestGroupData(t *testing.T) {
 		t.Fatalf("res = %v, want %v", res, updateVal)
 	}
 }
+
+func TestQuotaLimits(t *testing.T) {
+	db := modeltesthelper.NewMongoDB(t)
+	defer db.Close()
+
+	slug := bson.NewObjectId().Hex()
+
+	if err := modelhelper.AddQuotaOverride(slug, "user"); err != nil {
+		t.Fatalf("AddQuotaOverride() = %v, want %v", err, nil)
+	}
+
+	if err := modelhelper.SetQuotaLimits(slug, &models.Quota{MaxMachines: 2, Regions: []string{"us-east-1"}}); err != nil {
+		t.Fatalf("SetQuotaLimits() = %v, want %v", err, nil)
+	}
+
+	if err := modelhelper.SetQuotaLimits(slug, &models.Quota{MaxCPU: 4}); err != nil {
+		t.Fatalf("SetQuotaLimits() = %v, want %v", err, nil)
+	}
+
+	q, err := modelhelper.GetGroupQuota(slug)
+	if err != nil {
+		t.Fatalf("GetGroupQuota() = %v, want %v", err, nil)
+	}
+
+	want := &models.Quota{MaxCPU: 4, Overrides: []string{"user"}}
+	if !reflect.DeepEqual(q, want) {
+		t.Fatalf("quota = %+v, want %+v", q, want)
+	}
+
+	if err := modelhelper.RemoveQuotaLimits(slug); err != nil {
+		t.Fatalf("RemoveQuotaLimits() = %v, want %v", err, nil)
+	}
+
+	if q, err = modelhelper.GetGroupQuota(slug); err != nil {
+		t.Fatalf("GetGroupQuota() = %v, want %v", err, nil)
+	}
+
+	want = &models.Quota{Overrides: []string{"user"}}
+	if !reflect.DeepEqual(q, want) {
+		t.Fatalf("quota = %+v, want %+v", q, want)
+	}
+}
@@ -230,6 +230,36 @@ func GetOwnGroupMachines(userId bson.ObjectId, group *models.Group) ([]*MachineC
 	return findMachineContainers(query)
 }
 
+// GetOwnGroupMachinesExcept gives machines, which the given user
+// owns within the group. Machines with the exclude IDs are omitted.
+func GetOwnGroupMachinesExcept(username string, group *models.Group, exclude ...bson.ObjectId) ([]*models.Machine, error) {
+	query := bson.M{
+		"users": bson.M{
+			"$elemMatch": bson.M{
+				"username": username,
+				"owner":    true,
+			},
+		},
+		"groups": bson.M{
+			"$elemMatch": bson.M{
+				"id": group.Id,
+			},
+		},
+	}
+
+	if len(exclude) != 0 {
+		query["_id"] = bson.M{"$nin": exclude}
+	}
+
+	var machines []*models.Machine
+
+	err := Mongo.Run(MachinesColl, func(c *mgo.Collection) error {
+		return c.Find(query).All(&machines)
+	})
+
+	return machines, err
+}
+
 func GetSharedMachines(userId bson.ObjectId) ([]*MachineContainer, error) {
 	query := bson.M{
 		"users": bson.M{
@@ -433,6 +463,50 @@ func UpdateMachineAlwaysOn(machineId bson.ObjectId, alwaysOn bool) error {
 	return Mongo.Run(MachinesColl, query)
 }
 
+// UpdateMachineSchedule sets meta.schedule of the given machine. If the
+// schedule is nil, it is removed instead.
+func UpdateMachineSchedule(machineId bson.ObjectId, schedule interface{}) error {
+	return UpdateMachine(machineId, scheduleChange(schedule))
+}
+
+// UpdateGroupMachineSchedule sets machineSchedule of the given group,
+// which is used by team machines that have no schedule of their own.
+// If the schedule is nil, team schedule is removed instead.
+//
+// It returns the number of team machines, which use the team schedule.
+func UpdateGroupMachineSchedule(group *models.Group, schedule interface{}) (int, error) {
+	change := Selector{"$set": bson.M{"machineSchedule": schedule}}
+
+	if schedule == nil {
+		change = Selector{"$unset": bson.M{"machineSchedule": ""}}
+	}
+
+	if err := UpdateGroupPartial(Selector{"_id": group.Id}, change); err != nil {
+		return 0, err
+	}
+
+	var n int
+
+	query := func(c *mgo.Collection) (err error) {
+		n, err = c.Find(bson.M{
+			"groups.id":     group.Id,
+			"meta.schedule": bson.M{"$exists": false},
+		}).Count()
+
+		return err
+	}
+
+	return n, Mongo.Run(MachinesColl, query)
+}
+
+func scheduleChange(schedule interface{}) bson.M {
+	if schedule == nil {
+		return bson.M{"$unset": bson.M{"meta.schedule": ""}}
+	}
+
+	return bson.M{"$set": bson.M{"meta.schedule": schedule}}
+}
+
 func UpdateMachine(machineId bson.ObjectId, change interface{}) error {
 	query := func(c *mgo.Collection) error {
 		return c.UpdateId(machineId, change)
@@ -3,6 +3,7 @@ package modelhelper
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"koding/db/models"
 
@@ -87,7 +88,108 @@ func HasTemplateAccess(tmpl *models.StackTemplate, username string) error {
 	return nil
 }
 
+const StackTemplateRevisionColl = "jStackTemplateRevisions"
+
+// CreateStackTemplate inserts the stack template and records
+// its content as the first revision.
 func CreateStackTemplate(tmpl *models.StackTemplate) error {
 	query := insertQuery(tmpl)
-	return Mongo.Run(StackTemplateColl, query)
+
+	if err := Mongo.Run(StackTemplateColl, query); err != nil {
+		return err
+	}
+
+	_, err := AddStackTemplateRevision(tmpl)
+	return err
+}
+
+// AddStackTemplateRevision stores current content of the given stack template
+// as a new revision, unless it is equal to the latest revision stored.
+// It is meant to be called each time the template content is changed.
+//
+// The jStackTemplateRevisions collection has a unique index on
+// templateId and revision, thus concurrent calls never store
+// the same revision number twice.
+//
+// It returns the latest revision of the template.
+func AddStackTemplateRevision(tmpl *models.StackTemplate) (*models.StackTemplateRevision, error) {
+	const maxRetries = 5
+
+	for i := 0; ; i++ {
+		revs, err := lastStackTemplateRevision(tmpl.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(revs) != 0 && revs[0].Sum == tmpl.Template.Sum {
+			return revs[0], nil
+		}
+
+		rev := NewStackTemplateRevision(tmpl, revs)
+
+		switch err := Mongo.Run(StackTemplateRevisionColl, insertQuery(rev)); {
+		case err == nil:
+			return rev, nil
+		case mgo.IsDup(err) && i < maxRetries:
+			// Revision was added concurrently, try again.
+		default:
+			return nil, err
+		}
+	}
+}
+
+// NewStackTemplateRevision gives a revision of the current content of
+// the stack template, which follows the latest of the given revisions.
+func NewStackTemplateRevision(tmpl *models.StackTemplate, revs []*models.StackTemplateRevision) *models.StackTemplateRevision {
+	rev := &models.StackTemplateRevision{
+		Id:         bson.NewObjectId(),
+		TemplateID: tmpl.Id,
+		Revision:   1,
+		Sum:        tmpl.Template.Sum,
+		Content:    tmpl.Template.Content,
+		RawContent: tmpl.Template.RawContent,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	for _, r := range revs {
+		if r.Revision >= rev.Revision {
+			rev.Revision = r.Revision + 1
+		}
+	}
+
+	return rev
+}
+
+func lastStackTemplateRevision(id bson.ObjectId) ([]*models.StackTemplateRevision, error) {
+	var revs []*models.StackTemplateRevision
+
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"templateId": id}).Sort("-revision").Limit(1).All(&revs)
+	}
+
+	if err := Mongo.Run(StackTemplateRevisionColl, query); err != nil {
+		return nil, err
+	}
+
+	return revs, nil
+}
+
+// GetStackTemplateRevisions gives all revisions of the given stack template,
+// sorted from the oldest to the latest one.
+func GetStackTemplateRevisions(id string) ([]*models.StackTemplateRevision, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, fmt.Errorf("Not valid ObjectIdHex: '%s'", id)
+	}
+
+	var revs []*models.StackTemplateRevision
+
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"templateId": bson.ObjectIdHex(id)}).Sort("revision").All(&revs)
+	}
+
+	if err := Mongo.Run(StackTemplateRevisionColl, query); err != nil {
+		return nil, err
+	}
+
+	return revs, nil
 }
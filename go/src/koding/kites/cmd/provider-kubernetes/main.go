@@ -0,0 +1,13 @@
+package main
+
+import (
+	"koding/kites/terraformplugins/kubernetes"
+
+	"github.com/hashicorp/terraform/plugin"
+)
+
+func main() {
+	plugin.Serve(&plugin.ServeOpts{
+		ProviderFunc: kubernetes.Provider,
+	})
+}
@@ -0,0 +1,167 @@
+// Package keyring provides AES-256 key encryption keys, which are
+// used for envelope encryption of data kept at rest.
+//
+// Each payload is encrypted with its own random data key, which is
+// then encrypted (wrapped) with a key encryption key (KEK). Rotating
+// keys requires re-wrapping data keys only, payloads are left intact.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// KeySize is a size of both key encryption keys and data keys.
+const KeySize = 32
+
+// Keyring is a set of AES-256 key encryption keys.
+//
+// New data keys are always wrapped with the Primary key, while
+// the other keys are used to unwrap data keys wrapped before
+// rotation.
+type Keyring struct {
+	Primary string
+	Keys    map[string][]byte
+}
+
+// Parse parses a keyring given in the following format:
+//
+//	id1:base64key1,id2:base64key2
+//
+// The first key is the primary one. In order to rotate keys,
+// a new key is prepended to the list.
+func Parse(s string) (*Keyring, error) {
+	kr := &Keyring{
+		Keys: make(map[string][]byte),
+	}
+
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+
+		kv := strings.SplitN(field, ":", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid key %q: expected id:base64key", field)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q key: %s", kv[0], err)
+		}
+
+		if _, ok := kr.Keys[kv[0]]; ok {
+			return nil, fmt.Errorf("duplicate %q key", kv[0])
+		}
+
+		if kr.Primary == "" {
+			kr.Primary = kv[0]
+		}
+
+		kr.Keys[kv[0]] = key
+	}
+
+	if err := kr.Valid(); err != nil {
+		return nil, err
+	}
+
+	return kr, nil
+}
+
+// Valid ensures the keyring has a primary key and all
+// the keys are AES-256 ones.
+func (kr *Keyring) Valid() error {
+	if kr.Primary == "" {
+		return errors.New("keyring has no primary key")
+	}
+
+	if _, ok := kr.Keys[kr.Primary]; !ok {
+		return fmt.Errorf("keyring is missing primary %q key", kr.Primary)
+	}
+
+	for id, key := range kr.Keys {
+		if len(key) != KeySize {
+			return fmt.Errorf("%q key has invalid length %d, expected %d", id, len(key), KeySize)
+		}
+	}
+
+	return nil
+}
+
+// NewDataKey generates a new data key. It returns the key
+// and its copy wrapped with the primary key.
+func (kr *Keyring) NewDataKey() (key, wrapped []byte, err error) {
+	key = make([]byte, KeySize)
+
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+
+	if wrapped, err = kr.Wrap(key); err != nil {
+		return nil, nil, err
+	}
+
+	return key, wrapped, nil
+}
+
+// Wrap encrypts the data key with the primary key.
+func (kr *Keyring) Wrap(key []byte) ([]byte, error) {
+	return Seal(kr.Keys[kr.Primary], key)
+}
+
+// Unwrap decrypts the data key, which was wrapped with
+// the given key encryption key.
+func (kr *Keyring) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := kr.Keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown %q key", keyID)
+	}
+
+	return Open(kek, wrapped)
+}
+
+// Seal encrypts the payload with AES-GCM, using the given key.
+// The random nonce is prepended to the ciphertext.
+func Seal(key, p []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, p, nil), nil
+}
+
+// Open decrypts the ciphertext created by Seal.
+func Open(key, p []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return gcm.Open(nil, p[:gcm.NonceSize()], p[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
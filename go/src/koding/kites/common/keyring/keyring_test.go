@@ -0,0 +1,79 @@
+package keyring_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"koding/kites/common/keyring"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keyring.KeySize))
+}
+
+func TestParse(t *testing.T) {
+	kr, err := keyring.Parse("new:" + key(2) + ", old:" + key(1))
+	if err != nil {
+		t.Fatalf("Parse()=%s", err)
+	}
+
+	if kr.Primary != "new" || len(kr.Keys) != 2 {
+		t.Fatalf("got %q primary key and %d keys, want %q and 2", kr.Primary, len(kr.Keys), "new")
+	}
+
+	cases := map[string]string{
+		"empty":     "",
+		"no id":     key(1),
+		"short key": "a:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"duplicate": "a:" + key(1) + ",a:" + key(2),
+	}
+
+	for name, s := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := keyring.Parse(s); err == nil {
+				t.Fatal("expected Parse() to fail")
+			}
+		})
+	}
+}
+
+func TestDataKey(t *testing.T) {
+	old, err := keyring.Parse("old:" + key(1))
+	if err != nil {
+		t.Fatalf("Parse()=%s", err)
+	}
+
+	rotated, err := keyring.Parse("new:" + key(2) + ",old:" + key(1))
+	if err != nil {
+		t.Fatalf("Parse()=%s", err)
+	}
+
+	dataKey, wrapped, err := old.NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey()=%s", err)
+	}
+
+	p, err := keyring.Seal(dataKey, []byte("payload"))
+	if err != nil {
+		t.Fatalf("Seal()=%s", err)
+	}
+
+	// Data key wrapped before rotation is unwrapped with the old key.
+	unwrapped, err := rotated.Unwrap("old", wrapped)
+	if err != nil {
+		t.Fatalf("Unwrap()=%s", err)
+	}
+
+	if p, err = keyring.Open(unwrapped, p); err != nil || string(p) != "payload" {
+		t.Fatalf("got %q, %v; want %q, nil", p, err, "payload")
+	}
+
+	if _, err := rotated.Unwrap("new", wrapped); err == nil {
+		t.Fatal("expected Unwrap() to fail with other key")
+	}
+
+	if _, err := old.Unwrap("new", wrapped); err == nil {
+		t.Fatal("expected Unwrap() to fail with unknown key")
+	}
+}
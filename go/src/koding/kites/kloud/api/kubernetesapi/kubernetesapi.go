@@ -0,0 +1,246 @@
+// Package kubernetesapi provides a minimal client for the Kubernetes API,
+// which is shared by kloud and the kubernetes terraform provider.
+package kubernetesapi
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the requested object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Config is a cluster access configuration.
+type Config struct {
+	Server    string
+	Namespace string
+	Insecure  bool
+
+	// PEM-encoded certificates and key.
+	CA   string
+	Cert string
+	Key  string
+
+	Token    string
+	Username string
+	Password string
+}
+
+// Deployment represents a subset of apps/v1 Deployment fields.
+type Deployment struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+		Selector struct {
+			MatchLabels map[string]string `json:"matchLabels"`
+		} `json:"selector"`
+	} `json:"spec"`
+	Status struct {
+		Replicas      int `json:"replicas"`
+		ReadyReplicas int `json:"readyReplicas"`
+	} `json:"status"`
+}
+
+// Pod represents a subset of v1 Pod fields.
+type Pod struct {
+	Metadata struct {
+		Name              string     `json:"name"`
+		DeletionTimestamp *time.Time `json:"deletionTimestamp"`
+	} `json:"metadata"`
+	Status struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+// Client is a minimal Kubernetes API client.
+type Client struct {
+	Config     *Config
+	HTTPClient *http.Client
+}
+
+// NewClient gives new client for the given configuration.
+func NewClient(cfg *Config) (*Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.Insecure,
+	}
+
+	if cfg.CA != "" {
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM([]byte(cfg.CA)) {
+			return nil, errors.New("invalid certificate authority")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.Cert != "" || cfg.Key != "" {
+		cert, err := tls.X509KeyPair([]byte(cfg.Cert), []byte(cfg.Key))
+		if err != nil {
+			return nil, errors.New("invalid client certificate: " + err.Error())
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &Client{
+		Config: cfg,
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// Version requests server version, it is used to check
+// whether the cluster is accessible.
+func (c *Client) Version() error {
+	return c.do("GET", "/version", nil, nil)
+}
+
+// Deployment gives a deployment with the given name.
+func (c *Client) Deployment(namespace, name string) (*Deployment, error) {
+	var d Deployment
+
+	if err := c.do("GET", deploymentPath(namespace, name), nil, &d); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// CreateDeployment creates the given deployment object.
+func (c *Client) CreateDeployment(namespace string, d interface{}) error {
+	return c.do("POST", deploymentPath(namespace, ""), d, nil)
+}
+
+// ReplaceDeployment replaces a deployment with the given name
+// with the given object.
+func (c *Client) ReplaceDeployment(namespace, name string, d interface{}) error {
+	return c.do("PUT", deploymentPath(namespace, name), d, nil)
+}
+
+// DeleteDeployment deletes a deployment with the given name
+// together with its pods.
+func (c *Client) DeleteDeployment(namespace, name string) error {
+	return c.do("DELETE", deploymentPath(namespace, name)+"?propagationPolicy=Background", nil, nil)
+}
+
+// ScaleDeployment sets number of replicas of the given deployment.
+func (c *Client) ScaleDeployment(namespace, name string, replicas int) error {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+		},
+	}
+
+	return c.do("PATCH", deploymentPath(namespace, name), patch, nil)
+}
+
+// Pods lists pods, which match the given labels.
+func (c *Client) Pods(namespace string, labels map[string]string) ([]Pod, error) {
+	var list struct {
+		Items []Pod `json:"items"`
+	}
+
+	path := "/api/v1/namespaces/" + namespace + "/pods?labelSelector=" + url.QueryEscape(labelSelector(labels))
+
+	if err := c.do("GET", path, nil, &list); err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+func (c *Client) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+
+	if in != nil {
+		p, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(p)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(c.Config.Server, "/")+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	switch {
+	case method == "PATCH":
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	case body != nil:
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	switch {
+	case c.Config.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Config.Token)
+	case c.Config.Username != "":
+		req.SetBasicAuth(c.Config.Username, c.Config.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		p, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, bytes.TrimSpace(p))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func deploymentPath(namespace, name string) string {
+	path := "/apis/apps/v1/namespaces/" + namespace + "/deployments"
+
+	if name != "" {
+		path += "/" + name
+	}
+
+	return path
+}
+
+func labelSelector(labels map[string]string) string {
+	selector := make([]string, 0, len(labels))
+
+	for k, v := range labels {
+		selector = append(selector, k+"="+v)
+	}
+
+	sort.Strings(selector)
+
+	return strings.Join(selector, ",")
+}
@@ -16,6 +16,9 @@ type Openstack struct {
 	Provider string
 	Client   gophercloud.CloudServersProvider
 
+	access gophercloud.AccessProvider
+	api    gophercloud.ApiCriteria
+
 	Creds struct {
 		Username   string `mapstructure:"username"`
 		Password   string `mapstructure:"password"`
@@ -117,6 +120,8 @@ func New(authURL, providerName string, credential, builder map[string]interface{
 		return nil, err
 	}
 	o.Client = csp
+	o.access = access
+	o.api = api
 
 	return o, nil
 }
@@ -3,6 +3,7 @@ package openstack
 import (
 	"encoding/json"
 	"errors"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -69,3 +70,51 @@ func (o *Openstack) Server() (*gophercloud.Server, error) {
 
 	return nil, err
 }
+
+// StartServer powers on the server.
+func (o *Openstack) StartServer() error {
+	return o.serverAction(map[string]interface{}{"os-start": nil})
+}
+
+// StopServer powers off the server.
+func (o *Openstack) StopServer() error {
+	return o.serverAction(map[string]interface{}{"os-stop": nil})
+}
+
+// serverAction requests the given action for the server. The servers
+// API of gophercloud has no start and stop actions, thus they are
+// posted directly to the compute endpoint.
+func (o *Openstack) serverAction(action interface{}) error {
+	if o.Id() == "" {
+		return errors.New("Server id is empty")
+	}
+
+	endpoint := o.access.FirstEndpointUrlByCriteria(o.api)
+	if endpoint == "" {
+		return errors.New("compute endpoint is not found")
+	}
+
+	post := func() error {
+		return perigee.Post(strings.TrimRight(endpoint, "/")+"/servers/"+o.Id()+"/action", perigee.Options{
+			ReqBody: action,
+			OkCodes: []int{http.StatusAccepted},
+			MoreHeaders: map[string]string{
+				"X-Auth-Token": o.access.AuthToken(),
+			},
+		})
+	}
+
+	err := post()
+
+	// The token may have been revoked or expired, try
+	// once again with a new one.
+	if e, ok := err.(*perigee.UnexpectedResponseCodeError); ok && e.Actual == http.StatusUnauthorized {
+		if err := o.access.Reauthenticate(); err != nil {
+			return err
+		}
+
+		err = post()
+	}
+
+	return err
+}
@@ -0,0 +1,122 @@
+package credential
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"koding/kites/common/keyring"
+)
+
+// Envelope represents credential data encrypted at rest.
+//
+// Each payload is encrypted with its own random data key, which
+// is then wrapped with a key encryption key (KEK) of a keyring,
+// identified by KeyID.
+type Envelope struct {
+	KeyID string `json:"keyId"`
+	Key   []byte `json:"key"`  // data key wrapped with KEK
+	Data  []byte `json:"data"` // payload encrypted with data key
+}
+
+// sealEnvelope encrypts the given payload with a new data key.
+func sealEnvelope(kr *keyring.Keyring, p []byte) (*Envelope, error) {
+	dataKey, wrapped, err := kr.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := keyring.Seal(dataKey, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		KeyID: kr.Primary,
+		Key:   wrapped,
+		Data:  data,
+	}, nil
+}
+
+// openEnvelope decrypts payload of the given envelope.
+func openEnvelope(kr *keyring.Keyring, env *Envelope) ([]byte, error) {
+	dataKey, err := kr.Unwrap(env.KeyID, env.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyring.Open(dataKey, env.Data)
+}
+
+// rewrap wraps data key of the envelope with the primary key.
+//
+// It returns false if the envelope is already wrapped with
+// the primary key.
+func rewrap(kr *keyring.Keyring, env *Envelope) (bool, error) {
+	if env.KeyID == kr.Primary {
+		return false, nil
+	}
+
+	dataKey, err := kr.Unwrap(env.KeyID, env.Key)
+	if err != nil {
+		return false, err
+	}
+
+	key, err := kr.Wrap(dataKey)
+	if err != nil {
+		return false, err
+	}
+
+	env.KeyID = kr.Primary
+	env.Key = key
+
+	return true, nil
+}
+
+// sealCred encodes the given credential data and encrypts it.
+func sealCred(kr *keyring.Keyring, ident string, data interface{}) (*Envelope, error) {
+	if v, ok := data.(validator); ok {
+		if err := v.Valid(); err != nil {
+			return nil, fmt.Errorf("%q: failed validating data: %s", ident, err)
+		}
+	}
+
+	p, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%q: unable to encode: %s", ident, err)
+	}
+
+	return sealEnvelope(kr, p)
+}
+
+// openCred decrypts credential data and decodes it into v.
+//
+// If v is nil, the data is decoded into a generic value,
+// which is returned.
+func openCred(kr *keyring.Keyring, ident string, env *Envelope, v interface{}) (interface{}, error) {
+	p, err := openEnvelope(kr, env)
+	if err != nil {
+		return nil, fmt.Errorf("%q: unable to decrypt: %s", ident, err)
+	}
+
+	if v == nil {
+		var data interface{}
+
+		if err := json.Unmarshal(p, &data); err != nil {
+			return nil, fmt.Errorf("%q: failed decoding data: %s", ident, err)
+		}
+
+		return data, nil
+	}
+
+	if err := json.Unmarshal(p, v); err != nil {
+		return nil, fmt.Errorf("%q: failed decoding data: %s", ident, err)
+	}
+
+	if validator, ok := v.(validator); ok {
+		if err := validator.Valid(); err != nil {
+			return nil, fmt.Errorf("%q: failed validating data: %s", ident, err)
+		}
+	}
+
+	return v, nil
+}
@@ -0,0 +1,202 @@
+package credential
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// fileStore implements fetching/updating credential data values
+// from a local directory.
+//
+// Each credential data is kept encrypted in an envelope under
+// the <dir>/<identifier>.json file.
+type fileStore struct {
+	*Options
+}
+
+var (
+	_ Store   = (*fileStore)(nil)
+	_ Rotator = (*fileStore)(nil)
+)
+
+func (fs *fileStore) Fetch(_ string, creds map[string]interface{}) error {
+	var missing []string
+
+	for ident, data := range creds {
+		env, err := fs.read(ident)
+		if err != nil {
+			fs.Log.Debug("failed to fetch credential data for %q: %s", ident, err)
+
+			missing = append(missing, ident)
+			continue
+		}
+
+		v, err := openCred(fs.Keyring, ident, env, data)
+		if err != nil {
+			fs.Log.Warning("failed to open credential data for %q: %s", ident, err)
+
+			missing = append(missing, ident)
+			continue
+		}
+
+		if data == nil {
+			creds[ident] = v
+		}
+
+		fs.Log.Debug("fetched credential data for %q", ident)
+	}
+
+	if len(missing) != 0 {
+		return &NotFoundError{
+			Identifiers: missing,
+		}
+	}
+
+	return nil
+}
+
+func (fs *fileStore) Put(_ string, creds map[string]interface{}) error {
+	var err error
+
+	for ident, data := range creds {
+		env, e := sealCred(fs.Keyring, ident, data)
+		if e == nil {
+			e = fs.write(ident, env)
+		}
+
+		if e != nil {
+			fs.Log.Debug("failed to put credential data for %q: %s", ident, e)
+
+			err = multierror.Append(err, e)
+			continue
+		}
+
+		fs.Log.Debug("put credential data for %q", ident)
+	}
+
+	return err
+}
+
+// Rotate implements the Rotator interface.
+func (fs *fileStore) Rotate() (int, error) {
+	files, err := filepath.Glob(filepath.Join(fs.Dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+
+	for _, file := range files {
+		ident := strings.TrimSuffix(filepath.Base(file), ".json")
+
+		env, e := fs.read(ident)
+		if e != nil {
+			err = multierror.Append(err, e)
+			continue
+		}
+
+		ok, e := rewrap(fs.Keyring, env)
+		if e != nil {
+			err = multierror.Append(err, fmt.Errorf("%q: %s", ident, e))
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if e := fs.write(ident, env); e != nil {
+			err = multierror.Append(err, e)
+			continue
+		}
+
+		n++
+	}
+
+	return n, err
+}
+
+func (fs *fileStore) read(ident string) (*Envelope, error) {
+	file, err := fs.file(ident)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var env Envelope
+
+	if err := json.Unmarshal(p, &env); err != nil {
+		return nil, fmt.Errorf("%q: failed decoding envelope: %s", ident, err)
+	}
+
+	return &env, nil
+}
+
+// write writes the envelope atomically, so a concurrent read
+// never sees partially written file.
+func (fs *fileStore) write(ident string, env *Envelope) error {
+	file, err := fs.file(ident)
+	if err != nil {
+		return err
+	}
+
+	p, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(fs.Dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(fs.Dir, ".cred")
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(p); err == nil {
+		err = f.Sync()
+	}
+
+	if e := f.Close(); e != nil && err == nil {
+		err = e
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("%q: unable to write: %s", ident, err)
+	}
+
+	return nil
+}
+
+func (fs *fileStore) file(ident string) (string, error) {
+	if err := validIdent(ident); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(fs.Dir, ident+".json"), nil
+}
+
+func (opts *Options) validFile() error {
+	if opts.Dir == "" {
+		return errors.New("credential directory is empty")
+	}
+
+	return opts.validKeyring()
+}
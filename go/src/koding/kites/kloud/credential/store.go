@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 
 	"koding/db/mongodb"
+	"koding/kites/common/keyring"
 	"koding/kites/kloud/utils/object"
 
 	"github.com/hashicorp/go-multierror"
@@ -70,6 +72,16 @@ type Store interface {
 	Putter
 }
 
+// Rotator is implemented by stores, which keep credential data
+// encrypted with a keyring.
+type Rotator interface {
+	// Rotate re-wraps data keys of all stored credentials,
+	// which are not wrapped with the primary key.
+	//
+	// It returns number of credentials that were updated.
+	Rotate() (int, error)
+}
+
 // Options are used to alter default behavior of credential store
 // implementations.
 type Options struct {
@@ -78,6 +90,19 @@ type Options struct {
 	CredURL       *url.URL
 	ObjectBuilder *object.Builder
 	Client        *http.Client
+
+	// Keyring is used to encrypt credential data kept
+	// in vault and file stores.
+	Keyring *keyring.Keyring
+
+	// Vault store configuration.
+	VaultURL    *url.URL
+	VaultToken  string
+	VaultMount  string // KV v2 mount path; "secret" by default
+	VaultPrefix string // "kloud/credentials" by default
+
+	// Dir is a directory used by file store.
+	Dir string
 }
 
 func (opts *Options) objectBuilder() *object.Builder {
@@ -90,7 +115,7 @@ func (opts *Options) objectBuilder() *object.Builder {
 
 func (opts *Options) new(logName string) *Options {
 	optsCopy := *opts
-	optsCopy.Log = opts.Log.New(logName)
+	optsCopy.Log = opts.log().New(logName)
 
 	return &optsCopy
 }
@@ -117,6 +142,30 @@ func NewStore(opts *Options) Store {
 	}
 }
 
+// NewVaultStore gives new credential store, which keeps
+// credentials encrypted in HashiCorp Vault KV v2 secrets engine.
+func NewVaultStore(opts *Options) (Store, error) {
+	if err := opts.validVault(); err != nil {
+		return nil, err
+	}
+
+	return &vaultStore{
+		Options: opts.new("vault"),
+	}, nil
+}
+
+// NewFileStore gives new credential store, which keeps
+// credentials encrypted in a local directory.
+func NewFileStore(opts *Options) (Store, error) {
+	if err := opts.validFile(); err != nil {
+		return nil, err
+	}
+
+	return &fileStore{
+		Options: opts.new("file"),
+	}, nil
+}
+
 // MigratingStore creates a Store that on Fetch tries to fetch
 // credentials from dst first and for every missing credential it
 // falls back to src. Every credential fetched from src store
@@ -137,6 +186,16 @@ func MigratingStore(src, dst Store) Store {
 	}
 }
 
+// validIdent checks whether the identifier is safe to be used
+// as a path element by file and vault stores.
+func validIdent(ident string) error {
+	if ident == "" || ident == "." || ident == ".." || strings.ContainsAny(ident, `/\`) {
+		return fmt.Errorf("invalid credential identifier: %q", ident)
+	}
+
+	return nil
+}
+
 func toIdents(creds map[string]interface{}) []string {
 	idents := make([]string, 0, len(creds))
 	for ident := range creds {
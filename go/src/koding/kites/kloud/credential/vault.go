@@ -0,0 +1,295 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// vaultStore implements fetching/updating credential data values
+// from HashiCorp Vault KV version 2 secrets engine.
+//
+// Each credential data is kept encrypted in an envelope under
+// the <mount>/data/<prefix>/<identifier> path.
+type vaultStore struct {
+	*Options
+}
+
+var (
+	_ Store   = (*vaultStore)(nil)
+	_ Rotator = (*vaultStore)(nil)
+)
+
+type vaultSecret struct {
+	Data struct {
+		Data *Envelope `json:"data"`
+	} `json:"data"`
+}
+
+type vaultList struct {
+	Data struct {
+		Keys []string `json:"keys"`
+	} `json:"data"`
+}
+
+type vaultError struct {
+	StatusCode int      `json:"-"`
+	Errors     []string `json:"errors"`
+}
+
+func (err *vaultError) Error() string {
+	return fmt.Sprintf("%s (status=%d, errors=%q)", http.StatusText(err.StatusCode), err.StatusCode, err.Errors)
+}
+
+func (vs *vaultStore) Fetch(_ string, creds map[string]interface{}) error {
+	var missing []string
+
+	for ident, data := range creds {
+		env, err := vs.read(ident)
+		if err != nil {
+			vs.Log.Debug("failed to fetch credential data for %q: %s", ident, err)
+
+			missing = append(missing, ident)
+			continue
+		}
+
+		v, err := openCred(vs.Keyring, ident, env, data)
+		if err != nil {
+			vs.Log.Warning("failed to open credential data for %q: %s", ident, err)
+
+			missing = append(missing, ident)
+			continue
+		}
+
+		if data == nil {
+			creds[ident] = v
+		}
+
+		vs.Log.Debug("fetched credential data for %q", ident)
+	}
+
+	if len(missing) != 0 {
+		return &NotFoundError{
+			Identifiers: missing,
+		}
+	}
+
+	return nil
+}
+
+func (vs *vaultStore) Put(_ string, creds map[string]interface{}) error {
+	var err error
+
+	for ident, data := range creds {
+		env, e := sealCred(vs.Keyring, ident, data)
+		if e == nil {
+			e = vs.write(ident, env)
+		}
+
+		if e != nil {
+			vs.Log.Debug("failed to put credential data for %q: %s", ident, e)
+
+			err = multierror.Append(err, e)
+			continue
+		}
+
+		vs.Log.Debug("put credential data for %q", ident)
+	}
+
+	return err
+}
+
+// Rotate implements the Rotator interface.
+func (vs *vaultStore) Rotate() (int, error) {
+	var list vaultList
+
+	if err := vs.do("LIST", vs.dir("metadata"), nil, &list); err != nil {
+		if e, ok := err.(*vaultError); ok && e.StatusCode == http.StatusNotFound {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	var (
+		n   int
+		err error
+	)
+
+	for _, ident := range list.Data.Keys {
+		env, e := vs.read(ident)
+		if e != nil {
+			err = multierror.Append(err, e)
+			continue
+		}
+
+		ok, e := rewrap(vs.Keyring, env)
+		if e != nil {
+			err = multierror.Append(err, fmt.Errorf("%q: %s", ident, e))
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if e := vs.write(ident, env); e != nil {
+			err = multierror.Append(err, e)
+			continue
+		}
+
+		n++
+	}
+
+	return n, err
+}
+
+func (vs *vaultStore) read(ident string) (*Envelope, error) {
+	urlPath, err := vs.path("data", ident)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret vaultSecret
+
+	if err := vs.do("GET", urlPath, nil, &secret); err != nil {
+		return nil, fmt.Errorf("%q: calling vault failed: %s", ident, err)
+	}
+
+	if secret.Data.Data == nil {
+		return nil, fmt.Errorf("%q: empty secret", ident)
+	}
+
+	return secret.Data.Data, nil
+}
+
+func (vs *vaultStore) write(ident string, env *Envelope) error {
+	urlPath, err := vs.path("data", ident)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"data": env,
+	}
+
+	if err := vs.do("POST", urlPath, body, nil); err != nil {
+		return fmt.Errorf("%q: calling vault failed: %s", ident, err)
+	}
+
+	return nil
+}
+
+// path gives a URL path of the secret for the given credential.
+//
+// The identifier must not contain path separators, as it
+// would allow accessing secrets outside the prefix.
+func (vs *vaultStore) path(kind, ident string) (string, error) {
+	if err := validIdent(ident); err != nil {
+		return "", err
+	}
+
+	return path.Join(vs.dir(kind), ident), nil
+}
+
+func (vs *vaultStore) dir(kind string) string {
+	mount := vs.VaultMount
+	if mount == "" {
+		mount = "secret"
+	}
+
+	prefix := vs.VaultPrefix
+	if prefix == "" {
+		prefix = "kloud/credentials"
+	}
+
+	return path.Join("/v1", mount, kind, prefix)
+}
+
+func (vs *vaultStore) do(method, urlPath string, body, resp interface{}) error {
+	u := *vs.VaultURL
+	u.Path = urlPath
+
+	var r io.Reader
+	if body != nil {
+		p, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		r = bytes.NewReader(p)
+	}
+
+	req, err := http.NewRequest(method, u.String(), r)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Vault-Token", vs.VaultToken)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := vs.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	default:
+		e := &vaultError{
+			StatusCode: res.StatusCode,
+		}
+
+		// ignore decoding errors, if body is empty/broken,
+		// we're going to use default error value
+		json.NewDecoder(res.Body).Decode(e)
+
+		return e
+	}
+
+	if resp != nil {
+		if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+			return fmt.Errorf("failed decoding response: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (vs *vaultStore) client() *http.Client {
+	if vs.Client != nil {
+		return vs.Client
+	}
+
+	return http.DefaultClient
+}
+
+func (opts *Options) validVault() error {
+	if opts.VaultURL == nil {
+		return errors.New("vault address is empty")
+	}
+
+	if opts.VaultToken == "" {
+		return errors.New("vault token is empty")
+	}
+
+	return opts.validKeyring()
+}
+
+func (opts *Options) validKeyring() error {
+	if opts.Keyring == nil {
+		return errors.New("keyring is empty")
+	}
+
+	return opts.Keyring.Valid()
+}
@@ -0,0 +1,309 @@
+package credential_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"koding/kites/common/keyring"
+	"koding/kites/kloud/credential"
+)
+
+const testToken = "s.testtoken"
+
+// VaultServer is a stand-in for Vault dev server, which
+// implements a subset of KV v2 secrets engine API.
+type VaultServer struct {
+	mu      sync.Mutex
+	secrets map[string]json.RawMessage // maps path to secret data
+}
+
+func NewVaultServer() *VaultServer {
+	return &VaultServer{
+		secrets: make(map[string]json.RawMessage),
+	}
+}
+
+func (vs *VaultServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != testToken {
+		vs.error(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	const (
+		data     = "/v1/secret/data/"
+		metadata = "/v1/secret/metadata/"
+	)
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	switch {
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, data):
+		secret, ok := vs.secrets[strings.TrimPrefix(r.URL.Path, data)]
+		if !ok {
+			vs.error(w, http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     secret,
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		})
+	case r.Method == "POST" && strings.HasPrefix(r.URL.Path, data):
+		var req struct {
+			Data json.RawMessage `json:"data"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			vs.error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		vs.secrets[strings.TrimPrefix(r.URL.Path, data)] = req.Data
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"version": 1},
+		})
+	case r.Method == "LIST" && strings.HasPrefix(r.URL.Path, metadata):
+		prefix := strings.TrimPrefix(r.URL.Path, metadata) + "/"
+
+		var keys []string
+		for path := range vs.secrets {
+			if strings.HasPrefix(path, prefix) {
+				keys = append(keys, strings.TrimPrefix(path, prefix))
+			}
+		}
+
+		if len(keys) == 0 {
+			vs.error(w, http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"keys": keys},
+		})
+	default:
+		vs.error(w, http.StatusMethodNotAllowed)
+	}
+}
+
+func (vs *VaultServer) error(w http.ResponseWriter, code int, errs ...string) {
+	if errs == nil {
+		errs = []string{}
+	}
+
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": errs})
+}
+
+type Data struct {
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+func newKeyring(t *testing.T, ids ...string) *keyring.Keyring {
+	var keys []string
+
+	for _, id := range ids {
+		key := make([]byte, 32)
+		copy(key, id)
+
+		keys = append(keys, id+":"+base64.StdEncoding.EncodeToString(key))
+	}
+
+	kr, err := keyring.Parse(strings.Join(keys, ","))
+	if err != nil {
+		t.Fatalf("Parse()=%s", err)
+	}
+
+	return kr
+}
+
+func TestStores(t *testing.T) {
+	server := httptest.NewServer(NewVaultServer())
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Parse()=%s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "credential")
+	if err != nil {
+		t.Fatalf("TempDir()=%s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := &credential.Options{
+		VaultURL:   u,
+		VaultToken: testToken,
+		Dir:        dir,
+	}
+
+	cases := map[string]func(*credential.Options) (credential.Store, error){
+		"vault": credential.NewVaultStore,
+		"file":  credential.NewFileStore,
+	}
+
+	for name, newStore := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := *opts
+			o.Keyring = newKeyring(t, "key1")
+
+			s, err := newStore(&o)
+			if err != nil {
+				t.Fatalf("newStore()=%s", err)
+			}
+
+			put := map[string]interface{}{
+				"cred1": &Data{AccessKey: "access1", SecretKey: "secret1"},
+				"cred2": &Data{AccessKey: "access2", SecretKey: "secret2"},
+			}
+
+			if err := s.Put("user", put); err != nil {
+				t.Fatalf("Put()=%s", err)
+			}
+
+			got := map[string]interface{}{
+				"cred1": &Data{},
+				"cred2": nil,
+				"credX": nil,
+			}
+
+			nfe, ok := s.Fetch("user", got).(*credential.NotFoundError)
+			if !ok {
+				t.Fatalf("expected err to be NotFoundError, was %T", nfe)
+			}
+
+			if len(nfe.Identifiers) != 1 || nfe.Identifiers[0] != "credX" {
+				t.Fatalf(`expected err.Identifiers=["credX"]; got %v`, nfe.Identifiers)
+			}
+
+			want := map[string]interface{}{
+				"cred1": &Data{AccessKey: "access1", SecretKey: "secret1"},
+				"cred2": map[string]interface{}{"access_key": "access2", "secret_key": "secret2"},
+				"credX": nil,
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v; want %+v", got, want)
+			}
+
+			// Rotate primary key, keeping the old one for decryption.
+			o.Keyring = newKeyring(t, "key2", "key1")
+
+			s, err = newStore(&o)
+			if err != nil {
+				t.Fatalf("newStore()=%s", err)
+			}
+
+			n, e := s.(credential.Rotator).Rotate()
+			if e != nil {
+				t.Fatalf("Rotate()=%s", e)
+			}
+
+			if n != 2 {
+				t.Fatalf("got %d rotated, want 2", n)
+			}
+
+			if n, e = s.(credential.Rotator).Rotate(); e != nil || n != 0 {
+				t.Fatalf("got %d, %v; want 0, nil", n, e)
+			}
+
+			// Ensure old key is no longer needed.
+			o.Keyring = newKeyring(t, "key2")
+
+			s, err = newStore(&o)
+			if err != nil {
+				t.Fatalf("newStore()=%s", err)
+			}
+
+			got = map[string]interface{}{
+				"cred1": &Data{},
+				"cred2": &Data{},
+			}
+
+			if e := s.Fetch("user", got); e != nil {
+				t.Fatalf("Fetch()=%s", e)
+			}
+
+			if !reflect.DeepEqual(got, put) {
+				t.Fatalf("got %+v; want %+v", got, put)
+			}
+
+			for _, ident := range []string{"../cred1", `..\cred1`, "..", "kloud/cred1"} {
+				bad := map[string]interface{}{
+					ident: &Data{AccessKey: "access", SecretKey: "secret"},
+				}
+
+				if err := s.Put("user", bad); err == nil {
+					t.Fatalf("expected Put() to fail for %q identifier", ident)
+				}
+			}
+		})
+	}
+}
+
+func TestVaultStore_Migrate(t *testing.T) {
+	server := httptest.NewServer(NewVaultServer())
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Parse()=%s", err)
+	}
+
+	vault, err := credential.NewVaultStore(&credential.Options{
+		VaultURL:   u,
+		VaultToken: testToken,
+		Keyring:    newKeyring(t, "key1"),
+	})
+	if err != nil {
+		t.Fatalf("NewVaultStore()=%s", err)
+	}
+
+	legacy := Creds{
+		"cred1": map[string]interface{}{"access_key": "access1"},
+		"cred2": map[string]interface{}{"access_key": "access2"},
+	}
+
+	s := credential.MigratingStore(legacy, vault)
+
+	got := map[string]interface{}{
+		"cred1": nil,
+		"cred2": nil,
+	}
+
+	if err := s.Fetch("user", got); err != nil {
+		t.Fatalf("Fetch()=%s", err)
+	}
+
+	if !reflect.DeepEqual(Creds(got), legacy) {
+		t.Fatalf("got %+v; want %+v", got, legacy)
+	}
+
+	// Credentials are expected to be migrated to vault.
+	migrated := map[string]interface{}{
+		"cred1": nil,
+		"cred2": nil,
+	}
+
+	if err := vault.Fetch("user", migrated); err != nil {
+		t.Fatalf("Fetch()=%s", err)
+	}
+
+	if !reflect.DeepEqual(Creds(migrated), legacy) {
+		t.Fatalf("got %+v; want %+v", migrated, legacy)
+	}
+}
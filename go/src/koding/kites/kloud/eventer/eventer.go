@@ -34,6 +34,17 @@ type Eventer interface {
 	Close()
 }
 
+// Tailer is implemented by eventers, which additionally keep
+// the output of the process, e.g. terraform logs.
+type Tailer interface {
+	// Append appends the lines to the output.
+	Append(lines ...string)
+
+	// Tail gives the lines of the output, starting from
+	// the given offset.
+	Tail(offset int) []string
+}
+
 // FromContext extracts the eventer from ctx, if present.
 func FromContext(ctx context.Context) (Eventer, bool) {
 	c, ok := ctx.Value(eventKey).(Eventer)
@@ -73,6 +84,7 @@ func (e *Event) String() string {
 
 type Events struct {
 	events  []*Event
+	output  []string
 	eventId string
 	closed  bool
 
@@ -100,6 +112,30 @@ func (e *Events) Push(ev *Event) {
 	e.events = append(e.events, ev)
 }
 
+// Append implements the Tailer interface.
+func (e *Events) Append(lines ...string) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.closed {
+		return
+	}
+
+	e.output = append(e.output, lines...)
+}
+
+// Tail implements the Tailer interface.
+func (e *Events) Tail(offset int) []string {
+	e.Lock()
+	defer e.Unlock()
+
+	if offset < 0 || offset >= len(e.output) {
+		return nil
+	}
+
+	return append([]string(nil), e.output[offset:]...)
+}
+
 func (e *Events) Show() *Event {
 	e.Lock()
 	defer e.Unlock()
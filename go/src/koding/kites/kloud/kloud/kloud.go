@@ -3,6 +3,7 @@ package kloud
 import (
 	"errors"
 	_ "expvar"
+	"fmt"
 	"io/ioutil"
 	"log"
 	_ "net/http/pprof"
@@ -19,6 +20,7 @@ import (
 	"koding/db/mongodb/modelhelper"
 	"koding/httputil"
 	"koding/kites/common"
+	"koding/kites/common/keyring"
 	"koding/kites/config"
 	"koding/kites/keygen"
 	"koding/kites/kloud/contexthelper/publickeys"
@@ -28,6 +30,9 @@ import (
 	"koding/kites/kloud/keycreator"
 	"koding/kites/kloud/machine"
 	"koding/kites/kloud/metrics"
+	"koding/kites/kloud/pkg/dnsclient"
+	"koding/kites/kloud/pricing"
+	awsprovider "koding/kites/kloud/provider/aws"
 	"koding/kites/kloud/queue"
 	"koding/kites/kloud/stack"
 	"koding/kites/kloud/stack/provider"
@@ -150,9 +155,44 @@ type Config struct {
 	AWSAccessKeyId     string
 	AWSSecretAccessKey string
 
+	// AWSExternalIDSecret is used to generate external IDs of teams,
+	// which are required when assuming roles of AWS credentials.
+	// If empty, KloudSecretKey is used instead.
+	AWSExternalIDSecret string
+
 	KloudSecretKey       string
 	TerraformerSecretKey string
 
+	// DriftInterval is a minimum time between two drift checks
+	// of a single stack.
+	DriftInterval time.Duration `default:"1h"`
+
+	// PriceTables is a directory with JSON price tables, which
+	// replace default ones used for estimating stack costs.
+	PriceTables string
+
+	// CredentialBackend is a store, which credential data is migrated
+	// to and kept encrypted in; either "vault" or "file". When empty,
+	// credential data is kept in socialapi or MongoDB only.
+	CredentialBackend string
+	CredentialKeys    string // keyring in "id1:base64key1,id2:base64key2" format, first key is primary
+	CredentialDir     string // directory of "file" backend
+
+	// Vault configuration of "vault" backend.
+	VaultAddr  string
+	VaultToken string
+	VaultMount string `default:"secret"`
+
+	// DNSBackend is a DNS provider, which manages records of the
+	// HostedZone; either "route53", "rfc2136" or "cloudflare".
+	// When empty, kloud does not manage DNS records.
+	DNSBackend      string
+	DNSServer       string // name server address of "rfc2136" backend
+	DNSKeyName      string // TSIG key name of "rfc2136" backend
+	DNSKeySecret    string // base64-encoded TSIG key secret of "rfc2136" backend
+	DNSKeyAlgorithm string // TSIG algorithm of "rfc2136" backend, hmac-sha256 when empty
+	CloudflareToken string // API token of "cloudflare" backend
+
 	KodingURL *config.URL // Koding base URL
 	NoSneaker bool        // use Mongo for reading credentials, instead of /social/credential endpoint
 }
@@ -216,6 +256,28 @@ func New(conf *Config) (*Kloud, error) {
 
 	sess.Log.Debug("storeOpts: %+v", storeOpts)
 
+	credStore := credential.NewStore(storeOpts)
+
+	if conf.CredentialBackend != "" {
+		s, err := newSecretStore(conf, storeOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		if r, ok := s.(credential.Rotator); ok {
+			go func() {
+				n, err := r.Rotate()
+				if err != nil {
+					sess.Log.Error("failed to rotate keys of credentials: %s", err)
+				} else {
+					sess.Log.Info("rotated keys of %d credentials", n)
+				}
+			}()
+		}
+
+		credStore = credential.MigratingStore(credStore, s)
+	}
+
 	userPrivateKey, userPublicKey := userMachinesKeys(conf.UserPublicKey, conf.UserPrivateKey)
 
 	stacker := &provider.Stacker{
@@ -226,7 +288,7 @@ func New(conf *Config) (*Kloud, error) {
 		Debug:          conf.DebugMode,
 		Environment:    conf.Environment,
 		KloudSecretKey: conf.KloudSecretKey,
-		CredStore:      credential.NewStore(storeOpts),
+		CredStore:      credStore,
 		TunnelURL:      conf.TunnelURL,
 		SSHKey: &publickeys.Keys{
 			KeyName:    publickeys.DeployKeyName,
@@ -241,10 +303,13 @@ func New(conf *Config) (*Kloud, error) {
 		Kite:  k,
 		Stack: stack.New(),
 		Queue: &queue.Queue{
-			Interval: 5 * time.Second,
-			Log:      sess.Log.New("queue"),
-			Kite:     k,
-			MongoDB:  sess.DB,
+			Interval:      5 * time.Second,
+			Log:           sess.Log.New("queue"),
+			Kite:          k,
+			MongoDB:       sess.DB,
+			Terraformer:   sess.Terraformer,
+			DriftInterval: conf.DriftInterval,
+			Locker:        stacker,
 		},
 		closeChan: make(chan struct{}),
 	}
@@ -338,6 +403,24 @@ func New(conf *Config) (*Kloud, error) {
 		k.Log.Warning(`disabling "keygen" methods due to missing S3/STS credentials`)
 	}
 
+	// Kloud's own AWS credentials are used to assume roles
+	// of AWS stack credentials.
+	if conf.AWSAccessKeyId != "" {
+		awsprovider.AssumeRoleCredentials = credentials.NewStaticCredentials(conf.AWSAccessKeyId, conf.AWSSecretAccessKey, "")
+	}
+
+	awsprovider.ExternalIDSecret = conf.AWSExternalIDSecret
+
+	if awsprovider.ExternalIDSecret == "" {
+		awsprovider.ExternalIDSecret = conf.KloudSecretKey
+	}
+
+	if conf.PriceTables != "" {
+		if err := pricing.LoadDir(conf.PriceTables); err != nil {
+			return nil, err
+		}
+	}
+
 	publisher, err := metrics.NewPublisher(conf.KiteMetricsPublishURL)
 	if err != nil {
 		return nil, err
@@ -349,10 +432,15 @@ func New(conf *Config) (*Kloud, error) {
 	kloud.HandleFunc("plan", kloud.Stack.Plan)
 	kloud.HandleFunc("apply", kloud.Stack.Apply)
 	kloud.HandleFunc("describeStack", kloud.Stack.Status)
+	kloud.HandleFunc("reconcile", kloud.Stack.Reconcile)
 	kloud.HandleFunc("authenticate", kloud.Stack.Authenticate)
 	kloud.HandleFunc("bootstrap", kloud.Stack.Bootstrap)
 	kloud.HandleFunc("import", kloud.Stack.Import)
 
+	// Stack template history.
+	kloud.HandleFunc("template.history", kloud.Stack.TemplateHistory)
+	kloud.HandleFunc("template.diff", kloud.Stack.TemplateDiff)
+
 	// Credential handling.
 	kloud.HandleFunc("credential.describe", kloud.Stack.CredentialDescribe)
 	kloud.HandleFunc("credential.list", kloud.Stack.CredentialList)
@@ -371,6 +459,7 @@ func New(conf *Config) (*Kloud, error) {
 
 	// Machine handling.
 	kloud.HandleFunc("machine.list", kloud.Stack.MachineList)
+	kloud.HandleFunc("machine.schedule", kloud.Stack.Schedule)
 
 	// Single machine handling.
 	kloud.HandleFunc("stop", kloud.Stack.Stop)
@@ -495,9 +584,61 @@ func newSession(conf *Config, k *kite.Kite) (*session.Session, error) {
 
 	sess.DNSStorage = dnsstorage.NewMongodbStorage(sess.DB)
 
+	if conf.DNSBackend != "" {
+		dnsOpts := &dnsclient.Options{
+			Creds:         c,
+			HostedZone:    conf.HostedZone,
+			Log:           sess.Log.New("dns"),
+			Debug:         conf.DebugMode,
+			Backend:       conf.DNSBackend,
+			Server:        conf.DNSServer,
+			TSIGName:      conf.DNSKeyName,
+			TSIGSecret:    conf.DNSKeySecret,
+			TSIGAlgorithm: conf.DNSKeyAlgorithm,
+			APIToken:      conf.CloudflareToken,
+		}
+
+		dns, err := dnsclient.New(dnsOpts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS configuration: %s", err)
+		}
+
+		sess.DNSClient = dns
+	}
+
 	return sess, nil
 }
 
+func newSecretStore(conf *Config, opts *credential.Options) (credential.Store, error) {
+	kr, err := keyring.Parse(conf.CredentialKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential keys: %s", err)
+	}
+
+	secretOpts := *opts
+	secretOpts.Keyring = kr
+
+	switch conf.CredentialBackend {
+	case "vault":
+		u, err := url.Parse(conf.VaultAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vault address: %s", err)
+		}
+
+		secretOpts.VaultURL = u
+		secretOpts.VaultToken = conf.VaultToken
+		secretOpts.VaultMount = conf.VaultMount
+
+		return credential.NewVaultStore(&secretOpts)
+	case "file":
+		secretOpts.Dir = conf.CredentialDir
+
+		return credential.NewFileStore(&secretOpts)
+	default:
+		return nil, fmt.Errorf("unknown credential backend: %q", conf.CredentialBackend)
+	}
+}
+
 func newEndpoints(cfg *Config) *config.Endpoints {
 	e := config.NewKonfig(&config.Environments{Env: cfg.Environment}).Endpoints
 
@@ -0,0 +1,384 @@
+package dnsclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultCloudflareURL is the default Cloudflare API endpoint.
+const DefaultCloudflareURL = "https://api.cloudflare.com/client/v4"
+
+// Cloudflare is a DNS client, which manages records with
+// the Cloudflare API.
+type Cloudflare struct {
+	ZoneID string
+
+	opts   *Options
+	client *http.Client
+}
+
+type cfRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+type cfError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type cfResponse struct {
+	Success bool            `json:"success"`
+	Errors  []cfError       `json:"errors"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// NewCloudflareClient gives new DNS client, which manages records
+// of the opts.HostedZone with the given API token.
+func NewCloudflareClient(opts *Options) (*Cloudflare, error) {
+	optsCopy := *opts
+
+	if optsCopy.HostedZone == "" {
+		return nil, errors.New("hosted zone is empty")
+	}
+
+	if optsCopy.APIToken == "" {
+		return nil, errors.New("Cloudflare API token is empty")
+	}
+
+	if optsCopy.APIURL == "" {
+		optsCopy.APIURL = DefaultCloudflareURL
+	}
+
+	optsCopy.APIURL = strings.TrimSuffix(optsCopy.APIURL, "/")
+
+	c := &Cloudflare{
+		opts: &optsCopy,
+		client: &http.Client{
+			Timeout: optsCopy.timeout(),
+		},
+	}
+
+	var zones []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	if err := c.do("GET", "/zones?name="+url.QueryEscape(optsCopy.HostedZone), nil, &zones); err != nil {
+		return nil, err
+	}
+
+	for _, zone := range zones {
+		if strings.EqualFold(zone.Name, optsCopy.HostedZone) {
+			c.ZoneID = zone.ID
+		}
+	}
+
+	if c.ZoneID == "" {
+		return nil, fmt.Errorf("Hosted zone with the name %q doesn't exist", opts.HostedZone)
+	}
+
+	return c, nil
+}
+
+// Upsert creates or updates the domain record with the given ip address. If
+// the record already exists, the record is updated with the new IP.
+func (c *Cloudflare) Upsert(domain, newIP string) error {
+	rec := &Record{
+		Name: domain,
+		Type: "A",
+		IP:   newIP,
+		TTL:  30,
+	}
+	return c.UpsertRecord(rec)
+}
+
+// UpsertRecord creates or updates a DNS record.
+//
+// Existing record of the same type is updated. Since a CNAME
+// record can't coexist with other records, upserting a CNAME
+// replaces address records and vice versa.
+func (c *Cloudflare) UpsertRecord(rec *Record) error {
+	c.opts.log().Debug("upserting record: %# v", rec)
+
+	existing, err := c.records(rec.Name, "")
+	if err != nil {
+		return c.errorf("could not upsert record %v: %s", rec, err)
+	}
+
+	var (
+		typ    = strings.ToUpper(rec.Type)
+		update *cfRecord
+		body   = newCFRecord(rec)
+	)
+
+	for i := range existing {
+		switch {
+		case existing[i].Type == typ && update == nil:
+			update = &existing[i]
+		case existing[i].Type == typ, conflicts(existing[i].Type, typ):
+			if err := c.do("DELETE", c.path(existing[i].ID), nil, nil); err != nil {
+				return c.errorf("could not upsert record %v: %s", rec, err)
+			}
+		}
+	}
+
+	if update != nil {
+		err = c.do("PUT", c.path(update.ID), body, nil)
+	} else {
+		err = c.do("POST", c.path(""), body, nil)
+	}
+
+	if err != nil {
+		return c.errorf("could not upsert record %v: %s", rec, err)
+	}
+
+	return nil
+}
+
+// UpsertRecords creates or updates the DNS records.
+//
+// The Cloudflare API has no batch changes, thus the records
+// are upserted one by one.
+func (c *Cloudflare) UpsertRecords(recs ...*Record) error {
+	for _, rec := range recs {
+		if err := c.UpsertRecord(rec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get retrieves the address or alias record for the given domain name.
+func (c *Cloudflare) Get(domain string) (*Record, error) {
+	c.opts.log().Debug("fetching domain record for domain: %s", domain)
+
+	existing, err := c.records(domain, "")
+	if err != nil {
+		return nil, c.errorf("could not fetch record for domain %q: %s", domain, err)
+	}
+
+	for _, r := range existing {
+		switch r.Type {
+		case "A", "AAAA", "CNAME":
+			return &Record{
+				Name: r.Name,
+				Type: r.Type,
+				IP:   r.Content,
+				TTL:  r.TTL,
+			}, nil
+		}
+	}
+
+	return nil, ErrNoRecord
+}
+
+// Rename changes the domain from oldDomain to newDomain.
+//
+// The record with the new name is created before the old
+// one is deleted.
+func (c *Cloudflare) Rename(oldDomain, newDomain string) error {
+	rec, err := c.Get(oldDomain)
+	if err != nil {
+		return err
+	}
+
+	c.opts.log().Debug("updating domain name of IP %s from %q to %q", rec.IP, oldDomain, newDomain)
+
+	err = c.UpsertRecord(&Record{
+		Name: newDomain,
+		Type: rec.Type,
+		IP:   rec.IP,
+		TTL:  rec.TTL,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Delete(oldDomain)
+}
+
+// Delete deletes address and alias records of the given domain.
+func (c *Cloudflare) Delete(domain string) error {
+	c.opts.log().Debug("deleting records of domain: %s", domain)
+
+	existing, err := c.records(domain, "")
+	if err != nil {
+		return c.errorf("could not delete domain %q: %s", domain, err)
+	}
+
+	for _, r := range existing {
+		switch r.Type {
+		case "A", "AAAA", "CNAME":
+			if err := c.do("DELETE", c.path(r.ID), nil, nil); err != nil {
+				return c.errorf("could not delete domain %q: %s", domain, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeleteRecord deletes the given record.
+func (c *Cloudflare) DeleteRecord(rec *Record) error {
+	c.opts.log().Debug("deleting record: %v", rec)
+
+	existing, err := c.records(rec.Name, strings.ToUpper(rec.Type))
+	if err != nil {
+		return c.errorf("could not delete record %v: %s", rec, err)
+	}
+
+	content := newCFRecord(rec).Content
+
+	for _, r := range existing {
+		if !strings.EqualFold(r.Content, content) {
+			continue
+		}
+
+		if err := c.do("DELETE", c.path(r.ID), nil, nil); err != nil {
+			return c.errorf("could not delete record %v: %s", rec, err)
+		}
+	}
+
+	return nil
+}
+
+// HostedZone returns the zone, which is managed by the client.
+func (c *Cloudflare) HostedZone() string {
+	return c.opts.HostedZone
+}
+
+// Validate validates if the given domain name is valid.
+func (c *Cloudflare) Validate(domain, username string) error {
+	if err := validate(c.HostedZone(), domain, username); err != nil {
+		return c.errorf("%s", err)
+	}
+
+	return nil
+}
+
+func (c *Cloudflare) records(name, typ string) ([]cfRecord, error) {
+	v := make(url.Values)
+	v.Set("name", cfName(name))
+	v.Set("per_page", "100")
+
+	if typ != "" {
+		v.Set("type", typ)
+	}
+
+	var recs []cfRecord
+
+	if err := c.do("GET", c.path("")+"?"+v.Encode(), nil, &recs); err != nil {
+		return nil, err
+	}
+
+	return recs, nil
+}
+
+func (c *Cloudflare) path(id string) string {
+	path := "/zones/" + c.ZoneID + "/dns_records"
+
+	if id != "" {
+		path += "/" + id
+	}
+
+	return path
+}
+
+func (c *Cloudflare) do(method, path string, in, out interface{}) error {
+	var body bytes.Buffer
+
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.opts.APIURL+path, &body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.opts.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var cfResp cfResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&cfResp); err != nil {
+		return fmt.Errorf("%s %s: unexpected response: %s", method, path, resp.Status)
+	}
+
+	if !cfResp.Success {
+		var msgs []string
+
+		for _, e := range cfResp.Errors {
+			msgs = append(msgs, fmt.Sprintf("%s (code %d)", e.Message, e.Code))
+		}
+
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.Join(msgs, "; "))
+	}
+
+	if out != nil {
+		return json.Unmarshal(cfResp.Result, out)
+	}
+
+	return nil
+}
+
+func (c *Cloudflare) errorf(format string, v ...interface{}) error {
+	err := fmt.Errorf(format, v...)
+	c.opts.log().Error(err.Error())
+	return err
+}
+
+func newCFRecord(rec *Record) *cfRecord {
+	r := &cfRecord{
+		Type:    strings.ToUpper(rec.Type),
+		Name:    cfName(rec.Name),
+		Content: rec.IP,
+		TTL:     rec.TTL,
+	}
+
+	if r.Type == "CNAME" {
+		r.Content = cfName(r.Content)
+	}
+
+	// Cloudflare does not accept TTLs lower than 60s,
+	// apart from 1, which means automatic TTL.
+	if r.TTL != 1 && r.TTL < 60 {
+		r.TTL = 60
+	}
+
+	return r
+}
+
+// cfName gives the domain name in the form used by Cloudflare,
+// without the trailing dot.
+func cfName(name string) string {
+	return strings.TrimSuffix(fqdn(name), ".")
+}
+
+// conflicts tells whether records of the given types can't
+// have the same name.
+func conflicts(typ, other string) bool {
+	if typ == other {
+		return false
+	}
+
+	return typ == "CNAME" || other == "CNAME"
+}
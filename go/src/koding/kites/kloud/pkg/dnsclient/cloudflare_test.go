@@ -0,0 +1,293 @@
+package dnsclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/koding/logging"
+)
+
+// testCloudflare is a fake of the Cloudflare API, which serves
+// DNS records of a single zone.
+type testCloudflare struct {
+	zone  string
+	token string
+
+	mu      sync.Mutex
+	id      int
+	records map[string]cfRecord // by ID
+}
+
+func (cf *testCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+cf.token {
+		cf.reply(w, http.StatusForbidden, nil, "Invalid API token")
+		return
+	}
+
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/client/v4")
+
+	switch {
+	case path == "/zones" && r.Method == "GET":
+		var zones []interface{}
+
+		if r.URL.Query().Get("name") == cf.zone {
+			zones = append(zones, map[string]string{"id": "z1", "name": cf.zone})
+		}
+
+		cf.reply(w, http.StatusOK, zones, "")
+	case path == "/zones/z1/dns_records" && r.Method == "GET":
+		q := r.URL.Query()
+
+		recs := []cfRecord{}
+
+		for _, rec := range cf.records {
+			if rec.Name == q.Get("name") && (q.Get("type") == "" || rec.Type == q.Get("type")) {
+				recs = append(recs, rec)
+			}
+		}
+
+		cf.reply(w, http.StatusOK, recs, "")
+	case path == "/zones/z1/dns_records" && r.Method == "POST":
+		var rec cfRecord
+
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil || rec.TTL < 60 {
+			cf.reply(w, http.StatusBadRequest, nil, "Invalid record")
+			return
+		}
+
+		cf.id++
+		rec.ID = strconv.Itoa(cf.id)
+		cf.records[rec.ID] = rec
+
+		cf.reply(w, http.StatusOK, rec, "")
+	case strings.HasPrefix(path, "/zones/z1/dns_records/"):
+		id := strings.TrimPrefix(path, "/zones/z1/dns_records/")
+
+		if _, ok := cf.records[id]; !ok {
+			cf.reply(w, http.StatusNotFound, nil, "Record not found")
+			return
+		}
+
+		switch r.Method {
+		case "PUT":
+			var rec cfRecord
+
+			if err := json.NewDecoder(r.Body).Decode(&rec); err != nil || rec.TTL < 60 {
+				cf.reply(w, http.StatusBadRequest, nil, "Invalid record")
+				return
+			}
+
+			rec.ID = id
+			cf.records[id] = rec
+
+			cf.reply(w, http.StatusOK, rec, "")
+		case "DELETE":
+			delete(cf.records, id)
+
+			cf.reply(w, http.StatusOK, map[string]string{"id": id}, "")
+		default:
+			cf.reply(w, http.StatusMethodNotAllowed, nil, "Method not allowed")
+		}
+	default:
+		cf.reply(w, http.StatusNotFound, nil, "Not found")
+	}
+}
+
+func (cf *testCloudflare) reply(w http.ResponseWriter, code int, result interface{}, errMsg string) {
+	resp := map[string]interface{}{
+		"success": errMsg == "",
+		"errors":  []cfError{},
+		"result":  result,
+	}
+
+	if errMsg != "" {
+		resp["errors"] = []cfError{{Code: 1000 + code, Message: errMsg}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (cf *testCloudflare) count() int {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	return len(cf.records)
+}
+
+func newTestCloudflare(t *testing.T) (*Cloudflare, *testCloudflare, func()) {
+	cf := &testCloudflare{
+		zone:    "example.com",
+		token:   "token",
+		records: make(map[string]cfRecord),
+	}
+
+	s := httptest.NewServer(cf)
+
+	c, err := NewCloudflareClient(&Options{
+		HostedZone: "example.com",
+		APIToken:   "token",
+		APIURL:     s.URL + "/client/v4/",
+		Log:        logging.NewCustom("dnsclient", testing.Verbose()),
+	})
+	if err != nil {
+		s.Close()
+		t.Fatalf("NewCloudflareClient()=%s", err)
+	}
+
+	return c, cf, s.Close
+}
+
+func TestCloudflare(t *testing.T) {
+	c, cf, done := newTestCloudflare(t)
+	defer done()
+
+	if c.ZoneID != "z1" {
+		t.Fatalf("got zone ID %q, want z1", c.ZoneID)
+	}
+
+	get := func(domain, typ, ip string) {
+		rec, err := c.Get(domain)
+		if err != nil {
+			t.Fatalf("Get(%q)=%s", domain, err)
+		}
+
+		if rec.Name != domain || rec.Type != typ || rec.IP != ip {
+			t.Fatalf("got %+v, want %s %s %s", rec, domain, typ, ip)
+		}
+	}
+
+	notFound := func(domain string) {
+		if rec, err := c.Get(domain); err != ErrNoRecord {
+			t.Fatalf("got %+v, %v; want %v", rec, err, ErrNoRecord)
+		}
+	}
+
+	notFound("foo.user.example.com")
+
+	if err := c.Upsert("foo.user.example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("Upsert()=%s", err)
+	}
+
+	get("foo.user.example.com", "A", "10.0.0.1")
+
+	if err := c.Upsert("foo.user.example.com", "10.0.0.2"); err != nil {
+		t.Fatalf("Upsert()=%s", err)
+	}
+
+	get("foo.user.example.com", "A", "10.0.0.2")
+
+	if n := cf.count(); n != 1 {
+		t.Fatalf("got %d records, want 1", n)
+	}
+
+	err := c.UpsertRecords(&Record{
+		Name: "user.example.com",
+		Type: "A",
+		IP:   "10.0.0.3",
+		TTL:  300,
+	}, &Record{
+		Name: `\052.user.example.com`,
+		Type: "CNAME",
+		IP:   "user.example.com",
+		TTL:  300,
+	})
+	if err != nil {
+		t.Fatalf("UpsertRecords()=%s", err)
+	}
+
+	get("user.example.com", "A", "10.0.0.3")
+	get("*.user.example.com", "CNAME", "user.example.com")
+
+	// Upserting an address record replaces the alias.
+	if err := c.Upsert("*.user.example.com", "10.0.0.4"); err != nil {
+		t.Fatalf("Upsert()=%s", err)
+	}
+
+	get("*.user.example.com", "A", "10.0.0.4")
+
+	if err := c.Rename("foo.user.example.com", "bar.user.example.com"); err != nil {
+		t.Fatalf("Rename()=%s", err)
+	}
+
+	notFound("foo.user.example.com")
+	get("bar.user.example.com", "A", "10.0.0.2")
+
+	err = c.DeleteRecord(&Record{
+		Name: "bar.user.example.com",
+		Type: "A",
+		IP:   "10.0.0.2",
+	})
+	if err != nil {
+		t.Fatalf("DeleteRecord()=%s", err)
+	}
+
+	notFound("bar.user.example.com")
+
+	if err := c.Delete("user.example.com"); err != nil {
+		t.Fatalf("Delete()=%s", err)
+	}
+
+	notFound("user.example.com")
+
+	if err := c.Delete("user.example.com"); err != nil {
+		t.Fatalf("Delete()=%s", err)
+	}
+
+	if n := cf.count(); n != 1 {
+		t.Fatalf("got %d records, want 1", n)
+	}
+}
+
+func TestCloudflareBadToken(t *testing.T) {
+	cf := &testCloudflare{
+		zone:    "example.com",
+		token:   "token",
+		records: make(map[string]cfRecord),
+	}
+
+	s := httptest.NewServer(cf)
+	defer s.Close()
+
+	_, err := NewCloudflareClient(&Options{
+		HostedZone: "example.com",
+		APIToken:   "invalid",
+		APIURL:     s.URL + "/client/v4",
+	})
+	if err == nil || !strings.Contains(err.Error(), "Invalid API token") {
+		t.Fatalf("got %v, want invalid token error", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	c, err := New(&Options{
+		Backend:    "rfc2136",
+		HostedZone: "example.com",
+		Server:     "127.0.0.1",
+	})
+	if err != nil {
+		t.Fatalf("New()=%s", err)
+	}
+
+	r, ok := c.(*RFC2136)
+	if !ok {
+		t.Fatalf("got %T, want *RFC2136", c)
+	}
+
+	if r.opts.Server != "127.0.0.1:53" {
+		t.Fatalf("got %q, want 127.0.0.1:53", r.opts.Server)
+	}
+
+	if _, err := New(&Options{Backend: "bind"}); err == nil {
+		t.Fatal("expected unsupported backend to fail")
+	}
+}
@@ -38,6 +38,55 @@ type Options struct {
 	// 0 means we're not waiting at all.
 	SyncTimeout time.Duration
 	Debug       bool
+
+	// Backend selects the DNS provider, one of "route53" (default),
+	// "rfc2136" or "cloudflare".
+	Backend string
+
+	// Server is an address of the name server, which accepts
+	// RFC 2136 dynamic updates for the HostedZone, e.g. "ns1.example.com:53".
+	Server string
+
+	// TSIGName, TSIGSecret and TSIGAlgorithm configure a key, which
+	// signs RFC 2136 messages. TSIGSecret is encoded with base64,
+	// TSIGAlgorithm defaults to "hmac-sha256".
+	//
+	// If TSIGName is empty, messages are not signed.
+	TSIGName      string
+	TSIGSecret    string
+	TSIGAlgorithm string
+
+	// APIToken is a Cloudflare API token, which is permitted to edit
+	// DNS records of the HostedZone.
+	APIToken string
+
+	// APIURL overwrites the default Cloudflare API endpoint.
+	APIURL string
+
+	// Timeout is a timeout of a single request sent to a name server
+	// or an API endpoint. Defaults to 10s.
+	Timeout time.Duration
+}
+
+var (
+	_ RecordClient = (*Route53)(nil)
+	_ RecordClient = (*RFC2136)(nil)
+	_ RecordClient = (*Cloudflare)(nil)
+)
+
+// New gives new DNS client for the backend configured
+// with opts.Backend.
+func New(opts *Options) (RecordClient, error) {
+	switch strings.ToLower(opts.Backend) {
+	case "", "route53":
+		return NewRoute53Client(opts)
+	case "rfc2136":
+		return NewRFC2136Client(opts)
+	case "cloudflare":
+		return NewCloudflareClient(opts)
+	default:
+		return nil, fmt.Errorf("unsupported DNS backend %q", opts.Backend)
+	}
 }
 
 func (opts *Options) log() logging.Logger {
@@ -47,6 +96,13 @@ func (opts *Options) log() logging.Logger {
 	return defaultLog
 }
 
+func (opts *Options) timeout() time.Duration {
+	if opts.Timeout != 0 {
+		return opts.Timeout
+	}
+	return 10 * time.Second
+}
+
 // NewRoute53Client initializes a new DNSClient interface instance based on AWS Route53
 func NewRoute53Client(opts *Options) (*Route53, error) {
 	optsCopy := *opts
@@ -334,31 +390,39 @@ func (r *Route53) HostedZone() string {
 }
 
 func (r *Route53) Validate(domain, username string) error {
-	hostedZone := r.HostedZone()
+	if err := validate(r.HostedZone(), domain, username); err != nil {
+		return r.errorf("%s", err)
+	}
+
+	return nil
+}
 
+// validate checks whether domain is a subdomain of the hosted zone,
+// which belongs to the given user.
+func validate(hostedZone, domain, username string) error {
 	if domain == "" {
-		return r.errorf("Domain name argument is empty")
+		return errors.New("Domain name argument is empty")
 	}
 
 	if domain == hostedZone {
-		return r.errorf("Domain %q can't be the same as top-level domain %q", domain, hostedZone)
+		return fmt.Errorf("Domain %q can't be the same as top-level domain %q", domain, hostedZone)
 	}
 
 	if !strings.Contains(domain, hostedZone) {
-		return r.errorf("Domain %q doesn't contain hostedzone %q", domain, hostedZone)
+		return fmt.Errorf("Domain %q doesn't contain hostedzone %q", domain, hostedZone)
 	}
 
 	rest := strings.TrimSuffix(domain, "."+hostedZone)
 	if rest == domain {
-		return r.errorf("Domain %q is invalid (1)", domain)
+		return fmt.Errorf("Domain %q is invalid (1)", domain)
 	}
 
 	if split := strings.Split(rest, "."); split[len(split)-1] != username {
-		return r.errorf("Domain %q doesn't contain %q username (hostedZone=%q)", domain, username, hostedZone)
+		return fmt.Errorf("Domain %q doesn't contain %q username (hostedZone=%q)", domain, username, hostedZone)
 	}
 
 	if !validator.IsValidDomain(domain) {
-		return r.errorf("Domain %q is invalid (2)", domain)
+		return fmt.Errorf("Domain %q is invalid (2)", domain)
 	}
 
 	return nil
@@ -0,0 +1,654 @@
+package dnsclient
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net"
+	"strings"
+	"time"
+)
+
+// This file implements the subset of the DNS wire format (RFC 1035)
+// required for sending dynamic updates (RFC 2136) signed with
+// TSIG (RFC 8945) and for querying single records.
+
+const (
+	typeA     = 1
+	typeNS    = 2
+	typeCNAME = 5
+	typeSOA   = 6
+	typeAAAA  = 28
+	typeTSIG  = 250
+	typeANY   = 255
+
+	classINET = 1
+	classNONE = 254
+	classANY  = 255
+
+	opcodeQuery  = 0
+	opcodeUpdate = 5
+
+	rcodeSuccess  = 0
+	rcodeNXDomain = 3
+	rcodeNotAuth  = 9
+
+	headerLen = 12
+)
+
+var rcodeNames = map[int]string{
+	1:  "FORMERR",
+	2:  "SERVFAIL",
+	3:  "NXDOMAIN",
+	4:  "NOTIMP",
+	5:  "REFUSED",
+	6:  "YXDOMAIN",
+	7:  "YXRRSET",
+	8:  "NXRRSET",
+	9:  "NOTAUTH",
+	10: "NOTZONE",
+	16: "BADSIG",
+	17: "BADKEY",
+	18: "BADTIME",
+}
+
+var typeNames = map[string]uint16{
+	"A":     typeA,
+	"AAAA":  typeAAAA,
+	"CNAME": typeCNAME,
+	"NS":    typeNS,
+	"SOA":   typeSOA,
+}
+
+// RcodeError is returned when DNS server responded with
+// a non-zero rcode.
+type RcodeError struct {
+	Rcode int
+}
+
+// Error implements the built-in error interface.
+func (e *RcodeError) Error() string {
+	if name, ok := rcodeNames[e.Rcode]; ok {
+		return "dns server responded with " + name
+	}
+
+	return fmt.Sprintf("dns server responded with rcode %d", e.Rcode)
+}
+
+type question struct {
+	name  string
+	typ   uint16
+	class uint16
+}
+
+type resource struct {
+	name  string
+	typ   uint16
+	class uint16
+	ttl   uint32
+	data  []byte // uncompressed rdata
+}
+
+// message is a DNS message. For update messages the sections
+// are called zone, prerequisite, update and additional.
+type message struct {
+	id        uint16
+	response  bool
+	opcode    int
+	truncated bool
+	rcode     int
+
+	question   []question
+	answer     []resource
+	authority  []resource
+	additional []resource
+
+	tsigOffset int // offset of the TSIG record in the unpacked message, if any
+}
+
+func (m *message) pack() ([]byte, error) {
+	p := make([]byte, headerLen, 512)
+
+	var flags uint16
+
+	if m.response {
+		flags |= 1 << 15
+	}
+
+	if m.truncated {
+		flags |= 1 << 9
+	}
+
+	flags |= uint16(m.opcode&0xF) << 11
+	flags |= uint16(m.rcode & 0xF)
+
+	binary.BigEndian.PutUint16(p[0:], m.id)
+	binary.BigEndian.PutUint16(p[2:], flags)
+	binary.BigEndian.PutUint16(p[4:], uint16(len(m.question)))
+	binary.BigEndian.PutUint16(p[6:], uint16(len(m.answer)))
+	binary.BigEndian.PutUint16(p[8:], uint16(len(m.authority)))
+	binary.BigEndian.PutUint16(p[10:], uint16(len(m.additional)))
+
+	var err error
+
+	for _, q := range m.question {
+		if p, err = appendName(p, q.name); err != nil {
+			return nil, err
+		}
+
+		p = appendUint16(p, q.typ)
+		p = appendUint16(p, q.class)
+	}
+
+	for _, section := range [][]resource{m.answer, m.authority, m.additional} {
+		for i := range section {
+			if p, err = section[i].append(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func (rr *resource) append(p []byte) ([]byte, error) {
+	p, err := appendName(p, rr.name)
+	if err != nil {
+		return nil, err
+	}
+
+	p = appendUint16(p, rr.typ)
+	p = appendUint16(p, rr.class)
+	p = appendUint32(p, rr.ttl)
+	p = appendUint16(p, uint16(len(rr.data)))
+
+	return append(p, rr.data...), nil
+}
+
+func unpack(p []byte) (*message, error) {
+	if len(p) < headerLen {
+		return nil, errors.New("dns message too short")
+	}
+
+	flags := binary.BigEndian.Uint16(p[2:])
+
+	m := &message{
+		id:        binary.BigEndian.Uint16(p[0:]),
+		response:  flags&(1<<15) != 0,
+		opcode:    int(flags>>11) & 0xF,
+		truncated: flags&(1<<9) != 0,
+		rcode:     int(flags & 0xF),
+	}
+
+	var (
+		qdcount = int(binary.BigEndian.Uint16(p[4:]))
+		counts  = []int{
+			int(binary.BigEndian.Uint16(p[6:])),
+			int(binary.BigEndian.Uint16(p[8:])),
+			int(binary.BigEndian.Uint16(p[10:])),
+		}
+		off = headerLen
+		err error
+	)
+
+	for i := 0; i < qdcount; i++ {
+		var q question
+
+		if q.name, off, err = readName(p, off); err != nil {
+			return nil, err
+		}
+
+		if off+4 > len(p) {
+			return nil, errors.New("dns question truncated")
+		}
+
+		q.typ = binary.BigEndian.Uint16(p[off:])
+		q.class = binary.BigEndian.Uint16(p[off+2:])
+		off += 4
+
+		m.question = append(m.question, q)
+	}
+
+	sections := []*[]resource{&m.answer, &m.authority, &m.additional}
+
+	for i, section := range sections {
+		for j := 0; j < counts[i]; j++ {
+			start := off
+
+			var rr resource
+
+			if rr, off, err = readResource(p, off); err != nil {
+				return nil, err
+			}
+
+			if rr.typ == typeTSIG {
+				m.tsigOffset = start
+			}
+
+			*section = append(*section, rr)
+		}
+	}
+
+	return m, nil
+}
+
+func readResource(p []byte, off int) (rr resource, _ int, err error) {
+	if rr.name, off, err = readName(p, off); err != nil {
+		return rr, 0, err
+	}
+
+	if off+10 > len(p) {
+		return rr, 0, errors.New("dns resource truncated")
+	}
+
+	rr.typ = binary.BigEndian.Uint16(p[off:])
+	rr.class = binary.BigEndian.Uint16(p[off+2:])
+	rr.ttl = binary.BigEndian.Uint32(p[off+4:])
+	n := int(binary.BigEndian.Uint16(p[off+8:]))
+	off += 10
+
+	if off+n > len(p) {
+		return rr, 0, errors.New("dns resource data truncated")
+	}
+
+	switch rr.typ {
+	case typeCNAME, typeNS:
+		// Names in rdata may be compressed.
+		name, _, err := readName(p, off)
+		if err != nil {
+			return rr, 0, err
+		}
+
+		if rr.data, err = appendName(nil, name); err != nil {
+			return rr, 0, err
+		}
+	default:
+		rr.data = append([]byte(nil), p[off:off+n]...)
+	}
+
+	return rr, off + n, nil
+}
+
+// readName reads possibly compressed domain name, starting at off.
+func readName(p []byte, off int) (string, int, error) {
+	var (
+		labels []string
+		next   = -1 // offset after the name, once a pointer is followed
+		jumps  int
+	)
+
+	for {
+		if off >= len(p) {
+			return "", 0, errors.New("dns name truncated")
+		}
+
+		n := int(p[off])
+
+		switch {
+		case n == 0:
+			off++
+
+			if next == -1 {
+				next = off
+			}
+
+			return strings.Join(labels, ".") + ".", next, nil
+		case n&0xC0 == 0xC0:
+			if off+1 >= len(p) {
+				return "", 0, errors.New("dns name pointer truncated")
+			}
+
+			if jumps++; jumps > 32 {
+				return "", 0, errors.New("dns name has too many pointers")
+			}
+
+			if next == -1 {
+				next = off + 2
+			}
+
+			off = int(binary.BigEndian.Uint16(p[off:]) & 0x3FFF)
+		case n&0xC0 != 0:
+			return "", 0, errors.New("dns name has invalid label")
+		default:
+			if off+1+n > len(p) {
+				return "", 0, errors.New("dns label truncated")
+			}
+
+			labels = append(labels, string(p[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+// appendName appends uncompressed wire representation of the name.
+//
+// Route53 escapes wildcard labels as \052, it is unescaped
+// for consistency.
+func appendName(p []byte, name string) ([]byte, error) {
+	name = fqdn(name)
+
+	if name == "." {
+		return append(p, 0), nil
+	}
+
+	if len(name) > 255 {
+		return nil, fmt.Errorf("dns name %q is too long", name)
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == `\052` {
+			label = "*"
+		}
+
+		if label == "" || len(label) > 63 {
+			return nil, fmt.Errorf("dns name %q is invalid", name)
+		}
+
+		p = append(p, byte(len(label)))
+		p = append(p, label...)
+	}
+
+	return append(p, 0), nil
+}
+
+func appendUint16(p []byte, v uint16) []byte {
+	return append(p, byte(v>>8), byte(v))
+}
+
+func appendUint32(p []byte, v uint32) []byte {
+	return append(p, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// fqdn gives fully qualified domain name, which ends with a ".".
+func fqdn(name string) string {
+	name = strings.Replace(name, `\052`, "*", -1)
+
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	return name + "."
+}
+
+// newResource gives a resource record for the given record.
+func newResource(rec *Record) (resource, error) {
+	rr := resource{
+		name:  rec.Name,
+		class: classINET,
+		ttl:   uint32(rec.TTL),
+	}
+
+	typ, ok := typeNames[strings.ToUpper(rec.Type)]
+	if !ok {
+		return rr, fmt.Errorf("unsupported record type %q", rec.Type)
+	}
+
+	rr.typ = typ
+
+	switch typ {
+	case typeA, typeAAAA:
+		ip := net.ParseIP(rec.IP)
+		if ip == nil {
+			return rr, fmt.Errorf("invalid IP address %q", rec.IP)
+		}
+
+		if typ == typeA {
+			if ip = ip.To4(); ip == nil {
+				return rr, fmt.Errorf("invalid IPv4 address %q", rec.IP)
+			}
+		} else {
+			ip = ip.To16()
+		}
+
+		rr.data = []byte(ip)
+	default:
+		data, err := appendName(nil, rec.IP)
+		if err != nil {
+			return rr, err
+		}
+
+		rr.data = data
+	}
+
+	return rr, nil
+}
+
+// record gives a record for the resource record of A, AAAA
+// or CNAME type.
+func (rr *resource) record() (*Record, bool) {
+	rec := &Record{
+		Name: strings.TrimSuffix(rr.name, "."),
+		TTL:  int(rr.ttl),
+	}
+
+	switch rr.typ {
+	case typeA, typeAAAA:
+		if len(rr.data) != net.IPv4len && len(rr.data) != net.IPv6len {
+			return nil, false
+		}
+
+		rec.Type = "A"
+		if rr.typ == typeAAAA {
+			rec.Type = "AAAA"
+		}
+
+		rec.IP = net.IP(rr.data).String()
+	case typeCNAME:
+		name, _, err := readName(rr.data, 0)
+		if err != nil {
+			return nil, false
+		}
+
+		rec.Type = "CNAME"
+		rec.IP = strings.TrimSuffix(name, ".")
+	default:
+		return nil, false
+	}
+
+	return rec, true
+}
+
+// tsigFudge is a permitted time difference between signing
+// and verifying the message.
+const tsigFudge = 300
+
+var tsigAlgorithms = map[string]func() hash.Hash{
+	"hmac-md5.sig-alg.reg.int.": md5.New,
+	"hmac-sha1.":                sha1.New,
+	"hmac-sha256.":              sha256.New,
+	"hmac-sha512.":              sha512.New,
+}
+
+// tsigKey signs and verifies messages with TSIG.
+type tsigKey struct {
+	name      string
+	algorithm string
+	secret    []byte
+	hash      func() hash.Hash
+}
+
+func newTSIGKey(name, algorithm, secret string) (*tsigKey, error) {
+	if algorithm == "" {
+		algorithm = "hmac-sha256"
+	}
+
+	algorithm = strings.ToLower(fqdn(algorithm))
+
+	if algorithm == "hmac-md5." {
+		algorithm = "hmac-md5.sig-alg.reg.int."
+	}
+
+	fn, ok := tsigAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TSIG algorithm %q", algorithm)
+	}
+
+	p, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TSIG secret: %s", err)
+	}
+
+	return &tsigKey{
+		name:      strings.ToLower(fqdn(name)),
+		algorithm: algorithm,
+		secret:    p,
+		hash:      fn,
+	}, nil
+}
+
+// sign appends TSIG record to the packed message. When signing
+// a response, requestMAC is MAC of the request.
+func (k *tsigKey) sign(p, requestMAC []byte, now time.Time) ([]byte, []byte, error) {
+	if len(p) < headerLen {
+		return nil, nil, errors.New("dns message too short")
+	}
+
+	signed := uint64(now.Unix())
+
+	mac, err := k.mac(p, requestMAC, signed, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := appendName(nil, k.algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data = appendUint48(data, signed)
+	data = appendUint16(data, tsigFudge)
+	data = appendUint16(data, uint16(len(mac)))
+	data = append(data, mac...)
+	data = append(data, p[0], p[1]) // original ID
+	data = appendUint16(data, 0)    // error
+	data = appendUint16(data, 0)    // other len
+
+	rr := resource{
+		name:  k.name,
+		typ:   typeTSIG,
+		class: classANY,
+		data:  data,
+	}
+
+	out := append([]byte(nil), p...)
+
+	if out, err = rr.append(out); err != nil {
+		return nil, nil, err
+	}
+
+	binary.BigEndian.PutUint16(out[10:], binary.BigEndian.Uint16(out[10:])+1)
+
+	return out, mac, nil
+}
+
+// verify verifies TSIG record of the unpacked message m, which is
+// required to be the last one. It gives the MAC of the message.
+func (k *tsigKey) verify(p []byte, m *message, requestMAC []byte, now time.Time) ([]byte, error) {
+	if len(m.additional) == 0 || m.additional[len(m.additional)-1].typ != typeTSIG {
+		return nil, errors.New("dns message is not signed")
+	}
+
+	rr := m.additional[len(m.additional)-1]
+
+	if strings.ToLower(rr.name) != k.name {
+		return nil, &RcodeError{Rcode: 17} // BADKEY
+	}
+
+	algorithm, off, err := readName(rr.data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ToLower(algorithm) != k.algorithm {
+		return nil, &RcodeError{Rcode: 17} // BADKEY
+	}
+
+	if off+10 > len(rr.data) {
+		return nil, errors.New("dns TSIG record truncated")
+	}
+
+	signed := readUint48(rr.data[off:])
+	fudge := int64(binary.BigEndian.Uint16(rr.data[off+6:]))
+	n := int(binary.BigEndian.Uint16(rr.data[off+8:]))
+	off += 10
+
+	if off+n+6 > len(rr.data) {
+		return nil, errors.New("dns TSIG record truncated")
+	}
+
+	mac := rr.data[off : off+n]
+	origID := rr.data[off+n : off+n+2]
+	tsigErr := binary.BigEndian.Uint16(rr.data[off+n+2:])
+
+	if tsigErr != 0 {
+		return nil, &RcodeError{Rcode: int(tsigErr)}
+	}
+
+	// Recreate the message as it was before signing.
+	orig := append([]byte(nil), p[:m.tsigOffset]...)
+	orig[0], orig[1] = origID[0], origID[1]
+	binary.BigEndian.PutUint16(orig[10:], uint16(len(m.additional)-1))
+
+	want, err := k.mac(orig, requestMAC, signed, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(mac, want) {
+		return nil, &RcodeError{Rcode: 16} // BADSIG
+	}
+
+	if d := now.Unix() - int64(signed); d > fudge || d < -fudge {
+		return nil, &RcodeError{Rcode: 18} // BADTIME
+	}
+
+	return mac, nil
+}
+
+func (k *tsigKey) mac(p, requestMAC []byte, signed uint64, tsigErr uint16) ([]byte, error) {
+	h := hmac.New(k.hash, k.secret)
+
+	if requestMAC != nil {
+		h.Write(appendUint16(nil, uint16(len(requestMAC))))
+		h.Write(requestMAC)
+	}
+
+	h.Write(p)
+
+	vars, err := appendName(nil, k.name)
+	if err != nil {
+		return nil, err
+	}
+
+	vars = appendUint16(vars, classANY)
+	vars = appendUint32(vars, 0) // TTL
+
+	if vars, err = appendName(vars, k.algorithm); err != nil {
+		return nil, err
+	}
+
+	vars = appendUint48(vars, signed)
+	vars = appendUint16(vars, tsigFudge)
+	vars = appendUint16(vars, tsigErr)
+	vars = appendUint16(vars, 0) // other len
+
+	h.Write(vars)
+
+	return h.Sum(nil), nil
+}
+
+func appendUint48(p []byte, v uint64) []byte {
+	return append(p, byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func readUint48(p []byte) uint64 {
+	return uint64(p[0])<<40 | uint64(p[1])<<32 | uint64(p[2])<<24 |
+		uint64(p[3])<<16 | uint64(p[4])<<8 | uint64(p[5])
+}
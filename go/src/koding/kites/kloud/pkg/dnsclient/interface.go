@@ -31,3 +31,18 @@ type Client interface {
 	// Validate validates if the given domain name is valid
 	Validate(domainName, username string) error
 }
+
+// RecordClient is a Client, which manages records of any type.
+type RecordClient interface {
+	Client
+
+	// UpsertRecord creates or updates a DNS record.
+	UpsertRecord(rec *Record) error
+
+	// UpsertRecords creates or updates the DNS records
+	// in a single change.
+	UpsertRecords(recs ...*Record) error
+
+	// DeleteRecord deletes the given record.
+	DeleteRecord(rec *Record) error
+}
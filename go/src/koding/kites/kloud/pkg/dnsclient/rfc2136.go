@@ -0,0 +1,408 @@
+package dnsclient
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// RFC2136 is a DNS client, which manages records with dynamic
+// updates (RFC 2136) sent to an authoritative name server,
+// like BIND or PowerDNS.
+type RFC2136 struct {
+	opts *Options
+	zone string // fully qualified hosted zone
+	key  *tsigKey
+}
+
+// NewRFC2136Client gives new DNS client, which sends dynamic updates
+// of the opts.HostedZone to the opts.Server name server.
+func NewRFC2136Client(opts *Options) (*RFC2136, error) {
+	optsCopy := *opts
+
+	if optsCopy.HostedZone == "" {
+		return nil, errors.New("hosted zone is empty")
+	}
+
+	if optsCopy.Server == "" {
+		return nil, errors.New("name server address is empty")
+	}
+
+	if _, _, err := net.SplitHostPort(optsCopy.Server); err != nil {
+		optsCopy.Server = net.JoinHostPort(optsCopy.Server, "53")
+	}
+
+	r := &RFC2136{
+		opts: &optsCopy,
+		zone: fqdn(optsCopy.HostedZone),
+	}
+
+	if optsCopy.TSIGName != "" {
+		key, err := newTSIGKey(optsCopy.TSIGName, optsCopy.TSIGAlgorithm, optsCopy.TSIGSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		r.key = key
+	}
+
+	return r, nil
+}
+
+// Upsert creates or updates the domain record with the given ip address. If
+// the record already exists, the record is updated with the new IP.
+func (r *RFC2136) Upsert(domain, newIP string) error {
+	rec := &Record{
+		Name: domain,
+		Type: "A",
+		IP:   newIP,
+		TTL:  30,
+	}
+	return r.UpsertRecord(rec)
+}
+
+// UpsertRecord creates or updates a DNS record.
+func (r *RFC2136) UpsertRecord(rec *Record) error {
+	return r.UpsertRecords(rec)
+}
+
+// UpsertRecords creates or updates the DNS records in a single update.
+//
+// Existing records of the same type are replaced. Since a CNAME
+// record can't coexist with other records, upserting a CNAME
+// replaces address records and vice versa.
+func (r *RFC2136) UpsertRecords(recs ...*Record) error {
+	var (
+		deletes []resource
+		adds    []resource
+		seen    = make(map[string]bool)
+	)
+
+	for _, rec := range recs {
+		r.opts.log().Debug("upserting record: %# v", rec)
+
+		rr, err := newResource(rec)
+		if err != nil {
+			return r.errorf("could not upsert record %v: %s", rec, err)
+		}
+
+		types := []uint16{rr.typ}
+
+		switch rr.typ {
+		case typeA, typeAAAA:
+			types = append(types, typeCNAME)
+		case typeCNAME:
+			types = append(types, typeA, typeAAAA)
+		}
+
+		for _, typ := range types {
+			key := fmt.Sprintf("%s/%d", strings.ToLower(fqdn(rec.Name)), typ)
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			deletes = append(deletes, deleteRRset(rec.Name, typ))
+		}
+
+		adds = append(adds, rr)
+	}
+
+	if err := r.update(append(deletes, adds...)); err != nil {
+		return r.errorf("Upserting domains failed: %s", err)
+	}
+
+	return nil
+}
+
+// Get retrieves the address or alias record for the given domain name.
+func (r *RFC2136) Get(domain string) (*Record, error) {
+	r.opts.log().Debug("fetching domain record for domain: %s", domain)
+
+	for _, typ := range []uint16{typeA, typeAAAA} {
+		rec, err := r.get(domain, typ)
+		if err == ErrNoRecord {
+			continue
+		}
+
+		if err != nil {
+			return nil, r.errorf("could not fetch record for domain %q: %s", domain, err)
+		}
+
+		return rec, nil
+	}
+
+	return nil, ErrNoRecord
+}
+
+func (r *RFC2136) get(domain string, typ uint16) (*Record, error) {
+	m := &message{
+		opcode: opcodeQuery,
+		question: []question{{
+			name:  domain,
+			typ:   typ,
+			class: classINET,
+		}},
+	}
+
+	resp, err := r.exchange(m)
+	if e, ok := err.(*RcodeError); ok && e.Rcode == rcodeNXDomain {
+		return nil, ErrNoRecord
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(fqdn(domain))
+
+	for i := range resp.answer {
+		if strings.ToLower(resp.answer[i].name) != name {
+			continue
+		}
+
+		if rec, ok := resp.answer[i].record(); ok {
+			return rec, nil
+		}
+	}
+
+	return nil, ErrNoRecord
+}
+
+// Rename changes the domain from oldDomain to newDomain in a single update.
+func (r *RFC2136) Rename(oldDomain, newDomain string) error {
+	rec, err := r.Get(oldDomain)
+	if err != nil {
+		return err
+	}
+
+	r.opts.log().Debug("updating domain name of IP %s from %q to %q", rec.IP, oldDomain, newDomain)
+
+	rr, err := newResource(&Record{
+		Name: newDomain,
+		Type: rec.Type,
+		IP:   rec.IP,
+		TTL:  rec.TTL,
+	})
+	if err != nil {
+		return r.errorf("could not rename domain %q to %q: %s", oldDomain, newDomain, err)
+	}
+
+	typ := typeNames[rec.Type]
+
+	if err := r.update([]resource{deleteRRset(oldDomain, typ), rr}); err != nil {
+		return r.errorf("could not rename domain %q to %q: %s", oldDomain, newDomain, err)
+	}
+
+	return nil
+}
+
+// Delete deletes address and alias records of the given domain.
+//
+// Deleting a domain, which has no records, is a no-op.
+func (r *RFC2136) Delete(domain string) error {
+	r.opts.log().Debug("deleting records of domain: %s", domain)
+
+	rrs := []resource{
+		deleteRRset(domain, typeA),
+		deleteRRset(domain, typeAAAA),
+		deleteRRset(domain, typeCNAME),
+	}
+
+	if err := r.update(rrs); err != nil {
+		return r.errorf("could not delete domain %q: %s", domain, err)
+	}
+
+	return nil
+}
+
+// DeleteRecord deletes the given record.
+func (r *RFC2136) DeleteRecord(rec *Record) error {
+	r.opts.log().Debug("deleting record: %v", rec)
+
+	rr, err := newResource(rec)
+	if err != nil {
+		return r.errorf("could not delete record %v: %s", rec, err)
+	}
+
+	// Deleting a single record is denoted by the NONE class.
+	rr.class = classNONE
+	rr.ttl = 0
+
+	if err := r.update([]resource{rr}); err != nil {
+		return r.errorf("could not delete record %v: %s", rec, err)
+	}
+
+	return nil
+}
+
+// HostedZone returns the zone, which is updated by the client.
+func (r *RFC2136) HostedZone() string {
+	return r.opts.HostedZone
+}
+
+// Validate validates if the given domain name is valid.
+func (r *RFC2136) Validate(domain, username string) error {
+	if err := validate(r.HostedZone(), domain, username); err != nil {
+		return r.errorf("%s", err)
+	}
+
+	return nil
+}
+
+// deleteRRset gives an update, which deletes all records
+// of the given type.
+func deleteRRset(name string, typ uint16) resource {
+	return resource{
+		name:  name,
+		typ:   typ,
+		class: classANY,
+	}
+}
+
+func (r *RFC2136) update(rrs []resource) error {
+	m := &message{
+		opcode: opcodeUpdate,
+		question: []question{{ // zone section
+			name:  r.zone,
+			typ:   typeSOA,
+			class: classINET,
+		}},
+		authority: rrs, // update section
+	}
+
+	_, err := r.exchange(m)
+	return err
+}
+
+// exchange sends the message over UDP, falling back to TCP when
+// the response was truncated.
+func (r *RFC2136) exchange(m *message) (*message, error) {
+	var id [2]byte
+
+	if _, err := io.ReadFull(rand.Reader, id[:]); err != nil {
+		return nil, err
+	}
+
+	m.id = binary.BigEndian.Uint16(id[:])
+
+	p, err := m.pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var mac []byte
+
+	if r.key != nil {
+		if p, mac, err = r.key.sign(p, nil, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, raw, err := r.roundTrip("udp", p, m.id)
+	if err == nil && resp.truncated {
+		resp, raw, err = r.roundTrip("tcp", p, m.id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var verifyErr error
+
+	if r.key != nil {
+		_, verifyErr = r.key.verify(raw, resp, mac, time.Now())
+	}
+
+	// Servers do not sign responses to requests signed with
+	// an unknown key, thus the rcode is more descriptive.
+	if resp.rcode != rcodeSuccess {
+		return nil, &RcodeError{Rcode: resp.rcode}
+	}
+
+	if verifyErr != nil {
+		return nil, fmt.Errorf("invalid response signature: %s", verifyErr)
+	}
+
+	return resp, nil
+}
+
+func (r *RFC2136) roundTrip(network string, p []byte, id uint16) (*message, []byte, error) {
+	timeout := r.opts.timeout()
+
+	conn, err := net.DialTimeout(network, r.opts.Server, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, nil, err
+	}
+
+	if network == "tcp" {
+		return roundTripTCP(conn, p, id)
+	}
+
+	if _, err := conn.Write(p); err != nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, 65535)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resp, err := unpack(buf[:n])
+		if err != nil || resp.id != id || !resp.response {
+			continue // ignore malformed and stray responses
+		}
+
+		return resp, buf[:n], nil
+	}
+}
+
+func roundTripTCP(conn net.Conn, p []byte, id uint16) (*message, []byte, error) {
+	if _, err := conn.Write(append(appendUint16(nil, uint16(len(p))), p...)); err != nil {
+		return nil, nil, err
+	}
+
+	var n [2]byte
+
+	if _, err := io.ReadFull(conn, n[:]); err != nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, binary.BigEndian.Uint16(n[:]))
+
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := unpack(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.id != id {
+		return nil, nil, errors.New("dns response has unexpected ID")
+	}
+
+	return resp, buf, nil
+}
+
+func (r *RFC2136) errorf(format string, v ...interface{}) error {
+	err := fmt.Errorf(format, v...)
+	r.opts.log().Error(err.Error())
+	return err
+}
@@ -0,0 +1,346 @@
+package dnsclient
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/koding/logging"
+)
+
+const testSecret = "c2VjcmV0LWtleS1mb3ItdGVzdGluZw=="
+
+// testServer is a stand-in for an authoritative name server, which
+// applies TSIG-signed dynamic updates to an in-memory zone.
+type testServer struct {
+	conn net.PacketConn
+	zone string
+	key  *tsigKey
+
+	mu      sync.Mutex
+	records map[string][]resource // by lowercase fqdn
+}
+
+func newTestServer(t *testing.T, zone string) *testServer {
+	key, err := newTSIGKey("kloud.", "hmac-sha256", testSecret)
+	if err != nil {
+		t.Fatalf("newTSIGKey()=%s", err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket()=%s", err)
+	}
+
+	s := &testServer{
+		conn:    conn,
+		zone:    fqdn(zone),
+		key:     key,
+		records: make(map[string][]resource),
+	}
+
+	go s.serve()
+
+	return s
+}
+
+func (s *testServer) Close() error {
+	return s.conn.Close()
+}
+
+func (s *testServer) serve() {
+	buf := make([]byte, 65535)
+
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if resp := s.handle(buf[:n]); resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *testServer) handle(p []byte) []byte {
+	req, err := unpack(p)
+	if err != nil {
+		return nil
+	}
+
+	resp := &message{
+		id:       req.id,
+		response: true,
+		opcode:   req.opcode,
+		question: req.question,
+	}
+
+	mac, err := s.key.verify(p, req, nil, time.Now())
+	if err != nil {
+		resp.rcode = rcodeNotAuth
+
+		p, _ := resp.pack()
+		return p
+	}
+
+	s.mu.Lock()
+	switch req.opcode {
+	case opcodeUpdate:
+		resp.rcode = s.update(req)
+	case opcodeQuery:
+		resp.rcode, resp.answer = s.query(req.question[0])
+	}
+	s.mu.Unlock()
+
+	out, err := resp.pack()
+	if err != nil {
+		return nil
+	}
+
+	out, _, err = s.key.sign(out, mac, time.Now())
+	if err != nil {
+		return nil
+	}
+
+	return out
+}
+
+func (s *testServer) update(req *message) int {
+	if len(req.question) != 1 || !strings.EqualFold(req.question[0].name, s.zone) {
+		return 10 // NOTZONE
+	}
+
+	for _, rr := range req.authority {
+		name := strings.ToLower(rr.name)
+
+		if !strings.HasSuffix(name, "."+s.zone) {
+			return 10 // NOTZONE
+		}
+
+		var kept []resource
+
+		for _, existing := range s.records[name] {
+			switch rr.class {
+			case classANY:
+				if rr.typ == typeANY || rr.typ == existing.typ {
+					continue
+				}
+			case classNONE, classINET:
+				if rr.typ == existing.typ && bytes.Equal(rr.data, existing.data) {
+					continue
+				}
+			}
+
+			kept = append(kept, existing)
+		}
+
+		if rr.class == classINET {
+			rr.name = name
+			kept = append(kept, rr)
+		}
+
+		s.records[name] = kept
+	}
+
+	return rcodeSuccess
+}
+
+func (s *testServer) query(q question) (int, []resource) {
+	rrs, ok := s.records[strings.ToLower(q.name)]
+	if !ok || len(rrs) == 0 {
+		return rcodeNXDomain, nil
+	}
+
+	var answer []resource
+
+	for _, rr := range rrs {
+		if rr.typ == q.typ || rr.typ == typeCNAME {
+			answer = append(answer, rr)
+		}
+	}
+
+	return rcodeSuccess, answer
+}
+
+func newTestRFC2136(t *testing.T, s *testServer, secret string) *RFC2136 {
+	c, err := NewRFC2136Client(&Options{
+		HostedZone: strings.TrimSuffix(s.zone, "."),
+		Server:     s.conn.LocalAddr().String(),
+		TSIGName:   "kloud",
+		TSIGSecret: secret,
+		Timeout:    5 * time.Second,
+		Log:        logging.NewCustom("dnsclient", testing.Verbose()),
+	})
+	if err != nil {
+		t.Fatalf("NewRFC2136Client()=%s", err)
+	}
+
+	return c
+}
+
+func TestRFC2136(t *testing.T) {
+	s := newTestServer(t, "example.com")
+	defer s.Close()
+
+	c := newTestRFC2136(t, s, testSecret)
+
+	get := func(domain, typ, ip string) {
+		rec, err := c.Get(domain)
+		if err != nil {
+			t.Fatalf("Get(%q)=%s", domain, err)
+		}
+
+		if rec.Name != domain || rec.Type != typ || rec.IP != ip {
+			t.Fatalf("got %+v, want %s %s %s", rec, domain, typ, ip)
+		}
+	}
+
+	notFound := func(domain string) {
+		if rec, err := c.Get(domain); err != ErrNoRecord {
+			t.Fatalf("got %+v, %v; want %v", rec, err, ErrNoRecord)
+		}
+	}
+
+	notFound("foo.user.example.com")
+
+	if err := c.Upsert("foo.user.example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("Upsert()=%s", err)
+	}
+
+	get("foo.user.example.com", "A", "10.0.0.1")
+
+	if err := c.Upsert("foo.user.example.com", "10.0.0.2"); err != nil {
+		t.Fatalf("Upsert()=%s", err)
+	}
+
+	get("foo.user.example.com", "A", "10.0.0.2")
+
+	err := c.UpsertRecords(&Record{
+		Name: "user.example.com",
+		Type: "A",
+		IP:   "10.0.0.3",
+		TTL:  300,
+	}, &Record{
+		Name: `\052.user.example.com`,
+		Type: "CNAME",
+		IP:   "user.example.com",
+		TTL:  300,
+	})
+	if err != nil {
+		t.Fatalf("UpsertRecords()=%s", err)
+	}
+
+	get("user.example.com", "A", "10.0.0.3")
+	get("*.user.example.com", "CNAME", "user.example.com")
+
+	if err := c.Rename("foo.user.example.com", "bar.user.example.com"); err != nil {
+		t.Fatalf("Rename()=%s", err)
+	}
+
+	notFound("foo.user.example.com")
+	get("bar.user.example.com", "A", "10.0.0.2")
+
+	err = c.DeleteRecord(&Record{
+		Name: "bar.user.example.com",
+		Type: "A",
+		IP:   "10.0.0.2",
+	})
+	if err != nil {
+		t.Fatalf("DeleteRecord()=%s", err)
+	}
+
+	notFound("bar.user.example.com")
+
+	if err := c.Delete("user.example.com"); err != nil {
+		t.Fatalf("Delete()=%s", err)
+	}
+
+	notFound("user.example.com")
+
+	// Deleting a domain, which has no records, is a no-op.
+	if err := c.Delete("user.example.com"); err != nil {
+		t.Fatalf("Delete()=%s", err)
+	}
+
+	if err := c.Upsert("foo.user.example.org", "10.0.0.1"); err == nil {
+		t.Fatal("expected upserting domain outside of the zone to fail")
+	}
+}
+
+func TestRFC2136BadKey(t *testing.T) {
+	s := newTestServer(t, "example.com")
+	defer s.Close()
+
+	c := newTestRFC2136(t, s, "b3RoZXIta2V5")
+
+	err := c.Upsert("foo.user.example.com", "10.0.0.1")
+	if err == nil || !strings.Contains(err.Error(), "NOTAUTH") {
+		t.Fatalf("got %v, want NOTAUTH", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.records) != 0 {
+		t.Fatalf("got %d records, want none", len(s.records))
+	}
+}
+
+func TestTSIG(t *testing.T) {
+	for _, alg := range []string{"hmac-md5", "hmac-sha1", "hmac-sha256", "hmac-sha512"} {
+		t.Run(alg, func(t *testing.T) {
+			key, err := newTSIGKey("kloud", alg, testSecret)
+			if err != nil {
+				t.Fatalf("newTSIGKey()=%s", err)
+			}
+
+			m := &message{
+				id:     42,
+				opcode: opcodeQuery,
+				question: []question{{
+					name:  "example.com",
+					typ:   typeA,
+					class: classINET,
+				}},
+			}
+
+			p, err := m.pack()
+			if err != nil {
+				t.Fatalf("pack()=%s", err)
+			}
+
+			now := time.Now()
+
+			if p, _, err = key.sign(p, nil, now); err != nil {
+				t.Fatalf("sign()=%s", err)
+			}
+
+			signed, err := unpack(p)
+			if err != nil {
+				t.Fatalf("unpack()=%s", err)
+			}
+
+			if _, err := key.verify(p, signed, nil, now); err != nil {
+				t.Fatalf("verify()=%s", err)
+			}
+
+			if _, err := key.verify(p, signed, nil, now.Add(time.Hour)); err == nil {
+				t.Fatal("expected verifying expired signature to fail")
+			}
+
+			p[headerLen+1] ^= 0x01 // tamper with the question name
+
+			if signed, err = unpack(p); err != nil {
+				t.Fatalf("unpack()=%s", err)
+			}
+
+			if _, err := key.verify(p, signed, nil, now); err == nil {
+				t.Fatal("expected verifying tampered message to fail")
+			}
+		})
+	}
+}
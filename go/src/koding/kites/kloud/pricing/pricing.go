@@ -0,0 +1,233 @@
+// Package pricing provides offline price tables, which are used
+// to estimate cost of running stack machines.
+//
+// Each kloud provider may register its default price table,
+// which can be replaced by loading JSON tables from disk.
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"koding/kites/kloud/stack"
+)
+
+// HoursPerMonth is an average number of hours in a month, used
+// to convert between hourly and monthly prices.
+const HoursPerMonth = 730
+
+// DefaultRegion is a key of prices, which are used for regions
+// that are not explicitly listed in a table.
+const DefaultRegion = "*"
+
+var (
+	mu     sync.RWMutex
+	tables = make(map[string]*Table)
+)
+
+// Spec describes a single machine, which cost is estimated.
+type Spec struct {
+	Region       string
+	InstanceType string
+	DiskSize     int // in GB
+}
+
+// Prices describes prices within a single region.
+type Prices struct {
+	// Instances maps instance types to their hourly prices.
+	Instances map[string]float64 `json:"instances"`
+
+	// Disk is a monthly price of 1GB of storage.
+	Disk float64 `json:"disk"`
+}
+
+// Shape describes compute resources of an instance type.
+type Shape struct {
+	CPU    int     `json:"cpu"`    // vCPUs
+	Memory float64 `json:"memory"` // in GB
+}
+
+// Table is a price table of a single provider.
+type Table struct {
+	Provider string             `json:"provider"`
+	Currency string             `json:"currency"`
+	Regions  map[string]*Prices `json:"regions"`
+
+	// Shapes maps instance types to their compute resources.
+	Shapes map[string]*Shape `json:"shapes,omitempty"`
+}
+
+var _ stack.Validator = (*Table)(nil)
+
+// Valid implements the stack.Validator interface.
+func (t *Table) Valid() error {
+	if t.Provider == "" {
+		return errors.New("price table has empty provider")
+	}
+
+	if t.Currency == "" {
+		return fmt.Errorf("%s: price table has empty currency", t.Provider)
+	}
+
+	if len(t.Regions) == 0 {
+		return fmt.Errorf("%s: price table has no regions", t.Provider)
+	}
+
+	return nil
+}
+
+// Estimate gives cost of running a machine described by the given spec.
+//
+// If the spec's region is not present in the table, default region
+// prices are used instead.
+func (t *Table) Estimate(spec *Spec) (*stack.Cost, error) {
+	prices, ok := t.Regions[spec.Region]
+	if !ok {
+		if prices, ok = t.Regions[DefaultRegion]; !ok {
+			return nil, fmt.Errorf("%s: no prices for %q region", t.Provider, spec.Region)
+		}
+	}
+
+	instance, ok := prices.Instances[spec.InstanceType]
+	if !ok {
+		return nil, fmt.Errorf("%s: no price for %q instance type", t.Provider, spec.InstanceType)
+	}
+
+	hourly := instance + prices.Disk*float64(spec.DiskSize)/HoursPerMonth
+
+	return newCost(hourly, t.Currency), nil
+}
+
+// Sum gives total cost of the given costs.
+//
+// It returns non-nil error if the costs have different currencies.
+func Sum(costs ...*stack.Cost) (*stack.Cost, error) {
+	if len(costs) == 0 {
+		return nil, nil
+	}
+
+	total := &stack.Cost{
+		Currency: costs[0].Currency,
+	}
+
+	for _, cost := range costs {
+		if cost.Currency != total.Currency {
+			return nil, fmt.Errorf("unable to sum costs of different currencies: %s and %s",
+				total.Currency, cost.Currency)
+		}
+
+		total.Hourly += cost.Hourly
+		total.Monthly += cost.Monthly
+	}
+
+	total.Hourly = round(total.Hourly, 10000)
+	total.Monthly = round(total.Monthly, 100)
+
+	return total, nil
+}
+
+// Register registers a price table for the provider, replacing
+// the table that was registered previously, if any.
+func Register(t *Table) {
+	if err := t.Valid(); err != nil {
+		panic("pricing: " + err.Error())
+	}
+
+	mu.Lock()
+	tables[t.Provider] = t
+	mu.Unlock()
+}
+
+// Lookup gives a price table of the given provider.
+func Lookup(provider string) (*Table, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t, ok := tables[provider]
+	return t, ok
+}
+
+// Load reads a price table encoded in JSON.
+func Load(r io.Reader) (*Table, error) {
+	var t Table
+
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return nil, err
+	}
+
+	if err := t.Valid(); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// LoadDir reads all *.json price tables from the given directory
+// and registers them.
+func LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		t, err := loadFile(file)
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+
+		Register(t)
+	}
+
+	return nil
+}
+
+func loadFile(file string) (*Table, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+func newCost(hourly float64, currency string) *stack.Cost {
+	return &stack.Cost{
+		Hourly:   round(hourly, 10000),
+		Monthly:  round(hourly*HoursPerMonth, 100),
+		Currency: currency,
+	}
+}
+
+func round(f, prec float64) float64 {
+	return float64(int64(f*prec+0.5)) / prec
+}
+
+// Size converts a disk size read from a template to int.
+//
+// It returns 0 if the value is not a valid size.
+func Size(v interface{}) int {
+	switch v := v.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0
+		}
+		return n
+	default:
+		return 0
+	}
+}
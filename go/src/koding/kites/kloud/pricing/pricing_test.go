@@ -0,0 +1,102 @@
+package pricing_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"koding/kites/kloud/pricing"
+	"koding/kites/kloud/stack"
+)
+
+func TestLoadDir(t *testing.T) {
+	if err := pricing.LoadDir("testdata"); err != nil {
+		t.Fatalf("LoadDir()=%s", err)
+	}
+
+	table, ok := pricing.Lookup("aws")
+	if !ok {
+		t.Fatal("want aws price table to be registered")
+	}
+
+	cases := map[string]struct {
+		spec *pricing.Spec
+		want *stack.Cost
+	}{
+		"region": {
+			&pricing.Spec{Region: "eu-central-1", InstanceType: "t2.micro", DiskSize: 8},
+			&stack.Cost{Hourly: 0.0147, Monthly: 10.73, Currency: "EUR"},
+		},
+		"default region": {
+			&pricing.Spec{Region: "us-east-1", InstanceType: "t2.micro", DiskSize: 8},
+			&stack.Cost{Hourly: 0.0127, Monthly: 9.27, Currency: "EUR"},
+		},
+		"unknown instance type": {
+			&pricing.Spec{Region: "us-east-1", InstanceType: "t2.nano"},
+			nil,
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := table.Estimate(cas.spec)
+			if cas.want == nil {
+				if err == nil {
+					t.Fatalf("want err != nil, got %+v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Estimate()=%s", err)
+			}
+
+			if !reflect.DeepEqual(got, cas.want) {
+				t.Fatalf("got %+v, want %+v", got, cas.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cases := map[string]string{
+		"missing provider": `{"currency": "USD", "regions": {"*": {}}}`,
+		"missing currency": `{"provider": "aws", "regions": {"*": {}}}`,
+		"missing regions":  `{"provider": "aws", "currency": "USD"}`,
+		"invalid json":     `{"provider": "aws",`,
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := pricing.Load(strings.NewReader(cas)); err == nil {
+				t.Fatal("want err != nil")
+			}
+		})
+	}
+}
+
+func TestSum(t *testing.T) {
+	costs := []*stack.Cost{
+		{Hourly: 0.0069, Monthly: 5.03, Currency: "USD"},
+		{Hourly: 0.0081, Monthly: 5.95, Currency: "USD"},
+	}
+
+	want := &stack.Cost{Hourly: 0.015, Monthly: 10.98, Currency: "USD"}
+
+	got, err := pricing.Sum(costs...)
+	if err != nil {
+		t.Fatalf("Sum()=%s", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := pricing.Sum(append(costs, &stack.Cost{Currency: "EUR"})...); err == nil {
+		t.Fatal("want err != nil")
+	}
+
+	if got, err := pricing.Sum(); err != nil || got != nil {
+		t.Fatalf("got %+v, %v; want nil, nil", got, err)
+	}
+}
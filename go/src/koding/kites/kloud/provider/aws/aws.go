@@ -30,6 +30,8 @@ var Provider = &provider.Provider{
 		NewBootstrap:  newBootstrap,
 		NewMetadata:   newMetadata,
 	},
+	Prices:    Prices,
+	PriceSpec: priceSpec,
 }
 
 func init() {
@@ -93,6 +95,16 @@ type Cred struct {
 	AccessKey string `json:"access_key" bson:"access_key" hcl:"access_key" kloud:"Access Key ID,secret"`
 	SecretKey string `json:"secret_key" bson:"secret_key" hcl:"secret_key" kloud:"Secret Access Key,secret"`
 	Region    Region `json:"region" bson:"region" hcl:"region" kloud:"Region"`
+
+	// RoleARN describes a role, which is assumed in order to obtain
+	// short-lived credentials. When RoleARN is set, no static access
+	// keys are stored.
+	//
+	// The role is assumed with an external ID generated by kloud
+	// for the team the credential is used within, see ExternalID.
+	RoleARN string `json:"role_arn,omitempty" bson:"role_arn,omitempty" hcl:"role_arn" kloud:"Role ARN"`
+
+	team string // set with BindTeam
 }
 
 var _ stack.Validator = (*Cred)(nil)
@@ -143,7 +155,14 @@ func (b *Bootstrap) Valid() error {
 }
 
 // Credentials creates new AWS credentials value from the given meta.
+//
+// If the meta describes a role, the credentials are obtained
+// by assuming it.
 func (c *Cred) Credentials() *credentials.Credentials {
+	if c.RoleARN != "" {
+		return c.roleCredentials()
+	}
+
 	return credentials.NewStaticCredentials(c.AccessKey, c.SecretKey, "")
 }
 
@@ -161,6 +180,10 @@ func (c *Cred) session() *session.Session {
 
 // AccountID parses an AWS arn string to get the Account ID.
 func (c *Cred) AccountID() (string, error) {
+	if c.RoleARN != "" {
+		return parseAccountID(c.RoleARN)
+	}
+
 	user, err := iam.New(c.session()).GetUser(nil)
 	if err == nil {
 		return parseAccountID(aws.StringValue(user.User.Arn))
@@ -196,6 +219,9 @@ func (meta *Cred) Valid() error {
 	if meta.Region == "" {
 		return errors.New("aws: region is empty")
 	}
+	if meta.RoleARN != "" {
+		return meta.validRole()
+	}
 	if meta.AccessKey == "" {
 		return errors.New("aws: access key is empty")
 	}
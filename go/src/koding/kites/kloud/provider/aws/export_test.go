@@ -2,9 +2,22 @@ package aws
 
 import (
 	"text/template"
+
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 )
 
 // Bootstrap exports the bootstrap template for tests purposes.
 func BootstrapTemplate() *template.Template {
 	return bootstrap
 }
+
+// SetAssumeRoler replaces STS client used to assume roles
+// for test purposes.
+func SetAssumeRoler(fn func(region string) stscreds.AssumeRoler) {
+	newAssumeRoler = fn
+}
+
+// WithToken exports withToken func for test purposes.
+func WithToken(content string) (string, error) {
+	return withToken(content)
+}
@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"koding/kites/kloud/pricing"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+// defaultStorageSize is a size of root volume of AWS instances,
+// when it is not set explicitly within a template.
+const defaultStorageSize = 8
+
+// Prices is a default price table of AWS on-demand Linux instances
+// and General Purpose SSD volumes.
+var Prices = &pricing.Table{
+	Provider: "aws",
+	Currency: "USD",
+	Regions: map[string]*pricing.Prices{
+		pricing.DefaultRegion: {
+			Instances: map[string]float64{
+				"t2.nano":    0.0058,
+				"t2.micro":   0.0116,
+				"t2.small":   0.023,
+				"t2.medium":  0.0464,
+				"t2.large":   0.0928,
+				"t2.xlarge":  0.1856,
+				"t2.2xlarge": 0.3712,
+				"m4.large":   0.1,
+				"m4.xlarge":  0.2,
+				"m4.2xlarge": 0.4,
+				"c4.large":   0.1,
+				"c4.xlarge":  0.199,
+				"c4.2xlarge": 0.398,
+			},
+			Disk: 0.1,
+		},
+	},
+	Shapes: map[string]*pricing.Shape{
+		"t2.nano":    {CPU: 1, Memory: 0.5},
+		"t2.micro":   {CPU: 1, Memory: 1},
+		"t2.small":   {CPU: 1, Memory: 2},
+		"t2.medium":  {CPU: 2, Memory: 4},
+		"t2.large":   {CPU: 2, Memory: 8},
+		"t2.xlarge":  {CPU: 4, Memory: 16},
+		"t2.2xlarge": {CPU: 8, Memory: 32},
+		"m4.large":   {CPU: 2, Memory: 8},
+		"m4.xlarge":  {CPU: 4, Memory: 16},
+		"m4.2xlarge": {CPU: 8, Memory: 32},
+		"c4.large":   {CPU: 2, Memory: 3.75},
+		"c4.xlarge":  {CPU: 4, Memory: 7.5},
+		"c4.2xlarge": {CPU: 8, Memory: 15},
+	},
+}
+
+func priceSpec(m *stack.Machine, t *provider.Template) (*pricing.Spec, error) {
+	var resource struct {
+		AwsInstance map[string]map[string]interface{} `hcl:"aws_instance"`
+	}
+
+	if err := t.DecodeResource(&resource); err != nil {
+		return nil, err
+	}
+
+	spec := &pricing.Spec{
+		InstanceType: m.Attributes["instance_type"],
+		DiskSize:     defaultStorageSize,
+	}
+
+	if cred, ok := m.Credential.Credential.(*Cred); ok {
+		spec.Region = string(cred.Region)
+	}
+
+	if devices, ok := resource.AwsInstance[m.Label]["root_block_device"].([]map[string]interface{}); ok && len(devices) != 0 {
+		if n := pricing.Size(devices[0]["volume_size"]); n != 0 {
+			spec.DiskSize = n
+		}
+	}
+
+	return spec, nil
+}
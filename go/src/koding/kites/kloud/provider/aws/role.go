@@ -0,0 +1,216 @@
+package aws
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"koding/kites/kloud/api/amazon"
+	"koding/kites/kloud/stack"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// AssumeRoleCredentials are credentials kloud uses to assume roles
+// of credentials, which have RoleARN set. When nil, the default
+// credential chain of AWS SDK is used.
+var AssumeRoleCredentials *credentials.Credentials
+
+// ExternalIDSecret is a kloud secret used to generate external IDs
+// of teams. Changing it changes external IDs of all teams, thus
+// trust policies of their roles must be updated.
+var ExternalIDSecret string
+
+const (
+	// roleSessionName is a name of sessions created by kloud.
+	roleSessionName = "koding-kloud"
+
+	// roleSessionDuration is a lifetime of session credentials.
+	roleSessionDuration = time.Hour
+
+	// roleExpiryWindow makes cached session credentials expire
+	// earlier, so they do not expire during terraform apply.
+	roleExpiryWindow = 15 * time.Minute
+)
+
+var roles = struct {
+	sync.Mutex
+	m map[string]*credentials.Credentials // maps role ARN and external ID to session credentials
+}{
+	m: make(map[string]*credentials.Credentials),
+}
+
+// newAssumeRoler creates STS client used to assume roles.
+var newAssumeRoler = func(region string) stscreds.AssumeRoler {
+	return sts.New(amazon.NewSession(&amazon.ClientOptions{
+		Credentials: AssumeRoleCredentials,
+		Region:      region,
+	}))
+}
+
+// SessionCred represents short-lived credentials obtained by
+// assuming a role. It is injected into templates in place
+// of Cred, thus it is never stored.
+type SessionCred struct {
+	AccessKey string `hcl:"access_key"`
+	SecretKey string `hcl:"secret_key"`
+	Token     string `hcl:"token"`
+	Region    Region `hcl:"region"`
+}
+
+var (
+	_ stack.Assumer    = (*Cred)(nil)
+	_ stack.TeamBinder = (*Cred)(nil)
+)
+
+// BindTeam implements the stack.TeamBinder interface.
+func (c *Cred) BindTeam(team string) {
+	c.team = team
+}
+
+// ExternalID gives an external ID, which kloud uses when assuming
+// roles of the given team's credentials. Trust policies of the roles
+// must require it with the sts:ExternalId condition.
+//
+// The ID is derived from the team name with a kloud secret,
+// so no team can use a role, which trusts other team.
+func ExternalID(team string) (string, error) {
+	if team == "" {
+		return "", errors.New("aws: role credentials can be used within a team only")
+	}
+
+	if ExternalIDSecret == "" {
+		return "", errors.New("aws: external ID secret is not configured")
+	}
+
+	mac := hmac.New(sha256.New, []byte(ExternalIDSecret))
+	mac.Write([]byte(team))
+
+	return "koding-" + hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// Assume implements the stack.Assumer interface.
+//
+// If the credential has no role, Assume returns the credential itself.
+func (c *Cred) Assume() (interface{}, error) {
+	if c.RoleARN == "" {
+		return c, nil
+	}
+
+	v, err := c.Credentials().Get()
+	if err != nil {
+		return nil, c.roleError(err)
+	}
+
+	return &SessionCred{
+		AccessKey: v.AccessKeyID,
+		SecretKey: v.SecretAccessKey,
+		Token:     v.SessionToken,
+		Region:    c.Region,
+	}, nil
+}
+
+// roleCredentials gives session credentials for the role,
+// which are cached until they are near expiry.
+func (c *Cred) roleCredentials() *credentials.Credentials {
+	externalID, err := ExternalID(c.team)
+	if err != nil {
+		return credentials.NewCredentials(errProvider{err})
+	}
+
+	key := c.RoleARN + "\x00" + externalID
+
+	roles.Lock()
+	defer roles.Unlock()
+
+	if creds, ok := roles.m[key]; ok {
+		return creds
+	}
+
+	creds := stscreds.NewCredentialsWithClient(newAssumeRoler(string(c.Region)), c.RoleARN,
+		func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = roleSessionName
+			p.Duration = roleSessionDuration
+			p.ExpiryWindow = roleExpiryWindow
+			p.ExternalID = aws.String(externalID)
+		},
+	)
+
+	roles.m[key] = creds
+
+	return creds
+}
+
+// roleError annotates the error with the external ID the role
+// is expected to require, as it is the most common misconfiguration.
+func (c *Cred) roleError(err error) error {
+	externalID, e := ExternalID(c.team)
+	if e != nil {
+		return err
+	}
+
+	return fmt.Errorf("%s (ensure trust policy of %q role requires %q external ID)", err, c.RoleARN, externalID)
+}
+
+// errProvider is a credentials.Provider, which fails with
+// the given error.
+type errProvider struct {
+	err error
+}
+
+func (p errProvider) Retrieve() (credentials.Value, error) { return credentials.Value{}, p.err }
+func (p errProvider) IsExpired() bool                      { return true }
+
+func (c *Cred) validRole() error {
+	if !strings.HasPrefix(c.RoleARN, arnPrefix) || !strings.Contains(c.RoleARN, ":role/") {
+		return errors.New("aws: role ARN is invalid")
+	}
+
+	if c.AccessKey != "" || c.SecretKey != "" {
+		return errors.New("aws: access keys are not allowed when role ARN is set")
+	}
+
+	return nil
+}
+
+// withToken adds the session token variable to aws provider
+// block of the given template, unless it is already set.
+func withToken(content string) (string, error) {
+	var t map[string]interface{}
+
+	if err := json.Unmarshal([]byte(content), &t); err != nil {
+		return "", err
+	}
+
+	providers, ok := t["provider"].(map[string]interface{})
+	if !ok {
+		return content, nil
+	}
+
+	p, ok := providers["aws"].(map[string]interface{})
+	if !ok {
+		return content, nil
+	}
+
+	if _, ok := p["token"]; ok {
+		return content, nil
+	}
+
+	p["token"] = "${var.aws_token}"
+
+	out, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
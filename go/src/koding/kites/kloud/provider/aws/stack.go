@@ -42,6 +42,10 @@ func (s *Stack) VerifyCredential(c *stack.Credential) error {
 
 	_, err := amazon.NewClient(cred.Options())
 	if err != nil {
+		if cred.RoleARN != "" {
+			err = cred.roleError(err)
+		}
+
 		return &stack.Error{
 			Err: err,
 		}
@@ -74,6 +78,12 @@ func (s *Stack) BootstrapTemplates(c *stack.Credential) ([]*stack.Template, erro
 		return nil, err
 	}
 
+	if cred.RoleARN != "" {
+		if t.Content, err = withToken(t.Content); err != nil {
+			return nil, err
+		}
+	}
+
 	if accountID, err := cred.AccountID(); err == nil {
 		t.Key = accountID + "-" + s.BootstrapArg().GroupName + "-" + c.Identifier
 	} else {
@@ -94,6 +104,12 @@ func (s *Stack) ApplyTemplate(c *stack.Credential) (*stack.Template, error) {
 		return nil, err
 	}
 
+	if cred.RoleARN != "" {
+		if err := s.SetAwsToken(); err != nil {
+			return nil, err
+		}
+	}
+
 	var resource struct {
 		AwsInstance map[string]map[string]interface{} `hcl:"aws_instance"`
 	}
@@ -186,6 +202,36 @@ func (s *Stack) SetAwsRegion(region string) error {
 	return t.Flush()
 }
 
+// SetAwsToken sets session token in the aws provider block,
+// which is required by credentials of assumed roles.
+//
+// Other attributes of the block are left unchanged.
+func (s *Stack) SetAwsToken() error {
+	t := s.Builder.Template
+
+	var p struct {
+		Aws map[string]interface{} `hcl:"aws"`
+	}
+
+	if err := t.DecodeProvider(&p); err != nil {
+		return err
+	}
+
+	if p.Aws == nil {
+		p.Aws = make(map[string]interface{})
+	}
+
+	if token, ok := p.Aws["token"].(string); ok && token != "" {
+		return nil
+	}
+
+	p.Aws["token"] = "${var.aws_token}"
+
+	t.Provider["aws"] = p.Aws
+
+	return t.Flush()
+}
+
 func (s *Stack) Credential() *Cred {
 	return s.BaseStack.Credential.(*Cred)
 }
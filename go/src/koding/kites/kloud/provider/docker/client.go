@@ -0,0 +1,172 @@
+package docker
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrContainerNotFound is returned when the requested
+// container does not exist.
+var ErrContainerNotFound = errors.New("container not found")
+
+// Container represents a subset of container details
+// returned by Docker Engine API.
+type Container struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	State struct {
+		Status  string `json:"Status"`
+		Running bool   `json:"Running"`
+	} `json:"State"`
+}
+
+// Client is a minimal Docker Engine API client.
+type Client struct {
+	URL        string // base URL of the daemon, e.g. https://docker.example.com:2376
+	HTTPClient *http.Client
+}
+
+// newClient creates a client for the Docker daemon
+// described by the given credential.
+func newClient(cred *Credential) (*Client, error) {
+	u, err := url.Parse(cred.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{}
+
+	if cred.TLS() {
+		cfg, err := cred.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		transport.TLSClientConfig = cfg
+	}
+
+	c := &Client{
+		HTTPClient: &http.Client{
+			Transport: transport,
+			Timeout:   60 * time.Second,
+		},
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.Dial = func(string, string) (net.Conn, error) {
+			return net.Dial("unix", socket)
+		}
+		c.URL = "http://docker"
+	case "tcp":
+		if cred.TLS() {
+			c.URL = "https://" + u.Host
+		} else {
+			c.URL = "http://" + u.Host
+		}
+	default:
+		c.URL = u.Scheme + "://" + u.Host
+	}
+
+	return c, nil
+}
+
+// Ping checks whether the daemon is reachable.
+func (c *Client) Ping() error {
+	return c.do("GET", "/_ping", nil)
+}
+
+// Container gives details of a container with the given ID.
+func (c *Client) Container(id string) (*Container, error) {
+	var container Container
+
+	if err := c.do("GET", "/containers/"+id+"/json", &container); err != nil {
+		return nil, err
+	}
+
+	return &container, nil
+}
+
+// StartContainer starts a container with the given ID.
+func (c *Client) StartContainer(id string) error {
+	return c.do("POST", "/containers/"+id+"/start", nil)
+}
+
+// StopContainer stops a container with the given ID, killing it
+// if it does not stop within the given timeout.
+func (c *Client) StopContainer(id string, timeout time.Duration) error {
+	return c.do("POST", fmt.Sprintf("/containers/%s/stop?t=%d", id, int(timeout.Seconds())), nil)
+}
+
+func (c *Client) do(method, path string, out interface{}) error {
+	req, err := http.NewRequest(method, c.URL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		// Container was already started or stopped.
+		return nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		p, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		// Docker Engine reports missing containers with "No such container"
+		// message. Other 404 responses, e.g. due to an unsupported API
+		// version or a proxy in front of the daemon, are not treated
+		// as a missing container.
+		if resp.StatusCode == http.StatusNotFound && bytes.Contains(p, []byte("No such container")) {
+			return ErrContainerNotFound
+		}
+
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, bytes.TrimSpace(p))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Credential) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.X509KeyPair([]byte(c.CertMaterial), []byte(c.KeyMaterial))
+	if err != nil {
+		return nil, errors.New("invalid TLS certificate: " + err.Error())
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if strings.TrimSpace(c.CAMaterial) != "" {
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM([]byte(c.CAMaterial)) {
+			return nil, errors.New("invalid CA certificate")
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
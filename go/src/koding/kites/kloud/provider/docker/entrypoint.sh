@@ -0,0 +1,64 @@
+#!/bin/sh
+
+# Koding entrypoint responsible for running a klient service
+# within a Docker container.
+#
+# Copyright (C) 2012-2017 Koding Inc., all rights reserved.
+#
+# NOTE: the script is embedded in a Terraform template, so
+# it must not use the dollar-brace syntax for variables.
+
+set -eu
+
+KODING_DIR=/var/lib/koding
+KLIENT_DIR=/opt/kite/klient
+
+install_curl() {
+	if command -v apt-get >/dev/null 2>&1; then
+		apt-get update -qq && apt-get install -y -qq curl ca-certificates
+	elif command -v apk >/dev/null 2>&1; then
+		apk add --no-cache curl ca-certificates
+	elif command -v yum >/dev/null 2>&1; then
+		yum install -y -q curl ca-certificates
+	fi
+}
+
+fetch() {
+	if ! command -v curl >/dev/null 2>&1 && ! command -v wget >/dev/null 2>&1; then
+		install_curl
+	fi
+
+	if command -v curl >/dev/null 2>&1; then
+		curl --location --silent --show-error --retry 5 "$1" --output "$2"
+	else
+		wget -q -O "$2" "$1"
+	fi
+}
+
+main() {
+	touch /var/log/klient.log /var/log/cloud-init-output.log
+
+	if [ ! -x $KLIENT_DIR/klient ]; then
+		mkdir -p $KLIENT_DIR
+		fetch "$KODING_KLIENT_URL" /tmp/klient.gz
+		gzip --decompress --force --stdout /tmp/klient.gz > $KLIENT_DIR/klient
+		chmod +x $KLIENT_DIR/klient
+	fi
+
+	$KLIENT_DIR/klient -metadata-file $KODING_DIR/metadata.json >>/var/log/klient.log 2>&1 &
+
+	# User script is run once, on the first start of the container.
+	if [ -f $KODING_DIR/user-data.sh ] && [ ! -f $KODING_DIR/user-data.done ]; then
+		chmod +x $KODING_DIR/user-data.sh
+		$KODING_DIR/user-data.sh >>/var/log/cloud-init-output.log 2>&1 || true
+		touch $KODING_DIR/user-data.done
+	fi
+}
+
+main >>/var/log/cloud-init-output.log 2>&1
+
+if [ $# -ne 0 ]; then
+	exec "$@"
+fi
+
+wait
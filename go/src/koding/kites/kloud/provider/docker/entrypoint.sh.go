@@ -0,0 +1,246 @@
+// Code generated for package docker by go-bindata DO NOT EDIT. (@generated)
+// sources:
+// entrypoint.sh
+package docker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes []byte
+	info  os.FileInfo
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// Name return file name
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+
+// Size return file size
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+
+// Mode return file mode
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+
+// Mode return file modify time
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+// IsDir return file whether a directory
+func (fi bindataFileInfo) IsDir() bool {
+	return fi.mode&os.ModeDir != 0
+}
+
+// Sys return file is sys mode
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var _entrypointSh = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x54\x61\x6b\x1b\x47\x10\xfd\x7c\xfb\x2b\x9e\x25\x61\x12\xea\xf1\xd9\x82\x52\x68\xa9\x28\xc4\xa1\x18\x07\x1b\x82\xf3\xa9\x84\xb0\xda\x9b\xd3\x6d\xb5\xb7\x7b\xde\x9d\x93\xad\x34\xfd\xef\xe5\x56\x92\x2d\xbb\x72\xc8\xd7\x7d\x33\xf3\xde\xbc\x79\x77\xe3\xa3\x72\x6e\x7d\x99\x1a\xa5\xc6\xb8\x0a\x95\xf5\x0b\xb0\x97\xb8\xee\x82\xf5\x82\xc8\xa9\x0b\x3e\xd9\xb9\x63\xd4\x21\x22\xf6\xde\x0f\x25\x1a\x4b\x67\xd9\x0b\x12\xc7\x95\x35\xac\xc6\xb8\xb7\xd2\x58\x0f\x8d\x8b\x60\x96\x1c\x61\x82\x17\x6d\x3d\xc7\x53\x35\x56\x63\xbc\x0b\xdd\x3a\xda\x45\x23\x78\xf3\xee\x2d\xa6\x67\xe7\x53\x9a\x9e\x9d\xff\xb2\xe3\xbc\xf4\xe6\xf4\x04\xda\x39\xe4\xa2\x34\x30\x73\x5c\x71\xb5\xe9\xbe\xbe\xb9\x7d\xff\x2b\xa4\x61\x24\x13\x6d\x27\xb0\x09\xdc\xce\xb9\xaa\xb8\x42\x66\xbd\xe5\x18\x75\x1d\x62\x0b\xe1\xb6\x73\x5a\xf8\x04\x29\xa8\x31\xac\xa0\xed\x93\xc0\x07\x41\x9f\x38\x0f\xa9\x82\x73\x3a\xd2\x3c\x6a\xc3\x48\x6b\x2f\xfa\x21\xaf\xb7\xd2\xd1\xea\xb9\xe3\x74\xaa\x54\x62\x01\x71\xaf\xd4\xd5\xcd\xc5\xe5\xf5\x9f\x5f\x2e\x2e\x3f\xfe\x5e\xae\x74\x2c\x9d\x9d\x97\xcb\xac\x5a\x5d\x7d\xb8\x7c\x7f\x7d\xbb\x81\x42\x27\xe5\xd2\x0a\x97\x1b\x67\x94\xb2\x3e\x89\x76\xee\x8b\xe9\xa3\x7b\xf3\x16\xff\xa8\xc2\xd6\x30\xa1\x6d\xb5\xaf\x40\x2b\xe8\x4e\x68\xc1\x82\x59\x59\xf1\xaa\xf4\xbd\x73\x98\xce\x8e\xcf\x7f\x1b\x14\x7a\x55\x14\x3b\xbc\xef\x2a\x2d\x0c\xba\xbb\xc3\xf1\xf1\x63\xd7\x76\x3a\x68\x9d\x91\x81\x04\x46\x93\xe1\x28\xb6\xb6\x46\x0b\x27\x55\xb0\x7b\x49\xb9\xfc\x0e\xdd\x12\xba\xaa\x40\xe4\x03\x19\x6d\x1a\xfe\xc1\xa1\xeb\xbe\x7d\x75\xe8\x80\x3d\x53\xfa\xca\xcc\xda\xaa\x7f\x95\xaa\x59\x4c\xf3\x68\xd5\xd1\x3e\x49\x6e\x7b\xc1\x32\xd8\xf1\xac\xe8\xfe\x7b\x76\x6e\x65\xe4\x73\xa8\xa2\xb6\xea\xe5\x3d\x0e\x51\x3c\x76\x67\x90\xc8\x05\xa3\xc5\x06\x0f\xa2\x64\xdd\xf0\x01\x10\xa5\x26\xdc\x13\xc7\x18\x22\x88\x22\x4b\x5c\xe3\x67\x8c\x26\xe7\x23\x10\x85\x5e\xba\x5e\x30\x9a\x4c\x47\x83\x71\x89\x55\x51\x64\x95\x74\x07\xba\xc9\xef\xb9\x74\xe7\x40\xab\xad\xdf\x18\x20\xa1\x37\x0d\x36\x81\x0b\x8b\x6d\xa8\x4e\x5d\x58\x3c\xbd\x19\x17\xfa\x8a\xac\xb7\xb2\xe5\x19\xe0\xcd\x5a\x7f\xe1\x08\xf4\x80\xc9\x53\x40\xb7\x13\xf0\xf9\x71\xa5\x76\x59\xd9\x08\xea\xf6\xab\x54\x51\xe4\x23\x60\x34\xd9\xc6\x7e\x8b\x7d\xfa\xf8\x61\x84\x52\xda\x6e\xa7\x64\xf1\x55\x15\xc5\xe2\xab\xed\x40\x54\xb1\x09\x6d\x17\x39\x25\x10\xd5\x21\x1a\x1e\xfc\x91\x2a\xf4\xf2\xa2\x07\xb3\x03\x9a\x06\x7b\x9b\x36\x54\xf8\xe9\x90\xe2\xed\xad\xfe\x0f\x80\x5a\x16\x5d\x69\xd1\x54\x5b\xc7\xd8\x29\x1e\x2a\x76\xc8\xe9\xdf\x29\x78\xcc\x66\x87\x7c\x1c\xee\x8b\x63\xa5\x8a\x31\x3e\x25\x8e\x7b\x7f\x96\xd8\x7b\x04\x6f\xf8\x04\xc1\x0f\x6e\xa1\xb6\x31\x09\x92\xe8\x28\x08\x75\x7e\xda\xfb\xbd\x6d\x0c\xa7\xfa\x99\x82\x3e\x71\xa4\x2c\x21\x35\xf8\x3c\x44\x35\xdf\xe4\xb5\xa2\x2a\x78\xde\xbb\xcd\x9e\x1f\x87\x47\xaa\xa2\x78\x0d\xda\x5f\xf7\x60\x44\xf2\x77\x81\x6f\xdf\x20\xb1\x1f\x02\xb9\x89\xda\xe1\x71\x83\xae\xfd\x70\xfe\xd8\x70\xa5\xb2\x25\x93\x31\xc8\x33\xce\x9e\x16\xe3\x07\x36\x18\x4d\xfe\x18\xa9\xda\x2a\x75\xaf\xad\xa8\xff\x06\x00\xd2\x99\xed\x0b\x82\x06\x00\x00")
+
+func entrypointShBytes() ([]byte, error) {
+	return bindataRead(
+		_entrypointSh,
+		"entrypoint.sh",
+	)
+}
+
+func entrypointSh() (*asset, error) {
+	bytes, err := entrypointShBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "entrypoint.sh", size: 1666, mode: os.FileMode(420), modTime: time.Unix(1476403200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"entrypoint.sh": entrypointSh,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"entrypoint.sh": {entrypointSh, map[string]*bintree{}},
+}}
+
+// RestoreAsset restores an asset under the given directory
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreAssets restores an asset under the given directory recursively
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(cannonicalName, "/")...)...)
+}
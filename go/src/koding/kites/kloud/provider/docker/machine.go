@@ -0,0 +1,153 @@
+package docker
+
+import (
+	"errors"
+	"time"
+
+	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+	"koding/kites/kloud/waitstate"
+
+	"golang.org/x/net/context"
+)
+
+// stopTimeout is a time the container is given to
+// shut down gracefully, before it gets killed.
+const stopTimeout = 30 * time.Second
+
+// startTimeout is a max time to wait for the container to run.
+const startTimeout = 2 * time.Minute
+
+// waitInterval is a time between consecutive checks
+// of the container state.
+var waitInterval = 2 * time.Second
+
+// ErrInvalidContainerID is returned if an invalid container ID is used
+var ErrInvalidContainerID = errors.New("container ID is invalid")
+
+// Machine represents a single Docker container.
+type Machine struct {
+	*provider.BaseMachine
+
+	client *Client
+}
+
+var (
+	_ provider.Machine = (*Machine)(nil) // public API
+	_ stack.Machiner   = (*Machine)(nil) // internal API
+)
+
+func newMachine(bm *provider.BaseMachine) (provider.Machine, error) {
+	cred, ok := bm.Credential.(*Credential)
+	if !ok {
+		return nil, errors.New("not a valid Docker credential")
+	}
+
+	client, err := newClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Machine{
+		BaseMachine: bm,
+		client:      client,
+	}, nil
+}
+
+// Start starts the container.
+func (m *Machine) Start(context.Context) (interface{}, error) {
+	id, err := m.ContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.client.StartContainer(id); err != nil {
+		return nil, err
+	}
+
+	return nil, m.waitState(id, machinestate.Running, startTimeout)
+}
+
+// Stop stops the container.
+func (m *Machine) Stop(context.Context) (interface{}, error) {
+	id, err := m.ContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.client.StopContainer(id, stopTimeout); err != nil {
+		return nil, err
+	}
+
+	// The container is killed after stopTimeout, give it
+	// additional time to actually exit.
+	return nil, m.waitState(id, machinestate.Stopped, stopTimeout+time.Minute)
+}
+
+// Info gives state of the container.
+func (m *Machine) Info(context.Context) (machinestate.State, interface{}, error) {
+	id, err := m.ContainerID()
+	if err != nil {
+		return machinestate.Unknown, nil, err
+	}
+
+	container, err := m.client.Container(id)
+	if err == ErrContainerNotFound {
+		return machinestate.NotInitialized, nil, nil
+	}
+
+	if err != nil {
+		return machinestate.Unknown, nil, err
+	}
+
+	return statusToState(container.State.Status), nil, nil
+}
+
+// waitState polls the container until it reaches the desired state
+// or the timeout is exceeded.
+func (m *Machine) waitState(id string, desired machinestate.State, timeout time.Duration) error {
+	ws := waitstate.WaitState{
+		StateFunc: func(int) (machinestate.State, error) {
+			container, err := m.client.Container(id)
+			if err != nil {
+				return machinestate.Unknown, err
+			}
+
+			return statusToState(container.State.Status), nil
+		},
+		DesiredState:   desired,
+		Timeout:        timeout,
+		PollerInterval: waitInterval,
+	}
+
+	return ws.Wait()
+}
+
+// ContainerID gives an ID of the container associated with the machine.
+func (m *Machine) ContainerID() (string, error) {
+	meta, ok := m.BaseMachine.Metadata.(*Metadata)
+	if !ok || meta.ContainerID == "" {
+		return "", ErrInvalidContainerID
+	}
+
+	return meta.ContainerID, nil
+}
+
+// statusToState converts a container status to a machinestate.State.
+func statusToState(status string) machinestate.State {
+	switch status {
+	case "created", "restarting":
+		return machinestate.Starting
+	case "running":
+		return machinestate.Running
+	case "paused", "exited":
+		return machinestate.Stopped
+	case "removing":
+		return machinestate.Terminating
+	case "dead":
+		return machinestate.Terminated
+	default:
+		return machinestate.Unknown
+	}
+}
@@ -0,0 +1,209 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"koding/db/models"
+	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+
+	"golang.org/x/net/context"
+)
+
+const testContainerID = "8dfafdbc3a40"
+
+func init() {
+	waitInterval = 10 * time.Millisecond
+}
+
+func TestEmptyContainerID(t *testing.T) {
+	machine, err := newMachine(newDockerBaseMachine("tcp://127.0.0.1:2376", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = machine.Start(context.Background())
+	if err != ErrInvalidContainerID {
+		t.Errorf("Container ID should return %s, got %s", ErrInvalidContainerID, err)
+	}
+}
+
+func TestMachineStartStop(t *testing.T) {
+	cases := map[string]struct {
+		path       string
+		statusCode int
+		status     string // container status once the action is done
+		fn         func(provider.Machine, context.Context) (interface{}, error)
+	}{
+		"start":         {"/containers/" + testContainerID + "/start", http.StatusNoContent, "running", provider.Machine.Start},
+		"start started": {"/containers/" + testContainerID + "/start", http.StatusNotModified, "running", provider.Machine.Start},
+		"stop":          {"/containers/" + testContainerID + "/stop", http.StatusNoContent, "exited", provider.Machine.Stop},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			var paths []string
+			var polls int
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" && r.URL.Path == "/containers/"+testContainerID+"/json" {
+					var c Container
+					c.ID = testContainerID
+					c.State.Status = "restarting"
+
+					// Report the desired status on the second poll.
+					if polls++; polls > 1 {
+						c.State.Status = cas.status
+					}
+
+					_ = json.NewEncoder(w).Encode(&c)
+					return
+				}
+
+				if r.Method != "POST" {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+
+				paths = append(paths, r.URL.Path)
+				w.WriteHeader(cas.statusCode)
+			}
+
+			err := withMachine(http.HandlerFunc(handler), func(m *Machine) error {
+				_, err := cas.fn(m, context.Background())
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(paths) != 1 || paths[0] != cas.path {
+				t.Fatalf("got %v, want [%s]", paths, cas.path)
+			}
+
+			if polls != 2 {
+				t.Fatalf("got %d polls, want 2", polls)
+			}
+		})
+	}
+}
+
+func TestMachineInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int // by default 200
+		body       string
+		status     string
+		state      machinestate.State
+	}{
+		{
+			name:   "created container",
+			status: "created",
+			state:  machinestate.Starting,
+		},
+		{
+			name:   "running container",
+			status: "running",
+			state:  machinestate.Running,
+		},
+		{
+			name:   "exited container",
+			status: "exited",
+			state:  machinestate.Stopped,
+		},
+		{
+			name:   "dead container",
+			status: "dead",
+			state:  machinestate.Terminated,
+		},
+		{
+			name:       "removed container",
+			state:      machinestate.NotInitialized,
+			statusCode: http.StatusNotFound,
+			body:       `{"message":"No such container: ` + testContainerID + `"}`,
+		},
+		{
+			name:       "unsupported API version",
+			state:      machinestate.Unknown,
+			statusCode: http.StatusNotFound,
+			body:       `{"message":"page not found"}`,
+		},
+		{
+			name:       "daemon failure",
+			state:      machinestate.Unknown,
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		// capture range variable here
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if test.statusCode != 0 {
+					w.WriteHeader(test.statusCode)
+					fmt.Fprint(w, test.body)
+					return
+				}
+
+				var c Container
+				c.ID = testContainerID
+				c.State.Status = test.status
+				c.State.Running = test.status == "running"
+
+				_ = json.NewEncoder(w).Encode(&c)
+			}
+
+			err := withMachine(http.HandlerFunc(handler), func(m *Machine) error {
+				state, _, _ := m.Info(context.Background())
+				if state != test.state {
+					return fmt.Errorf("expecting %q, got: %q", test.state, state)
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func withMachine(handler http.Handler, fn func(m *Machine) error) error {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	host := strings.Replace(server.URL, "http://", "tcp://", 1)
+
+	machine, err := newMachine(newDockerBaseMachine(host, testContainerID))
+	if err != nil {
+		return err
+	}
+
+	m, ok := machine.(*Machine)
+	if !ok {
+		return fmt.Errorf("can't type assert %T to *Machine", machine)
+	}
+
+	return fn(m)
+}
+
+func newDockerBaseMachine(host, containerID string) *provider.BaseMachine {
+	return &provider.BaseMachine{
+		Machine:    &models.Machine{},
+		Credential: &Credential{Host: host},
+		Metadata: Provider.Schema.NewMetadata(&stack.Machine{
+			Attributes: map[string]string{"id": containerID},
+		}),
+		Provider: "docker",
+	}
+}
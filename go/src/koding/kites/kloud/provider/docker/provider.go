@@ -0,0 +1,26 @@
+// Package docker implements Kloud provider for Docker containers.
+//
+// The provider deploys stacks as containers on a Docker host owned
+// by a team, using the Terraform docker provider:
+//
+//	https://www.terraform.io/docs/providers/docker/
+//
+// Since containers do not run cloud-init, klient is installed
+// and started by the Koding entrypoint, which is injected
+// into each container.
+package docker
+
+import "koding/kites/kloud/stack/provider"
+
+var Provider = &provider.Provider{
+	Name:         "docker",
+	ResourceName: "container",
+	NoCloudInit:  true,
+	Machine:      newMachine,
+	Stack:        newStack,
+	Schema:       newSchema(),
+}
+
+func init() {
+	provider.Register(Provider)
+}
@@ -0,0 +1,103 @@
+package docker
+
+import (
+	"errors"
+	"net/url"
+
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+var (
+	_ stack.Validator = (*Credential)(nil)
+	_ stack.Validator = (*Metadata)(nil)
+)
+
+// Credential represents credential information
+// that are required to access a Docker host.
+type Credential struct {
+	// Host is an address of Docker daemon, e.g. tcp://docker.example.com:2376.
+	Host string `json:"host" bson:"host" hcl:"host"`
+
+	// PEM-encoded TLS materials, required only if the daemon
+	// is protected with TLS.
+	CAMaterial   string `json:"ca_material,omitempty" bson:"ca_material,omitempty" hcl:"ca_material"`
+	CertMaterial string `json:"cert_material,omitempty" bson:"cert_material,omitempty" hcl:"cert_material"`
+	KeyMaterial  string `json:"key_material,omitempty" bson:"key_material,omitempty" hcl:"key_material"`
+}
+
+// Metadata represents a single container metadata.
+type Metadata struct {
+	ContainerID string `json:"container_id" bson:"container_id" hcl:"container_id"`
+	Name        string `json:"name" bson:"name" hcl:"name"`
+	Image       string `json:"image" bson:"image" hcl:"image"`
+}
+
+func newSchema() *provider.Schema {
+	return &provider.Schema{
+		NewCredential: newCredential,
+		NewBootstrap:  nil,
+		NewMetadata:   newMetadata,
+	}
+}
+
+func newCredential() interface{} {
+	return &Credential{}
+}
+
+func newMetadata(m *stack.Machine) interface{} {
+	if m == nil {
+		return &Metadata{}
+	}
+
+	return &Metadata{
+		ContainerID: m.Attributes["id"],
+		Name:        m.Attributes["name"],
+		Image:       m.Attributes["image"],
+	}
+}
+
+// TLS tells whether the credential requires TLS connection.
+func (c *Credential) TLS() bool {
+	return c.CAMaterial != "" || c.CertMaterial != "" || c.KeyMaterial != ""
+}
+
+// Valid implements the stack.Validator interface.
+func (c *Credential) Valid() error {
+	if c.Host == "" {
+		return errors.New("host is empty")
+	}
+
+	u, err := url.Parse(c.Host)
+	if err != nil {
+		return errors.New("invalid host: " + err.Error())
+	}
+
+	switch u.Scheme {
+	case "tcp", "http", "https":
+		if u.Host == "" {
+			return errors.New("invalid host: missing address")
+		}
+	case "unix":
+		if u.Path == "" {
+			return errors.New("invalid host: missing socket path")
+		}
+	default:
+		return errors.New("invalid host: unsupported scheme " + u.Scheme)
+	}
+
+	if c.TLS() && (c.CertMaterial == "" || c.KeyMaterial == "") {
+		return errors.New("both cert_material and key_material are required for TLS")
+	}
+
+	return nil
+}
+
+// Valid implements the stack.Validator interface.
+func (m *Metadata) Valid() error {
+	if m.ContainerID == "" {
+		return errors.New("container ID cannot be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package docker
+
+import "testing"
+
+func TestCredential_Valid(t *testing.T) {
+	cases := map[string]struct {
+		cred *Credential
+		ok   bool
+	}{
+		"unix socket":      {&Credential{Host: "unix:///var/run/docker.sock"}, true},
+		"tcp host":         {&Credential{Host: "tcp://docker.example.com:2375"}, true},
+		"tls host":         {&Credential{Host: "tcp://docker.example.com:2376", CertMaterial: "cert", KeyMaterial: "key"}, true},
+		"missing tls key":  {&Credential{Host: "tcp://docker.example.com:2376", CertMaterial: "cert"}, false},
+		"missing address":  {&Credential{Host: "tcp://"}, false},
+		"invalid scheme":   {&Credential{Host: "ssh://docker.example.com"}, false},
+		"empty credential": {&Credential{}, false},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := cas.cred.Valid()
+			if cas.ok && err != nil {
+				t.Fatalf("Valid()=%s", err)
+			}
+
+			if !cas.ok && err == nil {
+				t.Fatal("expected Valid() to fail")
+			}
+		})
+	}
+}
+
+func TestMetadata_Valid_Empty(t *testing.T) {
+	metadata := &Metadata{}
+
+	if err := metadata.Valid(); err == nil {
+		t.Errorf("metadata with empty data should give an error")
+	}
+}
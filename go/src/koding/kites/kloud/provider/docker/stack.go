@@ -0,0 +1,281 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"koding/kites/kloud/metadata"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+//go:generate $GOPATH/bin/go-bindata -mode 420 -modtime 1476403200 -pkg docker -o entrypoint.sh.go entrypoint.sh
+//go:generate gofmt -l -w -s entrypoint.sh.go
+
+// entrypointPath is a path within a container, where
+// the Koding entrypoint is uploaded.
+const entrypointPath = "/var/lib/koding/entrypoint.sh"
+
+var entrypoint = string(MustAsset("entrypoint.sh"))
+
+// Stack represents a stack of Docker containers.
+type Stack struct {
+	*provider.BaseStack
+
+	KlientURL string
+}
+
+var (
+	_ provider.Stack = (*Stack)(nil) // public API
+	_ stack.Stacker  = (*Stack)(nil) // internal API
+)
+
+func newStack(bs *provider.BaseStack) (provider.Stack, error) {
+	return &Stack{
+		BaseStack: bs,
+		KlientURL: stack.Konfig.KlientGzURL(),
+	}, nil
+}
+
+// VerifyCredential checks whether the Docker daemon
+// is reachable with the given credential.
+func (s *Stack) VerifyCredential(c *stack.Credential) error {
+	cred := c.Credential.(*Credential)
+
+	if err := cred.Valid(); err != nil {
+		return err
+	}
+
+	client, err := newClient(cred)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Ping(); err != nil {
+		return &stack.Error{
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// BootstrapTemplates implements the provider.Stack interface.
+//
+// It is a nop for Docker.
+func (s *Stack) BootstrapTemplates(*stack.Credential) (_ []*stack.Template, _ error) {
+	return
+}
+
+// ApplyTemplate applies the given credentials to user's stack template
+// and injects the Koding entrypoint into each container.
+func (s *Stack) ApplyTemplate(c *stack.Credential) (*stack.Template, error) {
+	cred, ok := c.Credential.(*Credential)
+	if !ok {
+		return nil, fmt.Errorf("credential is not of type docker.Credential: %T", c.Credential)
+	}
+
+	t := s.Builder.Template
+
+	var resource struct {
+		Container map[string]map[string]interface{} `hcl:"docker_container"`
+		Image     map[string]map[string]interface{} `hcl:"docker_image"`
+	}
+
+	if err := t.DecodeResource(&resource); err != nil {
+		return nil, err
+	}
+
+	if len(resource.Container) == 0 {
+		return nil, errors.New("there are no Docker containers defined")
+	}
+
+	if resource.Image == nil {
+		resource.Image = make(map[string]map[string]interface{})
+	}
+
+	docker := map[string]interface{}{
+		"host": cred.Host,
+	}
+
+	if cred.TLS() {
+		docker["ca_material"] = "${var.docker_ca_material}"
+		docker["cert_material"] = "${var.docker_cert_material}"
+		docker["key_material"] = "${var.docker_key_material}"
+	}
+
+	t.Provider["docker"] = docker
+
+	for name, container := range resource.Container {
+		s.injectImage(container, resource.Image)
+
+		// Koding stops and starts containers on its own, Terraform
+		// must not recreate them when they are found stopped.
+		if _, ok := container["must_run"]; !ok {
+			container["must_run"] = false
+		}
+
+		if err := s.injectEntrypoint(name, container); err != nil {
+			return nil, err
+		}
+	}
+
+	t.Resource["docker_container"] = resource.Container
+
+	if len(resource.Image) != 0 {
+		t.Resource["docker_image"] = resource.Image
+	}
+
+	err := t.ShadowVariables("FORBIDDEN", "docker_ca_material", "docker_cert_material", "docker_key_material")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.Flush(); err != nil {
+		return nil, err
+	}
+
+	content, err := t.JsonOutput()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stack.Template{
+		Content: content,
+	}, nil
+}
+
+var nonAlnum = regexp.MustCompile("[^a-z0-9]+")
+
+// injectImage makes the container reference a docker_image resource,
+// so the image is pulled onto the host before the container is created.
+//
+// Image given by an interpolation is expected to be already
+// managed by the user.
+func (s *Stack) injectImage(container map[string]interface{}, images map[string]map[string]interface{}) {
+	image, ok := container["image"].(string)
+	if !ok || image == "" || strings.Contains(image, "${") {
+		return
+	}
+
+	name := "koding-" + strings.Trim(nonAlnum.ReplaceAllString(strings.ToLower(image), "-"), "-")
+
+	if _, ok := images[name]; !ok {
+		images[name] = map[string]interface{}{
+			"name": image,
+		}
+	}
+
+	container["image"] = "${docker_image." + name + ".latest}"
+}
+
+// injectEntrypoint uploads klient metadata and the Koding entrypoint
+// into the container, and makes the entrypoint wrap the original
+// container command.
+//
+// Since there is no cloud-init within a container, only files from
+// the cloud-init built for the container are uploaded. The user_data
+// script is run once by the entrypoint, on the first container start.
+func (s *Stack) injectEntrypoint(name string, container map[string]interface{}) error {
+	if err := s.BuildUserdata(name, container); err != nil {
+		return err
+	}
+
+	ci, err := metadata.ParseCloudInit([]byte(container["user_data"].(string)))
+	if err != nil {
+		return err
+	}
+
+	delete(container, "user_data")
+
+	upload := getSlice(container["upload"])
+
+	files, _ := ci["write_files"].([]interface{})
+
+	for _, v := range files {
+		file, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		path, _ := file["path"].(string)
+		content, _ := file["content"].(string)
+
+		// The cloud-init provisioning script installs klient as a system
+		// service, which is not available within a container.
+		if path == "" || path == "/var/lib/koding/provision.sh" {
+			continue
+		}
+
+		upload = append(upload, map[string]interface{}{
+			"file":    path,
+			"content": content,
+		})
+	}
+
+	upload = append(upload, map[string]interface{}{
+		"file":    entrypointPath,
+		"content": entrypoint,
+	})
+
+	container["upload"] = upload
+
+	// Original entrypoint and command are passed to the Koding one
+	// as arguments, which is run after klient is started.
+	args := append(getStrings(container["entrypoint"]), getStrings(container["command"])...)
+
+	container["entrypoint"] = []string{"/bin/sh", entrypointPath}
+
+	if len(args) != 0 {
+		container["command"] = args
+	} else {
+		delete(container, "command")
+	}
+
+	env := append(getStrings(container["env"]), "KODING_KLIENT_URL="+s.KlientURL)
+	sort.Strings(env)
+
+	container["env"] = env
+
+	return nil
+}
+
+func getStrings(v interface{}) []string {
+	var s []string
+
+	switch v := v.(type) {
+	case string:
+		s = append(s, v)
+	case []string:
+		s = append(s, v...)
+	case []interface{}:
+		for _, v := range v {
+			s = append(s, fmt.Sprintf("%v", v))
+		}
+	}
+
+	return s
+}
+
+func getSlice(v interface{}) []interface{} {
+	var slice []interface{}
+
+	switch v := v.(type) {
+	case nil:
+	case []map[string]interface{}:
+		slice = make([]interface{}, 0, len(v))
+
+		for _, elem := range v {
+			slice = append(slice, elem)
+		}
+	case []interface{}:
+		slice = v
+	default:
+		slice = []interface{}{v}
+	}
+
+	return slice
+}
@@ -0,0 +1,232 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/koding/kite"
+	"github.com/koding/kite/testkeys"
+	"github.com/koding/logging"
+
+	"koding/kites/kloud/contexthelper/session"
+	"koding/kites/kloud/keycreator"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+	"koding/kites/kloud/userdata"
+)
+
+func TestStack_VerifyCredential(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_ping" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		fmt.Fprint(w, "OK")
+	}))
+	defer server.Close()
+
+	s := &Stack{}
+
+	cred := &stack.Credential{
+		Credential: &Credential{
+			Host: strings.Replace(server.URL, "http://", "tcp://", 1),
+		},
+	}
+
+	if err := s.VerifyCredential(cred); err != nil {
+		t.Fatalf("VerifyCredential()=%s", err)
+	}
+
+	server.Close()
+
+	if err := s.VerifyCredential(cred); err == nil {
+		t.Fatal("VerifyCredential should error in case of an unreachable host")
+	}
+}
+
+func TestStack_ApplyTemplate(t *testing.T) {
+	log := logging.NewCustom("test", true)
+
+	cred := &stack.Credential{
+		Credential: &Credential{
+			Host: "tcp://127.0.0.1:2376",
+		},
+	}
+
+	cases := map[string]struct {
+		stackFile string
+		wantFile  string
+	}{
+		"basic stack": {
+			"testdata/basic-stack.json",
+			"testdata/basic-stack.json.golden",
+		},
+		"basic stack count 2": {
+			"testdata/basic-stack-count-2.json",
+			"testdata/basic-stack-count-2.json.golden",
+		},
+	}
+
+	for name, cas := range cases {
+		// capture range variable here
+		cas := cas
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			content, err := ioutil.ReadFile(cas.stackFile)
+			if err != nil {
+				t.Fatalf("ReadFile(%s)=%s", cas.stackFile, err)
+			}
+
+			want, err := ioutil.ReadFile(cas.wantFile)
+			if err != nil {
+				t.Fatalf("ReadFile(%s)=%s", cas.wantFile, err)
+			}
+
+			template, err := provider.ParseTemplate(string(content), log)
+			if err != nil {
+				t.Fatalf("ParseTemplate()=%s", err)
+			}
+
+			s := &Stack{
+				BaseStack: &provider.BaseStack{
+					Provider: Provider,
+					Session: &session.Session{
+						Userdata: &userdata.Userdata{
+							KlientURL: "http://127.0.0.1/klient.gz",
+							Keycreator: &keycreator.Key{
+								KontrolURL:        "http://127.0.0.1/kontrol/kite",
+								KontrolPublicKey:  testkeys.Public,
+								KontrolPrivateKey: testkeys.Private,
+							},
+						},
+					},
+					Builder: &provider.Builder{
+						Template: template,
+					},
+					Req: &kite.Request{
+						Username: "user",
+					},
+					KlientIDs: make(stack.KiteMap),
+				},
+				KlientURL: "http://127.0.0.1/klient.gz",
+			}
+
+			stack, err := s.ApplyTemplate(cred)
+			if err != nil {
+				t.Fatalf("ApplyTemplate()=%s", err)
+			}
+
+			if err := equal(stack.Content, string(want)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func equal(got, want string) error {
+	var v1, v2 interface{}
+
+	if err := json.Unmarshal([]byte(got), &v1); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(want), &v2); err != nil {
+		return err
+	}
+
+	stripNondeterministicResources(v1)
+	stripNondeterministicResources(v2)
+
+	if !reflect.DeepEqual(v1, v2) {
+		p1, err := json.MarshalIndent(v1, "", "\t")
+		if err != nil {
+			panic(err)
+		}
+
+		p2, err := json.MarshalIndent(v2, "", "\t")
+		if err != nil {
+			panic(err)
+		}
+
+		return fmt.Errorf("got:\n%s\nwant:\n%s\n", p1, p2)
+	}
+
+	return nil
+}
+
+// stripNondeterministicResources sets the following fields to "...",
+// as they change between test runs:
+//
+//   - resource.docker_container.*.upload.*.content of Koding files
+//   - variable.kitekeys_*.default.*
+func stripNondeterministicResources(v interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	resource, ok := m["resource"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	container, ok := resource["docker_container"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for _, v := range container {
+		c, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		upload, ok := c["upload"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, v := range upload {
+			file, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if path, ok := file["file"].(string); ok && strings.HasPrefix(path, "/var/lib/koding/") {
+				file["content"] = "..."
+			}
+		}
+	}
+
+	variable, ok := m["variable"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for name, v := range variable {
+		if !strings.HasPrefix(name, "kitekeys_") {
+			continue
+		}
+
+		v, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		list, ok := v["default"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for i := range list {
+			list[i] = "..."
+		}
+	}
+}
@@ -28,6 +28,8 @@ var Provider = &provider.Provider{
 		NewBootstrap:  newBootstrap,
 		NewMetadata:   newMetadata,
 	},
+	Prices:    Prices,
+	PriceSpec: priceSpec,
 }
 
 func init() {
@@ -0,0 +1,79 @@
+package google
+
+import (
+	"koding/kites/kloud/pricing"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+// defaultStorageSize is a size of defaultMachineImage, used when
+// a disk size was neither set in a template nor read with Image2Size.
+const defaultStorageSize = 10
+
+// Prices is a default price table of GCE predefined machine types
+// and standard persistent disks.
+var Prices = &pricing.Table{
+	Provider: "google",
+	Currency: "USD",
+	Regions: map[string]*pricing.Prices{
+		pricing.DefaultRegion: {
+			Instances: map[string]float64{
+				"f1-micro":      0.0076,
+				"g1-small":      0.0257,
+				"n1-standard-1": 0.0475,
+				"n1-standard-2": 0.095,
+				"n1-standard-4": 0.19,
+				"n1-standard-8": 0.38,
+				"n1-highmem-2":  0.1184,
+				"n1-highmem-4":  0.2368,
+				"n1-highcpu-2":  0.0709,
+				"n1-highcpu-4":  0.1418,
+			},
+			Disk: 0.04,
+		},
+	},
+	Shapes: map[string]*pricing.Shape{
+		"f1-micro":      {CPU: 1, Memory: 0.6},
+		"g1-small":      {CPU: 1, Memory: 1.7},
+		"n1-standard-1": {CPU: 1, Memory: 3.75},
+		"n1-standard-2": {CPU: 2, Memory: 7.5},
+		"n1-standard-4": {CPU: 4, Memory: 15},
+		"n1-standard-8": {CPU: 8, Memory: 30},
+		"n1-highmem-2":  {CPU: 2, Memory: 13},
+		"n1-highmem-4":  {CPU: 4, Memory: 26},
+		"n1-highcpu-2":  {CPU: 2, Memory: 1.8},
+		"n1-highcpu-4":  {CPU: 4, Memory: 3.6},
+	},
+}
+
+func priceSpec(m *stack.Machine, t *provider.Template) (*pricing.Spec, error) {
+	var resource struct {
+		GCInstance map[string]map[string]interface{} `hcl:"google_compute_instance"`
+	}
+
+	if err := t.DecodeResource(&resource); err != nil {
+		return nil, err
+	}
+
+	spec := &pricing.Spec{
+		InstanceType: m.Attributes["machine_type"],
+	}
+
+	if cred, ok := m.Credential.Credential.(*Cred); ok {
+		spec.Region = string(cred.Region)
+	}
+
+	// Disk sizes, which were not set by user, are
+	// injected by Image2Size during ApplyTemplate.
+	disks, _ := resource.GCInstance[m.Label]["disk"].([]map[string]interface{})
+
+	for _, disk := range disks {
+		spec.DiskSize += pricing.Size(disk["size"])
+	}
+
+	if spec.DiskSize == 0 {
+		spec.DiskSize = defaultStorageSize
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,144 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"koding/kites/kloud/api/kubernetesapi"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Kubeconfig represents a subset of kubeconfig file fields,
+// which are required to access a cluster.
+//
+//	https://kubernetes.io/docs/concepts/configuration/organize-cluster-access-kubeconfig/
+type Kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Token                 string `yaml:"token"`
+			Username              string `yaml:"username"`
+			Password              string `yaml:"password"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// ParseKubeconfig parses kubeconfig content.
+//
+// Certificates and keys must be embedded in the content
+// with *-data fields, as file paths cannot be accessed
+// by kloud.
+func ParseKubeconfig(content string) (*Kubeconfig, error) {
+	var k Kubeconfig
+
+	if err := yaml.Unmarshal([]byte(content), &k); err != nil {
+		return nil, errors.New("invalid kubeconfig: " + err.Error())
+	}
+
+	return &k, nil
+}
+
+// Config resolves configuration for the given context.
+//
+// If context is empty, current-context is used instead.
+func (k *Kubeconfig) Config(context string) (*kubernetesapi.Config, error) {
+	if context == "" {
+		context = k.CurrentContext
+	}
+
+	if context == "" {
+		return nil, errors.New("no context was specified")
+	}
+
+	var cfg kubernetesapi.Config
+	var cluster, user string
+
+	for _, c := range k.Contexts {
+		if c.Name == context {
+			cluster = c.Context.Cluster
+			user = c.Context.User
+			cfg.Namespace = c.Context.Namespace
+			break
+		}
+	}
+
+	if cluster == "" {
+		return nil, fmt.Errorf("context %q was not found", context)
+	}
+
+	for _, c := range k.Clusters {
+		if c.Name != cluster {
+			continue
+		}
+
+		ca, err := decodeData(c.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %q: invalid certificate-authority-data: %s", cluster, err)
+		}
+
+		cfg.Server = c.Cluster.Server
+		cfg.Insecure = c.Cluster.InsecureSkipTLSVerify
+		cfg.CA = ca
+	}
+
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("cluster %q was not found or has no server", cluster)
+	}
+
+	for _, u := range k.Users {
+		if u.Name != user {
+			continue
+		}
+
+		cert, err := decodeData(u.User.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("user %q: invalid client-certificate-data: %s", user, err)
+		}
+
+		key, err := decodeData(u.User.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("user %q: invalid client-key-data: %s", user, err)
+		}
+
+		cfg.Cert = cert
+		cfg.Key = key
+		cfg.Token = u.User.Token
+		cfg.Username = u.User.Username
+		cfg.Password = u.User.Password
+	}
+
+	return &cfg, nil
+}
+
+func decodeData(s string) (string, error) {
+	p, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+
+	return string(p), nil
+}
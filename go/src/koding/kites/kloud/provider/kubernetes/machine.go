@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	"errors"
+	"time"
+
+	"koding/kites/kloud/api/kubernetesapi"
+	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+	"koding/kites/kloud/waitstate"
+
+	"golang.org/x/net/context"
+)
+
+// startTimeout is a max time to wait for the pod to run,
+// it includes pulling images and installing klient.
+const startTimeout = 5 * time.Minute
+
+// stopTimeout is a max time to wait for the pod to terminate.
+const stopTimeout = 2 * time.Minute
+
+// waitInterval is a time between consecutive checks
+// of the deployment state.
+var waitInterval = 2 * time.Second
+
+// ErrInvalidDeployment is returned if machine metadata
+// does not describe a deployment.
+var ErrInvalidDeployment = errors.New("deployment name or namespace is invalid")
+
+// Machine represents a single-replica deployment.
+type Machine struct {
+	*provider.BaseMachine
+
+	client *kubernetesapi.Client
+}
+
+var (
+	_ provider.Machine = (*Machine)(nil) // public API
+	_ stack.Machiner   = (*Machine)(nil) // internal API
+)
+
+func newMachine(bm *provider.BaseMachine) (provider.Machine, error) {
+	cred, ok := bm.Credential.(*Credential)
+	if !ok {
+		return nil, errors.New("not a valid Kubernetes credential")
+	}
+
+	cfg, err := cred.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kubernetesapi.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Machine{
+		BaseMachine: bm,
+		client:      client,
+	}, nil
+}
+
+// Start scales the deployment up to 1 replica and waits
+// until its pod is running.
+func (m *Machine) Start(context.Context) (interface{}, error) {
+	if err := m.scale(1); err != nil {
+		return nil, err
+	}
+
+	return nil, m.waitState(machinestate.Running, startTimeout)
+}
+
+// Stop scales the deployment down to 0 replicas and waits
+// until its pod is gone.
+func (m *Machine) Stop(context.Context) (interface{}, error) {
+	if err := m.scale(0); err != nil {
+		return nil, err
+	}
+
+	return nil, m.waitState(machinestate.Stopped, stopTimeout)
+}
+
+// Info gives state of the deployment's pod.
+func (m *Machine) Info(context.Context) (machinestate.State, interface{}, error) {
+	state, err := m.state()
+	return state, nil, err
+}
+
+// Meta gives metadata of the deployment associated with the machine.
+func (m *Machine) Meta() (*Metadata, error) {
+	meta, ok := m.BaseMachine.Metadata.(*Metadata)
+	if !ok || meta.Valid() != nil {
+		return nil, ErrInvalidDeployment
+	}
+
+	return meta, nil
+}
+
+func (m *Machine) state() (machinestate.State, error) {
+	meta, err := m.Meta()
+	if err != nil {
+		return machinestate.Unknown, err
+	}
+
+	d, err := m.client.Deployment(meta.Namespace, meta.Name)
+	if err == kubernetesapi.ErrNotFound {
+		return machinestate.NotInitialized, nil
+	}
+
+	if err != nil {
+		return machinestate.Unknown, err
+	}
+
+	pods, err := m.client.Pods(meta.Namespace, d.Spec.Selector.MatchLabels)
+	if err != nil {
+		return machinestate.Unknown, err
+	}
+
+	return deploymentState(d, pods), nil
+}
+
+// waitState polls the deployment until it reaches the desired state
+// or the timeout is exceeded.
+func (m *Machine) waitState(desired machinestate.State, timeout time.Duration) error {
+	ws := waitstate.WaitState{
+		StateFunc: func(int) (machinestate.State, error) {
+			return m.state()
+		},
+		DesiredState:   desired,
+		Timeout:        timeout,
+		PollerInterval: waitInterval,
+	}
+
+	return ws.Wait()
+}
+
+func (m *Machine) scale(replicas int) error {
+	meta, err := m.Meta()
+	if err != nil {
+		return err
+	}
+
+	return m.client.ScaleDeployment(meta.Namespace, meta.Name, replicas)
+}
+
+// deploymentState converts replicas count and pod phases
+// to a machinestate.State.
+func deploymentState(d *kubernetesapi.Deployment, pods []kubernetesapi.Pod) machinestate.State {
+	scaledDown := d.Spec.Replicas != nil && *d.Spec.Replicas == 0
+
+	var live []kubernetesapi.Pod
+	for _, pod := range pods {
+		if pod.Metadata.DeletionTimestamp == nil {
+			live = append(live, pod)
+		}
+	}
+
+	switch {
+	case scaledDown && len(pods) == 0:
+		return machinestate.Stopped
+	case scaledDown:
+		return machinestate.Stopping
+	case len(live) == 0:
+		return machinestate.Starting
+	}
+
+	return phaseToState(live[0].Status.Phase)
+}
+
+// phaseToState converts a pod phase to a machinestate.State.
+func phaseToState(phase string) machinestate.State {
+	switch phase {
+	case "Pending":
+		return machinestate.Starting
+	case "Running":
+		return machinestate.Running
+	case "Succeeded", "Failed":
+		return machinestate.Stopped
+	default:
+		return machinestate.Unknown
+	}
+}
@@ -0,0 +1,248 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"koding/db/models"
+	"koding/kites/kloud/api/kubernetesapi"
+	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+
+	"golang.org/x/net/context"
+)
+
+const testKubeconfig = `
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+users:
+- name: test
+  user:
+    token: token
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: dev
+`
+
+func init() {
+	waitInterval = 10 * time.Millisecond
+}
+
+const testDeploymentPath = "/apis/apps/v1/namespaces/dev/deployments/koding-vm"
+
+func TestMachineStartStop(t *testing.T) {
+	cases := map[string]struct {
+		replicas int
+		phases   []string // pod phases reported on consecutive polls
+		fn       func(provider.Machine, context.Context) (interface{}, error)
+	}{
+		"start": {1, []string{"Pending", "Running"}, provider.Machine.Start},
+		"stop":  {0, []string{"Running", ""}, provider.Machine.Stop},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			var patches []string
+			var polls int
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				switch {
+				case r.Method == "PATCH" && r.URL.Path == testDeploymentPath:
+					p, _ := ioutil.ReadAll(r.Body)
+					patches = append(patches, string(p))
+
+					fmt.Fprint(w, "{}")
+				case r.Method == "GET" && r.URL.Path == testDeploymentPath:
+					var d kubernetesapi.Deployment
+					d.Spec.Replicas = &cas.replicas
+					d.Spec.Selector.MatchLabels = map[string]string{"app": "koding"}
+
+					_ = json.NewEncoder(w).Encode(&d)
+				case r.Method == "GET" && r.URL.Path == "/api/v1/namespaces/dev/pods":
+					var list struct {
+						Items []kubernetesapi.Pod `json:"items"`
+					}
+
+					if phase := cas.phases[polls]; phase != "" {
+						var pod kubernetesapi.Pod
+						pod.Status.Phase = phase
+						list.Items = append(list.Items, pod)
+					}
+
+					polls++
+
+					_ = json.NewEncoder(w).Encode(&list)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+
+			err := withMachine(http.HandlerFunc(handler), func(m *Machine) error {
+				_, err := cas.fn(m, context.Background())
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := fmt.Sprintf(`{"spec":{"replicas":%d}}`, cas.replicas)
+
+			if len(patches) != 1 || patches[0] != want {
+				t.Fatalf("got %v, want [%s]", patches, want)
+			}
+
+			if polls != len(cas.phases) {
+				t.Fatalf("got %d polls, want %d", polls, len(cas.phases))
+			}
+		})
+	}
+}
+
+func TestMachineInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int // by default 200
+		replicas   int
+		phases     []string
+		state      machinestate.State
+	}{
+		{
+			name:     "pending pod",
+			replicas: 1,
+			phases:   []string{"Pending"},
+			state:    machinestate.Starting,
+		},
+		{
+			name:     "running pod",
+			replicas: 1,
+			phases:   []string{"Running"},
+			state:    machinestate.Running,
+		},
+		{
+			name:     "no pods yet",
+			replicas: 1,
+			state:    machinestate.Starting,
+		},
+		{
+			name:     "scaling down",
+			replicas: 0,
+			phases:   []string{"Running"},
+			state:    machinestate.Stopping,
+		},
+		{
+			name:     "scaled down",
+			replicas: 0,
+			state:    machinestate.Stopped,
+		},
+		{
+			name:       "deleted deployment",
+			statusCode: http.StatusNotFound,
+			state:      machinestate.NotInitialized,
+		},
+		{
+			name:       "api failure",
+			statusCode: http.StatusInternalServerError,
+			state:      machinestate.Unknown,
+		},
+	}
+
+	for _, test := range tests {
+		// capture range variable here
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if test.statusCode != 0 {
+					w.WriteHeader(test.statusCode)
+					return
+				}
+
+				switch r.URL.Path {
+				case testDeploymentPath:
+					var d kubernetesapi.Deployment
+					d.Spec.Replicas = &test.replicas
+					d.Spec.Selector.MatchLabels = map[string]string{"app": "koding"}
+
+					_ = json.NewEncoder(w).Encode(&d)
+				case "/api/v1/namespaces/dev/pods":
+					if r.URL.Query().Get("labelSelector") != "app=koding" {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+
+					var list struct {
+						Items []kubernetesapi.Pod `json:"items"`
+					}
+
+					for _, phase := range test.phases {
+						var pod kubernetesapi.Pod
+						pod.Status.Phase = phase
+						list.Items = append(list.Items, pod)
+					}
+
+					_ = json.NewEncoder(w).Encode(&list)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+
+			err := withMachine(http.HandlerFunc(handler), func(m *Machine) error {
+				state, _, _ := m.Info(context.Background())
+				if state != test.state {
+					return fmt.Errorf("expecting %q, got: %q", test.state, state)
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func withMachine(handler http.Handler, fn func(m *Machine) error) error {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	bm := &provider.BaseMachine{
+		Machine: &models.Machine{},
+		Credential: &Credential{
+			Kubeconfig: fmt.Sprintf(testKubeconfig, server.URL),
+		},
+		Metadata: Provider.Schema.NewMetadata(&stack.Machine{
+			Attributes: map[string]string{"id": "dev/koding-vm"},
+		}),
+		Provider: "kubernetes",
+	}
+
+	machine, err := newMachine(bm)
+	if err != nil {
+		return err
+	}
+
+	m, ok := machine.(*Machine)
+	if !ok {
+		return fmt.Errorf("can't type assert %T to *Machine", machine)
+	}
+
+	return fn(m)
+}
@@ -0,0 +1,26 @@
+// Package kubernetes implements Kloud provider for Kubernetes.
+//
+// Each machine is a single-replica deployment, which pod is extended
+// with a klient sidecar container. The provider requires a Terraform
+// provider that supports the kubernetes_deployment resource:
+//
+//	https://www.terraform.io/docs/providers/kubernetes/
+//
+// Stopping a machine scales its deployment down to 0 replicas,
+// starting scales it back to 1.
+package kubernetes
+
+import "koding/kites/kloud/stack/provider"
+
+var Provider = &provider.Provider{
+	Name:         "kubernetes",
+	ResourceName: "deployment",
+	NoCloudInit:  true,
+	Machine:      newMachine,
+	Stack:        newStack,
+	Schema:       newSchema(),
+}
+
+func init() {
+	provider.Register(Provider)
+}
@@ -0,0 +1,121 @@
+package kubernetes
+
+import (
+	"errors"
+	"strings"
+
+	"koding/kites/kloud/api/kubernetesapi"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+// DefaultNamespace is used when neither credential
+// nor kubeconfig context specify a namespace.
+const DefaultNamespace = "default"
+
+var (
+	_ stack.Validator = (*Credential)(nil)
+	_ stack.Validator = (*Metadata)(nil)
+)
+
+// Credential represents credential information
+// that are required to access a Kubernetes cluster.
+type Credential struct {
+	// Kubeconfig is a content of kubeconfig file.
+	Kubeconfig string `json:"kubeconfig" bson:"kubeconfig" hcl:"kubeconfig"`
+
+	// Context is a kubeconfig context to use, if empty
+	// current-context is used instead.
+	Context string `json:"context,omitempty" bson:"context,omitempty" hcl:"context"`
+
+	// Namespace overwrites namespace from the kubeconfig context.
+	Namespace string `json:"namespace,omitempty" bson:"namespace,omitempty" hcl:"namespace"`
+}
+
+// Metadata represents a single deployment metadata.
+type Metadata struct {
+	Namespace string `json:"namespace" bson:"namespace" hcl:"namespace"`
+	Name      string `json:"name" bson:"name" hcl:"name"`
+}
+
+func newSchema() *provider.Schema {
+	return &provider.Schema{
+		NewCredential: newCredential,
+		NewBootstrap:  nil,
+		NewMetadata:   newMetadata,
+	}
+}
+
+func newCredential() interface{} {
+	return &Credential{}
+}
+
+func newMetadata(m *stack.Machine) interface{} {
+	if m == nil {
+		return &Metadata{}
+	}
+
+	meta := &Metadata{
+		Namespace: m.Attributes["metadata.0.namespace"],
+		Name:      m.Attributes["metadata.0.name"],
+	}
+
+	// Terraform identifies deployments with namespace/name IDs.
+	if i := strings.IndexRune(m.Attributes["id"], '/'); i != -1 {
+		if meta.Namespace == "" {
+			meta.Namespace = m.Attributes["id"][:i]
+		}
+
+		if meta.Name == "" {
+			meta.Name = m.Attributes["id"][i+1:]
+		}
+	}
+
+	return meta
+}
+
+// Config gives cluster access configuration for the credential.
+func (c *Credential) Config() (*kubernetesapi.Config, error) {
+	k, err := ParseKubeconfig(c.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := k.Config(c.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Namespace != "" {
+		cfg.Namespace = c.Namespace
+	}
+
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultNamespace
+	}
+
+	return cfg, nil
+}
+
+// Valid implements the stack.Validator interface.
+func (c *Credential) Valid() error {
+	if c.Kubeconfig == "" {
+		return errors.New("kubeconfig is empty")
+	}
+
+	_, err := c.Config()
+	return err
+}
+
+// Valid implements the stack.Validator interface.
+func (m *Metadata) Valid() error {
+	if m.Name == "" {
+		return errors.New("deployment name cannot be empty")
+	}
+
+	if m.Namespace == "" {
+		return errors.New("deployment namespace cannot be empty")
+	}
+
+	return nil
+}
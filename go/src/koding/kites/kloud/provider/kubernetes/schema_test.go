@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"koding/kites/kloud/stack"
+)
+
+func TestCredential_Config(t *testing.T) {
+	kubeconfig, err := ioutil.ReadFile("testdata/kubeconfig.yaml")
+	if err != nil {
+		t.Fatalf("ReadFile()=%s", err)
+	}
+
+	cases := map[string]struct {
+		cred      *Credential
+		namespace string
+		ok        bool
+	}{
+		"current context": {
+			&Credential{Kubeconfig: string(kubeconfig)},
+			"dev",
+			true,
+		},
+		"context without namespace": {
+			&Credential{Kubeconfig: string(kubeconfig), Context: "other"},
+			DefaultNamespace,
+			true,
+		},
+		"namespace overwrite": {
+			&Credential{Kubeconfig: string(kubeconfig), Namespace: "koding"},
+			"koding",
+			true,
+		},
+		"missing context": {
+			&Credential{Kubeconfig: string(kubeconfig), Context: "missing"},
+			"",
+			false,
+		},
+		"invalid kubeconfig": {
+			&Credential{Kubeconfig: "{"},
+			"",
+			false,
+		},
+		"empty": {
+			&Credential{},
+			"",
+			false,
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := cas.cred.Valid(); (err == nil) != cas.ok {
+				t.Fatalf("Valid()=%v, want ok=%t", err, cas.ok)
+			}
+
+			if !cas.ok {
+				return
+			}
+
+			cfg, err := cas.cred.Config()
+			if err != nil {
+				t.Fatalf("Config()=%s", err)
+			}
+
+			if cfg.Server != "https://k8s.example.com:6443" {
+				t.Errorf("got %q, want %q", cfg.Server, "https://k8s.example.com:6443")
+			}
+
+			if cfg.Token != "s3cr3t" {
+				t.Errorf("got %q, want %q", cfg.Token, "s3cr3t")
+			}
+
+			if cfg.Namespace != cas.namespace {
+				t.Errorf("got %q, want %q", cfg.Namespace, cas.namespace)
+			}
+		})
+	}
+}
+
+func TestNewMetadata(t *testing.T) {
+	meta := newMetadata(&stack.Machine{
+		Attributes: map[string]string{"id": "dev/koding-vm"},
+	}).(*Metadata)
+
+	if err := meta.Valid(); err != nil {
+		t.Fatalf("Valid()=%s", err)
+	}
+
+	if meta.Namespace != "dev" || meta.Name != "koding-vm" {
+		t.Fatalf("got %+v", meta)
+	}
+}
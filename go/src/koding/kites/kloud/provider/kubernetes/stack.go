@@ -0,0 +1,294 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+
+	"koding/kites/kloud/api/kubernetesapi"
+	"koding/kites/kloud/metadata"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+const (
+	// klientContainer is a name of the klient sidecar container.
+	klientContainer = "koding-klient"
+
+	// defaultKlientImage is used for the klient sidecar container,
+	// unless koding_klient_image is set for a deployment.
+	defaultKlientImage = "ubuntu:16.04"
+)
+
+// klientScript installs and runs klient within the sidecar container.
+//
+// NOTE: the script is embedded in a Terraform template, so
+// it must not use the dollar-brace syntax for variables.
+const klientScript = `set -e
+if ! command -v curl >/dev/null 2>&1; then
+	apt-get update -qq && apt-get install -y -qq curl ca-certificates
+fi
+mkdir -p /opt/kite/klient /var/lib/koding
+curl --location --silent --show-error --retry 5 "$KODING_KLIENT_URL" | gzip --decompress > /opt/kite/klient/klient
+chmod +x /opt/kite/klient/klient
+printf '%s' "$KODING_METADATA" > /var/lib/koding/metadata.json
+exec /opt/kite/klient/klient -metadata-file /var/lib/koding/metadata.json`
+
+// Stack represents a stack of Kubernetes deployments.
+type Stack struct {
+	*provider.BaseStack
+
+	KlientURL string
+}
+
+var (
+	_ provider.Stack = (*Stack)(nil) // public API
+	_ stack.Stacker  = (*Stack)(nil) // internal API
+)
+
+func newStack(bs *provider.BaseStack) (provider.Stack, error) {
+	return &Stack{
+		BaseStack: bs,
+		KlientURL: stack.Konfig.KlientGzURL(),
+	}, nil
+}
+
+// VerifyCredential checks whether the cluster
+// is accessible with the given kubeconfig.
+func (s *Stack) VerifyCredential(c *stack.Credential) error {
+	cred := c.Credential.(*Credential)
+
+	if err := cred.Valid(); err != nil {
+		return err
+	}
+
+	cfg, err := cred.Config()
+	if err != nil {
+		return err
+	}
+
+	client, err := kubernetesapi.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Version(); err != nil {
+		return &stack.Error{
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+// BootstrapTemplates implements the provider.Stack interface.
+//
+// It is a nop for Kubernetes.
+func (s *Stack) BootstrapTemplates(*stack.Credential) (_ []*stack.Template, _ error) {
+	return
+}
+
+// ApplyTemplate applies the given credentials to user's stack template
+// and injects a klient sidecar into each deployment.
+func (s *Stack) ApplyTemplate(c *stack.Credential) (*stack.Template, error) {
+	cred, ok := c.Credential.(*Credential)
+	if !ok {
+		return nil, fmt.Errorf("credential is not of type kubernetes.Credential: %T", c.Credential)
+	}
+
+	cfg, err := cred.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	t := s.Builder.Template
+
+	var resource struct {
+		Deployment map[string]map[string]interface{} `hcl:"kubernetes_deployment"`
+	}
+
+	if err := t.DecodeResource(&resource); err != nil {
+		return nil, err
+	}
+
+	if len(resource.Deployment) == 0 {
+		return nil, errors.New("there are no Kubernetes deployments defined")
+	}
+
+	t.Provider["kubernetes"] = providerArgs(cfg)
+
+	for name, deployment := range resource.Deployment {
+		if err := s.injectSidecar(name, cfg, deployment); err != nil {
+			return nil, err
+		}
+	}
+
+	t.Resource["kubernetes_deployment"] = resource.Deployment
+
+	if err := t.ShadowVariables("FORBIDDEN", "kubernetes_kubeconfig"); err != nil {
+		return nil, err
+	}
+
+	if err := t.Flush(); err != nil {
+		return nil, err
+	}
+
+	content, err := t.JsonOutput()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stack.Template{
+		Content: content,
+	}, nil
+}
+
+// injectSidecar configures the deployment to run a single pod with
+// a klient container, which connects the pod to Koding.
+func (s *Stack) injectSidecar(name string, cfg *kubernetesapi.Config, deployment map[string]interface{}) error {
+	if _, ok := deployment["user_data"]; ok {
+		return fmt.Errorf("%q: user_data is not supported by kubernetes provider, use container command instead", name)
+	}
+
+	image := defaultKlientImage
+	if s, ok := deployment["koding_klient_image"].(string); ok && s != "" {
+		image = s
+	}
+	delete(deployment, "koding_klient_image")
+
+	meta := object(deployment, "metadata")
+
+	if ns, ok := meta["namespace"].(string); !ok || ns == "" {
+		meta["namespace"] = cfg.Namespace
+	}
+
+	// Each deployment is a single machine, Start and Stop
+	// scale it between 1 and 0 replicas.
+	spec := object(deployment, "spec")
+	spec["replicas"] = 1
+
+	podSpec := object(object(spec, "template"), "spec")
+
+	if err := s.BuildUserdata(name, deployment); err != nil {
+		return err
+	}
+
+	metadata, err := klientMetadata(deployment["user_data"].(string))
+	if err != nil {
+		return err
+	}
+
+	delete(deployment, "user_data")
+
+	podSpec["container"] = append(getSlice(podSpec["container"]), map[string]interface{}{
+		"name":    klientContainer,
+		"image":   image,
+		"command": []string{"/bin/sh", "-c", klientScript},
+		"env": []map[string]interface{}{{
+			"name":  "KODING_KLIENT_URL",
+			"value": s.KlientURL,
+		}, {
+			"name":  "KODING_METADATA",
+			"value": metadata,
+		}},
+	})
+
+	return nil
+}
+
+// klientMetadata extracts klient metadata file from the cloud-init
+// built by BuildUserdata, as there is no cloud-init within pods.
+func klientMetadata(userdata string) (string, error) {
+	ci, err := metadata.ParseCloudInit([]byte(userdata))
+	if err != nil {
+		return "", err
+	}
+
+	files, _ := ci["write_files"].([]interface{})
+
+	for _, v := range files {
+		file, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if file["path"] == "/var/lib/koding/metadata.json" {
+			if content, ok := file["content"].(string); ok {
+				return content, nil
+			}
+		}
+	}
+
+	return "", errors.New("klient metadata was not found")
+}
+
+func providerArgs(cfg *kubernetesapi.Config) map[string]interface{} {
+	args := map[string]interface{}{
+		"host": cfg.Server,
+	}
+
+	if cfg.Insecure {
+		args["insecure"] = true
+	}
+
+	for k, v := range map[string]string{
+		"cluster_ca_certificate": cfg.CA,
+		"client_certificate":     cfg.Cert,
+		"client_key":             cfg.Key,
+		"token":                  cfg.Token,
+		"username":               cfg.Username,
+		"password":               cfg.Password,
+	} {
+		if v != "" {
+			args[k] = v
+		}
+	}
+
+	return args
+}
+
+// object gives a nested block of the given resource, creating it
+// if it does not exist. Terraform blocks are decoded as lists with
+// an element per each block key, which are merged here into
+// a single object.
+func object(resource map[string]interface{}, key string) map[string]interface{} {
+	obj := make(map[string]interface{})
+
+	for _, v := range getSlice(resource[key]) {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for k, v := range m {
+			if old, ok := obj[k]; ok {
+				obj[k] = append(getSlice(old), getSlice(v)...)
+			} else {
+				obj[k] = v
+			}
+		}
+	}
+
+	resource[key] = []map[string]interface{}{obj}
+
+	return obj
+}
+
+func getSlice(v interface{}) []interface{} {
+	var slice []interface{}
+
+	switch v := v.(type) {
+	case nil:
+	case []map[string]interface{}:
+		slice = make([]interface{}, 0, len(v))
+
+		for _, elem := range v {
+			slice = append(slice, elem)
+		}
+	case []interface{}:
+		slice = v
+	default:
+		slice = []interface{}{v}
+	}
+
+	return slice
+}
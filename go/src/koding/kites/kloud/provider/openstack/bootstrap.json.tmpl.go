@@ -0,0 +1,246 @@
+// Code generated for package openstack by go-bindata DO NOT EDIT. (@generated)
+// sources:
+// bootstrap.json.tmpl
+package openstack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes []byte
+	info  os.FileInfo
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// Name return file name
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+
+// Size return file size
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+
+// Mode return file mode
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+
+// Mode return file modify time
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+// IsDir return file whether a directory
+func (fi bindataFileInfo) IsDir() bool {
+	return fi.mode&os.ModeDir != 0
+}
+
+// Sys return file is sys mode
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var _bootstrapJsonTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x56\xdf\x6b\xdb\x30\x10\x7e\xcf\x5f\x21\xc4\x1e\x5b\xaf\x0d\xdd\xaf\x3e\x6f\x8c\x51\x28\x65\x7d\x1c\x43\xa8\xf2\x25\x13\x71\x24\x71\x96\xdc\x05\xe3\xff\x7d\xc8\x96\x6c\xb9\x71\xdc\x64\x0b\x23\x79\x48\xee\x3e\xdd\xdd\xf7\xe9\x93\xec\x7a\x41\x08\x35\xa8\x2b\x99\x03\xd2\x5b\xe2\xff\x13\x42\xb5\x01\x55\x5a\x2e\x36\x7d\x88\x10\xca\x9d\xfd\xc5\x1c\x16\xf4\x96\xd0\x37\x75\xc5\x31\xeb\x61\x2c\xe6\x1a\x7a\x11\xe1\xae\x04\x64\x8a\x6f\x61\x0a\xdf\x27\x93\x05\x86\x97\xe5\xb3\xc6\x7c\x0a\x1f\x73\x09\xdc\x82\xe2\xca\x1e\xec\x90\xa4\x93\x45\x08\x6b\xa9\xd5\x14\xbe\xcb\x34\xb4\x1d\xbf\x59\x10\xd2\xf8\x45\x54\x3b\x6b\x9c\x1d\x94\xd9\xc0\x8e\x19\x2e\x07\xad\x08\xa1\x15\x2f\x5c\x98\x61\xa8\x27\xf4\xd6\x38\x0b\x6c\x03\x3b\x8f\x67\xd5\x32\xdb\xe8\x5c\xaa\x75\x8c\x64\x7e\xf2\xd8\xcf\xf7\x22\x84\x96\x20\x1c\x4a\xbb\x63\x6b\xd4\xce\x1c\xdd\xa3\x04\xd1\x2e\x48\x9a\xc4\xd0\x54\x17\x05\xf6\x59\xe3\x86\xc9\xfc\xd5\x0e\x01\xea\xc7\x8e\xab\x86\x1e\x21\x92\xc9\xfc\x25\x0d\xf7\xa4\xc0\x9e\x58\x3f\x2c\x4a\x28\xb4\x81\xa4\x7a\xbf\x29\x08\xa5\x76\x28\x60\xc2\xb0\x13\xba\xa7\x43\x8c\xb7\x20\xc9\x1c\xe5\x8d\x68\x23\x42\x68\xb4\x5d\x5d\x67\x77\xb0\x7b\xe0\x12\xef\xbd\xd0\x23\x8c\x71\x4f\x85\x14\xbe\x59\x40\x3e\xb4\x81\x3b\xd8\x35\x81\x52\x47\x6a\x10\x6e\x76\x6b\x27\x78\xc4\xec\x79\x88\x3c\x06\xf7\x7d\xf5\x25\xf7\xe9\xe4\x50\x0a\x94\xc6\x86\xda\x77\xad\x94\x24\x5a\x96\xb4\x0e\x4c\xf1\xe8\x0a\x5f\xf9\x47\x1f\x21\xc9\x90\xfe\x4b\x57\xa8\xb7\xcc\x68\xf4\xe7\x6b\xb9\x1c\x96\xfa\x0f\xb5\xfa\x60\x4a\x1a\x66\x50\x5b\x2d\x74\x7b\x19\x59\x91\xf6\xf5\x5f\x2a\x64\xee\x8f\x28\xbd\xca\xda\xcf\xdb\xab\xa8\x77\xa2\xf6\xab\x23\xbd\x7b\xff\xe1\xe3\xa7\x83\x53\x4d\x65\xff\xd3\x60\x97\xd7\x07\xa7\xba\xbc\x9e\x1f\x49\x8a\xed\x89\x33\xf5\xbf\x7f\xbe\x62\xd9\xe4\x2c\x87\x9f\xd3\xa6\x0d\xc9\xf3\x78\xf6\xbe\x2b\xb6\xef\x56\x9e\x6f\xa5\x62\xa5\xe5\x16\x58\x7b\x40\xa8\x45\x07\xf4\x78\x0e\xfd\x7d\x34\x41\xa1\xcb\x9d\x87\xc1\x63\x5b\x6b\x9f\x40\x18\xa5\xbb\x45\xff\xee\x52\xbe\x58\xec\xed\x71\x37\xdb\x88\x06\xf3\x9e\x1c\x81\xa5\x61\x15\x60\xd9\x1d\xf4\x9b\xb1\x64\x75\x2d\x57\x24\xfb\xf2\xdb\x02\x2a\x5e\x04\xf9\xbf\x7d\x6e\x66\xb5\x44\xed\x2c\x1c\xb8\x8b\xbb\xdc\x79\xb4\xfc\xde\xd6\x3a\xda\x0c\x09\x02\x02\x21\xb6\xe6\x16\x9e\x79\xbc\xb1\x27\x78\x9e\x60\xa1\x40\x5b\x2a\x0b\xb8\xe2\x02\xe6\x04\x18\x50\xff\x20\x45\x2c\x35\x67\x99\x80\x19\x1c\xd3\x05\x5e\x1a\x26\x78\x63\xb6\x54\xc0\x0c\xa5\x5e\x3e\xb2\x13\xd7\x80\xca\x9b\xe1\xb5\xaa\xe2\x28\xf9\x53\x91\x3c\xc1\x47\x15\x58\xb0\x6b\x2f\x55\x0e\x2b\xee\x0a\xff\x30\xa0\xd7\xf1\x9a\x5a\xde\x24\xef\x05\x8b\x66\xf1\x67\x00\xba\xe5\x51\xc6\xcc\x0a\x00\x00")
+
+func bootstrapJsonTmplBytes() ([]byte, error) {
+	return bindataRead(
+		_bootstrapJsonTmpl,
+		"bootstrap.json.tmpl",
+	)
+}
+
+func bootstrapJsonTmpl() (*asset, error) {
+	bytes, err := bootstrapJsonTmplBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "bootstrap.json.tmpl", size: 2764, mode: os.FileMode(420), modTime: time.Unix(1476403200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"bootstrap.json.tmpl": bootstrapJsonTmpl,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"bootstrap.json.tmpl": {bootstrapJsonTmpl, map[string]*bintree{}},
+}}
+
+// RestoreAsset restores an asset under the given directory
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreAssets restores an asset under the given directory recursively
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(cannonicalName, "/")...)...)
+}
@@ -0,0 +1,204 @@
+package openstack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	osapi "koding/kites/kloud/api/openstack"
+	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/stack/provider"
+
+	"github.com/cenkalti/backoff"
+	"golang.org/x/net/context"
+)
+
+// Statuses of an OpenStack server, which are relevant to Koding.
+//
+// http://developer.openstack.org/api-guide/compute/server_concepts.html
+const (
+	statusActive       = "active"
+	statusBuild        = "build"
+	statusRebuild      = "rebuild"
+	statusReboot       = "reboot"
+	statusHardReboot   = "hard_reboot"
+	statusShutoff      = "shutoff"
+	statusSuspended    = "suspended"
+	statusPaused       = "paused"
+	statusDeleted      = "deleted"
+	statusSoftDeleted  = "soft_deleted"
+	statusError        = "error"
+	statusShelved      = "shelved"
+	statusShelvedOffld = "shelved_offloaded"
+)
+
+var (
+	_ provider.Machine = (*Machine)(nil)
+
+	// ErrInvalidServerID is returned if an invalid server ID is used
+	ErrInvalidServerID = errors.New("server ID is invalid")
+)
+
+// Machine is responsible of handling a single OpenStack server
+type Machine struct {
+	*provider.BaseMachine
+
+	cred *Credential
+}
+
+// newMachine returns a provider.Machine that can be used to manage
+// OpenStack servers.
+func newMachine(bm *provider.BaseMachine) (provider.Machine, error) {
+	cred, ok := bm.Credential.(*Credential)
+	if !ok {
+		return nil, errors.New("not a valid OpenStack credential")
+	}
+
+	return &Machine{
+		cred:        cred,
+		BaseMachine: bm,
+	}, nil
+}
+
+// Start starts an existing server associated to the machine
+func (m *Machine) Start(ctx context.Context) (interface{}, error) {
+	client, err := m.client()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.StartServer(); err != nil {
+		return nil, err
+	}
+
+	if err := waitForState(ctx, client, machinestate.Running); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Stop stops an existing server associated to the machine
+func (m *Machine) Stop(ctx context.Context) (interface{}, error) {
+	client, err := m.client()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.StopServer(); err != nil {
+		return nil, err
+	}
+
+	if err := waitForState(ctx, client, machinestate.Stopped); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Info returns the server state
+func (m *Machine) Info(context.Context) (machinestate.State, interface{}, error) {
+	client, err := m.client()
+	if err != nil {
+		return machinestate.Unknown, nil, err
+	}
+
+	server, err := client.Server()
+	if err == osapi.ErrServerNotFound {
+		return machinestate.NotInitialized, nil, nil
+	}
+
+	if err != nil {
+		return machinestate.Unknown, nil, err
+	}
+
+	return statusToState(server.Status), nil, nil
+}
+
+// ServerID returns the server ID associated for this given machine
+func (m *Machine) ServerID() (string, error) {
+	metadata, ok := m.BaseMachine.Metadata.(*Metadata)
+	if !ok {
+		return "", fmt.Errorf("meta data is not of type openstack.Metadata: %T",
+			m.BaseMachine.Metadata)
+	}
+
+	if metadata.ServerID == "" {
+		return "", ErrInvalidServerID
+	}
+
+	return metadata.ServerID, nil
+}
+
+// client gives an API client for the server associated with the machine.
+func (m *Machine) client() (*osapi.Openstack, error) {
+	serverID, err := m.ServerID()
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(m.cred, serverID)
+}
+
+// newClient authenticates against the identity service and gives
+// an API client for the given server. The serverID may be empty
+// if no server calls are going to be made.
+func newClient(cred *Credential, serverID string) (*osapi.Openstack, error) {
+	credential := map[string]interface{}{
+		"username":    cred.Username,
+		"password":    cred.Password,
+		"tenant_name": cred.TenantName,
+	}
+
+	builder := map[string]interface{}{
+		"instanceId": serverID,
+		"region":     cred.region(),
+	}
+
+	return osapi.New(cred.AuthURL, "openstack", credential, builder)
+}
+
+// waitForState polls the server until it reaches the given state.
+func waitForState(ctx context.Context, client *osapi.Openstack, want machinestate.State) error {
+	ticker := backoff.NewTicker(backoff.NewExponentialBackOff())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			server, err := client.Server()
+			if err != nil {
+				return err
+			}
+
+			state := statusToState(server.Status)
+
+			if state == want {
+				return nil
+			}
+
+			if strings.ToLower(server.Status) == statusError {
+				return fmt.Errorf("server %q is in error state", client.Id())
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for server %q to become %s", client.Id(), want)
+		}
+	}
+}
+
+// statusToState converts a server status to a sensible machinestate.State
+// enum.
+func statusToState(status string) machinestate.State {
+	switch strings.ToLower(status) {
+	case statusBuild, statusRebuild, statusReboot, statusHardReboot:
+		return machinestate.Starting
+	case statusActive:
+		return machinestate.Running
+	case statusShutoff, statusSuspended, statusPaused, statusShelved, statusShelvedOffld:
+		return machinestate.Stopped
+	case statusDeleted, statusSoftDeleted:
+		return machinestate.Terminated
+	default:
+		return machinestate.Unknown
+	}
+}
@@ -0,0 +1,294 @@
+package openstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"koding/db/models"
+	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+
+	"golang.org/x/net/context"
+)
+
+const testServerID = "3f2e5b2c-6f43-4b7a-9d3c-0e35b1f8fd7e"
+
+func TestNewMachine(t *testing.T) {
+	bm := newOpenstackBaseMachine("", testServerID)
+	_, err := newMachine(bm)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmptyServerID(t *testing.T) {
+	bm := newOpenstackBaseMachine("", "")
+	machine, err := newMachine(bm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = machine.Start(context.Background())
+	if err != ErrInvalidServerID {
+		t.Errorf("Server ID should return %s, got %s", ErrInvalidServerID, err)
+	}
+}
+
+func TestMachineStartStop(t *testing.T) {
+	cases := map[string]struct {
+		action string
+		status string
+		fn     func(provider.Machine, context.Context) (interface{}, error)
+	}{
+		"start": {"os-start", "ACTIVE", provider.Machine.Start},
+		"stop":  {"os-stop", "SHUTOFF", provider.Machine.Stop},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			var actions []string
+
+			compute := func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Auth-Token") != "token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				switch r.URL.Path {
+				case "/compute/servers/" + testServerID + "/action":
+					var v map[string]interface{}
+					_ = json.NewDecoder(r.Body).Decode(&v)
+
+					for action := range v {
+						actions = append(actions, action)
+					}
+
+					w.WriteHeader(http.StatusAccepted)
+				case "/compute/servers/" + testServerID:
+					_ = json.NewEncoder(w).Encode(newRootServer(cas.status))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+
+			err := withMachine(compute, func(m *Machine) error {
+				_, err := cas.fn(m, context.Background())
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(actions) != 1 || actions[0] != cas.action {
+				t.Fatalf("got %v, want [%s]", actions, cas.action)
+			}
+		})
+	}
+}
+
+func TestMachineInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int // by default 200
+		body       string
+		status     string
+		state      machinestate.State
+	}{
+		{
+			name:   "building server",
+			status: "BUILD",
+			state:  machinestate.Starting,
+		},
+		{
+			name:   "running server",
+			status: "ACTIVE",
+			state:  machinestate.Running,
+		},
+		{
+			name:   "stopped server",
+			status: "SHUTOFF",
+			state:  machinestate.Stopped,
+		},
+		{
+			name:   "deleted server",
+			status: "DELETED",
+			state:  machinestate.Terminated,
+		},
+		{
+			name:   "failed server",
+			status: "ERROR",
+			state:  machinestate.Unknown,
+		},
+		{
+			name:       "not available server",
+			state:      machinestate.NotInitialized,
+			statusCode: http.StatusNotFound,
+			body:       `{"itemNotFound":{"message":"Instance could not be found","code":404}}`,
+		},
+		{
+			name:       "misconfigured endpoint",
+			state:      machinestate.Unknown,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "server not responding",
+			state:      machinestate.Unknown,
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		// capture range variable here
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			compute := func(w http.ResponseWriter, r *http.Request) {
+				if test.statusCode != 0 {
+					w.WriteHeader(test.statusCode)
+					fmt.Fprint(w, test.body)
+					return
+				}
+
+				_ = json.NewEncoder(w).Encode(newRootServer(test.status))
+			}
+
+			err := withMachine(compute, func(m *Machine) error {
+				state, _, _ := m.Info(context.Background())
+				if state != test.state {
+					return fmt.Errorf("expecting %q, got: %q", test.state, state)
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMachineReauthenticate(t *testing.T) {
+	var rejected bool
+
+	compute := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/compute/servers/" + testServerID + "/action":
+			// Reject the first token as if it was revoked.
+			if !rejected {
+				rejected = true
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			w.WriteHeader(http.StatusAccepted)
+		case "/compute/servers/" + testServerID:
+			_ = json.NewEncoder(w).Encode(newRootServer("ACTIVE"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	err := withMachine(compute, func(m *Machine) error {
+		_, err := m.Start(context.Background())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !rejected {
+		t.Fatal("expected the first start request to be rejected")
+	}
+}
+
+// withKeystone starts Identity API v2.0 server, which accepts
+// user:secret credentials only.
+func withKeystone(fn func(authURL string) error) error {
+	return withCompute(nil, func(url string) error {
+		return fn(url + "/v2.0")
+	})
+}
+
+func withCompute(compute http.HandlerFunc, fn func(url string) error) error {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/v2.0/tokens", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Auth struct {
+				PasswordCredentials struct {
+					Username string `json:"username"`
+					Password string `json:"password"`
+				} `json:"passwordCredentials"`
+			} `json:"auth"`
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		if c := req.Auth.PasswordCredentials; c.Username != "user" || c.Password != "secret" {
+			http.Error(w, `{"error":{"code":401,"title":"Unauthorized"}}`, http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprintf(w, `{"access":{"token":{"id":"token","expires":"2030-01-01T00:00:00Z",`+
+			`"tenant":{"id":"1","name":"koding"}},"user":{"id":"1","name":"user"},"serviceCatalog":[`+
+			`{"name":"nova","type":"compute","endpoints":[{"region":"RegionOne","publicURL":"%s/compute"}]}]}}`, server.URL)
+	})
+
+	if compute != nil {
+		mux.HandleFunc("/compute/", compute)
+	}
+
+	return fn(server.URL)
+}
+
+func withMachine(compute http.HandlerFunc, fn func(m *Machine) error) error {
+	return withCompute(compute, func(url string) error {
+		machine, err := newMachine(newOpenstackBaseMachine(url+"/v2.0", testServerID))
+		if err != nil {
+			return err
+		}
+
+		m, ok := machine.(*Machine)
+		if !ok {
+			return fmt.Errorf("can't type assert %T to *Machine", machine)
+		}
+
+		return fn(m)
+	})
+}
+
+func newOpenstackBaseMachine(authURL, serverID string) *provider.BaseMachine {
+	return &provider.BaseMachine{
+		Machine: &models.Machine{},
+		Session: nil,
+		Credential: &Credential{
+			AuthURL:    authURL,
+			Username:   "user",
+			Password:   "secret",
+			TenantName: "koding",
+		},
+		Bootstrap: Provider.Schema.NewBootstrap(),
+		Metadata: Provider.Schema.NewMetadata(&stack.Machine{
+			Attributes: map[string]string{"id": serverID},
+		}),
+		Provider: "openstack",
+	}
+}
+
+func newRootServer(status string) interface{} {
+	return map[string]interface{}{
+		"server": map[string]interface{}{
+			"id":     testServerID,
+			"name":   "koding-" + strings.ToLower(status),
+			"status": status,
+		},
+	}
+}
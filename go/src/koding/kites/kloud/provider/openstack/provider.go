@@ -0,0 +1,15 @@
+package openstack
+
+import "koding/kites/kloud/stack/provider"
+
+var Provider = &provider.Provider{
+	Name:         "openstack",
+	ResourceName: "compute_instance_v2",
+	Machine:      newMachine,
+	Stack:        newStack,
+	Schema:       newSchema(),
+}
+
+func init() {
+	provider.Register(Provider)
+}
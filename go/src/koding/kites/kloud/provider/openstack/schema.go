@@ -0,0 +1,152 @@
+package openstack
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+// DefaultRegion is used when credential does not specify a region.
+const DefaultRegion = "RegionOne"
+
+// Credential stores the necessary credentials needed to interact with
+// OpenStack Identity (Keystone) and Compute (Nova) APIs.
+type Credential struct {
+	// AuthURL is the Keystone Identity API v2.0 endpoint,
+	// e.g. https://keystone.example.com:5000/v2.0.
+	AuthURL    string `json:"auth_url" bson:"auth_url" hcl:"auth_url"`
+	Username   string `json:"user_name" bson:"user_name" hcl:"user_name"`
+	Password   string `json:"password" bson:"password" hcl:"password"`
+	TenantName string `json:"tenant_name" bson:"tenant_name" hcl:"tenant_name"`
+	Region     string `json:"region,omitempty" bson:"region,omitempty" hcl:"region"`
+
+	// ExternalNetworkID is an ID of a public network. When non-empty
+	// the bootstrap network is routed to it, so the instances
+	// can reach Koding.
+	ExternalNetworkID string `json:"external_network_id,omitempty" bson:"external_network_id,omitempty" hcl:"external_network_id"`
+}
+
+// Bootstrap represent the data to bootstrap the OpenStack environment
+type Bootstrap struct {
+	KeyPair       string `json:"key_pair" bson:"key_pair" hcl:"key_pair"`
+	SecurityGroup string `json:"security_group" bson:"security_group" hcl:"security_group"`
+	NetworkID     string `json:"network_id" bson:"network_id" hcl:"network_id"`
+	SubnetID      string `json:"subnet_id" bson:"subnet_id" hcl:"subnet_id"`
+}
+
+// Metadata represent the data that is stored on Koding's DB storage
+type Metadata struct {
+	ServerID string `json:"server_id" bson:"server_id" hcl:"server_id"`
+	Region   string `json:"region" bson:"region" hcl:"region"`
+	Flavor   string `json:"flavor" bson:"flavor" hcl:"flavor"`
+	Image    string `json:"image" bson:"image" hcl:"image"`
+}
+
+func newSchema() *provider.Schema {
+	return &provider.Schema{
+		NewCredential: newCredential,
+		NewBootstrap:  newBootstrap,
+		NewMetadata:   newMetadata,
+	}
+}
+
+func newCredential() interface{} {
+	return &Credential{}
+}
+
+func newBootstrap() interface{} {
+	return &Bootstrap{}
+}
+
+func newMetadata(m *stack.Machine) interface{} {
+	if m == nil {
+		return &Metadata{}
+	}
+
+	meta := &Metadata{
+		ServerID: m.Attributes["id"],
+		Region:   m.Attributes["region"],
+		Flavor:   m.Attributes["flavor_name"],
+		Image:    m.Attributes["image_name"],
+	}
+
+	if meta.Flavor == "" {
+		meta.Flavor = m.Attributes["flavor_id"]
+	}
+
+	if meta.Image == "" {
+		meta.Image = m.Attributes["image_id"]
+	}
+
+	return meta
+}
+
+// region gives the credential region or DefaultRegion if none is set.
+func (c *Credential) region() string {
+	if c.Region != "" {
+		return c.Region
+	}
+	return DefaultRegion
+}
+
+// Valid implements stack.Validator
+func (c *Credential) Valid() error {
+	if c.AuthURL == "" {
+		return errors.New("auth_url is empty")
+	}
+
+	if u, err := url.Parse(c.AuthURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("auth_url is not a valid URL")
+	}
+
+	if strings.HasSuffix(strings.TrimRight(c.AuthURL, "/"), "/v3") {
+		return errors.New("auth_url must point to Identity API v2.0")
+	}
+
+	if c.Username == "" {
+		return errors.New("user_name is empty")
+	}
+
+	if c.Password == "" {
+		return errors.New("password is empty")
+	}
+
+	if c.TenantName == "" {
+		return errors.New("tenant_name is empty")
+	}
+
+	return nil
+}
+
+// Valid implements stack.Validator
+func (b *Bootstrap) Valid() error {
+	if b.KeyPair == "" {
+		return errors.New("key pair cannot be empty")
+	}
+
+	if b.SecurityGroup == "" {
+		return errors.New("security group cannot be empty")
+	}
+
+	if b.NetworkID == "" {
+		return errors.New("network id cannot be empty")
+	}
+
+	return nil
+}
+
+// Valid implements stack.Validator
+func (m *Metadata) Valid() error {
+	if m.ServerID == "" {
+		return errors.New("server ID cannot be empty")
+	}
+
+	if m.Flavor == "" {
+		return errors.New("flavor cannot be empty")
+	}
+
+	return nil
+}
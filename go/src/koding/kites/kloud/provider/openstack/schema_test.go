@@ -0,0 +1,112 @@
+package openstack
+
+import (
+	"testing"
+
+	"koding/kites/kloud/stack"
+)
+
+func TestCredential_Valid(t *testing.T) {
+	cases := map[string]struct {
+		cred *Credential
+		ok   bool
+	}{
+		"identity v2.0": {
+			&Credential{
+				AuthURL:    "https://keystone.example.com:5000/v2.0",
+				Username:   "user",
+				Password:   "secret",
+				TenantName: "koding",
+			},
+			true,
+		},
+		"identity v3": {
+			&Credential{
+				AuthURL:    "https://keystone.example.com:5000/v3/",
+				Username:   "user",
+				Password:   "secret",
+				TenantName: "koding",
+			},
+			false,
+		},
+		"invalid auth url": {
+			&Credential{
+				AuthURL:    "keystone",
+				Username:   "user",
+				Password:   "secret",
+				TenantName: "koding",
+			},
+			false,
+		},
+		"empty": {
+			&Credential{},
+			false,
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := cas.cred.Valid()
+			if cas.ok && err != nil {
+				t.Fatalf("Valid()=%s", err)
+			}
+
+			if !cas.ok && err == nil {
+				t.Fatal("expected Valid() to fail")
+			}
+		})
+	}
+}
+
+func TestBootstrap_Valid(t *testing.T) {
+	bootstrap := &Bootstrap{
+		KeyPair:       "koding-keypair",
+		SecurityGroup: "koding-secgroup",
+		NetworkID:     "d32019d3-bc6e-4319-9c1d-6722fc136a22",
+	}
+
+	if err := bootstrap.Valid(); err != nil {
+		t.Errorf("bootstrap with a valid data should not give an error, have: %s", err)
+	}
+}
+
+func TestBootstrap_Valid_Empty(t *testing.T) {
+	bootstrap := &Bootstrap{}
+
+	if err := bootstrap.Valid(); err == nil {
+		t.Errorf("bootstrap with empty data should give an error")
+	}
+}
+
+func TestNewMetadata(t *testing.T) {
+	m := &stack.Machine{
+		Attributes: map[string]string{
+			"id":        "3f2e5b2c-6f43-4b7a-9d3c-0e35b1f8fd7e",
+			"region":    "RegionOne",
+			"flavor_id": "2",
+			"image_id":  "7c9c5d0a-2a3c-4f1e-a0b4-d9a5e1b6c8f3",
+		},
+	}
+
+	meta := newMetadata(m).(*Metadata)
+
+	if err := meta.Valid(); err != nil {
+		t.Fatalf("Valid()=%s", err)
+	}
+
+	if meta.Flavor != "2" {
+		t.Errorf("got %q, want %q", meta.Flavor, "2")
+	}
+
+	if meta.Image != m.Attributes["image_id"] {
+		t.Errorf("got %q, want %q", meta.Image, m.Attributes["image_id"])
+	}
+}
+
+func TestMetadata_Valid_Empty(t *testing.T) {
+	metadata := &Metadata{}
+
+	if err := metadata.Valid(); err == nil {
+		t.Errorf("metadata with empty data should give an error")
+	}
+}
@@ -0,0 +1,213 @@
+package openstack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+//go:generate $GOPATH/bin/go-bindata -mode 420 -modtime 1476403200 -pkg openstack -o bootstrap.json.tmpl.go bootstrap.json.tmpl
+//go:generate gofmt -l -w -s bootstrap.json.tmpl.go
+
+var bootstrapTmpl = template.Must(
+	template.New("").Parse(string(MustAsset("bootstrap.json.tmpl"))),
+)
+
+type bootstrapConfig struct {
+	KeyPairName       string
+	PublicKey         string
+	SecurityGroupName string
+	NetworkName       string
+	SubnetName        string
+	RouterName        string
+	ExternalNetworkID string
+}
+
+var _ provider.Stack = (*Stack)(nil)
+
+// Stack is responsible of handling the terraform templates
+type Stack struct {
+	*provider.BaseStack
+
+	sshKeyPair *stack.SSHKeyPair
+}
+
+func newStack(bs *provider.BaseStack) (provider.Stack, error) {
+	s := &Stack{
+		BaseStack: bs,
+	}
+
+	bs.SSHKeyPairFunc = s.setSSHKeyPair
+
+	return s, nil
+}
+
+// VerifyCredential verifies whether the user's OpenStack credentials
+// are valid by authenticating against the identity service.
+func (s *Stack) VerifyCredential(c *stack.Credential) error {
+	cred := c.Credential.(*Credential)
+
+	if err := cred.Valid(); err != nil {
+		return err
+	}
+
+	if _, err := newClient(cred, ""); err != nil {
+		return &stack.Error{
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (s *Stack) setSSHKeyPair(keypair *stack.SSHKeyPair) error {
+	s.sshKeyPair = keypair
+	return nil
+}
+
+// BootstrapTemplates returns terraform templates that needs to be executed
+// before a server is created. The template creates a key pair, a security
+// group which allows SSH and klient traffic and a private network, which
+// are later used during ApplyTemplate().
+func (s *Stack) BootstrapTemplates(c *stack.Credential) ([]*stack.Template, error) {
+	cred, ok := c.Credential.(*Credential)
+	if !ok {
+		return nil, fmt.Errorf("credential is not of type openstack.Credential: %T", c.Credential)
+	}
+
+	cfg := &bootstrapConfig{
+		KeyPairName:       s.sshKeyPair.Name,
+		PublicKey:         string(s.sshKeyPair.Public),
+		SecurityGroupName: "koding-secgroup-" + c.Identifier,
+		NetworkName:       "koding-network-" + c.Identifier,
+		SubnetName:        "koding-subnet-" + c.Identifier,
+		RouterName:        "koding-router-" + c.Identifier,
+		ExternalNetworkID: cred.ExternalNetworkID,
+	}
+
+	var buf bytes.Buffer
+	if err := bootstrapTmpl.Execute(&buf, cfg); err != nil {
+		return nil, err
+	}
+
+	return []*stack.Template{
+		{Content: buf.String()},
+	}, nil
+}
+
+// ApplyTemplate enhances and updates the OpenStack terraform template. It
+// updates the various sections of the template, such as Provider, Resources,
+// Variables, etc... so it can be executed without any problems
+func (s *Stack) ApplyTemplate(c *stack.Credential) (*stack.Template, error) {
+	cred, ok := c.Credential.(*Credential)
+	if !ok {
+		return nil, fmt.Errorf("credential is not of type openstack.Credential: %T", c.Credential)
+	}
+
+	bootstrap, ok := c.Bootstrap.(*Bootstrap)
+	if !ok {
+		return nil, fmt.Errorf("bootstrap is not of type openstack.Bootstrap: %T", c.Bootstrap)
+	}
+
+	openstack := map[string]interface{}{
+		"auth_url":    cred.AuthURL,
+		"user_name":   cred.Username,
+		"password":    "${var.openstack_password}",
+		"tenant_name": cred.TenantName,
+		"region":      cred.region(),
+	}
+
+	template := s.Builder.Template
+	template.Provider["openstack"] = openstack
+
+	instances, err := s.modifyInstances(cred, bootstrap)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Resource["openstack_compute_instance_v2"] = instances
+
+	if err := template.ShadowVariables("FORBIDDEN", "openstack_password"); err != nil {
+		return nil, err
+	}
+
+	if err := template.Flush(); err != nil {
+		return nil, err
+	}
+
+	content, err := template.JsonOutput()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stack.Template{
+		Content: content,
+	}, nil
+}
+
+// modifyInstances returns a modified 'openstack_compute_instance_v2'
+// terraform resource from the stack that injects key pair, security group,
+// network and kite.
+func (s *Stack) modifyInstances(cred *Credential, b *Bootstrap) (map[string]map[string]interface{}, error) {
+	var resource struct {
+		Instance map[string]map[string]interface{} `hcl:"openstack_compute_instance_v2"`
+	}
+
+	if err := s.Builder.Template.DecodeResource(&resource); err != nil {
+		return nil, err
+	}
+
+	if len(resource.Instance) == 0 {
+		return nil, errors.New("there are no OpenStack instances defined")
+	}
+
+	for name, instance := range resource.Instance {
+		// Do not overwrite key pair when user sets it explicitly in a template.
+		if k, ok := instance["key_pair"].(string); !ok || k == "" {
+			instance["key_pair"] = b.KeyPair
+		}
+
+		// Always allow SSH and klient traffic, keeping the user groups.
+		groups := []interface{}{b.SecurityGroup}
+		switch g := instance["security_groups"].(type) {
+		case []interface{}:
+			groups = append(groups, g...)
+		case []string:
+			for _, group := range g {
+				groups = append(groups, group)
+			}
+		case string:
+			groups = append(groups, g)
+		}
+		instance["security_groups"] = groups
+
+		// Attach the instance to the bootstrap network, unless
+		// user chose the network explicitly.
+		if _, ok := instance["network"]; !ok {
+			instance["network"] = []map[string]interface{}{{
+				"uuid": b.NetworkID,
+			}}
+		}
+
+		if r, ok := instance["region"].(string); !ok || r == "" {
+			instance["region"] = cred.region()
+		}
+
+		if err := s.BuildUserdata(name, instance); err != nil {
+			return nil, err
+		}
+
+		resource.Instance[name] = instance
+	}
+
+	return resource.Instance, nil
+}
+
+// BootstrapArg returns the bootstrap argument made to the bootrap kite
+func (s *Stack) BootstrapArg() *stack.BootstrapRequest {
+	return s.BaseStack.Arg.(*stack.BootstrapRequest)
+}
@@ -0,0 +1,406 @@
+package openstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/koding/kite"
+	"github.com/koding/kite/testkeys"
+	"github.com/koding/logging"
+
+	"koding/kites/kloud/contexthelper/publickeys"
+	"koding/kites/kloud/contexthelper/session"
+	"koding/kites/kloud/keycreator"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+	"koding/kites/kloud/userdata"
+)
+
+func TestNewStack(t *testing.T) {
+	bs, err := newOpenstackBaseStack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = newStack(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStack_VerifyCredential(t *testing.T) {
+	bs, err := newOpenstackBaseStack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := newStack(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = withKeystone(func(authURL string) error {
+		cred := &stack.Credential{
+			Credential: &Credential{
+				AuthURL:    authURL,
+				Username:   "user",
+				Password:   "secret",
+				TenantName: "koding",
+			},
+		}
+
+		if err := st.VerifyCredential(cred); err != nil {
+			return fmt.Errorf("VerifyCredential()=%s", err)
+		}
+
+		cred.Credential.(*Credential).Password = "invalid"
+
+		if err := st.VerifyCredential(cred); err == nil {
+			return fmt.Errorf("VerifyCredential should error in case of an invalid password")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStack_BootstrapTemplates(t *testing.T) {
+	cases := map[string]struct {
+		cred      *Credential
+		resources []string
+	}{
+		"private network": {
+			&Credential{},
+			[]string{
+				"openstack_compute_keypair_v2",
+				"openstack_compute_secgroup_v2",
+				"openstack_networking_network_v2",
+				"openstack_networking_subnet_v2",
+			},
+		},
+		"routed network": {
+			&Credential{ExternalNetworkID: "ext-net"},
+			[]string{
+				"openstack_compute_keypair_v2",
+				"openstack_compute_secgroup_v2",
+				"openstack_networking_network_v2",
+				"openstack_networking_router_interface_v2",
+				"openstack_networking_router_v2",
+				"openstack_networking_subnet_v2",
+			},
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			bs, err := newOpenstackBaseStack()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			st, err := newStack(bs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			st.(*Stack).sshKeyPair = &stack.SSHKeyPair{
+				Name:   "test-key",
+				Public: []byte("random-publickey"),
+			}
+
+			templates, err := st.BootstrapTemplates(&stack.Credential{
+				Identifier: "abcdef",
+				Credential: cas.cred,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(templates) != 1 {
+				t.Fatalf("the number of templates should be only one, got %d", len(templates))
+			}
+
+			var v struct {
+				Output   map[string]interface{}            `json:"output"`
+				Resource map[string]map[string]interface{} `json:"resource"`
+			}
+
+			if err := json.Unmarshal([]byte(templates[0].Content), &v); err != nil {
+				t.Fatalf("Unmarshal()=%s:\n%s", err, templates[0].Content)
+			}
+
+			var resources []string
+			for name := range v.Resource {
+				resources = append(resources, name)
+			}
+
+			sort.Strings(resources)
+
+			if err := equal(toJSON(resources), toJSON(cas.resources)); err != nil {
+				t.Fatal(err)
+			}
+
+			keypair := v.Resource["openstack_compute_keypair_v2"]["koding_keypair"].(map[string]interface{})
+
+			if keypair["public_key"] != "random-publickey" {
+				t.Errorf("got %v, want %q", keypair["public_key"], "random-publickey")
+			}
+
+			for _, output := range []string{"key_pair", "security_group", "network_id", "subnet_id"} {
+				if _, ok := v.Output[output]; !ok {
+					t.Errorf("missing %q output", output)
+				}
+			}
+		})
+	}
+}
+
+func TestStack_ApplyTemplate(t *testing.T) {
+	log := logging.NewCustom("test", true)
+
+	cred := &stack.Credential{
+		Credential: &Credential{
+			AuthURL:    "https://keystone.example.com:5000/v2.0",
+			Username:   "user",
+			Password:   "secret",
+			TenantName: "koding",
+		},
+		Bootstrap: &Bootstrap{
+			KeyPair:       "koding-keypair",
+			SecurityGroup: "koding-secgroup",
+			NetworkID:     "d32019d3-bc6e-4319-9c1d-6722fc136a22",
+			SubnetID:      "0a3a1b3b-8f0c-4c83-8a8b-0e3b0f4b8a6e",
+		},
+	}
+
+	cases := map[string]struct {
+		stackFile string
+		wantFile  string
+	}{
+		"basic stack": {
+			"testdata/basic-stack.json",
+			"testdata/basic-stack.json.golden",
+		},
+		"basic stack count 2": {
+			"testdata/basic-stack-count-2.json",
+			"testdata/basic-stack-count-2.json.golden",
+		},
+	}
+
+	for name, cas := range cases {
+		// capture range variable here
+		cas := cas
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			content, err := ioutil.ReadFile(cas.stackFile)
+			if err != nil {
+				t.Fatalf("ReadFile(%s)=%s", cas.stackFile, err)
+			}
+
+			want, err := ioutil.ReadFile(cas.wantFile)
+			if err != nil {
+				t.Fatalf("ReadFile(%s)=%s", cas.wantFile, err)
+			}
+
+			template, err := provider.ParseTemplate(string(content), log)
+			if err != nil {
+				t.Fatalf("ParseTemplate()=%s", err)
+			}
+
+			s := &Stack{
+				BaseStack: &provider.BaseStack{
+					Provider: Provider,
+					Session: &session.Session{
+						Userdata: &userdata.Userdata{
+							KlientURL: "http://127.0.0.1/klient.gz",
+							Keycreator: &keycreator.Key{
+								KontrolURL:        "http://127.0.0.1/kontrol/kite",
+								KontrolPublicKey:  testkeys.Public,
+								KontrolPrivateKey: testkeys.Private,
+							},
+						},
+					},
+					Builder: &provider.Builder{
+						Template: template,
+					},
+					Req: &kite.Request{
+						Username: "user",
+					},
+					KlientIDs: make(stack.KiteMap),
+				},
+			}
+
+			stack, err := s.ApplyTemplate(cred)
+			if err != nil {
+				t.Fatalf("ApplyTemplate()=%s", err)
+			}
+
+			if err := equal(stack.Content, string(want)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func newOpenstackBaseStack() (*provider.BaseStack, error) {
+	log := logging.NewCustom("test", true)
+
+	testTemplate := `{
+    "variable": {
+        "username": {
+            "default": "testuser"
+        }
+    },
+    "provider": {
+        "openstack": {
+            "auth_url": "${var.openstack_auth_url}"
+        }
+    },
+    "resource": {
+        "openstack_compute_instance_v2": {
+            "example": {
+                "name": "web-1",
+                "image_name": "ubuntu-16.04",
+                "flavor_name": "m1.small",
+                "user_data": "sudo apt-get install sl -y\ntouch /tmp/${var.username}.txt"
+            }
+        }
+    }
+}`
+
+	template, err := provider.ParseTemplate(testTemplate, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.BaseStack{
+		Provider: Provider,
+		Req: &kite.Request{
+			Username: "testuser",
+		},
+		Arg: &stack.BootstrapRequest{
+			Provider:  "openstack",
+			GroupName: "testgroup",
+		},
+		Keys: &publickeys.Keys{
+			PublicKey: "random-publickey",
+		},
+		Builder: &provider.Builder{
+			Template: template,
+		},
+		Session: &session.Session{
+			Userdata: &userdata.Userdata{
+				KlientURL: "https://example-klient.com",
+				Keycreator: &keycreator.Key{
+					KontrolURL:        "https://example-kontrol.com",
+					KontrolPrivateKey: testkeys.Private,
+					KontrolPublicKey:  testkeys.Public,
+				},
+			},
+		},
+		KlientIDs: stack.KiteMap(map[string]string{}),
+	}, nil
+}
+
+func toJSON(v interface{}) string {
+	p, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(p)
+}
+
+func equal(got, want string) error {
+	var v1, v2 interface{}
+
+	if err := json.Unmarshal([]byte(got), &v1); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(want), &v2); err != nil {
+		return err
+	}
+
+	stripNondeterministicResources(v1)
+	stripNondeterministicResources(v2)
+
+	if !reflect.DeepEqual(v1, v2) {
+		p1, err := json.MarshalIndent(v1, "", "\t")
+		if err != nil {
+			panic(err)
+		}
+
+		p2, err := json.MarshalIndent(v2, "", "\t")
+		if err != nil {
+			panic(err)
+		}
+
+		return fmt.Errorf("got:\n%s\nwant:\n%s\n", p1, p2)
+	}
+
+	return nil
+}
+
+// stripNondeterministicResources sets the following fields to "...",
+// as they change between test runs:
+//
+//   - resource.openstack_compute_instance_v2.*.user_data
+//   - variable.kitekeys_*.default.*
+func stripNondeterministicResources(v interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	resource, ok := m["resource"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	instance, ok := resource["openstack_compute_instance_v2"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for _, v := range instance {
+		vm, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		vm["user_data"] = "..."
+		vm["name"] = "..."
+	}
+
+	variable, ok := m["variable"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for name, v := range variable {
+		if !strings.HasPrefix(name, "kitekeys_") {
+			continue
+		}
+
+		v, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		list, ok := v["default"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for i := range list {
+			list[i] = "..."
+		}
+	}
+}
@@ -3,15 +3,22 @@ package queue
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"koding/db/models"
 	"koding/db/mongodb"
 	"koding/db/mongodb/modelhelper"
 	"koding/kites/kloud/contexthelper/request"
+	"koding/kites/kloud/contexthelper/session"
 	"koding/kites/kloud/klient"
 	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/schedule"
+	"koding/kites/kloud/stack"
 	"koding/kites/kloud/stack/provider"
+	"koding/kites/kloud/stackstate"
+	"koding/kites/kloud/terraformer"
 	"koding/kites/kloud/utils/object"
 
 	"github.com/koding/kite"
@@ -22,8 +29,14 @@ import (
 )
 
 var (
-	defaultInterval = 15 * time.Second
-	planTimeout     = 50 * time.Minute
+	defaultInterval      = 15 * time.Second
+	defaultDriftInterval = 1 * time.Hour
+	scheduleInterval     = 1 * time.Minute
+	planTimeout          = 50 * time.Minute
+
+	// scheduleConcurrency is a max number of machines, which
+	// schedules are executed at a time per provider.
+	scheduleConcurrency = 8
 )
 
 type Queue struct {
@@ -32,6 +45,21 @@ type Queue struct {
 	MongoDB  *mongodb.MongoDB
 	Kite     *kite.Kite
 
+	// Terraformer, when non-nil, enables drift checks, which
+	// periodically refresh state of each applied stack.
+	Terraformer *session.TerraformerOptions
+
+	// DriftInterval is a minimum time between two drift
+	// checks of the same stack.
+	//
+	// If zero, 1h is used instead.
+	DriftInterval time.Duration
+
+	// Locker, when non-nil, enables execution of machine
+	// schedules. It is used to lock a machine for the time
+	// of a scheduled start or stop.
+	Locker stack.Locker
+
 	stackers map[string]*provider.Stacker
 }
 
@@ -51,6 +79,22 @@ func (q *Queue) Run() {
 					q.Log.Debug("failed to check %q provider: %s", s.Provider.Name, err)
 				}
 			}(s)
+
+			if q.Locker != nil {
+				go func(s *provider.Stacker) {
+					if err := q.CheckSchedule(s); err != nil {
+						q.Log.Debug("failed to check %q provider schedules: %s", s.Provider.Name, err)
+					}
+				}(s)
+			}
+		}
+
+		if q.Terraformer != nil {
+			go func() {
+				if err := q.CheckDrift(); err != nil {
+					q.Log.Debug("failed to check stack drift: %s", err)
+				}
+			}()
 		}
 	}
 }
@@ -95,6 +139,117 @@ func (q *Queue) FetchProvider(provider string, machine interface{}) error {
 	return q.MongoDB.Run("jMachines", query)
 }
 
+// FetchStack fetches the least recently checked stack, which is
+// due for a drift check.
+func (q *Queue) FetchStack(computeStack *models.ComputeStack) error {
+	now := time.Now().UTC()
+
+	query := func(c *mgo.Collection) error {
+		// check only stacks that:
+		// 1. were applied successfully
+		// 2. were not checked during last drift interval
+		egligibleStacks := bson.M{
+			"status.state": stackstate.Initialized.String(),
+			"$or": []bson.M{
+				{"drift.checkedAt": bson.M{"$exists": false}},
+				{"drift.checkedAt": bson.M{"$lt": now.Add(-q.driftInterval())}},
+			},
+		}
+
+		// update so others don't pick up the same stack
+		update := mgo.Change{
+			Update: bson.M{
+				"$set": bson.M{
+					"drift.checkedAt": now,
+				},
+			},
+		}
+
+		_, err := c.Find(egligibleStacks).Sort("drift.checkedAt").Limit(1).Apply(update, computeStack)
+		return err
+	}
+
+	return q.MongoDB.Run("jComputeStacks", query)
+}
+
+// FetchScheduled fetches the least recently checked machine of the given
+// provider, which has a start/stop schedule of its own or belongs to
+// one of the given teams.
+//
+// The returned machine has meta.scheduleCheckedAt set to the time
+// of previous check.
+func (q *Queue) FetchScheduled(provider string, teams []bson.ObjectId, machine *models.Machine) error {
+	now := time.Now().UTC()
+
+	if teams == nil {
+		teams = []bson.ObjectId{}
+	}
+
+	query := func(c *mgo.Collection) error {
+		// check only machines that:
+		// 1. belongs to the given provider
+		// 2. have a schedule or belong to a team with a schedule
+		// 3. are not assigned to anyone yet (unlocked)
+		// 4. were not checked during last minute
+		egligibleMachines := bson.M{
+			"provider":            provider,
+			"assignee.inProgress": bson.M{"$ne": true},
+			"$and": []bson.M{{
+				"$or": []bson.M{
+					{"meta.schedule": bson.M{"$exists": true}},
+					{"groups.id": bson.M{"$in": teams}},
+				},
+			}, {
+				"$or": []bson.M{
+					{"meta.scheduleCheckedAt": bson.M{"$exists": false}},
+					{"meta.scheduleCheckedAt": bson.M{"$lt": now.Add(-scheduleInterval)}},
+				},
+			}},
+		}
+
+		// update so others don't pick up the same machine
+		update := mgo.Change{
+			Update: bson.M{
+				"$set": bson.M{
+					"meta.scheduleCheckedAt": now,
+				},
+			},
+		}
+
+		_, err := c.Find(egligibleMachines).Sort("meta.scheduleCheckedAt").Limit(1).Apply(update, machine)
+		return err
+	}
+
+	return q.MongoDB.Run("jMachines", query)
+}
+
+// FetchTeamSchedules fetches machine schedules of teams,
+// which have one.
+func (q *Queue) FetchTeamSchedules() (map[bson.ObjectId]*schedule.Schedule, error) {
+	var groups []struct {
+		ID       bson.ObjectId      `bson:"_id"`
+		Schedule *schedule.Schedule `bson:"machineSchedule"`
+	}
+
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"machineSchedule": bson.M{"$exists": true}}).Select(bson.M{"machineSchedule": 1}).All(&groups)
+	}
+
+	if err := q.MongoDB.Run("jGroups", query); err != nil {
+		return nil, err
+	}
+
+	teams := make(map[bson.ObjectId]*schedule.Schedule, len(groups))
+
+	for _, g := range groups {
+		if g.Schedule != nil {
+			teams[g.ID] = g.Schedule
+		}
+	}
+
+	return teams, nil
+}
+
 func (q *Queue) Register(s *provider.Stacker) {
 	if q.stackers == nil {
 		q.stackers = make(map[string]*provider.Stacker)
@@ -115,6 +270,14 @@ func (q *Queue) interval() time.Duration {
 	return defaultInterval
 }
 
+func (q *Queue) driftInterval() time.Duration {
+	if q.DriftInterval != 0 {
+		return q.DriftInterval
+	}
+
+	return defaultDriftInterval
+}
+
 func (q *Queue) Check(s *provider.Stacker) error {
 	var m models.Machine
 
@@ -216,3 +379,241 @@ func (q *Queue) CheckUsage(providerName string, m provider.Machine, bm *provider
 
 	return modelhelper.UpdateMachine(bm.ObjectId, bson.M{"$set": obj})
 }
+
+// CheckDrift refreshes state of a single stack with terraformer and
+// records machines, which resources were removed or changed outside
+// of Koding.
+//
+// The result is available with describeStack kite method.
+func (q *Queue) CheckDrift() error {
+	var s models.ComputeStack
+
+	if err := q.FetchStack(&s); err != nil {
+		// no stack is due for a check
+		if err == mgo.ErrNotFound {
+			return nil
+		}
+
+		return fmt.Errorf("fetch stack error: %s", err)
+	}
+
+	var machines []*models.Machine
+
+	err := q.MongoDB.Run("jMachines", func(c *mgo.Collection) error {
+		return c.Find(bson.M{"_id": bson.M{"$in": s.Machines}}).All(&machines)
+	})
+	if err != nil {
+		return err
+	}
+
+	tfKite, err := terraformer.Connect(q.Terraformer.Endpoint, q.Terraformer.SecretKey, q.Terraformer.Kite)
+	if err != nil {
+		return err
+	}
+	defer tfKite.Close()
+
+	state, err := tfKite.Refresh(&terraformer.TerraformRequest{
+		ContentID: s.Group + "-" + s.Id.Hex(),
+		Provider:  stackProvider(machines),
+	})
+	if err != nil {
+		return fmt.Errorf("[%s] refresh error: %s", s.Id.Hex(), err)
+	}
+
+	drift := &models.StackDrift{
+		CheckedAt: time.Now().UTC(),
+		Resources: provider.DetectDrift(state, machines),
+	}
+
+	if len(drift.Resources) != 0 {
+		q.Log.Info("[%s] stack has %d drifted resources", s.Id.Hex(), len(drift.Resources))
+	}
+
+	return q.MongoDB.Run("jComputeStacks", func(c *mgo.Collection) error {
+		return c.UpdateId(s.Id, bson.M{"$set": bson.M{"drift": drift}})
+	})
+}
+
+// stackProvider gives provider name of the stack machines, which
+// is used by terraformer to limit concurrent requests per provider.
+func stackProvider(machines []*models.Machine) string {
+	for _, m := range machines {
+		if m.Provider != "" {
+			return m.Provider
+		}
+	}
+
+	return ""
+}
+
+// CheckSchedule executes schedules of all machines of the given provider,
+// which are due for a check, starting or stopping them if the schedule
+// says so. At most scheduleConcurrency machines are handled at a time.
+func (q *Queue) CheckSchedule(s *provider.Stacker) error {
+	teams, err := q.FetchTeamSchedules()
+	if err != nil {
+		return fmt.Errorf("fetch team schedules error: %s", err)
+	}
+
+	ids := make([]bson.ObjectId, 0, len(teams))
+
+	for id := range teams {
+		ids = append(ids, id)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	sem := make(chan struct{}, scheduleConcurrency)
+
+	for {
+		m := &models.Machine{}
+
+		if err := q.FetchScheduled(s.Provider.Name, ids, m); err != nil {
+			// no more machines are due for a check
+			if err == mgo.ErrNotFound {
+				return nil
+			}
+
+			return fmt.Errorf("fetch scheduled %q machine error: %s", s.Provider.Name, err)
+		}
+
+		sched, err := machineSchedule(m, teams)
+		if err != nil {
+			q.Log.Debug("[%s] invalid schedule: %s", m.ObjectId.Hex(), err)
+			continue
+		}
+
+		if sched == nil {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := q.checkSchedule(s, m, sched); err != nil {
+				q.Log.Debug("[%s] failed to check schedule: %s", m.ObjectId.Hex(), err)
+			}
+		}()
+	}
+}
+
+// machineSchedule gives machine's own schedule or, if it has none,
+// a schedule of the first of its teams, which has one.
+func machineSchedule(m *models.Machine, teams map[bson.ObjectId]*schedule.Schedule) (*schedule.Schedule, error) {
+	sched, err := schedule.FromMeta(m.Meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if sched != nil {
+		return sched, nil
+	}
+
+	for _, g := range m.Groups {
+		if sched, ok := teams[g.Id]; ok {
+			return sched, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// checkSchedule executes the schedule of a single machine.
+func (q *Queue) checkSchedule(s *provider.Stacker, m *models.Machine, sched *schedule.Schedule) error {
+	now := time.Now().UTC()
+	since, _ := m.Meta["scheduleCheckedAt"].(time.Time)
+
+	if since.IsZero() {
+		since = now.Add(-scheduleInterval)
+	}
+
+	action, err := sched.Action(since, now)
+	if err != nil {
+		return fmt.Errorf("[%s] invalid schedule: %s", m.ObjectId.Hex(), err)
+	}
+
+	var pending, final machinestate.State
+
+	switch state := m.State(); {
+	case action == schedule.Start && state == machinestate.Stopped:
+		pending, final = machinestate.Starting, machinestate.Running
+	case action == schedule.Stop && state == machinestate.Running:
+		pending, final = machinestate.Stopping, machinestate.Stopped
+	default:
+		return nil
+	}
+
+	id := m.ObjectId.Hex()
+
+	if err := q.Locker.Lock(id); err != nil {
+		// someone else is already working with the machine
+		if err == stack.ErrLockAcquired {
+			return nil
+		}
+
+		return err
+	}
+	defer q.Locker.Unlock(id)
+
+	req := &kite.Request{
+		Method: "internal",
+	}
+
+	if u := m.Owner(); u != nil {
+		req.Username = u.Username
+	}
+
+	ctx := request.NewContext(context.Background(), req)
+
+	bm, err := s.BuildBaseMachine(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	machine, err := s.BuildMachine(ctx, bm)
+	if err != nil {
+		return err
+	}
+
+	q.Log.Info("[%s] ======> %s started (scheduled) <======", id, strings.ToUpper(action.String()))
+
+	reason := fmt.Sprintf("Machine is %s due to schedule", strings.ToLower(final.String()))
+
+	if err := modelhelper.ChangeMachineState(m.ObjectId, reason, pending); err != nil {
+		return err
+	}
+
+	var meta interface{}
+
+	if action == schedule.Start {
+		meta, err = machine.Start(ctx)
+	} else {
+		meta, err = machine.Stop(ctx)
+	}
+
+	if err != nil {
+		q.Log.Info("[%s] ======> %s aborted (scheduled: %s) <======", id, strings.ToUpper(action.String()), err)
+
+		// revert to the original state, so the schedule can be retried
+		// manually or by the next occurrence
+		modelhelper.ChangeMachineState(m.ObjectId, "Scheduled "+action.String()+" failed: "+err.Error(), m.State())
+
+		return err
+	}
+
+	q.Log.Info("[%s] ======> %s finished (scheduled) <======", id, strings.ToUpper(action.String()))
+
+	obj := object.MetaBuilder.Build(meta)
+	obj["status.modifiedAt"] = time.Now().UTC()
+	obj["status.state"] = final.String()
+	obj["status.reason"] = reason
+
+	return modelhelper.UpdateMachine(m.ObjectId, bson.M{"$set": obj})
+}
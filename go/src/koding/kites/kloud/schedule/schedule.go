@@ -0,0 +1,235 @@
+// Package schedule implements cron-like start/stop policies for machines.
+//
+// A schedule is stored under jMachine.meta.schedule, or under
+// jGroup.machineSchedule for team machines that have no schedule
+// of their own. It is executed periodically by kloud queue.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Action is a machine operation triggered by a schedule.
+type Action int
+
+// Actions of a schedule.
+const (
+	None Action = iota
+	Start
+	Stop
+)
+
+// String implements the fmt.Stringer interface.
+func (a Action) String() string {
+	switch a {
+	case Start:
+		return "start"
+	case Stop:
+		return "stop"
+	default:
+		return "none"
+	}
+}
+
+// maxSteps limits the number of cron occurrences that are
+// iterated over when looking for the most recent one.
+const maxSteps = 10000
+
+// Schedule describes when a machine is going to be started
+// and stopped.
+//
+// Start and Stop are standard 5-field crontab specs
+// (minute, hour, day of month, month, day of week)
+// or descriptors like "@daily".
+type Schedule struct {
+	Start    string `bson:"start,omitempty" json:"start,omitempty"`
+	Stop     string `bson:"stop,omitempty" json:"stop,omitempty"`
+	Timezone string `bson:"timezone,omitempty" json:"timezone,omitempty"`
+
+	// Team is a name of the team, which owns the schedule.
+	// It is empty for machine's own schedules.
+	Team string `bson:"team,omitempty" json:"team,omitempty"`
+}
+
+// Parse parses a schedule given in the following format:
+//
+//	start=0 8 * * 1-5;stop=0 20 * * 1-5;timezone=Europe/Berlin
+//
+// Both start and stop are optional, but at least one of them
+// must be set. If timezone is empty, UTC is used.
+//
+// If the value is "off", the function returns nil schedule,
+// which disables scheduling for a machine.
+func Parse(s string) (*Schedule, error) {
+	s = strings.TrimSpace(s)
+
+	if s == "off" {
+		return nil, nil
+	}
+
+	var sched Schedule
+
+	for _, field := range strings.Split(s, ";") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid schedule field %q: expected key=value", field)
+		}
+
+		value := strings.TrimSpace(kv[1])
+
+		switch key := strings.TrimSpace(kv[0]); key {
+		case "start":
+			sched.Start = value
+		case "stop":
+			sched.Stop = value
+		case "timezone", "tz":
+			sched.Timezone = value
+		default:
+			return nil, fmt.Errorf("unknown schedule field %q", key)
+		}
+	}
+
+	if err := sched.Valid(); err != nil {
+		return nil, err
+	}
+
+	return &sched, nil
+}
+
+// FromMeta reads a schedule from the given jMachine.meta.
+//
+// If the meta has no schedule, the function returns nil.
+func FromMeta(meta bson.M) (*Schedule, error) {
+	v, ok := meta["schedule"]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	p, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var sched Schedule
+
+	if err := bson.Unmarshal(p, &sched); err != nil {
+		return nil, err
+	}
+
+	return &sched, nil
+}
+
+// Valid implements the stack.Validator interface.
+func (s *Schedule) Valid() error {
+	if s.Start == "" && s.Stop == "" {
+		return errors.New("schedule has neither start nor stop spec")
+	}
+
+	if _, err := parseSpec(s.Start); err != nil {
+		return fmt.Errorf("invalid start spec: %s", err)
+	}
+
+	if _, err := parseSpec(s.Stop); err != nil {
+		return fmt.Errorf("invalid stop spec: %s", err)
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", err)
+	}
+
+	return nil
+}
+
+// String gives a schedule in a format accepted by Parse.
+func (s *Schedule) String() string {
+	var fields []string
+
+	if s.Start != "" {
+		fields = append(fields, "start="+s.Start)
+	}
+
+	if s.Stop != "" {
+		fields = append(fields, "stop="+s.Stop)
+	}
+
+	if s.Timezone != "" {
+		fields = append(fields, "timezone="+s.Timezone)
+	}
+
+	return strings.Join(fields, ";")
+}
+
+// Action gives an action that is due within (since, now] period.
+//
+// If both start and stop are due within the period, the one
+// that occurred most recently wins.
+func (s *Schedule) Action(since, now time.Time) (Action, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return None, err
+	}
+
+	since, now = since.In(loc), now.In(loc)
+
+	start, err := last(s.Start, since, now)
+	if err != nil {
+		return None, err
+	}
+
+	stop, err := last(s.Stop, since, now)
+	if err != nil {
+		return None, err
+	}
+
+	switch {
+	case start.IsZero() && stop.IsZero():
+		return None, nil
+	case start.After(stop):
+		return Start, nil
+	default:
+		return Stop, nil
+	}
+}
+
+// last gives the most recent occurrence of the spec within
+// (since, now] period or zero time if there is none.
+func last(spec string, since, now time.Time) (t time.Time, err error) {
+	sched, err := parseSpec(spec)
+	if err != nil || sched == nil {
+		return time.Time{}, err
+	}
+
+	for i, next := 0, sched.Next(since); i < maxSteps && !next.IsZero() && !next.After(now); i++ {
+		t, next = next, sched.Next(next)
+	}
+
+	return t, nil
+}
+
+// parseSpec parses 5-field crontab spec, as the cron package
+// requires an additional seconds field.
+func parseSpec(spec string) (cron.Schedule, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(spec, "@") {
+		if n := len(strings.Fields(spec)); n != 5 {
+			return nil, fmt.Errorf("expected 5 fields, found %d: %q", n, spec)
+		}
+
+		spec = "0 " + spec
+	}
+
+	return cron.Parse(spec)
+}
@@ -0,0 +1,173 @@
+package schedule_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"koding/kites/kloud/schedule"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		s    string
+		want *schedule.Schedule
+		ok   bool
+	}{
+		"weekdays": {
+			"start=0 8 * * 1-5; stop=0 20 * * 1-5; timezone=Europe/Berlin",
+			&schedule.Schedule{
+				Start:    "0 8 * * 1-5",
+				Stop:     "0 20 * * 1-5",
+				Timezone: "Europe/Berlin",
+			},
+			true,
+		},
+		"stop only": {
+			"stop=@midnight",
+			&schedule.Schedule{
+				Stop: "@midnight",
+			},
+			true,
+		},
+		"off": {
+			"off",
+			nil,
+			true,
+		},
+		"empty": {
+			"",
+			nil,
+			false,
+		},
+		"unknown field": {
+			"start=0 8 * * *;when=now",
+			nil,
+			false,
+		},
+		"seconds field": {
+			"start=0 0 8 * * *",
+			nil,
+			false,
+		},
+		"invalid spec": {
+			"start=0 25 * * *",
+			nil,
+			false,
+		},
+		"invalid timezone": {
+			"start=0 8 * * *;timezone=Mars/Olympus",
+			nil,
+			false,
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := schedule.Parse(cas.s)
+			if cas.ok && err != nil {
+				t.Fatalf("Parse()=%s", err)
+			}
+
+			if !cas.ok {
+				if err == nil {
+					t.Fatalf("want err != nil, got %+v", got)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(got, cas.want) {
+				t.Fatalf("got %+v, want %+v", got, cas.want)
+			}
+		})
+	}
+}
+
+func TestFromMeta(t *testing.T) {
+	meta := bson.M{
+		"alwaysOn": false,
+		"schedule": bson.M{
+			"start": "0 8 * * 1-5",
+			"team":  "koding",
+		},
+	}
+
+	want := &schedule.Schedule{
+		Start: "0 8 * * 1-5",
+		Team:  "koding",
+	}
+
+	got, err := schedule.FromMeta(meta)
+	if err != nil {
+		t.Fatalf("FromMeta()=%s", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if got, err := schedule.FromMeta(bson.M{}); err != nil || got != nil {
+		t.Fatalf("got %+v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestScheduleAction(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone database is not available: %s", err)
+	}
+
+	sched := &schedule.Schedule{
+		Start:    "0 8 * * 1-5",
+		Stop:     "0 20 * * 1-5",
+		Timezone: "Europe/Berlin",
+	}
+
+	// 2017-03-06 is Monday.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2017, 3, day, hour, min, 0, 0, berlin).UTC()
+	}
+
+	cases := map[string]struct {
+		since time.Time
+		now   time.Time
+		want  schedule.Action
+	}{
+		"before start": {
+			at(6, 7, 58), at(6, 7, 59), schedule.None,
+		},
+		"start": {
+			at(6, 7, 59), at(6, 8, 0), schedule.Start,
+		},
+		"working hours": {
+			at(6, 12, 0), at(6, 12, 1), schedule.None,
+		},
+		"stop": {
+			at(6, 19, 59), at(6, 20, 1), schedule.Stop,
+		},
+		"most recent wins": {
+			at(6, 7, 0), at(6, 21, 0), schedule.Stop,
+		},
+		"next morning wins": {
+			at(6, 19, 0), at(7, 9, 0), schedule.Start,
+		},
+		"weekend": {
+			at(11, 7, 0), at(11, 21, 0), schedule.None,
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := sched.Action(cas.since, cas.now)
+			if err != nil {
+				t.Fatalf("Action()=%s", err)
+			}
+
+			if got != cas.want {
+				t.Fatalf("got %s, want %s", got, cas.want)
+			}
+		})
+	}
+}
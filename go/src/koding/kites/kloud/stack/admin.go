@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"koding/db/models"
 	"koding/db/mongodb/modelhelper"
 	"koding/kites/kloud/contexthelper/request"
 	"koding/kites/kloud/contexthelper/session"
@@ -17,10 +18,34 @@ import (
 type AdminRequest struct {
 	MachineId string `json:"machineId"`
 	GroupName string `json:"groupName"`
+
+	// When QuotaOverride is true, the request adds or removes
+	// team quota override for Username instead of adding
+	// the caller to a machine.
+	Username      string `json:"username,omitempty"`
+	QuotaOverride bool   `json:"quotaOverride,omitempty"`
+
+	// When Quota is set, admin.add replaces limits of the team
+	// quota with the given ones and admin.remove removes them.
+	// Quota overrides are kept in both cases.
+	Quota *models.Quota `json:"quota,omitempty"`
 }
 
 func (k *Kloud) AdminAdd(r *kite.Request) (interface{}, error) {
-	kl, err := k.authorizedKlient(r)
+	args, err := adminArgs(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.QuotaOverride {
+		return k.quotaOverride(r, args, modelhelper.AddQuotaOverride)
+	}
+
+	if args.Quota != nil {
+		return k.quotaLimits(r, args, modelhelper.SetQuotaLimits)
+	}
+
+	kl, err := k.authorizedKlient(r, args)
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +58,24 @@ func (k *Kloud) AdminAdd(r *kite.Request) (interface{}, error) {
 }
 
 func (k *Kloud) AdminRemove(r *kite.Request) (interface{}, error) {
-	kl, err := k.authorizedKlient(r)
+	args, err := adminArgs(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.QuotaOverride {
+		return k.quotaOverride(r, args, modelhelper.RemoveQuotaOverride)
+	}
+
+	if args.Quota != nil {
+		remove := func(slug string, _ *models.Quota) error {
+			return modelhelper.RemoveQuotaLimits(slug)
+		}
+
+		return k.quotaLimits(r, args, remove)
+	}
+
+	kl, err := k.authorizedKlient(r, args)
 	if err != nil {
 		return nil, err
 	}
@@ -45,16 +87,75 @@ func (k *Kloud) AdminRemove(r *kite.Request) (interface{}, error) {
 	return true, nil
 }
 
-func (k *Kloud) authorizedKlient(r *kite.Request) (*klient.Klient, error) {
+func adminArgs(r *kite.Request) (*AdminRequest, error) {
 	if r.Args == nil {
 		return nil, NewError(ErrNoArguments)
 	}
 
-	var args *AdminRequest
+	var args AdminRequest
 	if err := r.Args.One().Unmarshal(&args); err != nil {
 		return nil, err
 	}
 
+	return &args, nil
+}
+
+func (k *Kloud) quotaOverride(r *kite.Request, args *AdminRequest, fn func(slug, username string) error) (interface{}, error) {
+	if args.GroupName == "" {
+		return nil, errors.New("groupName is not passed")
+	}
+
+	if args.Username == "" {
+		return nil, errors.New("username is not passed")
+	}
+
+	if err := checkAdmin(r.Username, args.GroupName); err != nil {
+		return nil, err
+	}
+
+	if err := fn(args.GroupName, args.Username); err != nil {
+		return nil, err
+	}
+
+	k.Log.Debug("User '%s' changed quota override of '%s' in group '%s' with %s",
+		r.Username, args.Username, args.GroupName, r.Method)
+
+	return true, nil
+}
+
+func (k *Kloud) quotaLimits(r *kite.Request, args *AdminRequest, fn func(slug string, q *models.Quota) error) (interface{}, error) {
+	if args.GroupName == "" {
+		return nil, errors.New("groupName is not passed")
+	}
+
+	if err := checkAdmin(r.Username, args.GroupName); err != nil {
+		return nil, err
+	}
+
+	if err := fn(args.GroupName, args.Quota); err != nil {
+		return nil, err
+	}
+
+	k.Log.Debug("User '%s' changed quota limits of group '%s' with %s: %+v",
+		r.Username, args.GroupName, r.Method, args.Quota)
+
+	return true, nil
+}
+
+func checkAdmin(username, group string) error {
+	isAdmin, err := modelhelper.IsAdmin(username, group)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin {
+		return fmt.Errorf("User '%s' is not an admin of group '%s'", username, group)
+	}
+
+	return nil
+}
+
+func (k *Kloud) authorizedKlient(r *kite.Request, args *AdminRequest) (*klient.Klient, error) {
 	if args.MachineId == "" {
 		return nil, errors.New("machineId is not passed")
 	}
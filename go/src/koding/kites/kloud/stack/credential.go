@@ -412,6 +412,10 @@ func (k *Kloud) CredentialAdd(r *kite.Request) (interface{}, error) {
 		Bootstrap:  boot,
 	}
 
+	if tb, ok := cred.(TeamBinder); ok {
+		tb.BindTeam(req.Team)
+	}
+
 	if err := s.VerifyCredential(credential); err != nil {
 		return nil, err
 	}
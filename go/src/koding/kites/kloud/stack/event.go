@@ -9,6 +9,11 @@ import (
 type EventArg struct {
 	Type    string
 	EventId string
+
+	// Output, when true, requests the output of the process,
+	// e.g. terraform logs, starting from the given offset.
+	Output bool `json:"output,omitempty"`
+	Offset int  `json:"offset,omitempty"`
 }
 
 type EventArgs []EventArg
@@ -16,6 +21,7 @@ type EventArgs []EventArg
 type EventResponse struct {
 	EventId string         `json:"event_id"`
 	Event   *eventer.Event `json:"event"`
+	Output  []string       `json:"output,omitempty"`
 	Error   *kite.Error    `json:"err"`
 }
 
@@ -45,7 +51,7 @@ func (k *Kloud) Event(r *kite.Request) (interface{}, error) {
 			continue
 		}
 
-		ev, err := k.GetEvent(event.Type + "-" + event.EventId)
+		ev, err := k.getEventer(event.Type + "-" + event.EventId)
 		if err != nil {
 			events[i] = EventResponse{
 				EventId: event.EventId,
@@ -54,7 +60,11 @@ func (k *Kloud) Event(r *kite.Request) (interface{}, error) {
 			continue
 		}
 
-		events[i] = EventResponse{EventId: event.EventId, Event: ev}
+		events[i] = EventResponse{EventId: event.EventId, Event: ev.Show()}
+
+		if t, ok := ev.(eventer.Tailer); ok && event.Output {
+			events[i].Output = t.Tail(event.Offset)
+		}
 	}
 
 	return events, nil
@@ -88,6 +98,15 @@ func (k *Kloud) DelEventer(id string) {
 }
 
 func (k *Kloud) GetEvent(eventId string) (*eventer.Event, error) {
+	ev, err := k.getEventer(eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	return ev.Show(), nil
+}
+
+func (k *Kloud) getEventer(eventId string) (eventer.Eventer, error) {
 	// k.Log.Debug("[event] searching eventer for id: %s", eventId)
 	k.mu.RLock()
 	ev, ok := k.Eventers[eventId]
@@ -97,5 +116,5 @@ func (k *Kloud) GetEvent(eventId string) (*eventer.Event, error) {
 		return nil, NewError(ErrEventNotFound)
 	}
 
-	return ev.Show(), nil
+	return ev, nil
 }
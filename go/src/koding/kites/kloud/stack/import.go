@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"koding/api"
+	"koding/kites/kloud/credential"
 	"koding/remoteapi"
 	"koding/remoteapi/client"
 	stacktemplate "koding/remoteapi/client/j_stack_template"
@@ -26,6 +27,15 @@ type ImportRequest struct {
 	Provider    string              `json:"provider"`
 	Team        string              `json:"team"`
 	Title       string              `json:"title,omitempty"`
+
+	// Variables are injected into the template when
+	// the stack is planned and built, e.g. values
+	// for userInput_* variables.
+	//
+	// Values of userInput_* variables are stored as
+	// a userInput credential of the stack, so they are
+	// injected on every subsequent apply as well.
+	Variables map[string]string `json:"variables,omitempty"`
 }
 
 // Valid implements the Validator interface.
@@ -60,6 +70,10 @@ type ImportResponse struct {
 	EventID    string `json:"eventId"`
 }
 
+// userInput is a name of the provider, which credentials
+// hold values for userInput_* variables.
+const userInput = "userInput"
+
 var requiredData = map[string]interface{}{
 	"group": []interface{}{"slug"},
 	"user":  []interface{}{"username"},
@@ -110,6 +124,10 @@ func (k *Kloud) Import(r *kite.Request) (interface{}, error) {
 		return nil, NewError(ErrProviderNotFound)
 	}
 
+	if err := k.putVariables(r.Username, &req); err != nil {
+		return nil, errors.New("failure storing variables: " + err.Error())
+	}
+
 	teamReq := &TeamRequest{
 		Provider:   req.Provider,
 		GroupName:  req.Team,
@@ -137,6 +155,7 @@ func (k *Kloud) Import(r *kite.Request) (interface{}, error) {
 		Provider:        req.Provider,
 		StackTemplateID: sb.resp.TemplateID,
 		GroupName:       req.Team,
+		Variables:       req.Variables,
 	}
 
 	machines, err := k.doPlan(r, p, teamReq, planReq)
@@ -156,6 +175,7 @@ func (k *Kloud) Import(r *kite.Request) (interface{}, error) {
 		Provider:  req.Provider,
 		StackID:   sb.resp.StackID,
 		GroupName: req.Team,
+		Variables: req.Variables,
 	}
 
 	eventID, err := k.doApply(r, p, teamReq, applyReq)
@@ -168,6 +188,47 @@ func (k *Kloud) Import(r *kite.Request) (interface{}, error) {
 	return sb.resp, nil
 }
 
+// putVariables stores values of userInput_* variables as a new userInput
+// credential, which is then added to the request credentials.
+//
+// The values are kept encrypted like any other credential data and
+// are injected into the template whenever the stack is applied.
+//
+// If the request already has a userInput credential, the variables
+// are not stored.
+func (k *Kloud) putVariables(username string, req *ImportRequest) error {
+	if len(req.Credentials[userInput]) != 0 {
+		return nil
+	}
+
+	data := make(map[string]interface{})
+
+	for name, value := range req.Variables {
+		if strings.HasPrefix(name, userInput+"_") {
+			data[strings.TrimPrefix(name, userInput+"_")] = value
+		}
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	c := &credential.Cred{
+		Provider: userInput,
+		Title:    req.Title + " Variables",
+		Team:     req.Team,
+		Data:     data,
+	}
+
+	if err := k.CredClient.SetCred(username, c); err != nil {
+		return err
+	}
+
+	req.Credentials[userInput] = []string{c.Ident}
+
+	return nil
+}
+
 func (k *Kloud) doPlan(r *kite.Request, p Provider, teamReq *TeamRequest, req *PlanRequest) ([]*Machine, error) {
 	kiteReq := &kite.Request{
 		Method:   "plan",
@@ -50,6 +50,7 @@ type Stacker interface {
 	HandleAuthenticate(context.Context) (interface{}, error)
 	HandleBootstrap(context.Context) (interface{}, error)
 	HandlePlan(context.Context) (interface{}, error)
+	HandleReconcile(context.Context) (interface{}, error)
 }
 
 // Machiner is a copy of stackplan.Machine interface, duplicated here
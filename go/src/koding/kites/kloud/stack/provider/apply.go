@@ -11,6 +11,7 @@ import (
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/koding/kite/dnode"
 	uuid "github.com/satori/go.uuid"
 
 	"koding/db/models"
@@ -217,6 +218,8 @@ func (bs *BaseStack) destroyAsync(ctx context.Context, req *stack.ApplyRequest)
 		tfReq := &terraformer.TerraformRequest{
 			ContentID: req.GroupName + "-" + req.StackID,
 			TraceID:   bs.TraceID,
+			Provider:  bs.Provider.Name,
+			OnOutput:  bs.outputFunc(),
 		}
 
 		bs.Log.Debug("Calling terraform.destroy method with context: %+v", tfReq)
@@ -259,11 +262,6 @@ func (bs *BaseStack) applyAsync(ctx context.Context, req *stack.ApplyRequest) er
 		return err
 	}
 
-	cred, err := bs.Builder.CredentialByProvider(bs.Provider.Name)
-	if err != nil {
-		return err
-	}
-
 	bs.Log.Debug("Fetched terraform data: koding=%+v, template=%+v", bs.Builder.Koding, bs.Builder.Template)
 
 	opts := bs.Session.Terraformer
@@ -287,9 +285,18 @@ func (bs *BaseStack) applyAsync(ctx context.Context, req *stack.ApplyRequest) er
 		}
 	}
 
+	missing, err := bs.Builder.Template.MissingVariables("userInput")
+	if err != nil {
+		return err
+	}
+
+	if len(missing) != 0 {
+		return &MissingVariablesError{Names: missing}
+	}
+
 	bs.Log.Debug("Stack template before injecting Koding data: %s", bs.Builder.Template)
 
-	t, err := bs.stack.ApplyTemplate(cred)
+	t, err := bs.applyTemplates()
 	if err != nil {
 		return err
 	}
@@ -300,6 +307,16 @@ func (bs *BaseStack) applyAsync(ctx context.Context, req *stack.ApplyRequest) er
 
 	bs.Log.Debug("Stack template after injecting Koding data: %s", t)
 
+	bs.Eventer.Push(&eventer.Event{
+		Message:    "Checking team quota",
+		Percentage: 40,
+		Status:     machinestate.Building,
+	})
+
+	if err := bs.checkQuota(tfKite, t); err != nil {
+		return err
+	}
+
 	bs.Builder.Stack.Template = t.Content
 
 	done := make(chan struct{})
@@ -333,6 +350,8 @@ func (bs *BaseStack) applyAsync(ctx context.Context, req *stack.ApplyRequest) er
 		Content:   bs.Builder.Stack.Template,
 		ContentID: t.Key,
 		TraceID:   bs.TraceID,
+		Provider:  bs.Provider.Name,
+		OnOutput:  bs.outputFunc(),
 	}
 
 	bs.Log.Debug("Final stack template. Calling terraform.apply method:")
@@ -386,7 +405,8 @@ func (bs *BaseStack) UpdateResources(state *terraform.State) error {
 			continue
 		}
 
-		if machine.Provider != bs.Planner.Provider {
+		p, ok := bs.provider(machine.Provider)
+		if !ok {
 			continue
 		}
 
@@ -403,7 +423,13 @@ func (bs *BaseStack) UpdateResources(state *terraform.State) error {
 			continue
 		}
 
-		e := modelhelper.UpdateMachine(m.ObjectId, bson.M{"$set": bs.buildUpdateObj(machine, state, now)})
+		obj := bs.buildUpdateObj(p, machine, state, now)
+
+		if spec := bs.machineQuotaSpec(machine); spec != nil {
+			obj["meta.spec"] = spec
+		}
+
+		e := modelhelper.UpdateMachine(m.ObjectId, bson.M{"$set": obj})
 		if e != nil {
 			err = multierror.Append(err, fmt.Errorf("machine %q failed to update: %s", label, e))
 			continue
@@ -413,11 +439,11 @@ func (bs *BaseStack) UpdateResources(state *terraform.State) error {
 	return err
 }
 
-func (bs *BaseStack) buildUpdateObj(m *stack.Machine, s *DialState, now time.Time) bson.M {
-	obj := object.MetaBuilder.Build(bs.Provider.newMetadata(m))
+func (bs *BaseStack) buildUpdateObj(p *Provider, m *stack.Machine, s *DialState, now time.Time) bson.M {
+	obj := object.MetaBuilder.Build(p.newMetadata(m))
 
 	obj["credential"] = m.Credential.Identifier
-	obj["provider"] = bs.Provider.Name
+	obj["provider"] = p.Name
 	obj["queryString"] = m.QueryString
 	obj["status.modifiedAt"] = now
 	obj["status.state"] = m.State.String()
@@ -456,3 +482,26 @@ func (bs *BaseStack) BuildKiteKey(label, username string) (string, error) {
 
 	return kiteKey, nil
 }
+
+// outputFunc gives a callback for terraformer requests, which
+// appends terraform output to the eventer, so it can be tailed
+// with the event method.
+//
+// If the eventer does not keep output, the callback is
+// not valid and terraformer does not stream the output.
+func (bs *BaseStack) outputFunc() dnode.Function {
+	t, ok := bs.Eventer.(eventer.Tailer)
+	if !ok {
+		return dnode.Function{}
+	}
+
+	return dnode.Callback(func(r *dnode.Partial) {
+		line, err := r.One().String()
+		if err != nil {
+			bs.Log.Debug("invalid terraform output: %s", err)
+			return
+		}
+
+		t.Append(line)
+	})
+}
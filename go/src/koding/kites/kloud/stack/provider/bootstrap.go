@@ -92,6 +92,8 @@ func (bs *BaseStack) bootstrap(arg *stack.BootstrapRequest) (interface{}, error)
 					_, err := tfKite.Destroy(&terraformer.TerraformRequest{
 						ContentID: tmpl.Key,
 						TraceID:   bs.TraceID,
+						Provider:  bs.Provider.Name,
+						OnOutput:  bs.outputFunc(),
 					})
 					if err != nil {
 						return nil, err
@@ -119,6 +121,8 @@ func (bs *BaseStack) bootstrap(arg *stack.BootstrapRequest) (interface{}, error)
 					Content:   content,
 					ContentID: tmpl.Key,
 					TraceID:   bs.TraceID,
+					Provider:  bs.Provider.Name,
+					OnOutput:  bs.outputFunc(),
 				})
 				if err != nil {
 					return nil, err
@@ -513,6 +513,12 @@ func (b *Builder) BuildCredentials(method, username, groupname string, identifie
 		return models.ResError(err, "jCredentialData")
 	}
 
+	for _, cred := range creds {
+		if tb, ok := cred.Credential.(stack.TeamBinder); ok {
+			tb.BindTeam(groupname)
+		}
+	}
+
 	b.Credentials = append(b.Credentials, creds...)
 
 	for i, cred := range b.Credentials {
@@ -550,7 +556,12 @@ func (b *Builder) BuildTemplate(content, contentID string) error {
 		return err
 	}
 
-	if err := template.InjectCredentials(append(b.Credentials, b.Koding)...); err != nil {
+	creds, err := b.assumeCredentials()
+	if err != nil {
+		return err
+	}
+
+	if err := template.InjectCredentials(append(creds, b.Koding)...); err != nil {
 		return fmt.Errorf("error injecting variables: %s", err)
 	}
 
@@ -559,6 +570,29 @@ func (b *Builder) BuildTemplate(content, contentID string) error {
 	return nil
 }
 
+// assumeCredentials gives b.Credentials with short-lived credentials
+// in place of each credential that implements stack.Assumer.
+func (b *Builder) assumeCredentials() ([]*stack.Credential, error) {
+	creds := make([]*stack.Credential, 0, len(b.Credentials)+1)
+
+	for _, c := range b.Credentials {
+		if a, ok := c.Credential.(stack.Assumer); ok {
+			v, err := a.Assume()
+			if err != nil {
+				return nil, fmt.Errorf("unable to obtain credentials for %q: %s", c.Identifier, err)
+			}
+
+			assumed := *c
+			assumed.Credential = v
+			c = &assumed
+		}
+
+		creds = append(creds, c)
+	}
+
+	return creds, nil
+}
+
 // FetchCredentials fetches credential and bootstrap data from credential store.
 //
 // If no credentials are provided, the method is a nop.
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"koding/kites/kloud/pricing"
+	"koding/kites/kloud/stack"
+)
+
+// estimate sets estimated cost of each planned machine and
+// gives total cost of the stack.
+//
+// Estimation is best-effort - machines, which prices are
+// not known, are skipped.
+func (bs *BaseStack) estimate(machines stack.Machines) *stack.Cost {
+	var costs []*stack.Cost
+
+	for _, m := range machines {
+		cost, err := bs.estimateMachine(m)
+		if err != nil {
+			bs.Log.Debug("unable to estimate cost of %q machine: %s", m.Label, err)
+			continue
+		}
+
+		if cost != nil {
+			m.Cost = cost
+			costs = append(costs, cost)
+		}
+	}
+
+	total, err := pricing.Sum(costs...)
+	if err != nil {
+		bs.Log.Debug("unable to estimate cost of the stack: %s", err)
+		return nil
+	}
+
+	return total
+}
+
+func (bs *BaseStack) estimateMachine(m *stack.Machine) (*stack.Cost, error) {
+	table, spec, err := bs.machineSpec(m)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+
+	return table.Estimate(spec)
+}
+
+// machineSpec describes the planned machine using its provider's
+// PriceSpec func. It returns nil spec if the provider has either
+// no PriceSpec func or no price table registered.
+func (bs *BaseStack) machineSpec(m *stack.Machine) (*pricing.Table, *pricing.Spec, error) {
+	p, ok := bs.provider(m.Provider)
+	if !ok || p.PriceSpec == nil {
+		return nil, nil, nil
+	}
+
+	table, ok := pricing.Lookup(p.Name)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	if m.Credential == nil {
+		cred, err := bs.Builder.CredentialByProvider(p.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		m.Credential = cred
+	}
+
+	spec, err := p.PriceSpec(m, bs.Builder.Template)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return table, spec, nil
+}
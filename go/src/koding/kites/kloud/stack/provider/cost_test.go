@@ -0,0 +1,94 @@
+package provider_test
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+func TestBaseStack_Estimate(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/multi-aws-google.json")
+	if err != nil {
+		t.Fatalf("ReadFile()=%s", err)
+	}
+
+	creds := []*stack.Credential{cases["aws"].cred, cases["google"].cred}
+
+	creds[0].Provider = "aws"
+	creds[1].Provider = "google"
+
+	sized := strings.Replace(string(content), `"instance_type": "t2.nano",`,
+		`"instance_type": "t2.nano", "root_block_device": {"volume_size": 20},`, 1)
+	sized = strings.Replace(sized, `"image": "ubuntu-1404-lts"`,
+		`"image": "ubuntu-1404-lts", "size": 50`, 1)
+
+	cases := map[string]struct {
+		content string
+		aws     *stack.Cost
+		google  *stack.Cost
+		total   *stack.Cost
+	}{
+		"default disk sizes": {
+			string(content),
+			&stack.Cost{Hourly: 0.0069, Monthly: 5.03, Currency: "USD"},
+			&stack.Cost{Hourly: 0.0081, Monthly: 5.95, Currency: "USD"},
+			&stack.Cost{Hourly: 0.015, Monthly: 10.98, Currency: "USD"},
+		},
+		"template disk sizes": {
+			sized,
+			&stack.Cost{Hourly: 0.0085, Monthly: 6.23, Currency: "USD"},
+			&stack.Cost{Hourly: 0.0103, Monthly: 7.55, Currency: "USD"},
+			&stack.Cost{Hourly: 0.0188, Monthly: 13.78, Currency: "USD"},
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			bs := newBaseStack(t, cas.content, creds...)
+
+			if _, err := provider.ApplyTemplates(bs); err != nil {
+				t.Fatalf("ApplyTemplates()=%s", err)
+			}
+
+			machines := stack.Machines{
+				"aws-instance": {
+					Provider:   "aws",
+					Label:      "aws-instance",
+					Attributes: map[string]string{"instance_type": "t2.nano"},
+				},
+				"google-instance": {
+					Provider:   "google",
+					Label:      "google-instance",
+					Attributes: map[string]string{"machine_type": "f1-micro"},
+				},
+				"unknown-instance": {
+					Provider:   "aws",
+					Label:      "unknown-instance",
+					Attributes: map[string]string{"instance_type": "x1.unknown"},
+				},
+			}
+
+			total := provider.Estimate(bs, machines)
+
+			if !reflect.DeepEqual(total, cas.total) {
+				t.Errorf("got %+v, want %+v", total, cas.total)
+			}
+
+			if got := machines["aws-instance"].Cost; !reflect.DeepEqual(got, cas.aws) {
+				t.Errorf("got %+v, want %+v", got, cas.aws)
+			}
+
+			if got := machines["google-instance"].Cost; !reflect.DeepEqual(got, cas.google) {
+				t.Errorf("got %+v, want %+v", got, cas.google)
+			}
+
+			if got := machines["unknown-instance"].Cost; got != nil {
+				t.Errorf("want nil, got %+v", got)
+			}
+		})
+	}
+}
@@ -54,6 +54,10 @@ func TestDescribe(t *testing.T) {
 				Type:   "enum",
 				Label:  "Region",
 				Values: aws.Regions,
+			}, {
+				Name:  "role_arn",
+				Type:  "string",
+				Label: "Role ARN",
 			}},
 		},
 		"arbitrary Foo": {
@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"koding/db/models"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/utils/object"
+
+	"github.com/hashicorp/terraform/terraform"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Drift reasons.
+const (
+	DriftMissing = "missing" // resource no longer exists
+	DriftChanged = "changed" // resource metadata differs from jMachine.meta
+)
+
+// DetectDrift compares the given, refreshed Terraform state with
+// the stack machines and returns machine resources that either no
+// longer exist or which metadata differ from the stored one.
+//
+// Machines of unknown providers are ignored.
+func DetectDrift(state *terraform.State, machines []*models.Machine) []models.DriftedResource {
+	resources := stateResources(state)
+
+	var drifted []models.DriftedResource
+
+	for _, m := range machines {
+		p, ok := registered(m.Provider)
+		if !ok {
+			continue
+		}
+
+		name := p.Name + "_" + p.resourceName() + "." + m.Label
+
+		res := models.DriftedResource{
+			MachineID: m.ObjectId,
+			Label:     m.Label,
+			Provider:  p.Name,
+			Resource:  name,
+		}
+
+		attrs, ok := resources[name]
+		if !ok {
+			res.Reason = DriftMissing
+			drifted = append(drifted, res)
+			continue
+		}
+
+		if res.Changes = metaChanges(p, m, attrs); len(res.Changes) != 0 {
+			res.Reason = DriftChanged
+			drifted = append(drifted, res)
+		}
+	}
+
+	return drifted
+}
+
+// StateMeta builds jMachine.meta update for the machine,
+// read from the given Terraform state.
+//
+// If the machine resource does not exist in the state,
+// the function returns nil.
+func StateMeta(state *terraform.State, m *models.Machine) bson.M {
+	p, ok := registered(m.Provider)
+	if !ok {
+		return nil
+	}
+
+	attrs, ok := stateResources(state)[p.Name+"_"+p.resourceName()+"."+m.Label]
+	if !ok {
+		return nil
+	}
+
+	meta := bson.M{}
+
+	for key, value := range object.MetaBuilder.Build(p.newMetadata(stateMachine(p, m, attrs))) {
+		if !isZero(value) {
+			meta[key] = value
+		}
+	}
+
+	return meta
+}
+
+func registered(name string) (*Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	p, ok := providers[name]
+	return p, ok
+}
+
+// stateResources gives attributes of each managed resource
+// that exists in the state.
+func stateResources(state *terraform.State) map[string]map[string]string {
+	resources := make(map[string]map[string]string)
+
+	if state == nil {
+		return resources
+	}
+
+	for _, m := range state.Modules {
+		for name, r := range m.Resources {
+			if r.Primary == nil || r.Primary.ID == "" || strings.HasPrefix(name, "data.") {
+				continue
+			}
+
+			attrs := make(map[string]string, len(r.Primary.Attributes))
+			for key, val := range r.Primary.Attributes {
+				if !strings.ContainsAny(key, "#.") && val != "" {
+					attrs[key] = val
+				}
+			}
+
+			resources[name] = attrs
+		}
+	}
+
+	return resources
+}
+
+func stateMachine(p *Provider, m *models.Machine, attrs map[string]string) *stack.Machine {
+	return &stack.Machine{
+		Provider:   p.Name,
+		Label:      m.Label,
+		Attributes: attrs,
+		Credential: &stack.Credential{
+			Provider:   p.Name,
+			Identifier: m.Credential,
+		},
+	}
+}
+
+// metaChanges gives metadata fields, which values read from
+// the state differ from jMachine.meta ones.
+//
+// Fields, which are empty in either of them, are ignored,
+// as not every metadata field is read from a state.
+func metaChanges(p *Provider, m *models.Machine, attrs map[string]string) map[string]string {
+	changes := make(map[string]string)
+
+	for key, value := range object.MetaBuilder.Build(p.newMetadata(stateMachine(p, m, attrs))) {
+		path := strings.Split(strings.TrimPrefix(key, "meta."), ".")
+
+		old, ok := lookup(m.Meta, path)
+		if !ok || isZero(value) || isZero(old) {
+			continue
+		}
+
+		if fmt.Sprint(old) != fmt.Sprint(value) {
+			changes[strings.Join(path, ".")] = fmt.Sprintf("%v -> %v", old, value)
+		}
+	}
+
+	return changes
+}
+
+func lookup(m bson.M, path []string) (interface{}, bool) {
+	var v interface{} = m
+
+	for _, key := range path {
+		switch obj := v.(type) {
+		case bson.M:
+			v = obj[key]
+		case map[string]interface{}:
+			v = obj[key]
+		default:
+			return nil, false
+		}
+
+		if v == nil {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func isZero(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+
+	return reflect.DeepEqual(v, reflect.Zero(rv.Type()).Interface())
+}
@@ -0,0 +1,106 @@
+package provider_test
+
+import (
+	"reflect"
+	"testing"
+
+	"koding/db/models"
+	"koding/kites/kloud/stack/provider"
+
+	"github.com/hashicorp/terraform/terraform"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestDetectDrift(t *testing.T) {
+	state := &terraform.State{
+		Modules: []*terraform.ModuleState{{
+			Path: []string{"root"},
+			Resources: map[string]*terraform.ResourceState{
+				"aws_instance.unchanged": {
+					Type: "aws_instance",
+					Primary: &terraform.InstanceState{
+						ID: "i-1",
+						Attributes: map[string]string{
+							"id":                "i-1",
+							"availability_zone": "eu-central-1a",
+						},
+					},
+				},
+				"aws_instance.changed": {
+					Type: "aws_instance",
+					Primary: &terraform.InstanceState{
+						ID: "i-3",
+						Attributes: map[string]string{
+							"id":                "i-3",
+							"availability_zone": "eu-central-1b",
+						},
+					},
+				},
+			},
+		}},
+	}
+
+	machines := []*models.Machine{{
+		ObjectId: bson.NewObjectId(),
+		Provider: "aws",
+		Label:    "unchanged",
+		Meta: bson.M{
+			"instanceId":       "i-1",
+			"availabilityZone": "eu-central-1a",
+			"region":           "eu-central-1",
+		},
+	}, {
+		ObjectId: bson.NewObjectId(),
+		Provider: "aws",
+		Label:    "missing",
+		Meta: bson.M{
+			"instanceId": "i-2",
+		},
+	}, {
+		ObjectId: bson.NewObjectId(),
+		Provider: "aws",
+		Label:    "changed",
+		Meta: bson.M{
+			"instanceId":       "i-2",
+			"availabilityZone": "eu-central-1a",
+		},
+	}, {
+		ObjectId: bson.NewObjectId(),
+		Provider: "nonexisting",
+		Label:    "ignored",
+	}}
+
+	want := []models.DriftedResource{{
+		MachineID: machines[1].ObjectId,
+		Label:     "missing",
+		Provider:  "aws",
+		Resource:  "aws_instance.missing",
+		Reason:    provider.DriftMissing,
+	}, {
+		MachineID: machines[2].ObjectId,
+		Label:     "changed",
+		Provider:  "aws",
+		Resource:  "aws_instance.changed",
+		Reason:    provider.DriftChanged,
+		Changes: map[string]string{
+			"instanceId":       "i-2 -> i-3",
+			"availabilityZone": "eu-central-1a -> eu-central-1b",
+		},
+	}}
+
+	got := provider.DetectDrift(state, machines)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	meta := provider.StateMeta(state, machines[2])
+
+	if meta["meta.instanceId"] != "i-3" {
+		t.Fatalf("got %+v, want meta.instanceId=i-3", meta)
+	}
+
+	if meta := provider.StateMeta(state, machines[1]); meta != nil {
+		t.Fatalf("got %+v, want nil", meta)
+	}
+}
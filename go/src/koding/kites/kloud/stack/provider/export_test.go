@@ -1,6 +1,25 @@
 package provider
 
+import "koding/kites/kloud/stack"
+
 // Title exports title func for test purpose.
 func Title(s string) string {
 	return title(s)
 }
+
+// ApplyTemplates exports applyTemplates method for test purpose.
+func ApplyTemplates(bs *BaseStack) (*stack.Template, error) {
+	s, err := bs.Provider.Stack(bs)
+	if err != nil {
+		return nil, err
+	}
+
+	bs.stack = s
+
+	return bs.applyTemplates()
+}
+
+// Estimate exports estimate method for test purpose.
+func Estimate(bs *BaseStack, machines stack.Machines) *stack.Cost {
+	return bs.estimate(machines)
+}
@@ -0,0 +1,270 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+
+	"koding/kites/kloud/stack"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// ProviderError describes a failure of a single provider
+// within a multi-provider stack.
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+// Error implements the built-in error interface.
+func (pe *ProviderError) Error() string {
+	return pe.Provider + ": " + pe.Err.Error()
+}
+
+// TemplateProviders gives all registered providers, which are used
+// by the given template.
+//
+// A provider is considered to be used, if the template either has
+// its provider block or defines at least one of its machine resources.
+// The providers are sorted by name.
+func TemplateProviders(t *Template) []*Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	var used []*Provider
+
+	for name, p := range providers {
+		_, block := t.Provider[name]
+		_, machines := t.Resource[name+"_"+p.resourceName()]
+
+		if block || machines {
+			used = append(used, p)
+		}
+	}
+
+	sort.Sort(byName(used))
+
+	return used
+}
+
+type byName []*Provider
+
+func (p byName) Len() int           { return len(p) }
+func (p byName) Less(i, j int) bool { return p[i].Name < p[j].Name }
+func (p byName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// buildStacks creates a stack for each provider, other than bs.Provider,
+// that is used by the stack template.
+//
+// The stacks share the builder, the template and klient metadata
+// with bs, so each of them injects its provider-specific data
+// into the same template.
+func (bs *BaseStack) buildStacks() error {
+	var err error
+
+	bs.stacks = nil
+	bs.Planner.Resources = nil
+
+	for _, p := range TemplateProviders(bs.Builder.Template) {
+		if p.Name == bs.Provider.Name {
+			continue
+		}
+
+		sub := bs.newStack(p)
+
+		s, e := p.Stack(sub)
+		if e != nil {
+			err = multierror.Append(err, &ProviderError{Provider: p.Name, Err: e})
+			continue
+		}
+
+		sub.stack = s
+		bs.stacks = append(bs.stacks, sub)
+	}
+
+	if len(bs.stacks) != 0 {
+		bs.Planner.Resources = make(map[string]string, len(bs.stacks))
+
+		for _, s := range bs.stacks {
+			bs.Planner.Resources[s.Provider.Name] = s.Provider.resourceName()
+		}
+
+		if e := bs.checkLabels(); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+
+	return err
+}
+
+func (bs *BaseStack) newStack(p *Provider) *BaseStack {
+	sub := *bs
+
+	sub.Provider = p
+	sub.Log = bs.Log.New(p.Name)
+	sub.Planner = &Planner{
+		Provider:      p.Name,
+		ResourceType:  p.resourceName(),
+		Log:           sub.Log,
+		KlientTimeout: bs.Planner.KlientTimeout,
+		SessionFunc:   bs.Planner.SessionFunc,
+	}
+	sub.PlanFunc = nil
+	sub.StateFunc = nil
+	sub.SSHKeyPairFunc = nil
+	sub.stacks = nil
+	sub.stack = nil
+
+	return &sub
+}
+
+// checkLabels ensures machine labels are unique across all the providers,
+// as machines are identified by their labels only.
+func (bs *BaseStack) checkLabels() error {
+	var err error
+
+	owners := make(map[string]string)
+
+	for _, s := range bs.all() {
+		resource, ok := bs.Builder.Template.Resource[s.Provider.Name+"_"+s.Provider.resourceName()].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, label := range sortedKeys(resource) {
+			if owner, ok := owners[label]; ok {
+				err = multierror.Append(err, &ProviderError{
+					Provider: s.Provider.Name,
+					Err:      fmt.Errorf("machine label %q is already used by %s provider", label, owner),
+				})
+				continue
+			}
+
+			owners[label] = s.Provider.Name
+		}
+	}
+
+	return err
+}
+
+// all gives bs and all stacks of other providers.
+func (bs *BaseStack) all() []*BaseStack {
+	return append([]*BaseStack{bs}, bs.stacks...)
+}
+
+// provider gives a provider of the given name, if it is used by the stack.
+func (bs *BaseStack) provider(name string) (*Provider, bool) {
+	for _, s := range bs.all() {
+		if s.Provider.Name == name {
+			return s.Provider, true
+		}
+	}
+
+	return nil, false
+}
+
+// applyTemplates injects Koding data into the stack template by applying
+// credential of each provider used by the template.
+//
+// The returned template is a result of applying bs.Provider credential,
+// with the content containing changes made by all the providers.
+func (bs *BaseStack) applyTemplates() (*stack.Template, error) {
+	if err := bs.buildStacks(); err != nil {
+		return nil, err
+	}
+
+	if len(bs.stacks) == 0 {
+		return bs.applyTemplate()
+	}
+
+	var err error
+
+	t, e := bs.applyTemplate()
+	if e != nil {
+		err = multierror.Append(err, &ProviderError{Provider: bs.Provider.Name, Err: e})
+	}
+
+	for _, s := range bs.stacks {
+		st, e := s.applyTemplate()
+		if e != nil {
+			err = multierror.Append(err, &ProviderError{Provider: s.Provider.Name, Err: e})
+			continue
+		}
+
+		if t != nil {
+			t.Content = st.Content
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (bs *BaseStack) applyTemplate() (*stack.Template, error) {
+	cred, err := bs.Builder.CredentialByProvider(bs.Provider.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return bs.stack.ApplyTemplate(cred)
+}
+
+// planStacks merges machines planned with custom PlanFunc
+// of each provider into the given machines.
+func (bs *BaseStack) planStacks(machines stack.Machines) (stack.Machines, error) {
+	var err error
+
+	for _, s := range bs.all() {
+		if s.PlanFunc == nil {
+			continue
+		}
+
+		m, e := s.PlanFunc()
+		if e != nil {
+			err = multierror.Append(err, &ProviderError{Provider: s.Provider.Name, Err: e})
+			continue
+		}
+
+		for label, machine := range m {
+			machines[label] = machine
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return machines, nil
+}
+
+// stateStacks merges machines read with custom StateFunc
+// of each provider into the given machines.
+func (bs *BaseStack) stateStacks(state *terraform.State, machines map[string]*stack.Machine) (map[string]*stack.Machine, error) {
+	var err error
+
+	for _, s := range bs.all() {
+		if s.StateFunc == nil {
+			continue
+		}
+
+		m, e := s.StateFunc(state, bs.Klients)
+		if e != nil {
+			err = multierror.Append(err, &ProviderError{Provider: s.Provider.Name, Err: e})
+			continue
+		}
+
+		for label, machine := range m {
+			machines[label] = machine
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return machines, nil
+}
@@ -0,0 +1,159 @@
+package provider_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"koding/kites/kloud/contexthelper/publickeys"
+	"koding/kites/kloud/contexthelper/session"
+	"koding/kites/kloud/keycreator"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+	"koding/kites/kloud/stack/provider/providertest"
+	"koding/kites/kloud/userdata"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/koding/kite"
+	"github.com/koding/kite/testkeys"
+)
+
+func TestTemplateProviders(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/multi-aws-google.json")
+	if err != nil {
+		t.Fatalf("ReadFile()=%s", err)
+	}
+
+	template, err := provider.ParseTemplate(string(content), log)
+	if err != nil {
+		t.Fatalf("ParseTemplate()=%s", err)
+	}
+
+	var names []string
+	for _, p := range provider.TemplateProviders(template) {
+		names = append(names, p.Name)
+	}
+
+	if got := strings.Join(names, ","); got != "aws,google" {
+		t.Fatalf("got %q, want %q", got, "aws,google")
+	}
+}
+
+func TestBaseStack_ApplyTemplates(t *testing.T) {
+	flag.Parse()
+
+	const tmpl = "testdata/multi-aws-google.json"
+
+	content, err := ioutil.ReadFile(tmpl)
+	if err != nil {
+		t.Fatalf("ReadFile()=%s", err)
+	}
+
+	creds := []*stack.Credential{cases["aws"].cred, cases["google"].cred}
+
+	creds[0].Provider = "aws"
+	creds[1].Provider = "google"
+
+	t.Run("apply", func(t *testing.T) {
+		s, err := provider.ApplyTemplates(newBaseStack(t, string(content), creds...))
+		if err != nil {
+			t.Fatalf("ApplyTemplates()=%s", err)
+		}
+
+		golden := tmpl + ".golden"
+
+		if *update {
+			if err := providertest.Write(golden, s.Content, stripNondeterministicResources); err != nil {
+				t.Fatalf("Write()=%s", err)
+			}
+
+			return
+		}
+
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("ReadFile(%s)=%s", golden, err)
+		}
+
+		if err := providertest.Equal(s.Content, string(want), stripNondeterministicResources); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("missing credential", func(t *testing.T) {
+		_, err := provider.ApplyTemplates(newBaseStack(t, string(content), creds[0]))
+
+		if got := providerErrors(err); got != "google" {
+			t.Fatalf("got %q, want %q (err=%v)", got, "google", err)
+		}
+	})
+
+	t.Run("duplicated label", func(t *testing.T) {
+		dup := strings.Replace(string(content), "google-instance", "aws-instance", -1)
+
+		_, err := provider.ApplyTemplates(newBaseStack(t, dup, creds...))
+
+		if got := providerErrors(err); got != "google" {
+			t.Fatalf("got %q, want %q (err=%v)", got, "google", err)
+		}
+	})
+}
+
+func newBaseStack(t *testing.T, content string, creds ...*stack.Credential) *provider.BaseStack {
+	template, err := provider.ParseTemplate(content, log)
+	if err != nil {
+		t.Fatalf("ParseTemplate()=%s", err)
+	}
+
+	return &provider.BaseStack{
+		Provider: cases["aws"].provider,
+		Planner: &provider.Planner{
+			Provider:     "aws",
+			ResourceType: "instance",
+			Log:          log,
+		},
+		Session: &session.Session{
+			Userdata: &userdata.Userdata{
+				KlientURL: "http://127.0.0.1/klient.gz",
+				Keycreator: &keycreator.Key{
+					KontrolURL:        "http://127.0.0.1/kontrol/kite",
+					KontrolPublicKey:  testkeys.Public,
+					KontrolPrivateKey: testkeys.Private,
+				},
+			},
+		},
+		Builder: &provider.Builder{
+			Template:    template,
+			Credentials: creds,
+		},
+		Req: &kite.Request{
+			Username: "user",
+		},
+		KlientIDs: make(stack.KiteMap),
+		Log:       log,
+		Keys: &publickeys.Keys{
+			PublicKey:  testkeys.Public,
+			PrivateKey: testkeys.Private,
+			KeyName:    "koding",
+		},
+	}
+}
+
+// providerErrors gives comma-separated names of failed providers.
+func providerErrors(err error) string {
+	merr, ok := err.(*multierror.Error)
+	if !ok {
+		return ""
+	}
+
+	var names []string
+
+	for _, e := range merr.Errors {
+		if pe, ok := e.(*provider.ProviderError); ok {
+			names = append(names, pe.Provider)
+		}
+	}
+
+	return strings.Join(names, ",")
+}
@@ -57,12 +57,7 @@ func (bs *BaseStack) HandlePlan(ctx context.Context) (interface{}, error) {
 		return nil, err
 	}
 
-	cred, err := bs.Builder.CredentialByProvider(bs.Provider.Name)
-	if err != nil {
-		return nil, err
-	}
-
-	t, err := bs.stack.ApplyTemplate(cred)
+	t, err := bs.applyTemplates()
 	if err != nil {
 		return nil, err
 	}
@@ -91,8 +86,11 @@ func (bs *BaseStack) HandlePlan(ctx context.Context) (interface{}, error) {
 
 	bs.Log.Debug("Machines planned to be created: %+v", machines)
 
+	cost := bs.estimate(machines)
+
 	return &stack.PlanResponse{
 		Machines: machines.Slice(),
+		Cost:     cost,
 	}, nil
 }
 
@@ -114,6 +112,7 @@ func (bs *BaseStack) Plan() (stack.Machines, error) {
 		Content:   out,
 		ContentID: bs.Req.Username + "-" + bs.Arg.(*stack.PlanRequest).StackTemplateID,
 		TraceID:   bs.TraceID,
+		Provider:  bs.Provider.Name,
 	}
 
 	bs.Log.Debug("Calling plan with content: %+v", tfReq)
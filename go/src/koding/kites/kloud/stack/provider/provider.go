@@ -9,6 +9,7 @@ import (
 	"koding/kites/kloud/contexthelper/session"
 	"koding/kites/kloud/eventer"
 	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/pricing"
 	"koding/kites/kloud/stack"
 
 	"github.com/hashicorp/terraform/terraform"
@@ -38,6 +39,11 @@ func Register(p *Provider) {
 	}
 
 	providers[p.Name] = p
+
+	if p.Prices != nil {
+		pricing.Register(p.Prices)
+	}
+
 	providerDescs[p.Name] = &stack.Description{
 		Provider:   p.Name,
 		Credential: mustDescribe(schema(p.Name).newCredential()),
@@ -157,6 +163,20 @@ type Provider struct {
 	//
 	// If nil, DefaultSchema will be used instead.
 	Schema *Schema
+
+	// Prices is a default price table of the provider, it can be
+	// replaced by loading a table with pricing.LoadDir.
+	//
+	// If nil, no cost is estimated for provider's machines,
+	// unless a table is loaded.
+	Prices *pricing.Table
+
+	// PriceSpec describes the planned machine for cost estimation.
+	// The machine has its Credential set and the template has
+	// already Koding data injected.
+	//
+	// If nil, no cost is estimated for provider's machines.
+	PriceSpec func(*stack.Machine, *Template) (*pricing.Spec, error)
 }
 
 // DefaultSchema describes default schema used,
@@ -268,10 +288,20 @@ type BaseStack struct {
 	// is used, which is provided by (*Planner).MachinesFromState.
 	StateFunc func(*terraform.State, map[string]*DialState) (map[string]*stack.Machine, error)
 
-	stack Stack
+	stack  Stack
+	stacks []*BaseStack // stacks of other providers used by the template
 }
 
 func (bs *BaseStack) plan() (stack.Machines, error) {
+	if len(bs.stacks) != 0 {
+		machines, err := bs.Plan()
+		if err != nil {
+			return nil, err
+		}
+
+		return bs.planStacks(machines)
+	}
+
 	if bs.PlanFunc != nil {
 		return bs.PlanFunc()
 	}
@@ -280,6 +310,15 @@ func (bs *BaseStack) plan() (stack.Machines, error) {
 }
 
 func (bs *BaseStack) state(state *terraform.State) (map[string]*stack.Machine, error) {
+	if len(bs.stacks) != 0 {
+		machines, err := bs.Planner.MachinesFromState(state, bs.Klients, bs.Metas)
+		if err != nil {
+			return nil, err
+		}
+
+		return bs.stateStacks(state, machines)
+	}
+
 	if bs.StateFunc != nil {
 		return bs.StateFunc(state, bs.Klients)
 	}
@@ -0,0 +1,282 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"koding/db/models"
+	"koding/db/mongodb/modelhelper"
+	"koding/kites/kloud/pricing"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/terraformer"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// QuotaError is returned by apply when a stack exceeds
+// resource quota of a team.
+type QuotaError struct {
+	Team       string
+	Violations []string
+}
+
+// Error implements the built-in error interface.
+func (e *QuotaError) Error() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "stack exceeds quota of %q team:\n", e.Team)
+
+	for _, v := range e.Violations {
+		fmt.Fprintf(&buf, "  - %s\n", v)
+	}
+
+	buf.WriteString("please contact team admin to request a quota override")
+
+	return buf.String()
+}
+
+// QuotaMachine describes a machine, which is checked
+// against a team quota.
+type QuotaMachine struct {
+	Label string
+	Spec  *pricing.Spec  // nil if not known
+	Shape *pricing.Shape // nil if not known
+}
+
+// CheckQuota checks whether the planned machines together with
+// the machines the user already owns fit in the quota.
+//
+// Instance types and regions are checked for the planned machines
+// only. Resources of owned machines, which are not known, are
+// not counted.
+//
+// If the planned machine's spec or shape is not known and the
+// quota limits it, the machine is treated as a violation. This
+// is the case for machines of providers with no price table.
+func CheckQuota(team string, q *models.Quota, machines, owned []*QuotaMachine) error {
+	var violations []string
+
+	if n := len(owned) + len(machines); q.MaxMachines != 0 && n > q.MaxMachines {
+		violations = append(violations, fmt.Sprintf("number of machines would be %d, the limit is %d", n, q.MaxMachines))
+	}
+
+	var (
+		cpu    int
+		memory float64
+		disk   int
+	)
+
+	for _, m := range owned {
+		if m.Spec != nil {
+			disk += m.Spec.DiskSize
+		}
+
+		if m.Shape != nil {
+			cpu += m.Shape.CPU
+			memory += m.Shape.Memory
+		}
+	}
+
+	for _, m := range machines {
+		if m.Spec == nil {
+			if len(q.InstanceTypes) != 0 || len(q.Regions) != 0 || q.MaxDisk != 0 {
+				violations = append(violations, fmt.Sprintf("%q machine: unable to determine its instance type, region and disk size", m.Label))
+			}
+		} else {
+			if len(q.InstanceTypes) != 0 && !contains(q.InstanceTypes, m.Spec.InstanceType) {
+				violations = append(violations, fmt.Sprintf("%q machine: instance type %q is not allowed (allowed: %s)",
+					m.Label, m.Spec.InstanceType, strings.Join(q.InstanceTypes, ", ")))
+			}
+
+			if len(q.Regions) != 0 && !contains(q.Regions, m.Spec.Region) {
+				violations = append(violations, fmt.Sprintf("%q machine: region %q is not allowed (allowed: %s)",
+					m.Label, m.Spec.Region, strings.Join(q.Regions, ", ")))
+			}
+
+			disk += m.Spec.DiskSize
+		}
+
+		if m.Shape == nil {
+			if q.MaxCPU != 0 || q.MaxMemory != 0 {
+				violations = append(violations, fmt.Sprintf("%q machine: unable to determine its vCPU and memory", m.Label))
+			}
+		} else {
+			cpu += m.Shape.CPU
+			memory += m.Shape.Memory
+		}
+	}
+
+	if q.MaxCPU != 0 && cpu > q.MaxCPU {
+		violations = append(violations, fmt.Sprintf("total vCPUs would be %d, the limit is %d", cpu, q.MaxCPU))
+	}
+
+	if q.MaxMemory != 0 && memory > q.MaxMemory {
+		violations = append(violations, fmt.Sprintf("total memory would be %gGB, the limit is %gGB", memory, q.MaxMemory))
+	}
+
+	if q.MaxDisk != 0 && disk > q.MaxDisk {
+		violations = append(violations, fmt.Sprintf("total disk size would be %dGB, the limit is %dGB", disk, q.MaxDisk))
+	}
+
+	if len(violations) != 0 {
+		return &QuotaError{
+			Team:       team,
+			Violations: violations,
+		}
+	}
+
+	return nil
+}
+
+// checkQuota plans the given template and checks the planned
+// machines together with machines the user already owns
+// against the team quota.
+//
+// Users with a quota override and teams with no quota are
+// not checked.
+func (bs *BaseStack) checkQuota(tfKite *terraformer.Terraformer, t *stack.Template) error {
+	team := bs.Builder.Team
+
+	q, err := modelhelper.GetGroupQuota(team.Slug)
+	if err != nil {
+		return err
+	}
+
+	if q == nil || q.IsOverridden(bs.Req.Username) {
+		return nil
+	}
+
+	tfReq := &terraformer.TerraformRequest{
+		Content:   t.Content,
+		ContentID: t.Key,
+		TraceID:   bs.TraceID,
+		Provider:  bs.Provider.Name,
+	}
+
+	bs.Log.Debug("Calling plan to check %q team quota: %+v", team.Slug, tfReq)
+
+	plan, err := tfKite.Plan(tfReq)
+	if err != nil {
+		return err
+	}
+
+	planned, err := bs.Planner.MachinesFromPlan(plan)
+	if err != nil {
+		return err
+	}
+
+	// Machines of the stack being applied are already counted
+	// by the plan.
+	exclude := make([]bson.ObjectId, 0, len(bs.Builder.Machines))
+	for _, m := range bs.Builder.Machines {
+		exclude = append(exclude, m.ObjectId)
+	}
+
+	ownedMachines, err := modelhelper.GetOwnGroupMachinesExcept(bs.Req.Username, team, exclude...)
+	if err != nil {
+		return err
+	}
+
+	owned := make([]*QuotaMachine, 0, len(ownedMachines))
+
+	for _, m := range ownedMachines {
+		owned = append(owned, ownedQuotaMachine(m))
+	}
+
+	machines := make([]*QuotaMachine, 0, len(planned))
+
+	for _, m := range planned.Slice() {
+		qm := &QuotaMachine{
+			Label: m.Label,
+		}
+
+		table, spec, err := bs.machineSpec(m)
+		switch {
+		case err != nil:
+			bs.Log.Debug("unable to describe %q machine: %s", m.Label, err)
+		case table == nil:
+			bs.Log.Debug("%q provider has no price table, resources of %q machine are not known", m.Provider, m.Label)
+		default:
+			qm.Spec = spec
+			qm.Shape = table.Shapes[spec.InstanceType]
+		}
+
+		machines = append(machines, qm)
+	}
+
+	return CheckQuota(team.Slug, q, machines, owned)
+}
+
+// machineQuotaSpec describes resources of the applied machine,
+// which are stored with the machine to be counted against
+// a quota later on.
+//
+// It returns nil if the resources are not known.
+func (bs *BaseStack) machineQuotaSpec(m *stack.Machine) *models.MachineSpec {
+	table, spec, err := bs.machineSpec(m)
+	if err != nil {
+		bs.Log.Debug("unable to describe %q machine: %s", m.Label, err)
+	}
+
+	if spec == nil {
+		return nil
+	}
+
+	ms := &models.MachineSpec{
+		InstanceType: spec.InstanceType,
+		Region:       spec.Region,
+		DiskSize:     spec.DiskSize,
+	}
+
+	if shape, ok := table.Shapes[spec.InstanceType]; ok {
+		ms.CPU = shape.CPU
+		ms.Memory = shape.Memory
+	}
+
+	return ms
+}
+
+// ownedQuotaMachine builds a quota machine from the spec
+// stored under jMachine.meta.spec.
+func ownedQuotaMachine(m *models.Machine) *QuotaMachine {
+	qm := &QuotaMachine{
+		Label: m.Label,
+	}
+
+	v, ok := m.Meta["spec"]
+	if !ok {
+		return qm
+	}
+
+	var ms models.MachineSpec
+
+	if p, err := bson.Marshal(v); err != nil || bson.Unmarshal(p, &ms) != nil {
+		return qm
+	}
+
+	qm.Spec = &pricing.Spec{
+		Region:       ms.Region,
+		InstanceType: ms.InstanceType,
+		DiskSize:     ms.DiskSize,
+	}
+
+	if ms.CPU != 0 || ms.Memory != 0 {
+		qm.Shape = &pricing.Shape{
+			CPU:    ms.CPU,
+			Memory: ms.Memory,
+		}
+	}
+
+	return qm
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,139 @@
+package provider_test
+
+import (
+	"reflect"
+	"testing"
+
+	"koding/db/models"
+	"koding/kites/kloud/pricing"
+	"koding/kites/kloud/stack/provider"
+)
+
+func TestCheckQuota(t *testing.T) {
+	nano := &provider.QuotaMachine{
+		Label: "nano",
+		Spec:  &pricing.Spec{Region: "us-east-1", InstanceType: "t2.nano", DiskSize: 8},
+		Shape: &pricing.Shape{CPU: 1, Memory: 0.5},
+	}
+
+	large := &provider.QuotaMachine{
+		Label: "large",
+		Spec:  &pricing.Spec{Region: "eu-west-1", InstanceType: "m4.large", DiskSize: 100},
+		Shape: &pricing.Shape{CPU: 2, Memory: 8},
+	}
+
+	unknown := &provider.QuotaMachine{
+		Label: "unknown",
+	}
+
+	owned := func(n int) []*provider.QuotaMachine {
+		machines := make([]*provider.QuotaMachine, n)
+		for i := range machines {
+			machines[i] = unknown
+		}
+		return machines
+	}
+
+	cases := map[string]struct {
+		quota      *models.Quota
+		machines   []*provider.QuotaMachine
+		owned      []*provider.QuotaMachine
+		violations []string
+	}{
+		"empty quota": {
+			&models.Quota{},
+			[]*provider.QuotaMachine{nano, large, unknown},
+			owned(10),
+			nil,
+		},
+		"within limits": {
+			&models.Quota{
+				MaxMachines:   3,
+				MaxCPU:        3,
+				MaxMemory:     8.5,
+				MaxDisk:       108,
+				InstanceTypes: []string{"t2.nano", "m4.large"},
+				Regions:       []string{"us-east-1", "eu-west-1"},
+			},
+			[]*provider.QuotaMachine{nano, large},
+			owned(1),
+			nil,
+		},
+		"max machines": {
+			&models.Quota{MaxMachines: 2},
+			[]*provider.QuotaMachine{nano, unknown},
+			owned(1),
+			[]string{"number of machines would be 3, the limit is 2"},
+		},
+		"resources": {
+			&models.Quota{MaxCPU: 2, MaxMemory: 4, MaxDisk: 50},
+			[]*provider.QuotaMachine{nano, large},
+			nil,
+			[]string{
+				"total vCPUs would be 3, the limit is 2",
+				"total memory would be 8.5GB, the limit is 4GB",
+				"total disk size would be 108GB, the limit is 50GB",
+			},
+		},
+		"instance types and regions": {
+			&models.Quota{InstanceTypes: []string{"t2.nano", "t2.micro"}, Regions: []string{"us-east-1"}},
+			[]*provider.QuotaMachine{nano, large},
+			nil,
+			[]string{
+				`"large" machine: instance type "m4.large" is not allowed (allowed: t2.nano, t2.micro)`,
+				`"large" machine: region "eu-west-1" is not allowed (allowed: us-east-1)`,
+			},
+		},
+		"unknown machine": {
+			&models.Quota{MaxCPU: 4, Regions: []string{"us-east-1"}},
+			[]*provider.QuotaMachine{unknown},
+			nil,
+			[]string{
+				`"unknown" machine: unable to determine its instance type, region and disk size`,
+				`"unknown" machine: unable to determine its vCPU and memory`,
+			},
+		},
+		"owned resources": {
+			&models.Quota{MaxCPU: 3, MaxMemory: 8, MaxDisk: 120},
+			[]*provider.QuotaMachine{large},
+			[]*provider.QuotaMachine{nano, unknown},
+			[]string{
+				"total memory would be 8.5GB, the limit is 8GB",
+			},
+		},
+		"machine with no price table": {
+			&models.Quota{MaxMachines: 2, InstanceTypes: []string{"t2.nano"}},
+			[]*provider.QuotaMachine{unknown},
+			[]*provider.QuotaMachine{nano},
+			[]string{
+				`"unknown" machine: unable to determine its instance type, region and disk size`,
+			},
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := provider.CheckQuota("koding", cas.quota, cas.machines, cas.owned)
+
+			if cas.violations == nil {
+				if err != nil {
+					t.Fatalf("CheckQuota()=%s", err)
+				}
+				return
+			}
+
+			e, ok := err.(*provider.QuotaError)
+			if !ok {
+				t.Fatalf("got %T, want *provider.QuotaError", err)
+			}
+
+			if e.Team != "koding" {
+				t.Fatalf("got %q, want %q", e.Team, "koding")
+			}
+
+			if !reflect.DeepEqual(e.Violations, cas.violations) {
+				t.Fatalf("got %#v, want %#v", e.Violations, cas.violations)
+			}
+		})
+	}
+}
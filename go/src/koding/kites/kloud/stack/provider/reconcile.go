@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+
+	"koding/db/models"
+	"koding/db/mongodb/modelhelper"
+	"koding/kites/kloud/machinestate"
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/terraformer"
+
+	"golang.org/x/net/context"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// HandleReconcile refreshes state of the stack with the actual
+// cloud resources and updates drifted jMachine documents:
+//
+//   - machines which resources no longer exist are
+//     marked as NotInitialized, so they can be rebuilt
+//     with apply
+//   - machines which metadata changed have their
+//     jMachine.meta updated
+func (bs *BaseStack) HandleReconcile(ctx context.Context) (interface{}, error) {
+	arg, ok := ctx.Value(stack.ReconcileRequestKey).(*stack.ReconcileRequest)
+	if !ok {
+		arg = &stack.ReconcileRequest{}
+
+		if err := bs.Req.Args.One().Unmarshal(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := arg.Valid(); err != nil {
+		return nil, err
+	}
+
+	bs.Arg = arg
+
+	// Refresh uses the template stored by terraformer,
+	// thus missing jStackTemplate is not fatal.
+	err := bs.Builder.BuildStack(arg.StackID, nil)
+
+	if err != nil && !models.IsNotFound(err, "jStackTemplate") {
+		return nil, err
+	}
+
+	if state := bs.Builder.Stack.Stack.State(); state.InProgress() {
+		return nil, fmt.Errorf("State is currently %s. Please try again later", state)
+	}
+
+	if err := bs.Builder.BuildMachines(ctx); err != nil {
+		return nil, err
+	}
+
+	opts := bs.Session.Terraformer
+
+	tfKite, err := terraformer.Connect(opts.Endpoint, opts.SecretKey, opts.Kite)
+	if err != nil {
+		return nil, err
+	}
+	defer tfKite.Close()
+
+	tfReq := &terraformer.TerraformRequest{
+		ContentID: arg.GroupName + "-" + arg.StackID,
+		TraceID:   bs.TraceID,
+		Provider:  bs.Provider.Name,
+	}
+
+	bs.Log.Debug("Calling terraform.refresh method with context: %+v", tfReq)
+
+	state, err := tfKite.Refresh(tfReq)
+	if err != nil {
+		return nil, err
+	}
+
+	machines := make([]*models.Machine, 0, len(bs.Builder.Machines))
+	for _, m := range bs.Builder.Machines {
+		machines = append(machines, m)
+	}
+
+	drifted := DetectDrift(state, machines)
+	now := time.Now().UTC()
+
+	for _, res := range drifted {
+		change := bson.M{
+			"status.modifiedAt": now,
+		}
+
+		switch res.Reason {
+		case DriftMissing:
+			change["status.state"] = machinestate.NotInitialized.String()
+			change["status.reason"] = "Machine resource is missing, apply the stack to rebuild it"
+		case DriftChanged:
+			for k, v := range StateMeta(state, bs.Builder.Machines[res.Label]) {
+				change[k] = v
+			}
+		}
+
+		bs.Log.Debug("reconciling %q machine (%s): %+v", res.Label, res.Reason, change)
+
+		if err := modelhelper.UpdateMachine(res.MachineID, bson.M{"$set": change}); err != nil {
+			return nil, fmt.Errorf("machine %q failed to update: %s", res.Label, err)
+		}
+	}
+
+	if err := modelhelper.SetStackDrift(bs.Builder.Stack.ID, &models.StackDrift{CheckedAt: now}); err != nil {
+		return nil, err
+	}
+
+	return &stack.ReconcileResponse{
+		StackID:   arg.StackID,
+		Resources: drifted,
+	}, nil
+}
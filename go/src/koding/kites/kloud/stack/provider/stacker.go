@@ -273,7 +273,20 @@ func (s *Stacker) FetchCredData(bm *BaseMachine) error {
 		credentials[bm.Machine.Credential] = object.ToAddr(bm.Credential)
 	}
 
-	return s.CredStore.Fetch(bm.Username(), credentials)
+	if err := s.CredStore.Fetch(bm.Username(), credentials); err != nil {
+		return err
+	}
+
+	if tb, ok := bm.Credential.(stack.TeamBinder); ok && len(bm.Machine.Groups) != 0 {
+		group, err := modelhelper.GetGroupById(bm.Machine.Groups[0].Id.Hex())
+		if err != nil {
+			return models.ResError(err, "jGroup")
+		}
+
+		tb.BindTeam(group.Slug)
+	}
+
+	return nil
 }
 
 func (s *Stacker) ValidateUser(user *models.User, users []models.MachineUser, r *kite.Request) error {
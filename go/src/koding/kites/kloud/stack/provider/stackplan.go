@@ -81,6 +81,11 @@ type Planner struct {
 	Provider     string // Terraform provider name
 	ResourceType string // Terraform resource type
 
+	// Resources, when non-nil, maps names of other Terraform providers
+	// to their resource types. It is used by multi-provider stacks,
+	// to build machines of every provider used by a template.
+	Resources map[string]string
+
 	Log logging.Logger // used for logging
 
 	KlientTimeout time.Duration // when zero-value, DefaultKlientTimeout is used
@@ -98,7 +103,7 @@ type Planner struct {
 // MachinesFromState builds a list of machines from Terraform state value.
 //
 // It ignores any other resources than those specified by p.ResourceType
-// and p.Provider.
+// and p.Provider, or by p.Resources.
 func (p *Planner) MachinesFromState(state *terraform.State, klients map[string]*DialState,
 	metas map[string]map[string]interface{}) (map[string]*stack.Machine, error) {
 	if len(state.Modules) == 0 {
@@ -120,7 +125,7 @@ func (p *Planner) MachinesFromState(state *terraform.State, klients map[string]*
 				return nil, err
 			}
 
-			if resourceType != p.ResourceType || provider != p.Provider {
+			if !p.isMachine(provider, resourceType) {
 				continue
 			}
 
@@ -133,11 +138,11 @@ func (p *Planner) MachinesFromState(state *terraform.State, klients map[string]*
 
 			state, ok := klients[label]
 			if !ok {
-				return nil, fmt.Errorf("no klient state found for %q %s", label, p.ResourceType)
+				return nil, fmt.Errorf("no klient state found for %q %s", label, resourceType)
 			}
 
 			machine := &stack.Machine{
-				Provider:    p.Provider,
+				Provider:    provider,
 				Label:       label,
 				Attributes:  attrs,
 				QueryString: state.KiteID,
@@ -165,7 +170,7 @@ func (p *Planner) MachinesFromState(state *terraform.State, klients map[string]*
 // MachinesFromPlan builds a list of machines from Terraform plan result.
 //
 // It ignores any other resources than those specified by p.ResourceType
-// and p.Provider.
+// and p.Provider, or by p.Resources.
 func (p *Planner) MachinesFromPlan(plan *terraform.Plan) (stack.Machines, error) {
 	if plan.Diff == nil {
 		return nil, errors.New("plan diff is empty")
@@ -192,7 +197,7 @@ func (p *Planner) MachinesFromPlan(plan *terraform.Plan) (stack.Machines, error)
 				return nil, err
 			}
 
-			if resourceType != p.ResourceType || provider != p.Provider {
+			if !p.isMachine(provider, resourceType) {
 				continue
 			}
 
@@ -214,6 +219,15 @@ func (p *Planner) MachinesFromPlan(plan *terraform.Plan) (stack.Machines, error)
 	return machines, nil
 }
 
+func (p *Planner) isMachine(provider, resourceType string) bool {
+	if provider == p.Provider && resourceType == p.ResourceType {
+		return true
+	}
+
+	typ, ok := p.Resources[provider]
+	return ok && typ == resourceType
+}
+
 func (p *Planner) checkSingleKlient(k *kite.Kite, label, kiteID string) *DialState {
 	kiteID, err := utils.QueryString(kiteID)
 	if err != nil {
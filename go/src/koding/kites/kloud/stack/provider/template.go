@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"sort"
 	"strings"
 
 	"koding/kites/kloud/stack"
@@ -226,6 +227,39 @@ func (t *Template) FillVariables(prefix string) error {
 	return t.InjectVariables("", vars)
 }
 
+// MissingVariablesError is returned when a template uses variables,
+// which values were not provided.
+type MissingVariablesError struct {
+	Names []string
+}
+
+// Error implements the builtin error interface.
+func (e *MissingVariablesError) Error() string {
+	return "missing values for the following variables: " + strings.Join(e.Names, ", ")
+}
+
+// MissingVariables gives a sorted list of variables with the given prefix,
+// which are used within the template, but their value was
+// not injected nor declared.
+func (t *Template) MissingVariables(prefix string) ([]string, error) {
+	vars, err := t.DetectUserVariables(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+
+	for name := range vars {
+		if _, ok := t.Variable[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
 func (t *Template) InjectVariables(prefix string, meta interface{}) error {
 	t.inject(prefix, meta)
 
@@ -353,6 +353,47 @@ func TestTerraformTemplate_FillVariables(t *testing.T) {
 	equals(t, "", variable.UserInputFoo.Default)
 }
 
+func TestTerraformTemplate_MissingVariables(t *testing.T) {
+	userTestTemplate := `{
+    "variable": {
+        "userInput_declared": {
+            "default": "foo"
+        }
+    },
+    "resource": {
+        "aws_instance": {
+            "example": {
+                "count": "${var.userInput_count}",
+                "ami": "${var.userInput_declared}",
+                "user_data": "sudo apt-get install ${var.userInput_foo} ${var.userInput_bar} -y"
+            }
+        }
+    }
+}`
+	template, err := provider.ParseTemplate(userTestTemplate, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missing, err := template.MissingVariables("userInput")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equals(t, []string{"userInput_bar", "userInput_count", "userInput_foo"}, missing)
+
+	if err := template.InjectVariables("", map[string]string{"userInput_count": "2", "userInput_foo": "git"}); err != nil {
+		t.Fatal(err)
+	}
+
+	missing, err = template.MissingVariables("userInput")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equals(t, []string{"userInput_bar"}, missing)
+}
+
 func TestTerraformTemplate_SetAWSRegion(t *testing.T) {
 	missingRegionTemplate := `{
     "variable": {
@@ -0,0 +1,298 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"koding/kites/kloud/stack"
+)
+
+// TemplateError describes a single problem found in a stack template
+// during validation.
+type TemplateError struct {
+	// Path is a path to the offending value within the template,
+	// e.g. []string{"resource", "aws_instance", "example", "user_data"}.
+	Path []string
+
+	// Token is an optional text fragment, that was recognized
+	// as invalid, e.g. an interpolation "${var.koding_foo}".
+	Token string
+
+	// Warning is true when the problem does not make
+	// the template invalid, e.g. a user variable which
+	// is expected to be provided when building a stack.
+	Warning bool
+
+	// Err describes the problem.
+	Err error
+}
+
+// Error implements the builtin error interface.
+func (te *TemplateError) Error() string {
+	if len(te.Path) == 0 {
+		return te.Err.Error()
+	}
+
+	return strings.Join(te.Path, ".") + ": " + te.Err.Error()
+}
+
+// TemplateErrors represents a list of problems found in a template.
+type TemplateErrors []*TemplateError
+
+// Error implements the builtin error interface.
+func (te TemplateErrors) Error() string {
+	var s []string
+
+	for _, e := range te {
+		s = append(s, e.Error())
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// Err gives non-nil error if te contains at least
+// one problem, which is not a warning.
+func (te TemplateErrors) Err() error {
+	for _, e := range te {
+		if !e.Warning {
+			return te
+		}
+	}
+
+	return nil
+}
+
+// Validator checks stack templates without talking to any
+// external services.
+type Validator struct {
+	// Descs are used to validate provider blocks and
+	// provider-prefixed variables.
+	//
+	// If nil, descriptions of registered providers are used.
+	Descs stack.Descriptions
+
+	// UserPrefix is a prefix of variables, which are
+	// provided by user when a stack is created.
+	//
+	// If empty, "userInput" is used.
+	UserPrefix string
+}
+
+// Validate validates the given template.
+//
+// All problems found are returned, not only the first one.
+// In order to tell whether the template is valid use
+// the Err method of the returned value.
+func (v *Validator) Validate(t *Template) TemplateErrors {
+	var errs TemplateErrors
+
+	descs := v.Descs
+	if descs == nil {
+		descs = Desc()
+	}
+
+	if len(t.Resource) == 0 {
+		errs = append(errs, &TemplateError{
+			Path: []string{"resource"},
+			Err:  fmt.Errorf("no resources found"),
+		})
+	}
+
+	for _, name := range sortedKeys(t.Provider) {
+		if len(descs) == 0 {
+			break
+		}
+
+		if _, ok := descs[name]; !ok {
+			errs = append(errs, &TemplateError{
+				Path: []string{"provider", name},
+				Err:  fmt.Errorf("unsupported provider %q", name),
+			})
+		}
+	}
+
+	for _, typ := range sortedKeys(t.Resource) {
+		res, ok := t.Resource[typ].(map[string]interface{})
+		if !ok {
+			errs = append(errs, &TemplateError{
+				Path: []string{"resource", typ},
+				Err:  fmt.Errorf("resource type %q is expected to be an object", typ),
+			})
+			continue
+		}
+
+		for _, name := range sortedKeys(res) {
+			if _, ok := res[name].(map[string]interface{}); !ok {
+				errs = append(errs, &TemplateError{
+					Path: []string{"resource", typ, name},
+					Err:  fmt.Errorf("resource %q is expected to be an object", typ+"."+name),
+				})
+			}
+		}
+
+		if i := strings.IndexRune(typ, '_'); i != -1 {
+			name := typ[:i]
+
+			if _, ok := descs[name]; ok && t.Provider[name] == nil {
+				errs = append(errs, &TemplateError{
+					Path: []string{"resource", typ},
+					Err:  fmt.Errorf("resource uses %q provider, which is not declared in the provider block", name),
+				})
+			}
+		}
+	}
+
+	return append(errs, v.validateVariables(t, descs)...)
+}
+
+func (v *Validator) validateVariables(t *Template, descs stack.Descriptions) TemplateErrors {
+	var errs TemplateErrors
+
+	userPrefix := v.userPrefix()
+	kodingVars := make(map[string]struct{})
+
+	for _, value := range mustDescribe(&KodingMeta{}) {
+		kodingVars["koding_"+value.Name] = struct{}{}
+	}
+
+	check := make(map[string]error)
+
+	if vars, err := t.DetectUserVariables("koding"); err == nil {
+		for name := range vars {
+			if _, ok := kodingVars[name]; !ok {
+				check[name] = fmt.Errorf("undeclared koding variable %q", name)
+			}
+		}
+	}
+
+	if vars, err := t.DetectUserVariables(userPrefix); err == nil {
+		for name := range vars {
+			if _, ok := t.Variable[name]; !ok {
+				check[name] = &userVariableError{name: name}
+			}
+		}
+	}
+
+	for _, provider := range sortedKeys(t.Provider) {
+		desc, ok := descs[provider]
+		if !ok {
+			continue
+		}
+
+		vars, err := t.DetectUserVariables(provider)
+		if err != nil {
+			continue
+		}
+
+		known := make(map[string]struct{})
+
+		for _, value := range desc.Credential {
+			known[provider+"_"+value.Name] = struct{}{}
+		}
+
+		for _, value := range desc.Bootstrap {
+			known[provider+"_"+value.Name] = struct{}{}
+		}
+
+		for name := range vars {
+			if _, ok := t.Variable[name]; ok {
+				continue
+			}
+
+			if _, ok := known[name]; !ok {
+				check[name] = fmt.Errorf("undeclared %s variable %q", provider, name)
+			}
+		}
+	}
+
+	if len(check) == 0 {
+		return nil
+	}
+
+	blocks := []struct {
+		name string
+		v    map[string]interface{}
+	}{
+		{"provider", t.Provider},
+		{"resource", t.Resource},
+		{"output", t.Output},
+	}
+
+	for _, block := range blocks {
+		walkStrings([]string{block.name}, block.v, func(path []string, s string) {
+			for _, variable := range ReadVariables(s) {
+				err, ok := check[variable.Name]
+				if !ok {
+					continue
+				}
+
+				_, warn := err.(*userVariableError)
+
+				errs = append(errs, &TemplateError{
+					Path:    path,
+					Token:   s[variable.From:variable.To],
+					Warning: warn,
+					Err:     err,
+				})
+			}
+		})
+	}
+
+	return errs
+}
+
+func (v *Validator) userPrefix() string {
+	if v.UserPrefix != "" {
+		return v.UserPrefix
+	}
+
+	return "userInput"
+}
+
+type userVariableError struct {
+	name string
+}
+
+func (e *userVariableError) Error() string {
+	return fmt.Sprintf("user variable %q is not declared and must be provided when creating a stack", e.name)
+}
+
+// ValidateTemplate parses the given content and validates it using
+// the default validator.
+func ValidateTemplate(content string) TemplateErrors {
+	t, err := ParseTemplate(content, defaultLog)
+	if err != nil {
+		return TemplateErrors{{Err: err}}
+	}
+
+	return (&Validator{}).Validate(t)
+}
+
+func walkStrings(path []string, v interface{}, fn func([]string, string)) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			walkStrings(append(path[:len(path):len(path)], key), v[key], fn)
+		}
+	case []interface{}:
+		for i, v := range v {
+			walkStrings(append(path[:len(path):len(path)], strconv.Itoa(i)), v, fn)
+		}
+	case string:
+		fn(path, v)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,114 @@
+package provider_test
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+)
+
+const validateTemplate = `{
+    "provider": {
+        "aws": {
+            "access_key": "${var.aws_access_key}",
+            "secret_key": "${var.aws_secret_key}",
+            "region": "${var.aws_regio}"
+        },
+        "unknown": {}
+    },
+    "variable": {
+        "userInput_declared": {
+            "default": "foo"
+        }
+    },
+    "resource": {
+        "aws_instance": {
+            "example": {
+                "instance_type": "${var.userInput_type}",
+                "ami": "${var.userInput_declared}",
+                "tags": {
+                    "Name": "${var.koding_user_username}-${var.koding_group_name}"
+                }
+            }
+        },
+        "google_compute_instance": {
+            "example": {}
+        }
+    }
+}`
+
+func TestValidator(t *testing.T) {
+	descs := stack.Descriptions{
+		"aws": {
+			Credential: []stack.Value{{Name: "access_key"}, {Name: "secret_key"}, {Name: "region"}},
+			Bootstrap:  []stack.Value{{Name: "acl"}},
+		},
+		"google": {
+			Credential: []stack.Value{{Name: "credentials"}},
+		},
+	}
+
+	tmpl, err := provider.ParseTemplate(validateTemplate, log)
+	if err != nil {
+		t.Fatalf("ParseTemplate()=%s", err)
+	}
+
+	errs := (&provider.Validator{Descs: descs}).Validate(tmpl)
+
+	if errs.Err() == nil {
+		t.Fatal("expected template to be invalid")
+	}
+
+	want := []string{
+		"provider.aws.region: undeclared aws variable \"aws_regio\"",
+		"provider.unknown: unsupported provider \"unknown\"",
+		"resource.aws_instance.example.instance_type: user variable \"userInput_type\" is not declared and must be provided when creating a stack",
+		"resource.aws_instance.example.tags.Name: undeclared koding variable \"koding_group_name\"",
+		"resource.google_compute_instance: resource uses \"google\" provider, which is not declared in the provider block",
+	}
+
+	var got []string
+	var warnings int
+
+	for _, e := range errs {
+		got = append(got, e.Error())
+
+		if e.Warning {
+			warnings++
+
+			if !strings.HasPrefix(e.Token, "${var.userInput_") {
+				t.Errorf("unexpected warning: %s", e)
+			}
+		}
+	}
+
+	sort.Strings(got)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if warnings != 1 {
+		t.Fatalf("got %d warnings, want 1", warnings)
+	}
+}
+
+func TestValidatorValid(t *testing.T) {
+	descs := stack.Descriptions{
+		"aws": {
+			Credential: []stack.Value{{Name: "access_key"}, {Name: "secret_key"}, {Name: "region"}},
+		},
+	}
+
+	tmpl, err := provider.ParseTemplate(testTemplate, log)
+	if err != nil {
+		t.Fatalf("ParseTemplate()=%s", err)
+	}
+
+	if errs := (&provider.Validator{Descs: descs}).Validate(tmpl); len(errs) != 0 {
+		t.Fatalf("Validate()=%s", errs)
+	}
+}
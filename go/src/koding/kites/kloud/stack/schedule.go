@@ -0,0 +1,148 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+
+	"koding/db/mongodb/modelhelper"
+	"koding/kites/kloud/schedule"
+
+	"github.com/koding/kite"
+)
+
+// ScheduleRequest represents a request value for "machine.schedule"
+// kloud's kite method.
+//
+// When MachineID is empty, the schedule is stored with the team
+// and it is used by all team machines, which have no schedule
+// of their own.
+// Setting team schedules requires admin privileges.
+//
+// Nil Schedule removes a schedule.
+type ScheduleRequest struct {
+	MachineID string             `json:"machineId,omitempty"`
+	GroupName string             `json:"groupName,omitempty"`
+	Schedule  *schedule.Schedule `json:"schedule,omitempty"`
+}
+
+// Valid implements the Validator interface.
+func (req *ScheduleRequest) Valid() error {
+	if req.MachineID == "" && req.GroupName == "" {
+		return errors.New("either machine ID or group name is required")
+	}
+
+	if req.Schedule != nil {
+		return req.Schedule.Valid()
+	}
+
+	return nil
+}
+
+// ScheduleResponse represents a response value from "machine.schedule"
+// kloud's kite method.
+type ScheduleResponse struct {
+	Machines int `json:"machines"` // number of machines using the schedule
+}
+
+// Schedule is a kite.Handler for "machine.schedule" kite method.
+func (k *Kloud) Schedule(r *kite.Request) (interface{}, error) {
+	if r.Args == nil {
+		return nil, NewError(ErrNoArguments)
+	}
+
+	var req ScheduleRequest
+
+	if err := r.Args.One().Unmarshal(&req); err != nil {
+		return nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return nil, err
+	}
+
+	if req.MachineID != "" {
+		return k.scheduleMachine(r.Username, &req)
+	}
+
+	isAdmin, err := modelhelper.IsAdmin(r.Username, req.GroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin {
+		return nil, fmt.Errorf("User '%s' is not an admin of group '%s'", r.Username, req.GroupName)
+	}
+
+	group, err := modelhelper.GetGroup(req.GroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+
+	if req.Schedule != nil {
+		req.Schedule.Team = group.Slug
+		v = req.Schedule
+	}
+
+	n, err := modelhelper.UpdateGroupMachineSchedule(group, v)
+	if err != nil {
+		return nil, err
+	}
+
+	k.Log.Debug("User %q set %q team schedule used by %d machines: %s", r.Username, group.Slug, n, req.Schedule)
+
+	return &ScheduleResponse{Machines: n}, nil
+}
+
+func (k *Kloud) scheduleMachine(username string, req *ScheduleRequest) (*ScheduleResponse, error) {
+	m, err := modelhelper.GetMachine(req.MachineID)
+	if err != nil {
+		return nil, fmt.Errorf("getMachine(%s) err: %s", req.MachineID, err)
+	}
+
+	// Machine schedule can be changed by its owner
+	// or by an admin of the team.
+	if owner := m.Owner(); owner == nil || owner.Username != username {
+		if req.GroupName == "" {
+			return nil, fmt.Errorf("User '%s' is not an owner of '%s' machine", username, req.MachineID)
+		}
+
+		isAdmin, err := modelhelper.IsAdmin(username, req.GroupName)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isAdmin {
+			return nil, fmt.Errorf("User '%s' is not an admin of group '%s'", username, req.GroupName)
+		}
+
+		group, err := modelhelper.GetGroup(req.GroupName)
+		if err != nil {
+			return nil, err
+		}
+
+		isGroupMember := false
+		for _, g := range m.Groups {
+			if g.Id == group.Id {
+				isGroupMember = true
+			}
+		}
+		if !isGroupMember {
+			return nil, fmt.Errorf("'%s' machine does not belong to '%s' group", req.MachineID, req.GroupName)
+		}
+	}
+
+	var v interface{}
+
+	if req.Schedule != nil {
+		req.Schedule.Team = ""
+		v = req.Schedule
+	}
+
+	if err := modelhelper.UpdateMachineSchedule(m.ObjectId, v); err != nil {
+		return nil, err
+	}
+
+	return &ScheduleResponse{Machines: 1}, nil
+}
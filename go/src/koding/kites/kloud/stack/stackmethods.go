@@ -31,6 +31,7 @@ var (
 	ApplyRequestKey        = contextKey(2)
 	BootstrapRequestKey    = contextKey(3)
 	PlanRequestKey         = contextKey(4)
+	ReconcileRequestKey    = contextKey(5)
 )
 
 // KiteMap maps resource names to kite IDs they own.
@@ -112,6 +113,7 @@ type Machine struct {
 	Label      string            `json:"label"`
 	Attributes map[string]string `json:"attributes"`
 	Credential *Credential       `json:"-"`
+	Cost       *Cost             `json:"cost,omitempty"`
 
 	// Fields set by kloud.apply:
 	QueryString string                 `json:"queryString,omitempty"`
@@ -172,6 +174,23 @@ type Validator interface {
 	Valid() error
 }
 
+// Assumer is implemented by credentials, which are exchanged
+// for short-lived ones before they are used.
+type Assumer interface {
+	// Assume gives a credential value holding short-lived
+	// credentials. The receiver is left unchanged, so the
+	// short-lived credentials are never stored.
+	Assume() (interface{}, error)
+}
+
+// TeamBinder is implemented by credentials, which behave
+// differently depending on the team they are used within.
+type TeamBinder interface {
+	// BindTeam sets the team name, which the credential
+	// is going to be used within.
+	BindTeam(team string)
+}
+
 // InfoResponse is returned from a info method
 type InfoResponse struct {
 	// State defines the state of the machine
@@ -300,6 +319,18 @@ type PlanRequest struct {
 // PlanResponse represents a response type of the plan kite method.
 type PlanResponse struct {
 	Machines interface{} `json:"machines"`
+
+	// Cost is an estimated cost of running all the stack machines,
+	// which prices are known.
+	Cost *Cost `json:"cost,omitempty"`
+}
+
+// Cost represents an estimated price of running a machine
+// or a whole stack.
+type Cost struct {
+	Hourly   float64 `json:"hourly"`
+	Monthly  float64 `json:"monthly"`
+	Currency string  `json:"currency"`
 }
 
 // Valid implements the Validator interface.
@@ -318,6 +349,43 @@ func (k *Kloud) Plan(r *kite.Request) (interface{}, error) {
 	return k.stackMethod(r, Stacker.HandlePlan)
 }
 
+/// RECONCILE
+
+// ReconcileRequest represents an argument of reconcile kite method.
+type ReconcileRequest struct {
+	Provider  string `json:"provider"`
+	StackID   string `json:"stackId"`
+	GroupName string `json:"groupName"`
+}
+
+// Valid implements the Validator interface.
+func (req *ReconcileRequest) Valid() error {
+	if req.StackID == "" {
+		return errors.New("stackId is empty")
+	}
+	if req.GroupName == "" {
+		return errors.New("groupName is empty")
+	}
+	return nil
+}
+
+// ReconcileResponse represents a response of reconcile kite method.
+type ReconcileResponse struct {
+	StackID string `json:"stackId"`
+
+	// Resources lists drifted resources, which records
+	// were updated by the reconcile.
+	Resources []models.DriftedResource `json:"resources,omitempty"`
+}
+
+// Reconcile provides reconcile as a kite method.
+//
+// It refreshes the stack state with the actual cloud resources
+// and updates machine records accordingly.
+func (k *Kloud) Reconcile(r *kite.Request) (interface{}, error) {
+	return k.stackMethod(r, Stacker.HandleReconcile)
+}
+
 /// STATUS
 
 // StatusRequest represents an argument of status kite method.
@@ -338,6 +406,9 @@ type StatusResponse struct {
 	StackID    string    `json:"stackId"`
 	Status     string    `json:"status"`
 	ModifiedAt time.Time `json:"modifiedAt"`
+
+	// Drift is a result of the last drift check, if any.
+	Drift *models.StackDrift `json:"drift,omitempty"`
 }
 
 // Status
@@ -369,6 +440,7 @@ func (k *Kloud) Status(r *kite.Request) (interface{}, error) {
 			StackID:    arg.StackID,
 			Status:     computeStack.Status.State,
 			ModifiedAt: computeStack.Status.ModifiedAt,
+			Drift:      computeStack.Drift,
 		}
 
 		k.statusCache.Set(arg.StackID, resp)
@@ -52,6 +52,7 @@ type SpyStacker struct {
 	Auth      []*stack.AuthenticateRequest
 	Bootstrap []*stack.BootstrapRequest
 	Plan      []*stack.PlanRequest
+	Reconcile []*stack.ReconcileRequest
 }
 
 var (
@@ -107,6 +108,15 @@ func (ss *SpyStacker) HandlePlan(ctx context.Context) (interface{}, error) {
 	return make(stack.Machines), nil
 }
 
+// HandleReconcile implements the stack.Stacker interface.
+func (ss *SpyStacker) HandleReconcile(ctx context.Context) (interface{}, error) {
+	if req, ok := ctx.Value(stack.ReconcileRequestKey).(*stack.ReconcileRequest); ok {
+		ss.Reconcile = append(ss.Reconcile, req)
+	}
+
+	return &stack.ReconcileResponse{}, nil
+}
+
 // FakeKloud mocks stack.Kloud value, so it can be used
 // safely in unittests.
 //
@@ -0,0 +1,234 @@
+package stack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"koding/db/models"
+	"koding/db/mongodb/modelhelper"
+	"koding/kites/kloud/utils/object"
+
+	"github.com/koding/kite"
+)
+
+// TemplateRevision represents a single revision
+// of a jStackTemplate document.
+type TemplateRevision struct {
+	Revision  int       `json:"revision"`
+	Sum       string    `json:"sum"`
+	CreatedAt time.Time `json:"createdAt"`
+	Template  []byte    `json:"template,omitempty"`
+}
+
+// TemplateHistoryRequest represents a request value
+// for "template.history" kloud method.
+type TemplateHistoryRequest struct {
+	TemplateID string `json:"templateId"`
+	Content    bool   `json:"content,omitempty"` // whether to include template content
+}
+
+// Valid implements the Validator interface.
+func (req *TemplateHistoryRequest) Valid() error {
+	if req.TemplateID == "" {
+		return errors.New("template ID is empty")
+	}
+
+	return nil
+}
+
+// TemplateHistoryResponse represents a response value
+// from "template.history" kloud method.
+type TemplateHistoryResponse struct {
+	TemplateID string              `json:"templateId"`
+	Revisions  []*TemplateRevision `json:"revisions"`
+}
+
+// TemplateDiffRequest represents a request value
+// for "template.diff" kloud method.
+type TemplateDiffRequest struct {
+	TemplateID string `json:"templateId"`
+
+	// From is a revision number to compare. If 0,
+	// the revision preceding To is used.
+	//
+	// If To is the first revision, all its
+	// resources are reported as added.
+	From int `json:"from,omitempty"`
+
+	// To is a revision number to compare with. If 0,
+	// the latest revision is used.
+	To int `json:"to,omitempty"`
+}
+
+// Valid implements the Validator interface.
+func (req *TemplateDiffRequest) Valid() error {
+	if req.TemplateID == "" {
+		return errors.New("template ID is empty")
+	}
+
+	if req.From < 0 || req.To < 0 {
+		return errors.New("invalid revision number")
+	}
+
+	return nil
+}
+
+// TemplateDiffResponse represents a response value
+// from "template.diff" kloud method.
+type TemplateDiffResponse struct {
+	TemplateID string           `json:"templateId"`
+	From       int              `json:"from"`
+	To         int              `json:"to"`
+	Changes    []*object.Change `json:"changes"`
+}
+
+// TemplateHistory is a kite.Handler for "template.history" kite method.
+func (k *Kloud) TemplateHistory(r *kite.Request) (interface{}, error) {
+	var req TemplateHistoryRequest
+
+	if err := r.Args.One().Unmarshal(&req); err != nil {
+		return nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return nil, err
+	}
+
+	revs, err := k.templateRevisions(r.Username, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TemplateHistoryResponse{
+		TemplateID: req.TemplateID,
+		Revisions:  make([]*TemplateRevision, len(revs)),
+	}
+
+	for i, rev := range revs {
+		resp.Revisions[i] = &TemplateRevision{
+			Revision:  rev.Revision,
+			Sum:       rev.Sum,
+			CreatedAt: rev.CreatedAt,
+		}
+
+		if req.Content {
+			resp.Revisions[i].Template = []byte(rev.Content)
+		}
+	}
+
+	return resp, nil
+}
+
+// TemplateDiff is a kite.Handler for "template.diff" kite method.
+//
+// It gives a structural difference of resource blocks
+// between two revisions of a stack template.
+func (k *Kloud) TemplateDiff(r *kite.Request) (interface{}, error) {
+	var req TemplateDiffRequest
+
+	if err := r.Args.One().Unmarshal(&req); err != nil {
+		return nil, err
+	}
+
+	if err := req.Valid(); err != nil {
+		return nil, err
+	}
+
+	revs, err := k.templateRevisions(r.Username, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.To == 0 {
+		req.To = revs[len(revs)-1].Revision
+	}
+
+	if req.From == 0 {
+		req.From = req.To - 1
+	}
+
+	to, err := findRevision(revs, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	toRes, err := readResources(to)
+	if err != nil {
+		return nil, err
+	}
+
+	// For the very first revision all its resources
+	// are reported as added ones.
+	var fromRes map[string]interface{}
+
+	if req.From != 0 {
+		from, err := findRevision(revs, req.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if fromRes, err = readResources(from); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TemplateDiffResponse{
+		TemplateID: req.TemplateID,
+		From:       req.From,
+		To:         to.Revision,
+		Changes:    object.Diff(fromRes, toRes),
+	}, nil
+}
+
+// templateRevisions gives a revision history of a stack template
+// the user has access to.
+//
+// Revisions are recorded when the template is created or updated.
+// If the current content was not recorded, e.g. for templates
+// created before revisions were introduced, it is given
+// as the latest revision, without storing it.
+func (k *Kloud) templateRevisions(username, templateID string) ([]*models.StackTemplateRevision, error) {
+	tmpl, err := modelhelper.GetStackTemplate(templateID)
+	if err != nil {
+		return nil, models.ResError(err, "jStackTemplate")
+	}
+
+	if err := modelhelper.HasTemplateAccess(tmpl, username); err != nil {
+		return nil, err
+	}
+
+	revs, err := modelhelper.GetStackTemplateRevisions(templateID)
+	if err != nil {
+		return nil, models.ResError(err, modelhelper.StackTemplateRevisionColl)
+	}
+
+	if len(revs) == 0 || revs[len(revs)-1].Sum != tmpl.Template.Sum {
+		revs = append(revs, modelhelper.NewStackTemplateRevision(tmpl, revs))
+	}
+
+	return revs, nil
+}
+
+func findRevision(revs []*models.StackTemplateRevision, revision int) (*models.StackTemplateRevision, error) {
+	for _, rev := range revs {
+		if rev.Revision == revision {
+			return rev, nil
+		}
+	}
+
+	return nil, fmt.Errorf("revision %d not found", revision)
+}
+
+func readResources(rev *models.StackTemplateRevision) (map[string]interface{}, error) {
+	var v struct {
+		Resource map[string]interface{} `json:"resource"`
+	}
+
+	if err := json.Unmarshal([]byte(rev.Content), &v); err != nil {
+		return nil, fmt.Errorf("unable to read revision %d: %s", rev.Revision, err)
+	}
+
+	return v.Resource, nil
+}
@@ -0,0 +1,124 @@
+package terraformer
+
+import (
+	"time"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// LockRequest is a request value for state locking methods.
+//
+// Copied from kites/terraformer/state.go to avoid dependency
+// on the terraformer package.
+type LockRequest struct {
+	ContentID string
+	TraceID   string
+	LockID    string
+	TTL       time.Duration
+}
+
+// RevisionsRequest is a request value for state revision methods.
+//
+// Copied from kites/terraformer/state.go to avoid dependency
+// on the terraformer package.
+type RevisionsRequest struct {
+	ContentID string
+	TraceID   string
+	LockID    string
+	Revision  int
+}
+
+// Lock describes a lease on a state of a single content.
+//
+// Copied from kites/terraformer/storage/lock.go.
+type Lock struct {
+	ID        string    `json:"id"`
+	ContentID string    `json:"contentId"`
+	Owner     string    `json:"owner,omitempty"`
+	Created   time.Time `json:"created"`
+	Expires   time.Time `json:"expires"`
+}
+
+// Revision describes a single revision of a terraform state.
+//
+// Copied from kites/terraformer/storage/revision.go.
+type Revision struct {
+	Revision int       `json:"revision"`
+	Checksum string    `json:"checksum"`
+	TraceID  string    `json:"traceId,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// Job describes a single terraformer request.
+//
+// Copied from kites/terraformer/queue/queue.go.
+type Job struct {
+	ContentID string    `json:"contentId"`
+	TraceID   string    `json:"traceId,omitempty"`
+	Provider  string    `json:"provider,omitempty"`
+	Method    string    `json:"method"`
+	State     string    `json:"state"`
+	Position  int       `json:"position,omitempty"`
+	Created   time.Time `json:"created"`
+	Started   time.Time `json:"started,omitempty"`
+}
+
+// Lock locks the state of the given content. The returned lock ID
+// can be passed with TerraformRequest to operate on the locked state.
+func (t *Terraformer) Lock(req *LockRequest) (*Lock, error) {
+	resp, err := t.Client.Tell("state.lock", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock *Lock
+	if err := resp.Unmarshal(&lock); err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// Unlock releases a lock acquired with Lock.
+func (t *Terraformer) Unlock(req *LockRequest) error {
+	_, err := t.Client.Tell("state.unlock", req)
+	return err
+}
+
+// ForceUnlock releases a lock of the given content regardless
+// of its owner.
+func (t *Terraformer) ForceUnlock(req *LockRequest) error {
+	_, err := t.Client.Tell("state.forceUnlock", req)
+	return err
+}
+
+// Revisions gives revisions of the state of the given content,
+// the most recent one being the last.
+func (t *Terraformer) Revisions(req *RevisionsRequest) ([]*Revision, error) {
+	resp, err := t.Client.Tell("state.revisions", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var revs []*Revision
+	if err := resp.Unmarshal(&revs); err != nil {
+		return nil, err
+	}
+
+	return revs, nil
+}
+
+// Rollback restores the state of the given content to a prior revision.
+func (t *Terraformer) Rollback(req *RevisionsRequest) (*terraform.State, error) {
+	resp, err := t.Client.Tell("state.rollback", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var state *terraform.State
+	if err := resp.Unmarshal(&state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
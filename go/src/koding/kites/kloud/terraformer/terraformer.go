@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/koding/kite"
+	"github.com/koding/kite/dnode"
 )
 
 // TerraformRequest is a helper struct for terraformer kite requests.
@@ -17,6 +18,9 @@ type TerraformRequest struct {
 	Variables map[string]interface{}
 	ContentID string
 	TraceID   string
+	Provider  string
+	LockID    string
+	OnOutput  dnode.Function
 }
 
 // Terraformer represents a remote terraformer instance.
@@ -93,6 +97,65 @@ func (t *Terraformer) Destroy(req *TerraformRequest) (*terraform.State, error) {
 	return state, nil
 }
 
+// Refresh updates the state of the given content with the
+// real-world resources and returns it.
+func (t *Terraformer) Refresh(req *TerraformRequest) (*terraform.State, error) {
+	resp, err := t.Client.Tell("refresh", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var state *terraform.State
+	if err := resp.Unmarshal(&state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Logs gives terraform output of the operations, which
+// were run with the given trace ID.
+func (t *Terraformer) Logs(traceID string) (string, error) {
+	req := struct{ TraceID string }{traceID}
+
+	resp, err := t.Client.Tell("logs", req)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.String()
+}
+
+// Cancel cancels requests of the given content. Queued requests
+// are removed from the queue, the running one is interrupted.
+//
+// If traceID is not empty, only the request with
+// the given trace ID is canceled.
+func (t *Terraformer) Cancel(contentID, traceID string) error {
+	req := struct{ ContentID, TraceID string }{contentID, traceID}
+
+	_, err := t.Client.Tell("cancel", req)
+	return err
+}
+
+// Queue gives running and queued requests of the given content.
+// If contentID is empty, all the requests are returned.
+func (t *Terraformer) Queue(contentID string) ([]*Job, error) {
+	req := struct{ ContentID string }{contentID}
+
+	resp, err := t.Client.Tell("queue", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*Job
+	if err := resp.Unmarshal(&jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
 // Ping checks if the given terraformer response with "pong" to the "ping" we send.
 // A nil error means a successful pong result.
 func (t *Terraformer) Ping() error {
@@ -0,0 +1,83 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// DiffBuilder is used by Diff to flatten the compared values.
+var DiffBuilder = &Builder{
+	Sep:       ".",
+	Recursive: true,
+}
+
+// Change describes a single difference between
+// two flattened objects.
+type Change struct {
+	Key string      `json:"key"`
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+
+	// Type is one of:
+	//
+	//   - "+" when the key was added
+	//   - "-" when the key was removed
+	//   - "~" when the value under the key has changed
+	//
+	Type string `json:"type"`
+}
+
+// String implements the fmt.Stringer interface.
+func (c *Change) String() string {
+	switch c.Type {
+	case "+":
+		return fmt.Sprintf("+ %s = %v", c.Key, c.New)
+	case "-":
+		return fmt.Sprintf("- %s = %v", c.Key, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %v => %v", c.Key, c.Old, c.New)
+	}
+}
+
+// Diff flattens both v1 and v2 with DiffBuilder and gives a list
+// of changes required to turn v1 into v2, sorted by keys.
+func Diff(v1, v2 interface{}) []*Change {
+	return DiffBuilder.Diff(v1, v2)
+}
+
+// Diff flattens both v1 and v2 and gives a list of changes required
+// to turn v1 into v2, sorted by keys.
+//
+// Values which are not flattened by the builder (e.g. slices)
+// are compared with reflect.DeepEqual.
+func (b *Builder) Diff(v1, v2 interface{}) []*Change {
+	obj1, obj2 := b.Build(v1), b.Build(v2)
+
+	var changes []*Change
+
+	for key, old := range obj1 {
+		switch value, ok := obj2[key]; {
+		case !ok:
+			changes = append(changes, &Change{Key: key, Old: old, Type: "-"})
+		case !reflect.DeepEqual(old, value):
+			changes = append(changes, &Change{Key: key, Old: old, New: value, Type: "~"})
+		}
+	}
+
+	for key, value := range obj2 {
+		if _, ok := obj1[key]; !ok {
+			changes = append(changes, &Change{Key: key, New: value, Type: "+"})
+		}
+	}
+
+	sort.Sort(changesByKey(changes))
+
+	return changes
+}
+
+type changesByKey []*Change
+
+func (c changesByKey) Len() int           { return len(c) }
+func (c changesByKey) Less(i, j int) bool { return c[i].Key < c[j].Key }
+func (c changesByKey) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
@@ -0,0 +1,61 @@
+package object_test
+
+import (
+	"reflect"
+	"testing"
+
+	"koding/kites/kloud/utils/object"
+)
+
+func TestDiff(t *testing.T) {
+	v1 := map[string]interface{}{
+		"aws_instance": map[string]interface{}{
+			"example": map[string]interface{}{
+				"instance_type": "t2.nano",
+				"ami":           "",
+				"tags": map[string]interface{}{
+					"Name": "example",
+				},
+			},
+		},
+	}
+
+	v2 := map[string]interface{}{
+		"aws_instance": map[string]interface{}{
+			"example": map[string]interface{}{
+				"instance_type": "t2.micro",
+				"user_data":     "echo hello",
+				"tags":          map[string]interface{}{},
+			},
+		},
+	}
+
+	want := []*object.Change{{
+		Key:  "aws_instance.example.ami",
+		Old:  "",
+		Type: "-",
+	}, {
+		Key:  "aws_instance.example.instance_type",
+		Old:  "t2.nano",
+		New:  "t2.micro",
+		Type: "~",
+	}, {
+		Key:  "aws_instance.example.tags.Name",
+		Old:  "example",
+		Type: "-",
+	}, {
+		Key:  "aws_instance.example.user_data",
+		New:  "echo hello",
+		Type: "+",
+	}}
+
+	got := object.Diff(v1, v2)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if changes := object.Diff(v1, v1); len(changes) != 0 {
+		t.Fatalf("got %+v, want no changes", changes)
+	}
+}
@@ -0,0 +1,895 @@
+package kontrol
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"koding/db/mongodb/modelhelper"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/koding/cache"
+	"github.com/koding/kite"
+	"github.com/koding/kite/kitekey"
+	"github.com/koding/kite/kontrol"
+	"github.com/koding/kite/protocol"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DefaultGracePeriod is a time during which the previous key pairs
+// are still valid after rotation.
+var DefaultGracePeriod = 24 * time.Hour
+
+// adminGroup is a group, which admins are allowed to rotate
+// key pairs and revoke kite keys.
+const adminGroup = "koding"
+
+// RotateKeyRequest is a request value of rotateKey kite method.
+type RotateKeyRequest struct {
+	// Public and Private are PEM-encoded RSA key pair.
+	//
+	// If both are empty, new 2048-bit key pair is generated.
+	Public  string `json:"public,omitempty"`
+	Private string `json:"private,omitempty"`
+
+	// GracePeriod is a time during which the previous key pairs
+	// are still valid.
+	//
+	// If zero, DefaultGracePeriod is used.
+	GracePeriod time.Duration `json:"gracePeriod,omitempty"`
+}
+
+// RotateKeyResponse is a response value of rotateKey kite method.
+type RotateKeyResponse struct {
+	ID        string    `json:"id"`
+	Public    string    `json:"public"`
+	ExpiresAt time.Time `json:"expiresAt"` // expiration time of the previous key pairs
+}
+
+// RevokeRequest is a request value of revoke kite method.
+//
+// Exactly one of KiteID, MachineID or Username is expected
+// to be set.
+type RevokeRequest struct {
+	KiteID    string `json:"kiteID,omitempty"`
+	MachineID string `json:"machineID,omitempty"` // revokes kite key of the machine's klient
+	Username  string `json:"username,omitempty"`  // revokes kite keys of all user's kites
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Keys rotates kontrol key pairs and revokes kite keys.
+//
+// Kites, which kite keys are signed with a previous key pair,
+// get their kite keys re-signed with the current one on register.
+// Kites registered over HTTP are asked to register again on
+// their next heartbeat.
+//
+// Revoked kite keys, which were issued before the revocation,
+// are no longer accepted.
+type Keys struct {
+	Pairs   kontrol.KeyPairStorage // key pair storage of the kontrol
+	Store   KeyStore
+	Storage kontrol.Storage // if non-nil, revoked kites are deleted from it
+	Log     kite.Logger
+
+	// Authorize authorizes the requester of admin methods.
+	//
+	// If nil, requester is required to be an admin of koding group.
+	Authorize func(*kite.Request) error
+
+	// LookupMachine gives ID of the kite running on the machine
+	// with the given ID.
+	//
+	// If nil, the machine is looked up in the database.
+	LookupMachine func(id string) (kiteID string, err error)
+
+	mu          sync.RWMutex
+	current     *kontrol.KeyPair
+	revocations []*Revocation
+	registered  map[string]*registration // maps kite ID to its HTTP registration
+}
+
+type registration struct {
+	keyID    string
+	username string
+	time     time.Time
+	seen     time.Time // time of the last heartbeat
+}
+
+// Refresh deletes expired key pairs and reloads current key pair
+// and revocations, which may have been changed by other kontrols.
+func (k *Keys) Refresh() error {
+	k.prune()
+
+	if err := k.Store.DeleteExpired(); err != nil {
+		return err
+	}
+
+	current, err := k.Store.Current()
+	if err != nil {
+		return err
+	}
+
+	revocations, err := k.Store.Revocations()
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.revocations = revocations
+	k.mu.Unlock()
+
+	k.setCurrent(current)
+
+	return nil
+}
+
+// RefreshEvery calls Refresh periodically with the given interval.
+func (k *Keys) RefreshEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := k.Refresh(); err != nil {
+			k.Log.Error("unable to refresh keys: %s", err)
+		}
+	}
+}
+
+// Verify is used by kontrol's kite as a kite.Config.VerifyFunc.
+//
+// Unlike kontrol's default verify method it does not accept
+// deleted key pairs, so kite keys signed with a previous key pair
+// are accepted only until the grace period ends.
+func (k *Keys) Verify(pub string) error {
+	switch err := k.Pairs.IsValid(pub); err {
+	case nil:
+		return nil
+	case kontrol.ErrKeyDeleted, kontrol.ErrNoKeyFound, cache.ErrNotFound:
+		return kite.ErrKeyNotTrusted
+	default:
+		return err
+	}
+}
+
+// Authenticator wraps the given kite authenticator, so it rejects
+// requests authenticated with revoked kite keys.
+func (k *Keys) Authenticator(fn func(*kite.Request) error) func(*kite.Request) error {
+	return func(r *kite.Request) error {
+		if err := fn(r); err != nil {
+			return err
+		}
+
+		// Both kite keys and tokens are JWTs, which signature was
+		// already verified by the authenticator.
+		claims, err := parseClaims(r.Auth.Key)
+		if err != nil {
+			return err
+		}
+
+		var kiteIDs []string
+
+		if r.Auth.Type == "kiteKey" {
+			kiteIDs = append(kiteIDs, claims.Id) // kite keys are identified by kite IDs
+		}
+
+		if r.Client != nil {
+			kiteIDs = append(kiteIDs, r.Client.Kite.ID)
+		}
+
+		if rev := k.revokedKey(claims, kiteIDs...); rev != nil {
+			return revokedError(rev)
+		}
+
+		return nil
+	}
+}
+
+// HandleRegister wraps kontrol's register method, so it re-signs kite
+// keys signed with a previous key pair with the current one.
+func (k *Keys) HandleRegister(fn kite.HandlerFunc) kite.HandlerFunc {
+	return func(r *kite.Request) (interface{}, error) {
+		if r.Auth == nil || r.Auth.Type != "kiteKey" {
+			return fn(r)
+		}
+
+		kiteKey, pair, err := k.update(r.Auth.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		if kiteKey == "" {
+			return fn(r)
+		}
+
+		r.Auth.Key = kiteKey
+
+		resp, err := fn(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if res, ok := resp.(*protocol.RegisterResult); ok {
+			res.KiteKey = kiteKey
+			res.PublicKey = pair.Public
+		}
+
+		return resp, nil
+	}
+}
+
+// HandleRegisterHTTP wraps kontrol's /register HTTP handler, so it
+// rejects revoked kite keys and re-signs kite keys signed with
+// a previous key pair with the current one.
+func (k *Keys) HandleRegisterHTTP(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var args protocol.RegisterArgs
+
+		if err := json.NewDecoder(req.Body).Decode(&args); err != nil {
+			http.Error(w, jsonError(fmt.Errorf("wrong register input: '%s'", err)), http.StatusBadRequest)
+			return
+		}
+
+		if args.Kite == nil || args.Auth == nil || args.Auth.Type != "kiteKey" {
+			// Let kontrol handle the invalid request.
+			k.serve(fn, w, req, &args, nil)
+			return
+		}
+
+		claims, err := k.authenticate(args.Auth.Key)
+		if err != nil {
+			http.Error(w, jsonError(err), http.StatusUnauthorized)
+			return
+		}
+
+		if rev := k.revokedKey(claims, claims.Id, args.Kite.ID); rev != nil {
+			http.Error(w, jsonError(revokedError(rev)), http.StatusUnauthorized)
+			return
+		}
+
+		kiteKey, pair, err := k.update(args.Auth.Key)
+		if err != nil {
+			http.Error(w, jsonError(err), http.StatusBadRequest)
+			return
+		}
+
+		if kiteKey != "" {
+			args.Auth.Key = kiteKey
+		}
+
+		res := k.serve(fn, w, req, &args, func(res *protocol.RegisterResult) {
+			if kiteKey != "" {
+				res.KiteKey = kiteKey
+				res.PublicKey = pair.Public
+			}
+		})
+
+		if res != nil {
+			k.register(args.Kite.ID, claims.Subject, pair.ID)
+		}
+	}
+}
+
+// HandleHeartbeat wraps kontrol's /heartbeat HTTP handler, so it asks
+// kites to register again, when their kite keys are either signed
+// with a previous key pair or revoked.
+func (k *Keys) HandleHeartbeat(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if id := req.URL.Query().Get("id"); id != "" && k.heartbeat(id) {
+			k.Log.Debug("Sending registeragain to outdated kite '%s'", id)
+
+			w.Write([]byte("registeragain"))
+			return
+		}
+
+		fn(w, req)
+	}
+}
+
+// HandleGetKites wraps kontrol's getKites method, so it does not
+// return revoked kites.
+func (k *Keys) HandleGetKites(fn kite.HandlerFunc) kite.HandlerFunc {
+	return func(r *kite.Request) (interface{}, error) {
+		resp, err := fn(r)
+		if err != nil {
+			return nil, err
+		}
+
+		res, ok := resp.(*protocol.GetKitesResult)
+		if !ok {
+			return resp, nil
+		}
+
+		kites := res.Kites[:0]
+
+		for _, kt := range res.Kites {
+			if k.revoked("", kt.Kite.ID, time.Time{}) == nil {
+				kites = append(kites, kt)
+			}
+		}
+
+		res.Kites = kites
+
+		return res, nil
+	}
+}
+
+// HandleGetToken wraps kontrol's getToken method, so it does not
+// issue tokens for revoked kites.
+func (k *Keys) HandleGetToken(fn kite.HandlerFunc) kite.HandlerFunc {
+	return func(r *kite.Request) (interface{}, error) {
+		var args protocol.GetTokenArgs
+
+		if r.Args != nil {
+			if err := r.Args.One().Unmarshal(&args); err == nil && args.ID != "" {
+				if rev := k.revoked("", args.ID, time.Time{}); rev != nil {
+					return nil, revokedError(rev)
+				}
+			}
+		}
+
+		return fn(r)
+	}
+}
+
+// HandleGetKey wraps kontrol's getKey method, so kites which kite keys
+// are signed with a deleted key pair get the current public key.
+func (k *Keys) HandleGetKey(fn kite.HandlerFunc) kite.HandlerFunc {
+	return func(r *kite.Request) (interface{}, error) {
+		if r.Auth == nil || r.Auth.Type != "kiteKey" {
+			return fn(r)
+		}
+
+		claims, err := parseClaims(r.Auth.Key)
+		if err != nil || claims.KontrolKey == "" || k.Verify(claims.KontrolKey) != kite.ErrKeyNotTrusted {
+			return fn(r)
+		}
+
+		pair, err := k.currentKeyPair()
+		if err != nil {
+			return nil, err
+		}
+
+		return pair.Public, nil
+	}
+}
+
+// HandleMachine wraps kontrol's registerMachine method, so new kite
+// keys are signed with the current key pair.
+func (k *Keys) HandleMachine(fn kite.HandlerFunc) kite.HandlerFunc {
+	return func(r *kite.Request) (interface{}, error) {
+		resp, err := fn(r)
+		if err != nil {
+			return nil, err
+		}
+
+		kiteKey, ok := resp.(string)
+		if !ok {
+			return resp, nil
+		}
+
+		newKey, _, err := k.update(kiteKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if newKey != "" {
+			return newKey, nil
+		}
+
+		return kiteKey, nil
+	}
+}
+
+// RotateKey is a kite handler, which adds a new key pair and schedules
+// all previous key pairs to expire after the grace period.
+func (k *Keys) RotateKey(r *kite.Request) (interface{}, error) {
+	var req RotateKeyRequest
+
+	if r.Args != nil {
+		if err := r.Args.One().Unmarshal(&req); err != nil {
+			return nil, errors.New("invalid request: " + err.Error())
+		}
+	}
+
+	if err := k.authorize(r); err != nil {
+		return nil, err
+	}
+
+	pair, err := newKeyPair(req.Public, req.Private)
+	if err != nil {
+		return nil, err
+	}
+
+	grace := req.GracePeriod
+	if grace == 0 {
+		grace = DefaultGracePeriod
+	}
+
+	expire := time.Now().Add(grace)
+
+	if err := k.Store.Rotate(pair, expire); err != nil {
+		return nil, err
+	}
+
+	k.setCurrent(pair)
+
+	k.Log.Info("User '%s' rotated kontrol key pair, new key pair: %s, previous key pairs expire at: %s",
+		r.Username, pair.ID, expire)
+
+	return &RotateKeyResponse{
+		ID:        pair.ID,
+		Public:    pair.Public,
+		ExpiresAt: expire,
+	}, nil
+}
+
+// Revoke is a kite handler, which revokes kite key of a single kite,
+// a machine or all kites of a user.
+func (k *Keys) Revoke(r *kite.Request) (interface{}, error) {
+	var req RevokeRequest
+
+	if r.Args == nil {
+		return nil, errors.New("invalid request")
+	}
+
+	if err := r.Args.One().Unmarshal(&req); err != nil {
+		return nil, errors.New("invalid request: " + err.Error())
+	}
+
+	if err := k.authorize(r); err != nil {
+		return nil, err
+	}
+
+	rev := &Revocation{
+		KiteID:    req.KiteID,
+		Username:  req.Username,
+		Reason:    req.Reason,
+		CreatedBy: r.Username,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if req.MachineID != "" {
+		id, err := k.lookupMachine(req.MachineID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find kite of machine %q: %s", req.MachineID, err)
+		}
+
+		rev.KiteID = id
+	}
+
+	switch {
+	case rev.KiteID == "" && rev.Username == "":
+		return nil, errors.New("kiteID, machineID or username is required")
+	case rev.KiteID != "" && rev.Username != "":
+		return nil, errors.New("kiteID or machineID and username are mutually exclusive")
+	}
+
+	if err := k.Store.Revoke(rev); err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.revocations = append(k.revocations, rev)
+	k.mu.Unlock()
+
+	k.deleteKites(&protocol.KontrolQuery{
+		ID:       rev.KiteID,
+		Username: rev.Username,
+	})
+
+	k.Log.Info("User '%s' revoked kite keys: kiteID=%q, username=%q, reason=%q",
+		r.Username, rev.KiteID, rev.Username, rev.Reason)
+
+	return rev, nil
+}
+
+// revoked gives a revocation of the given user or kite, which was
+// created after the kite key or token was issued.
+//
+// If issued is zero, revocations of the kite are matched
+// regardless of time.
+func (k *Keys) revoked(username, kiteID string, issued time.Time) *Revocation {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, rev := range k.revocations {
+		if (username == "" || rev.Username != username) && (kiteID == "" || rev.KiteID != kiteID) {
+			continue
+		}
+
+		if !issued.IsZero() && issued.After(rev.CreatedAt) {
+			continue
+		}
+
+		return rev
+	}
+
+	return nil
+}
+
+// revokedKey gives a revocation of the kite key or token with
+// the given claims, which is used by kites with the given IDs.
+func (k *Keys) revokedKey(claims *kitekey.KiteClaims, kiteIDs ...string) *Revocation {
+	issued := issuedAt(claims)
+
+	if rev := k.revoked(claims.Subject, "", issued); rev != nil {
+		return rev
+	}
+
+	for _, id := range kiteIDs {
+		if id == "" {
+			continue
+		}
+
+		if rev := k.revoked("", id, issued); rev != nil {
+			return rev
+		}
+	}
+
+	return nil
+}
+
+// heartbeat tells whether the kite registered over HTTP should
+// register again.
+func (k *Keys) heartbeat(id string) bool {
+	k.mu.Lock()
+	reg, ok := k.registered[id]
+	if ok {
+		reg.seen = time.Now()
+	}
+	current := k.current
+	k.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	if current != nil && reg.keyID != current.ID {
+		return true
+	}
+
+	// The kite key was checked against revocations during
+	// registration, so revocations created afterwards apply.
+	return k.revoked(reg.username, id, reg.time) != nil
+}
+
+func (k *Keys) register(id, username, keyID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.registered == nil {
+		k.registered = make(map[string]*registration)
+	}
+
+	now := time.Now()
+
+	k.registered[id] = &registration{
+		keyID:    keyID,
+		username: username,
+		time:     now,
+		seen:     now,
+	}
+}
+
+// prune forgets HTTP registrations of kites, which stopped sending
+// heartbeats - kontrol deletes such kites as well.
+func (k *Keys) prune() {
+	deadline := time.Now().Add(-kontrol.HeartbeatInterval - kontrol.HeartbeatDelay)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for id, reg := range k.registered {
+		if reg.seen.Before(deadline) {
+			delete(k.registered, id)
+		}
+	}
+}
+
+// authenticate verifies the kite key and gives its claims.
+func (k *Keys) authenticate(kiteKey string) (*kitekey.KiteClaims, error) {
+	ex := &kitekey.Extractor{
+		Claims: &kitekey.KiteClaims{},
+	}
+
+	if _, err := jwt.ParseWithClaims(kiteKey, ex.Claims, ex.Extract); err != nil {
+		return nil, err
+	}
+
+	if ex.Claims.Subject == "" {
+		return nil, errors.New("token has no username")
+	}
+
+	if err := k.Verify(ex.Claims.KontrolKey); err != nil {
+		return nil, err
+	}
+
+	return ex.Claims, nil
+}
+
+// update re-signs the kite key with the current key pair. If the kite
+// key is already signed with it, the returned kite key is empty.
+func (k *Keys) update(kiteKey string) (string, *kontrol.KeyPair, error) {
+	pair, err := k.currentKeyPair()
+	if err != nil {
+		return "", nil, err
+	}
+
+	ex := &kitekey.Extractor{
+		Claims: &kitekey.KiteClaims{},
+	}
+
+	t, err := jwt.ParseWithClaims(kiteKey, ex.Claims, ex.Extract)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if ex.Claims.KontrolKey == pair.Public {
+		return "", pair, nil
+	}
+
+	ex.Claims.KontrolKey = pair.Public
+
+	rsaPrivate, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(pair.Private))
+	if err != nil {
+		return "", nil, err
+	}
+
+	newKey, err := t.SignedString(rsaPrivate)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return newKey, pair, nil
+}
+
+func (k *Keys) currentKeyPair() (*kontrol.KeyPair, error) {
+	k.mu.RLock()
+	current := k.current
+	k.mu.RUnlock()
+
+	if current != nil {
+		return current, nil
+	}
+
+	current, err := k.Store.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	k.setCurrent(current)
+
+	return current, nil
+}
+
+func (k *Keys) setCurrent(pair *kontrol.KeyPair) {
+	k.mu.Lock()
+	k.current = pair
+	k.mu.Unlock()
+}
+
+// pickKey is used by kontrol as a kontrol.MachineKeyPicker, so new kite
+// keys are signed with the current key pair.
+func (k *Keys) pickKey(*kite.Request) (*kontrol.KeyPair, error) {
+	return k.currentKeyPair()
+}
+
+// serve calls the given handler with the request body replaced with args.
+// If the handler succeeds, fn is called with the register result, before
+// it is written back to the requester.
+func (k *Keys) serve(h http.HandlerFunc, w http.ResponseWriter, req *http.Request, args *protocol.RegisterArgs,
+	fn func(*protocol.RegisterResult)) *protocol.RegisterResult {
+	p, err := json.Marshal(args)
+	if err != nil {
+		http.Error(w, jsonError(err), http.StatusInternalServerError)
+		return nil
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(p))
+	req.ContentLength = int64(len(p))
+
+	rec := &responseBuffer{
+		header: make(http.Header),
+		code:   http.StatusOK,
+	}
+
+	h(rec, req)
+
+	var res protocol.RegisterResult
+
+	if rec.code == http.StatusOK {
+		if err := json.Unmarshal(rec.buf.Bytes(), &res); err == nil {
+			if fn != nil {
+				fn(&res)
+
+				p, err := json.Marshal(&res)
+				if err != nil {
+					http.Error(w, jsonError(err), http.StatusInternalServerError)
+					return nil
+				}
+
+				rec.buf.Reset()
+				rec.buf.Write(p)
+			}
+		} else {
+			k.Log.Error("unable to decode register response: %s", err)
+		}
+	}
+
+	for key, v := range rec.header {
+		w.Header()[key] = v
+	}
+
+	w.WriteHeader(rec.code)
+	w.Write(rec.buf.Bytes())
+
+	if rec.code != http.StatusOK {
+		return nil
+	}
+
+	return &res
+}
+
+func (k *Keys) deleteKites(query *protocol.KontrolQuery) {
+	if k.Storage == nil {
+		return
+	}
+
+	kites, err := k.Storage.Get(query)
+	if err != nil {
+		k.Log.Warning("unable to get revoked kites %+v: %s", query, err)
+		return
+	}
+
+	for _, kt := range kites {
+		if err := k.Storage.Delete(&kt.Kite); err != nil {
+			k.Log.Warning("unable to delete revoked kite %s: %s", &kt.Kite, err)
+		}
+	}
+}
+
+func (k *Keys) authorize(r *kite.Request) error {
+	if k.Authorize != nil {
+		return k.Authorize(r)
+	}
+
+	isAdmin, err := modelhelper.IsAdmin(r.Username, adminGroup)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin {
+		return fmt.Errorf("User '%s' is not an admin of group '%s'", r.Username, adminGroup)
+	}
+
+	return nil
+}
+
+func (k *Keys) lookupMachine(id string) (string, error) {
+	if k.LookupMachine != nil {
+		return k.LookupMachine(id)
+	}
+
+	m, err := modelhelper.GetMachine(id)
+	if err != nil {
+		return "", err
+	}
+
+	kt, err := protocol.KiteFromString(m.QueryString)
+	if err != nil {
+		return "", err
+	}
+
+	if kt.ID == "" {
+		return "", errors.New("machine has no kite ID")
+	}
+
+	return kt.ID, nil
+}
+
+// newKeyPair gives a key pair with a unique ID, generating new
+// RSA key when both public and private are empty.
+func newKeyPair(public, private string) (*kontrol.KeyPair, error) {
+	public = strings.TrimSpace(public)
+	private = strings.TrimSpace(private)
+
+	if public == "" && private == "" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+
+		pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		public = strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: pub,
+		})))
+
+		private = strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		})))
+	}
+
+	if _, err := jwt.ParseRSAPublicKeyFromPEM([]byte(public)); err != nil {
+		return nil, fmt.Errorf("invalid public key: %s", err)
+	}
+
+	if _, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(private)); err != nil {
+		return nil, fmt.Errorf("invalid private key: %s", err)
+	}
+
+	pair := &kontrol.KeyPair{
+		ID:      uuid.NewV4().String(),
+		Public:  public,
+		Private: private,
+	}
+
+	return pair, pair.Validate()
+}
+
+// issuedAt gives time the kite key or token was issued at. Kontrol
+// backdates iat claims by TokenLeeway to account for clock skew.
+func issuedAt(claims *kitekey.KiteClaims) time.Time {
+	return time.Unix(claims.IssuedAt, 0).Add(kontrol.TokenLeeway)
+}
+
+// parseClaims gives claims of the JWT without verifying it.
+func parseClaims(token string) (*kitekey.KiteClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token contains an invalid number of segments")
+	}
+
+	p, err := jwt.DecodeSegment(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var claims kitekey.KiteClaims
+
+	if err := json.Unmarshal(p, &claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func revokedError(rev *Revocation) error {
+	if rev.Reason != "" {
+		return fmt.Errorf("kite key is revoked: %s", rev.Reason)
+	}
+
+	return errors.New("kite key is revoked")
+}
+
+// jsonError returns a JSON string of form {"err" : "error content"},
+// like kontrol does for HTTP errors.
+func jsonError(err error) string {
+	p, _ := json.Marshal(map[string]string{"err": err.Error()})
+	return string(p)
+}
+
+// responseBuffer is a http.ResponseWriter, which buffers
+// the response, so it can be modified before writing.
+type responseBuffer struct {
+	header http.Header
+	code   int
+	buf    bytes.Buffer
+}
+
+func (rb *responseBuffer) Header() http.Header         { return rb.header }
+func (rb *responseBuffer) Write(p []byte) (int, error) { return rb.buf.Write(p) }
+func (rb *responseBuffer) WriteHeader(code int)        { rb.code = code }
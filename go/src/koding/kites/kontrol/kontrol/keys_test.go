@@ -0,0 +1,392 @@
+package kontrol
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/koding/kite"
+	"github.com/koding/kite/dnode"
+	"github.com/koding/kite/kitekey"
+	"github.com/koding/kite/kontrol"
+	"github.com/koding/kite/protocol"
+	"github.com/koding/logging"
+)
+
+func newTestKeys(t *testing.T) (*Keys, *kontrol.KeyPair) {
+	pair, err := newKeyPair("", "")
+	if err != nil {
+		t.Fatalf("newKeyPair()=%s", err)
+	}
+
+	pairs := kontrol.NewMemKeyPairStorage()
+
+	if err := pairs.AddKey(pair); err != nil {
+		t.Fatalf("AddKey()=%s", err)
+	}
+
+	keys := &Keys{
+		Pairs:     pairs,
+		Store:     NewMemKeyStore(pairs, pair),
+		Log:       logging.NewCustom("kontrol", testing.Verbose()),
+		Authorize: func(*kite.Request) error { return nil },
+		LookupMachine: func(id string) (string, error) {
+			return "kite-" + id, nil
+		},
+	}
+
+	return keys, pair
+}
+
+func newKiteKey(t *testing.T, pair *kontrol.KeyPair, username, id string, issued time.Time) string {
+	claims := &kitekey.KiteClaims{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:   "kontrol",
+			Subject:  username,
+			IssuedAt: issued.Add(-kontrol.TokenLeeway).Unix(),
+			Id:       id,
+		},
+		KontrolKey: pair.Public,
+	}
+
+	rsaPrivate, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(pair.Private))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kiteKey, err := jwt.NewWithClaims(jwt.GetSigningMethod("RS256"), claims).SignedString(rsaPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return kiteKey
+}
+
+func newRequest(t *testing.T, v interface{}) *kite.Request {
+	p, err := json.Marshal([]interface{}{v})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &kite.Request{
+		Username: "admin",
+		Args:     &dnode.Partial{Raw: p},
+	}
+}
+
+func heartbeat(keys *Keys, id string) string {
+	h := keys.HandleHeartbeat(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("pong"))
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", "/heartbeat?id="+id, nil))
+
+	return rec.Body.String()
+}
+
+func TestKeysRotate(t *testing.T) {
+	keys, old := newTestKeys(t)
+
+	kiteKey := newKiteKey(t, old, "user", "kite-1", time.Now())
+
+	if newKey, _, err := keys.update(kiteKey); err != nil || newKey != "" {
+		t.Fatalf("got %q, %v; want kite key to be up to date", newKey, err)
+	}
+
+	keys.register("kite-1", "user", old.ID)
+
+	if s := heartbeat(keys, "kite-1"); s != "pong" {
+		t.Fatalf("got %q, want pong", s)
+	}
+
+	resp, err := keys.RotateKey(newRequest(t, &RotateKeyRequest{GracePeriod: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("RotateKey()=%s", err)
+	}
+
+	rotated := resp.(*RotateKeyResponse)
+
+	// The previous key pair is valid during the grace period.
+	for _, pub := range []string{old.Public, rotated.Public} {
+		if err := keys.Verify(pub); err != nil {
+			t.Fatalf("Verify()=%s", err)
+		}
+	}
+
+	// The kite registered with the previous key pair is going to
+	// register again.
+	if s := heartbeat(keys, "kite-1"); s != "registeragain" {
+		t.Fatalf("got %q, want registeragain", s)
+	}
+
+	newKey, pair, err := keys.update(kiteKey)
+	if err != nil {
+		t.Fatalf("update()=%s", err)
+	}
+
+	if pair.ID != rotated.ID {
+		t.Fatalf("got key pair %q, want %q", pair.ID, rotated.ID)
+	}
+
+	claims, err := keys.authenticate(newKey)
+	if err != nil {
+		t.Fatalf("authenticate()=%s", err)
+	}
+
+	if claims.KontrolKey != rotated.Public || claims.Subject != "user" || claims.Id != "kite-1" {
+		t.Fatalf("got unexpected claims: %+v", claims)
+	}
+
+	keys.register("kite-1", "user", pair.ID)
+
+	if s := heartbeat(keys, "kite-1"); s != "pong" {
+		t.Fatalf("got %q, want pong", s)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if err := keys.Refresh(); err != nil {
+		t.Fatalf("Refresh()=%s", err)
+	}
+
+	// The previous key pair is not trusted after the grace period.
+	if err := keys.Verify(old.Public); err != kite.ErrKeyNotTrusted {
+		t.Fatalf("got %v, want %v", err, kite.ErrKeyNotTrusted)
+	}
+
+	if _, err := keys.authenticate(kiteKey); err == nil {
+		t.Fatal("expected kite key signed with expired key pair to be rejected")
+	}
+
+	if err := keys.Verify(rotated.Public); err != nil {
+		t.Fatalf("Verify()=%s", err)
+	}
+
+	// Kites with kite keys signed with the expired key pair
+	// get the current public key.
+	getKey := keys.HandleGetKey(func(r *kite.Request) (interface{}, error) {
+		return parseClaims(r.Auth.Key)
+	})
+
+	for key, want := range map[string]string{kiteKey: rotated.Public, newKey: ""} {
+		resp, err := getKey(&kite.Request{Auth: &kite.Auth{Type: "kiteKey", Key: key}})
+		if err != nil {
+			t.Fatalf("HandleGetKey()=%s", err)
+		}
+
+		if pub, ok := resp.(string); ok != (want != "") || pub != want {
+			t.Fatalf("got %v, want %q", resp, want)
+		}
+	}
+}
+
+func TestKontrolPairs(t *testing.T) {
+	keys, old := newTestKeys(t)
+
+	self, err := newKeyPair("", "")
+	if err != nil {
+		t.Fatalf("newKeyPair()=%s", err)
+	}
+
+	pairs := &kontrolPairs{
+		KeyPairStorage: keys.Pairs,
+		self:           self,
+	}
+
+	// Adding stored key pair is a no-op.
+	if err := pairs.AddKey(old); err != nil {
+		t.Fatalf("AddKey()=%s", err)
+	}
+
+	if pair, err := pairs.GetKeyFromID(self.ID); err != nil || pair != self {
+		t.Fatalf("got %+v, %v; want kontrol's own key pair", pair, err)
+	}
+
+	deleted := &kontrol.KeyPair{ID: "deleted", Public: "deleted"}
+
+	pairs.KeyPairStorage = deletedPairs{keys.Pairs, deleted}
+
+	// Deleted key pairs are reported as missing, so kontrol
+	// falls back to the current key pair.
+	if _, err := pairs.GetKeyFromID(deleted.ID); err != kontrol.ErrNoKeyFound {
+		t.Fatalf("got %v, want %v", err, kontrol.ErrNoKeyFound)
+	}
+
+	if _, err := pairs.GetKeyFromPublic(deleted.Public); err != kontrol.ErrNoKeyFound {
+		t.Fatalf("got %v, want %v", err, kontrol.ErrNoKeyFound)
+	}
+
+	if err := pairs.IsValid(deleted.Public); err != kontrol.ErrNoKeyFound {
+		t.Fatalf("got %v, want %v", err, kontrol.ErrNoKeyFound)
+	}
+
+	if err := pairs.IsValid(old.Public); err != nil {
+		t.Fatalf("IsValid()=%s", err)
+	}
+}
+
+// deletedPairs is a key pair storage, which reports
+// the deleted key pair like kontrol.Postgres does.
+type deletedPairs struct {
+	kontrol.KeyPairStorage
+	deleted *kontrol.KeyPair
+}
+
+func (d deletedPairs) GetKeyFromID(id string) (*kontrol.KeyPair, error) {
+	if id == d.deleted.ID {
+		return nil, kontrol.ErrKeyDeleted
+	}
+	return d.KeyPairStorage.GetKeyFromID(id)
+}
+
+func (d deletedPairs) GetKeyFromPublic(public string) (*kontrol.KeyPair, error) {
+	if public == d.deleted.Public {
+		return nil, kontrol.ErrKeyDeleted
+	}
+	return d.KeyPairStorage.GetKeyFromPublic(public)
+}
+
+func (d deletedPairs) IsValid(public string) error {
+	_, err := d.GetKeyFromPublic(public)
+	return err
+}
+
+func TestKeysPrune(t *testing.T) {
+	keys, pair := newTestKeys(t)
+
+	keys.register("kite-1", "user", pair.ID)
+	keys.register("kite-2", "user", pair.ID)
+
+	// kite-1 stopped sending heartbeats.
+	keys.registered["kite-1"].seen = time.Now().Add(-kontrol.HeartbeatInterval - kontrol.HeartbeatDelay - time.Second)
+
+	if err := keys.Refresh(); err != nil {
+		t.Fatalf("Refresh()=%s", err)
+	}
+
+	if _, ok := keys.registered["kite-1"]; ok {
+		t.Fatal("expected registration of kite-1 to be pruned")
+	}
+
+	if _, ok := keys.registered["kite-2"]; !ok {
+		t.Fatal("expected registration of kite-2 to be kept")
+	}
+}
+
+func TestKeysRevoke(t *testing.T) {
+	keys, pair := newTestKeys(t)
+
+	authenticate := keys.Authenticator(func(r *kite.Request) error {
+		claims, err := keys.authenticate(r.Auth.Key)
+		if err != nil {
+			return err
+		}
+
+		r.Username = claims.Subject
+		return nil
+	})
+
+	auth := func(kiteKey string) error {
+		return authenticate(&kite.Request{
+			Auth: &kite.Auth{
+				Type: "kiteKey",
+				Key:  kiteKey,
+			},
+		})
+	}
+
+	issued := time.Now().Add(-time.Minute)
+
+	// Revocations are cumulative.
+	cases := []struct {
+		name    string
+		req     *RevokeRequest
+		revoked []string // kite IDs
+		valid   []string // kite IDs
+	}{{
+		"kite",
+		&RevokeRequest{KiteID: "kite-1", Reason: "stolen laptop"},
+		[]string{"kite-1"},
+		[]string{"kite-2", "kite-m1"},
+	}, {
+		"machine",
+		&RevokeRequest{MachineID: "m1"},
+		[]string{"kite-1", "kite-m1"},
+		[]string{"kite-2"},
+	}}
+
+	for _, cas := range cases {
+		t.Run(cas.name, func(t *testing.T) {
+			if _, err := keys.Revoke(newRequest(t, cas.req)); err != nil {
+				t.Fatalf("Revoke()=%s", err)
+			}
+
+			for _, id := range cas.revoked {
+				if err := auth(newKiteKey(t, pair, "user", id, issued)); err == nil {
+					t.Fatalf("expected kite key of %q to be revoked", id)
+				}
+			}
+
+			for _, id := range cas.valid {
+				if err := auth(newKiteKey(t, pair, "user", id, issued)); err != nil {
+					t.Fatalf("auth(%q)=%s", id, err)
+				}
+			}
+		})
+	}
+
+	keys.register("kite-3", "bob", pair.ID)
+
+	if _, err := keys.Revoke(newRequest(t, &RevokeRequest{Username: "bob"})); err != nil {
+		t.Fatalf("Revoke()=%s", err)
+	}
+
+	if err := auth(newKiteKey(t, pair, "bob", "kite-3", issued)); err == nil {
+		t.Fatal("expected kite key of bob to be revoked")
+	}
+
+	if s := heartbeat(keys, "kite-3"); s != "registeragain" {
+		t.Fatalf("got %q, want registeragain", s)
+	}
+
+	// Kite keys issued after the revocation are valid.
+	if err := auth(newKiteKey(t, pair, "bob", "kite-4", time.Now().Add(time.Second))); err != nil {
+		t.Fatalf("auth()=%s", err)
+	}
+
+	getKites := keys.HandleGetKites(func(*kite.Request) (interface{}, error) {
+		return &protocol.GetKitesResult{
+			Kites: []*protocol.KiteWithToken{
+				{Kite: protocol.Kite{ID: "kite-1"}},
+				{Kite: protocol.Kite{ID: "kite-2"}},
+				{Kite: protocol.Kite{ID: "kite-m1"}},
+			},
+		}, nil
+	})
+
+	resp, err := getKites(&kite.Request{})
+	if err != nil {
+		t.Fatalf("getKites()=%s", err)
+	}
+
+	if kites := resp.(*protocol.GetKitesResult).Kites; len(kites) != 1 || kites[0].Kite.ID != "kite-2" {
+		t.Fatalf("got %+v, want only kite-2", kites)
+	}
+
+	revocations, err := keys.Store.Revocations()
+	if err != nil {
+		t.Fatalf("Revocations()=%s", err)
+	}
+
+	if len(revocations) != 3 {
+		t.Fatalf("got %d revocations, want 3", len(revocations))
+	}
+
+	if _, err := keys.Revoke(newRequest(t, &RevokeRequest{Reason: "none"})); err == nil {
+		t.Fatal("expected revocation without kite ID and username to fail")
+	}
+}
@@ -0,0 +1,341 @@
+package kontrol
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/koding/cache"
+	"github.com/koding/kite/kontrol"
+)
+
+// Revocation revokes kite key of a single kite or kite keys
+// of all kites of a user.
+type Revocation struct {
+	KiteID    string    `json:"kiteID,omitempty"`
+	Username  string    `json:"username,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// KeyStore persists state of key pair rotations and kite key
+// revocations, so it is shared by all kontrol instances.
+type KeyStore interface {
+	// Current gives the newest key pair, which is used to sign
+	// kite keys.
+	Current() (*kontrol.KeyPair, error)
+
+	// Rotate adds the new key pair and schedules all previous ones
+	// to expire at the given time.
+	Rotate(pair *kontrol.KeyPair, expire time.Time) error
+
+	// DeleteExpired deletes key pairs, which have expired.
+	DeleteExpired() error
+
+	// Revoke stores the revocation.
+	Revoke(*Revocation) error
+
+	// Revocations gives all revocations.
+	Revocations() ([]*Revocation, error)
+}
+
+var (
+	_ KeyStore = (*PostgresKeyStore)(nil)
+	_ KeyStore = (*MemKeyStore)(nil)
+)
+
+// PostgresKeyStore is a KeyStore, which uses kite.key and
+// kite.revocation tables.
+type PostgresKeyStore struct {
+	DB    *sql.DB
+	Pairs kontrol.KeyPairStorage // adds and deletes key pairs
+}
+
+// Current implements the KeyStore interface.
+//
+// The kontrol-self key pair is a placeholder added by kontrol
+// when it has no kite key, thus it is never used for signing.
+func (p *PostgresKeyStore) Current() (*kontrol.KeyPair, error) {
+	var kp kontrol.KeyPair
+
+	err := p.DB.QueryRow(`SELECT id, public, private FROM kite.key
+		WHERE deleted_at IS NULL AND expires_at IS NULL AND public <> 'kontrol-self'
+		ORDER BY created_at DESC LIMIT 1`).Scan(&kp.ID, &kp.Public, &kp.Private)
+
+	if err == sql.ErrNoRows {
+		return nil, kontrol.ErrNoKeyFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &kp, nil
+}
+
+// Rotate implements the KeyStore interface.
+func (p *PostgresKeyStore) Rotate(pair *kontrol.KeyPair, expire time.Time) error {
+	if err := p.Pairs.AddKey(pair); err != nil {
+		return err
+	}
+
+	_, err := p.DB.Exec(`UPDATE kite.key SET expires_at = $1
+		WHERE id <> $2 AND deleted_at IS NULL AND expires_at IS NULL AND public <> 'kontrol-self'`,
+		expire.UTC(), pair.ID)
+
+	return err
+}
+
+// DeleteExpired implements the KeyStore interface.
+func (p *PostgresKeyStore) DeleteExpired() error {
+	rows, err := p.DB.Query(`SELECT id FROM kite.key
+		WHERE deleted_at IS NULL AND expires_at <= (now() at time zone 'utc')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var ids []string
+
+	for rows.Next() {
+		var id string
+
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Deleting through the key pair storage invalidates
+	// its cache as well.
+	for _, id := range ids {
+		if err := deleteKey(p.Pairs, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Revoke implements the KeyStore interface.
+func (p *PostgresKeyStore) Revoke(r *Revocation) error {
+	var kiteID sql.NullString
+
+	if r.KiteID != "" {
+		kiteID = sql.NullString{String: r.KiteID, Valid: true}
+	}
+
+	var username sql.NullString
+
+	if r.Username != "" {
+		username = sql.NullString{String: r.Username, Valid: true}
+	}
+
+	_, err := p.DB.Exec(`INSERT INTO kite.revocation (kite_id, username, reason, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)`, kiteID, username, r.Reason, r.CreatedBy, r.CreatedAt.UTC())
+
+	return err
+}
+
+// Revocations implements the KeyStore interface.
+func (p *PostgresKeyStore) Revocations() ([]*Revocation, error) {
+	rows, err := p.DB.Query(`SELECT kite_id, username, reason, created_by, created_at FROM kite.revocation`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revocations []*Revocation
+
+	for rows.Next() {
+		var (
+			r        Revocation
+			kiteID   sql.NullString
+			username sql.NullString
+		)
+
+		if err := rows.Scan(&kiteID, &username, &r.Reason, &r.CreatedBy, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		r.KiteID = kiteID.String
+		r.Username = username.String
+
+		revocations = append(revocations, &r)
+	}
+
+	return revocations, rows.Err()
+}
+
+// MemKeyStore is a KeyStore, which keeps the state in memory.
+//
+// It is meant for single-instance kontrols, which do not use
+// Postgres storage, and for tests.
+type MemKeyStore struct {
+	Pairs kontrol.KeyPairStorage // adds and deletes key pairs
+
+	mu          sync.Mutex
+	current     *kontrol.KeyPair
+	expiring    []*kontrol.KeyPair
+	expires     []time.Time // expiration time of each expiring key pair
+	revocations []*Revocation
+}
+
+// NewMemKeyStore gives new MemKeyStore, which uses the given key
+// pair as the current one.
+func NewMemKeyStore(pairs kontrol.KeyPairStorage, current *kontrol.KeyPair) *MemKeyStore {
+	return &MemKeyStore{
+		Pairs:   pairs,
+		current: current,
+	}
+}
+
+// Current implements the KeyStore interface.
+func (m *MemKeyStore) Current() (*kontrol.KeyPair, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.current == nil {
+		return nil, kontrol.ErrNoKeyFound
+	}
+
+	return m.current, nil
+}
+
+// Rotate implements the KeyStore interface.
+func (m *MemKeyStore) Rotate(pair *kontrol.KeyPair, expire time.Time) error {
+	if err := m.Pairs.AddKey(pair); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.current != nil {
+		m.expiring = append(m.expiring, m.current)
+		m.expires = append(m.expires, expire)
+	}
+
+	m.current = pair
+
+	return nil
+}
+
+// DeleteExpired implements the KeyStore interface.
+func (m *MemKeyStore) DeleteExpired() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		now      = time.Now()
+		expiring []*kontrol.KeyPair
+		expires  []time.Time
+	)
+
+	for i, kp := range m.expiring {
+		if m.expires[i].After(now) {
+			expiring = append(expiring, kp)
+			expires = append(expires, m.expires[i])
+			continue
+		}
+
+		if err := deleteKey(m.Pairs, kp.ID); err != nil {
+			return err
+		}
+	}
+
+	m.expiring, m.expires = expiring, expires
+
+	return nil
+}
+
+// Revoke implements the KeyStore interface.
+func (m *MemKeyStore) Revoke(r *Revocation) error {
+	if r.KiteID == "" && r.Username == "" {
+		return errors.New("revocation has neither kite ID nor username")
+	}
+
+	m.mu.Lock()
+	m.revocations = append(m.revocations, r)
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Revocations implements the KeyStore interface.
+func (m *MemKeyStore) Revocations() ([]*Revocation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*Revocation(nil), m.revocations...), nil
+}
+
+// deleteKey deletes the key pair with the given ID. The public key
+// is looked up by the storage, so it is removed from memory caches.
+func deleteKey(pairs kontrol.KeyPairStorage, id string) error {
+	switch err := pairs.DeleteKey(&kontrol.KeyPair{ID: id}); err {
+	case nil, cache.ErrNotFound, kontrol.ErrKeyDeleted:
+		return nil
+	default:
+		return err
+	}
+}
+
+// kontrolPairs is a key pair storage used by kontrol.
+//
+// Kontrol updates kite keys and issues tokens for kites registered
+// with deleted key pairs using the key pair that signed its own kite
+// key. Deleted key pairs are reported as missing ones instead, so
+// kontrol uses its MachineKeyPicker, which gives the current key pair.
+type kontrolPairs struct {
+	kontrol.KeyPairStorage
+	self *kontrol.KeyPair // signs kontrol's own kite key
+}
+
+// AddKey implements the kontrol.KeyPairStorage interface.
+//
+// Adding a key pair, which is already stored, is a no-op.
+func (k *kontrolPairs) AddKey(pair *kontrol.KeyPair) error {
+	if p, err := k.KeyPairStorage.GetKeyFromID(pair.ID); err == nil && p.Public == pair.Public {
+		return nil
+	}
+
+	return k.KeyPairStorage.AddKey(pair)
+}
+
+// GetKeyFromID implements the kontrol.KeyPairStorage interface.
+func (k *kontrolPairs) GetKeyFromID(id string) (*kontrol.KeyPair, error) {
+	if k.self != nil && k.self.ID == id {
+		// Tokens for kontrol are verified with its own kite key.
+		return k.self, nil
+	}
+
+	pair, err := k.KeyPairStorage.GetKeyFromID(id)
+	return pair, notDeleted(err)
+}
+
+// GetKeyFromPublic implements the kontrol.KeyPairStorage interface.
+func (k *kontrolPairs) GetKeyFromPublic(public string) (*kontrol.KeyPair, error) {
+	pair, err := k.KeyPairStorage.GetKeyFromPublic(public)
+	return pair, notDeleted(err)
+}
+
+// IsValid implements the kontrol.KeyPairStorage interface.
+func (k *kontrolPairs) IsValid(public string) error {
+	return notDeleted(k.KeyPairStorage.IsValid(public))
+}
+
+func notDeleted(err error) error {
+	if err == kontrol.ErrKeyDeleted {
+		return kontrol.ErrNoKeyFound
+	}
+
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"koding/db/mongodb/modelhelper"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/koding/kite"
 	"github.com/koding/kite/kontrol"
+	uuid "github.com/satori/go.uuid"
 	"gopkg.in/throttled/throttled.v2"
 	"gopkg.in/throttled/throttled.v2/store/memstore"
 )
@@ -55,47 +57,78 @@ func New(c *Config) *kontrol.Kontrol {
 	// TODO: Move the metrics instance somewhere meaningful
 	met := common.MustInitMetrics(Name)
 
+	// Previous key pairs are no longer trusted after
+	// the grace period of key rotation ends.
+	keys := &Keys{}
+	kiteConf.VerifyFunc = keys.Verify
+
 	kon := kontrol.NewWithoutHandlers(kiteConf, Version)
 	kon.TokenNoNBF = true
 
+	keys.Log = kon.Kite.Log
+	getKites := keys.HandleGetKites(kon.HandleGetKites)
+
 	kon.Kite.HandleFunc("register",
-		metrics.WrapKiteHandler(met, "HandleRegister", kon.HandleRegister),
+		metrics.WrapKiteHandler(met, "HandleRegister", keys.HandleRegister(kon.HandleRegister)),
 	)
 
 	kon.Kite.HandleFunc("registerMachine",
-		metrics.WrapKiteHandler(met, "HandleMachine", kon.HandleMachine),
+		metrics.WrapKiteHandler(met, "HandleMachine", keys.HandleMachine(kon.HandleMachine)),
 	).DisableAuthentication()
 
 	kon.Kite.HandleFunc("getKodingKites",
 		metrics.WrapKiteHandler(
-			met, "HandleGetKodingKites", HandleGetKodingKites(kon.HandleGetKites, kiteConf.Environment),
+			met, "HandleGetKodingKites", HandleGetKodingKites(getKites, kiteConf.Environment),
 		),
 	)
 
 	kon.Kite.HandleFunc("getKites",
-		metrics.WrapKiteHandler(met, "HandleGetKites", kon.HandleGetKites),
+		metrics.WrapKiteHandler(met, "HandleGetKites", getKites),
 	)
 	kon.Kite.HandleFunc("getToken",
-		metrics.WrapKiteHandler(met, "HandleGetToken", kon.HandleGetToken),
+		metrics.WrapKiteHandler(met, "HandleGetToken", keys.HandleGetToken(kon.HandleGetToken)),
 	)
 	kon.Kite.HandleFunc("getKey",
-		metrics.WrapKiteHandler(met, "HandleGetKey", kon.HandleGetKey),
+		metrics.WrapKiteHandler(met, "HandleGetKey", keys.HandleGetKey(kon.HandleGetKey)),
+	)
+
+	kon.Kite.HandleFunc("rotateKey",
+		metrics.WrapKiteHandler(met, "HandleRotateKey", keys.RotateKey),
+	)
+	kon.Kite.HandleFunc("revoke",
+		metrics.WrapKiteHandler(met, "HandleRevoke", keys.Revoke),
 	)
 
 	kon.Kite.HandleHTTPFunc("/heartbeat",
-		metrics.WrapHTTPHandler(met, "HandleHeartbeat", kon.HandleHeartbeat),
+		metrics.WrapHTTPHandler(met, "HandleHeartbeat", keys.HandleHeartbeat(kon.HandleHeartbeat)),
 	)
 
 	kon.Kite.HandleHTTP("/register", throttledHandler(
-		metrics.WrapHTTPHandler(met, "HandleRegisterHTTP", kon.HandleRegisterHTTP),
+		metrics.WrapHTTPHandler(met, "HandleRegisterHTTP", keys.HandleRegisterHTTP(kon.HandleRegisterHTTP)),
 	))
 
+	kon.AddAuthenticator("kiteKey", keys.Authenticator(kon.Kite.AuthenticateFromKiteKey))
+	kon.AddAuthenticator("token", keys.Authenticator(kon.Kite.AuthenticateFromToken))
 	kon.AddAuthenticator("sessionID", authenticateFromSessionID)
 	kon.MachineAuthenticate = authenticateMachine
 
+	// New kite keys are signed with the current key pair.
+	kon.MachineKeyPicker = keys.pickKey
+
+	selfPair := &kontrol.KeyPair{
+		ID:      uuid.NewV4().String(),
+		Public:  strings.TrimSpace(string(publicKey)),
+		Private: strings.TrimSpace(string(privateKey)),
+	}
+
 	switch c.Storage {
 	case "etcd":
-		kon.SetStorage(kontrol.NewEtcd(c.Machines, kon.Kite.Log))
+		etcd := kontrol.NewEtcd(c.Machines, kon.Kite.Log)
+		kon.SetStorage(etcd)
+
+		keys.Storage = etcd
+		keys.Pairs = kontrol.NewMemKeyPairStorage()
+		keys.Store = NewMemKeyStore(keys.Pairs, selfPair)
 	case "postgres":
 		postgresConf := &kontrol.PostgresConfig{
 			Host:     c.Postgres.Host,
@@ -107,18 +140,67 @@ func New(c *Config) *kontrol.Kontrol {
 		p := kontrol.NewPostgres(postgresConf, kon.Kite.Log)
 		p.DB.SetMaxOpenConns(20)
 		kon.SetStorage(p)
+		keys.Storage = p
 
 		s := kontrol.NewCachedStorage(
 			p,
 			kontrol.NewMemKeyPairStorageTTL(time.Minute*5),
 		)
-		kon.SetKeyPairStorage(s)
 		// kon.MachineKeyPicker = newMachineKeyPicker(p)
+
+		keys.Pairs = s
+		keys.Store = &PostgresKeyStore{
+			DB:    p.DB,
+			Pairs: s,
+		}
 	default:
 		panic(fmt.Sprintf("storage is not found: %q", c.Storage))
 	}
 
-	kon.AddKeyPair("", string(publicKey), string(privateKey))
+	pairs := &kontrolPairs{KeyPairStorage: keys.Pairs}
+	kon.SetKeyPairStorage(pairs)
+
+	// The key pair is already stored if kontrol was started before.
+	switch pair, err := keys.Pairs.GetKeyFromPublic(selfPair.Public); err {
+	case nil:
+		selfPair = pair
+	case kontrol.ErrKeyDeleted:
+		// The key pair was rotated, the current one is loaded below.
+	default:
+		if err := keys.Pairs.AddKey(selfPair); err != nil {
+			kon.Kite.Log.Error("unable to add key pair %s: %s", selfPair.ID, err)
+		}
+	}
+
+	if err := keys.Refresh(); err != nil {
+		kon.Kite.Log.Error("unable to load keys: %s", err)
+	}
+
+	// Kontrol's kite key is re-signed with the current key pair, so
+	// kontrol does not sign anything with a rotated one. The kite is
+	// not running yet, thus its config is safe to modify.
+	pair, err := keys.currentKeyPair()
+	if err != nil {
+		kon.Kite.Log.Error("unable to load current key pair: %s", err)
+		pair = selfPair
+	} else if kiteKey := kon.Kite.Config.KiteKey; kiteKey != "" {
+		switch newKey, _, err := keys.update(kiteKey); {
+		case err != nil:
+			kon.Kite.Log.Error("unable to re-sign kontrol kite key: %s", err)
+			pair = selfPair
+		case newKey != "":
+			kon.Kite.Config.KiteKey = newKey
+			kon.Kite.Config.KontrolKey = pair.Public
+		}
+	}
+
+	pairs.self = pair
+
+	if err := kon.AddKeyPair(pair.ID, pair.Public, pair.Private); err != nil {
+		kon.Kite.Log.Error("unable to add key pair %s: %s", pair.ID, err)
+	}
+
+	go keys.RefreshEvery(time.Minute)
 
 	if c.TLSKeyFile != "" && c.TLSCertFile != "" {
 		kon.Kite.UseTLSFile(c.TLSCertFile, c.TLSKeyFile)
@@ -1,5 +1,7 @@
 package terraformer
 
+import "time"
+
 // Config defines the configuration.
 type Config struct {
 	// Port
@@ -21,6 +23,30 @@ type Config struct {
 	// LocalStorePath stores base path for local store
 	LocalStorePath string `required:"true"`
 
+	// EncryptionKeys enables encryption of states and plans at rest.
+	// The keys are given as "id1:base64key1,id2:base64key2", where
+	// each key is 32 bytes long. The first key is used to encrypt,
+	// the others are kept to decrypt objects written before key
+	// rotation - such objects are re-encrypted on read.
+	EncryptionKeys string
+
+	// MongoURL is used to share state locks between multiple
+	// terraformers. If empty, locks are kept in local files,
+	// which are visible to a single host only - it is required
+	// when more than one terraformer shares the remote storage.
+	MongoURL string
+
+	// LockTTL is a lease time of state locks.
+	LockTTL time.Duration `default:"10m"`
+
+	// Concurrency is a number of requests, which are allowed
+	// to run concurrently for a single provider.
+	Concurrency int `default:"4"`
+
+	// ProviderConcurrency overwrites Concurrency for the
+	// given providers, e.g. "aws=8,vagrant=2".
+	ProviderConcurrency string
+
 	// SecretKey is used for kite-to-kite communication.
 	SecretKey string
 
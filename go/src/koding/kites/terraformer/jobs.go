@@ -0,0 +1,96 @@
+package terraformer
+
+import (
+	"errors"
+	"fmt"
+
+	"koding/kites/terraformer/queue"
+
+	"github.com/koding/kite"
+)
+
+// CancelRequest is a request value of cancel kite method.
+type CancelRequest struct {
+	ContentID string
+
+	// TraceID, if not empty, cancels only the
+	// request with the given trace ID.
+	TraceID string
+}
+
+// QueueRequest is a request value of queue kite method.
+type QueueRequest struct {
+	// ContentID, if not empty, limits the response
+	// to the requests of the given content.
+	ContentID string
+}
+
+// Cancel provides a kite call for canceling requests of the given
+// content. Queued requests are removed from the queue, the running
+// one is interrupted.
+func (t *Terraformer) Cancel(r *kite.Request) (interface{}, error) {
+	var args CancelRequest
+	if err := r.Args.One().Unmarshal(&args); err != nil {
+		return nil, err
+	}
+
+	if args.ContentID == "" {
+		return nil, errors.New("contentID is not set")
+	}
+
+	running, err := t.Queue.Cancel(args.ContentID, args.TraceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if running != nil {
+		t.Log.Info("%s: interrupting %s of %q", args.ContentID, running.Method, running.TraceID)
+
+		if err := t.Context.Cancel(args.ContentID); err != nil {
+			return nil, fmt.Errorf("unable to interrupt %s: %s", running.Method, err)
+		}
+	}
+
+	return true, nil
+}
+
+// QueueStatus provides a kite call for listing running
+// and queued requests.
+//
+// It responds with []*queue.Status.
+func (t *Terraformer) QueueStatus(r *kite.Request) (interface{}, error) {
+	var args QueueRequest
+
+	if r.Args != nil {
+		if err := r.Args.One().Unmarshal(&args); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.Queue.Status(args.ContentID), nil
+}
+
+// enqueue waits until the request is allowed to run.
+//
+// While the request is queued, its position is reported
+// back with the output callback.
+func (t *Terraformer) enqueue(args *TerraformRequest, method string) (done func(), err error) {
+	job := &queue.Job{
+		ContentID: args.ContentID,
+		TraceID:   args.TraceID,
+		Provider:  args.Provider,
+		Method:    method,
+	}
+
+	if args.OnOutput.IsValid() {
+		job.OnPosition = func(position int) {
+			args.OnOutput.Call(fmt.Sprintf("Waiting for %s, position in queue: %d", method, position))
+		}
+	}
+
+	if err := t.Queue.Enqueue(job); err != nil {
+		return nil, err
+	}
+
+	return func() { t.Queue.Done(job) }, nil
+}
@@ -33,6 +33,17 @@ func NewKite(t *Terraformer, conf *Config) (*kite.Kite, error) {
 	k.HandleFunc(wrapHandler(t.Metrics, "apply", t.Apply))
 	k.HandleFunc(wrapHandler(t.Metrics, "destroy", t.Destroy))
 	k.HandleFunc(wrapHandler(t.Metrics, "plan", t.Plan))
+	k.HandleFunc(wrapHandler(t.Metrics, "refresh", t.Refresh))
+	k.HandleFunc(wrapHandler(t.Metrics, "logs", t.Logs))
+	k.HandleFunc(wrapHandler(t.Metrics, "cancel", t.Cancel))
+	k.HandleFunc(wrapHandler(t.Metrics, "queue", t.QueueStatus))
+
+	// State handling methods
+	k.HandleFunc(wrapHandler(t.Metrics, "state.lock", t.StateLock))
+	k.HandleFunc(wrapHandler(t.Metrics, "state.unlock", t.StateUnlock))
+	k.HandleFunc(wrapHandler(t.Metrics, "state.forceUnlock", t.StateForceUnlock))
+	k.HandleFunc(wrapHandler(t.Metrics, "state.revisions", t.StateRevisions))
+	k.HandleFunc(wrapHandler(t.Metrics, "state.rollback", t.StateRollback))
 
 	// artifact handling
 	k.HandleHTTPFunc("/healthCheck", artifact.HealthCheckHandler(Name))
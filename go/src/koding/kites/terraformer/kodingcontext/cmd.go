@@ -3,8 +3,12 @@ package kodingcontext
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"path"
 
+	"koding/kites/terraformer/storage"
+
 	"github.com/mitchellh/cli"
 )
 
@@ -22,6 +26,11 @@ func (c *KodingContext) run(cmd cli.Command, content io.Reader, destroy bool, ar
 		return nil, err
 	}
 
+	// ensure the state was not modified outside of terraformer
+	if err := c.verifyState(paths); err != nil {
+		return nil, err
+	}
+
 	if !destroy && content != nil {
 		// override the current main file
 		if err := c.LocalStorage.Write(paths.mainRelativePath, content); err != nil {
@@ -31,13 +40,22 @@ func (c *KodingContext) run(cmd cli.Command, content io.Reader, destroy bool, ar
 
 	exitCode := cmd.Run(argsFunc(paths, destroy))
 
+	if e := c.persistOutput(); e != nil {
+		c.log.Warning("%s: unable to persist output of %q: %s", c.ContentID, c.TraceID, e)
+	}
+
 	if exitCode != 0 {
 		err = fmt.Errorf("apply failed with code: %d, output: %s", exitCode, c.Buffer)
 	}
 
 	// copy all contents from local to remote for later operating
-	e := c.LocalStorage.Clone(c.ContentID, c.RemoteStorage)
-	if e != nil && err == nil {
+	if e := c.LocalStorage.Clone(c.ContentID, c.RemoteStorage); e != nil && err == nil {
+		err = e
+	}
+
+	// record the state even if the command or the copy failed,
+	// as they may have partially changed the stored state
+	if e := c.addRevision(); e != nil && err == nil {
 		err = e
 	}
 
@@ -48,6 +66,51 @@ func (c *KodingContext) run(cmd cli.Command, content io.Reader, destroy bool, ar
 	return paths, nil
 }
 
+func (c *KodingContext) verifyState(paths *paths) error {
+	state, err := ioutil.ReadFile(paths.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.Revisions.Verify(c.ContentID, state)
+}
+
+// addRevision records the state, which is kept in the remote
+// storage, as a new revision.
+//
+// The state is read back from the remote storage instead
+// of the local one, so the revision always describes what
+// is going to be verified by the next operation.
+func (c *KodingContext) addRevision() error {
+	state, err := storage.ReadAll(c.RemoteStorage, c.statePath())
+	if storage.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rev, err := c.Revisions.Add(c.ContentID, c.TraceID, state)
+	if err != nil {
+		return err
+	}
+
+	c.log.Debug("%s: state revision %d (checksum %s)", c.ContentID, rev.Revision, rev.Checksum)
+
+	return nil
+}
+
+// statePath gives path of the state file, relative
+// to the storage root.
+func (c *KodingContext) statePath() string {
+	return path.Join(c.ContentID, stateFileName+terraformStateFileExt)
+}
+
 type paths struct {
 	contentPath      string
 	statePath        string
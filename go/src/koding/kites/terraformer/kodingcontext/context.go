@@ -32,6 +32,7 @@ var (
 
 type Context interface {
 	Get(string, string) (*KodingContext, error)
+	Cancel(string) error
 	Shutdown() error
 }
 
@@ -42,6 +43,9 @@ type context struct {
 	RemoteStorage storage.Interface
 	LocalStorage  storage.Interface
 
+	// Revisions keeps revisions of states in the remote storage.
+	Revisions *storage.Revisions
+
 	Providers    map[string]terraform.ResourceProviderFactory
 	Provisioners map[string]terraform.ResourceProvisionerFactory
 
@@ -63,6 +67,7 @@ func New(ls, rs storage.Interface, log logging.Logger, debug bool) (*context, er
 		Provisioners:  config.ProvisionerFactories(),
 		LocalStorage:  ls,
 		RemoteStorage: rs,
+		Revisions:     &storage.Revisions{Storage: rs},
 		log:           log,
 		debug:         debug,
 	}
@@ -106,6 +111,29 @@ func (c *context) BroadcastForceShutdown() {
 	shutdownChansMu.Unlock()
 }
 
+// Cancel interrupts the current operation of the given content.
+//
+// Apply and destroy operations stop gracefully, plan and
+// refresh operations fail before processing next resource.
+// An error is returned if the operation does not acknowledge
+// the interrupt within a few seconds.
+func (c *context) Cancel(contentID string) error {
+	shutdownChansMu.Lock()
+	shutdownChan, ok := shutdownChans[contentID]
+	shutdownChansMu.Unlock()
+
+	if !ok {
+		return errors.New("content is not being operated on")
+	}
+
+	select {
+	case shutdownChan <- struct{}{}:
+		return nil
+	case <-time.After(5 * time.Second):
+		return errors.New("operation can not be interrupted")
+	}
+}
+
 // Shutdown shutsdown koding context
 func (c *context) Shutdown() error {
 	shutdown := make(chan struct{}, 1)
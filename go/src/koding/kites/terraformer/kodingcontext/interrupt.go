@@ -0,0 +1,60 @@
+package kodingcontext
+
+import (
+	"errors"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var errInterrupted = errors.New("operation was interrupted")
+
+// interruptHook fails plan and refresh operations at the next
+// resource once the operation was interrupted.
+//
+// Unlike apply, the terraform plan and refresh commands are
+// not able to listen on a shutdown channel.
+type interruptHook struct {
+	terraform.NilHook
+	done <-chan struct{}
+}
+
+var _ terraform.Hook = (*interruptHook)(nil)
+
+func (h *interruptHook) PreRefresh(*terraform.InstanceInfo, *terraform.InstanceState) (terraform.HookAction, error) {
+	return h.check()
+}
+
+func (h *interruptHook) PreDiff(*terraform.InstanceInfo, *terraform.InstanceState) (terraform.HookAction, error) {
+	return h.check()
+}
+
+func (h *interruptHook) check() (terraform.HookAction, error) {
+	select {
+	case <-h.done:
+		return terraform.HookActionHalt, errInterrupted
+	default:
+		return terraform.HookActionContinue, nil
+	}
+}
+
+// interruptHooks gives hooks, which fail the operation when
+// a message is received on the shutdown channel.
+//
+// The returned stop func must be called after the operation
+// finishes.
+func (c *KodingContext) interruptHooks() (hooks []terraform.Hook, stop func()) {
+	done := make(chan struct{})
+	stopCh := make(chan struct{})
+
+	c.interrupted = done
+
+	go func() {
+		select {
+		case <-c.ShutdownChan:
+			close(done)
+		case <-stopCh:
+		}
+	}()
+
+	return []terraform.Hook{&interruptHook{done: done}}, func() { close(stopCh) }
+}
@@ -12,6 +12,7 @@ import (
 
 func (c *context) newKodingContext(sc <-chan struct{}, contentID, traceID string) *KodingContext {
 	errorBuf := new(bytes.Buffer)
+	output := new(Output)
 
 	kc := &KodingContext{
 		context: context{
@@ -19,11 +20,14 @@ func (c *context) newKodingContext(sc <-chan struct{}, contentID, traceID string
 			Provisioners:  c.Provisioners,
 			LocalStorage:  c.LocalStorage,
 			RemoteStorage: c.RemoteStorage,
+			Revisions:     c.Revisions,
 			log:           c.log,
 		},
 		ContentID:    contentID,
+		TraceID:      traceID,
 		Buffer:       errorBuf,
-		ui:           NewUI(errorBuf, traceID),
+		Output:       output,
+		ui:           &outputUi{Ui: NewUI(errorBuf, traceID), out: output},
 		ShutdownChan: sc,
 		debug:        c.debug,
 	}
@@ -37,12 +41,15 @@ type KodingContext struct {
 	context
 
 	Buffer       *bytes.Buffer
-	ui           *cli.PrefixedUi
+	Output       *Output // terraform output, persisted per TraceID
+	ui           cli.Ui
 	Variables    map[string]interface{}
 	ShutdownChan <-chan struct{}
 	ContentID    string
+	TraceID      string
 
-	debug bool
+	interrupted <-chan struct{} // closed when plan or refresh is interrupted
+	debug       bool
 }
 
 // TerraformContextOpts creates a basic context options for terraform itself
@@ -0,0 +1,125 @@
+package kodingcontext
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+
+	"koding/kites/terraformer/storage"
+)
+
+// LogsPrefix is a path in the remote storage, under which
+// terraform output is persisted for each trace ID.
+const LogsPrefix = "logs"
+
+// Output collects terraform UI output of a single context.
+//
+// Each complete line written to Output is passed to the
+// subscribed funcs and buffered, until it gets persisted.
+type Output struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	line []byte
+	fns  []func(string)
+}
+
+// Subscribe registers fn, which is going to be called
+// for each line of the output.
+func (o *Output) Subscribe(fn func(line string)) {
+	o.mu.Lock()
+	o.fns = append(o.fns, fn)
+	o.mu.Unlock()
+}
+
+// Write implements the io.Writer interface.
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.buf.Write(p)
+	o.line = append(o.line, p...)
+
+	for {
+		i := bytes.IndexByte(o.line, '\n')
+		if i == -1 {
+			break
+		}
+
+		o.emit(string(o.line[:i]))
+		o.line = o.line[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush passes the remaining incomplete line, if any,
+// to the subscribed funcs.
+func (o *Output) Flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.line) != 0 {
+		o.emit(string(o.line))
+		o.line = nil
+	}
+}
+
+// reset gives the buffered output and resets the buffer.
+func (o *Output) reset() []byte {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p := make([]byte, o.buf.Len())
+	copy(p, o.buf.Bytes())
+	o.buf.Reset()
+
+	return p
+}
+
+func (o *Output) emit(line string) {
+	line = strings.TrimRight(line, "\r")
+
+	for _, fn := range o.fns {
+		fn(line)
+	}
+}
+
+// ReadLogs reads terraform output persisted for the given trace ID.
+func ReadLogs(s storage.Interface, traceID string) ([]byte, error) {
+	r, err := s.Read(logsPath(traceID))
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	return ioutil.ReadAll(r)
+}
+
+// persistOutput appends the buffered output to the logs
+// of the context's trace ID.
+func (c *KodingContext) persistOutput() error {
+	c.Output.Flush()
+
+	p := c.Output.reset()
+
+	if c.TraceID == "" || len(p) == 0 {
+		return nil
+	}
+
+	logs, err := ReadLogs(c.RemoteStorage, c.TraceID)
+	if err != nil && !storage.IsNotExist(err) {
+		return err
+	}
+
+	return c.RemoteStorage.Write(logsPath(c.TraceID), bytes.NewReader(append(logs, p...)))
+}
+
+func logsPath(traceID string) string {
+	return path.Join(LogsPrefix, traceID+".log")
+}
@@ -11,10 +11,14 @@ import (
 
 // Plan plans the operation according to the given content
 func (c *KodingContext) Plan(content io.Reader, destroy bool) (*terraform.Plan, error) {
+	hooks, stop := c.interruptHooks()
+	defer stop()
+
 	cmd := &command.PlanCommand{
 		Meta: command.Meta{
 			ContextOpts: c.TerraformContextOpts(),
 			Ui:          c.ui,
+			ExtraHooks:  hooks,
 		},
 	}
 
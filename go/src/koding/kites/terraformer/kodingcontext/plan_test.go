@@ -0,0 +1,119 @@
+package kodingcontext
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"koding/kites/terraformer/storage"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/koding/logging"
+)
+
+const testTemplate = `{
+	"resource": {
+		"test_instance": {
+			"first": {},
+			"second": {
+				"depends_on": ["test_instance.first"]
+			}
+		}
+	}
+}`
+
+func TestPlanCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kodingcontext")
+	if err != nil {
+		t.Fatalf("TempDir()=%s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	log := logging.NewCustom("kodingcontext", false)
+
+	ls, err := storage.NewFile(dir+"/local", log)
+	if err != nil {
+		t.Fatalf("NewFile()=%s", err)
+	}
+
+	rs, err := storage.NewFile(dir+"/remote", log)
+	if err != nil {
+		t.Fatalf("NewFile()=%s", err)
+	}
+
+	diffing := make(chan struct{}, 2)
+	cancelled := make(chan struct{})
+
+	p := &terraform.MockResourceProvider{
+		ResourcesReturn: []terraform.ResourceType{{Name: "test_instance"}},
+		DiffFn: func(*terraform.InstanceInfo, *terraform.InstanceState, *terraform.ResourceConfig) (*terraform.InstanceDiff, error) {
+			diffing <- struct{}{}
+			<-cancelled
+			return &terraform.InstanceDiff{
+				Attributes: map[string]*terraform.ResourceAttrDiff{
+					"id": {NewComputed: true, RequiresNew: true},
+				},
+			}, nil
+		},
+	}
+
+	c := &context{
+		LocalStorage:  ls,
+		RemoteStorage: rs,
+		Revisions:     &storage.Revisions{Storage: rs},
+		Providers: map[string]terraform.ResourceProviderFactory{
+			"test": terraform.ResourceProviderFactoryFixed(p),
+		},
+		log: log,
+	}
+
+	shutdownChans = make(map[string]chan struct{})
+
+	kc, err := c.Get("content", "trace")
+	if err != nil {
+		t.Fatalf("Get()=%s", err)
+	}
+	defer kc.Close()
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := kc.Plan(strings.NewReader(testTemplate), false)
+		done <- err
+	}()
+
+	select {
+	case <-diffing:
+	case err := <-done:
+		t.Fatalf("Plan()=%v, want it to block", err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for plan to start")
+	}
+
+	if err := c.Cancel("content"); err != nil {
+		t.Fatalf("Cancel()=%s", err)
+	}
+
+	select {
+	case <-kc.interrupted:
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for plan to be interrupted")
+	}
+
+	close(cancelled)
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), errInterrupted.Error()) {
+			t.Fatalf("Plan()=%v, want %q error", err, errInterrupted)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for plan to finish")
+	}
+
+	if n := len(diffing); n != 0 {
+		t.Fatalf("got %d more resources diffed, want 0", n)
+	}
+}
@@ -0,0 +1,58 @@
+package kodingcontext
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform/command"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Refresh updates the stored state with the real-world resources,
+// using the template from the last apply operation.
+func (c *KodingContext) Refresh() (*terraform.State, error) {
+	hooks, stop := c.interruptHooks()
+	defer stop()
+
+	cmd := &command.RefreshCommand{
+		Meta: command.Meta{
+			ContextOpts: c.TerraformContextOpts(),
+			Ui:          c.ui,
+			ExtraHooks:  hooks,
+		},
+	}
+
+	paths, err := c.run(cmd, nil, false, c.populateRefreshArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	stateFile, err := os.Open(paths.statePath)
+	if err != nil {
+		return nil, err
+	}
+	defer stateFile.Close()
+
+	return terraform.ReadState(stateFile)
+}
+
+func (c *KodingContext) populateRefreshArgs(paths *paths, _ bool) []string {
+	// generate base args
+	args := []string{
+		"-no-color", // dont write with color
+		"-state", paths.statePath,
+		"-state-out", paths.statePath,
+		"-input=false", // do not ask for any input
+		paths.contentPath,
+	}
+
+	var vars []string
+	for key, val := range c.Variables {
+		// Set a variable in the Terraform configuration. This flag can be set
+		// multiple times.
+		vars = append(vars, "-var", fmt.Sprintf("%s=%s", key, val))
+	}
+
+	// prepend vars if there are
+	return append(vars, args...)
+}
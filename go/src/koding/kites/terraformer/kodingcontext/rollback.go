@@ -0,0 +1,70 @@
+package kodingcontext
+
+import (
+	"bytes"
+
+	"koding/kites/terraformer/storage"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Rollback replaces the current state with the state of the
+// given revision. The rolled back state is recorded as a new
+// revision.
+func (c *KodingContext) Rollback(revision int) (*terraform.State, error) {
+	p, err := c.Revisions.Read(c.ContentID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := terraform.ReadState(bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.RemoteStorage.Write(c.statePath(), bytes.NewReader(p)); err != nil {
+		return nil, err
+	}
+
+	rev, err := c.Revisions.Add(c.ContentID, c.TraceID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Info("%s: rolled back to revision %d as revision %d", c.ContentID, revision, rev.Revision)
+
+	return state, nil
+}
+
+// Rebaseline records the current state as a new revision,
+// without verifying it against the most recent one.
+//
+// It is used to recover from an operation, which failed after
+// writing the state but before recording its revision - the
+// state would otherwise fail verification until rolled back.
+//
+// If there is no state or it already matches the most recent
+// revision, Rebaseline returns nil revision.
+func (c *KodingContext) Rebaseline() (*storage.Revision, error) {
+	p, err := storage.ReadAll(c.RemoteStorage, c.statePath())
+	if storage.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Revisions.Verify(c.ContentID, p); err == nil {
+		return nil, nil
+	}
+
+	rev, err := c.Revisions.Add(c.ContentID, c.TraceID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Warning("%s: state rebaselined as revision %d (checksum %s)", c.ContentID, rev.Revision, rev.Checksum)
+
+	return rev, nil
+}
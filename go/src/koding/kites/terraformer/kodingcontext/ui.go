@@ -43,3 +43,32 @@ func NewUI(w io.Writer, traceID string) *cli.PrefixedUi {
 		},
 	}
 }
+
+// outputUi is a cli.Ui, which additionally writes
+// all messages, without prefixes, to the output.
+type outputUi struct {
+	cli.Ui
+	out io.Writer
+}
+
+var _ cli.Ui = (*outputUi)(nil)
+
+func (u *outputUi) Output(s string) {
+	u.Ui.Output(s)
+	fmt.Fprintln(u.out, s)
+}
+
+func (u *outputUi) Info(s string) {
+	u.Ui.Info(s)
+	fmt.Fprintln(u.out, s)
+}
+
+func (u *outputUi) Warn(s string) {
+	u.Ui.Warn(s)
+	fmt.Fprintln(u.out, s)
+}
+
+func (u *outputUi) Error(s string) {
+	u.Ui.Error(s)
+	fmt.Fprintln(u.out, s)
+}
@@ -0,0 +1,59 @@
+package terraformer
+
+import (
+	"errors"
+
+	"koding/kites/terraformer/kodingcontext"
+
+	"github.com/koding/kite"
+)
+
+// LogsRequest is a request value of logs kite method.
+type LogsRequest struct {
+	TraceID string
+}
+
+// Logs provides a kite call for reading terraform output
+// of the operations, which were run with the given trace ID.
+//
+// It responds with the output as a string.
+func (t *Terraformer) Logs(r *kite.Request) (interface{}, error) {
+	var args LogsRequest
+	if err := r.Args.One().Unmarshal(&args); err != nil {
+		return nil, err
+	}
+
+	if args.TraceID == "" {
+		return nil, errors.New("traceID is not set")
+	}
+
+	p, err := kodingcontext.ReadLogs(t.Storage, args.TraceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(p), nil
+}
+
+// stream sends the terraform output of the context back
+// to the caller, if the request has an output callback.
+func (t *Terraformer) stream(c *kodingcontext.KodingContext, args *TerraformRequest) {
+	if !args.OnOutput.IsValid() {
+		return
+	}
+
+	failed := false
+
+	c.Output.Subscribe(func(line string) {
+		if failed {
+			return
+		}
+
+		if err := args.OnOutput.Call(line); err != nil {
+			t.Log.Debug("%s: failed to send output of %q: %s", args.ContentID, args.TraceID, err)
+
+			// The caller is gone, there is no point in trying further.
+			failed = true
+		}
+	})
+}
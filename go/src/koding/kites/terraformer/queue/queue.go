@@ -0,0 +1,344 @@
+// Package queue implements a job queue for terraformer requests, which
+// limits the number of concurrently running jobs per provider and
+// serializes jobs operating on the same content.
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is a default number of jobs, which are
+// allowed to run concurrently for a single provider.
+const DefaultConcurrency = 4
+
+var (
+	// ErrCanceled is returned by Enqueue when the job was
+	// canceled before it started.
+	ErrCanceled = errors.New("job was canceled")
+
+	// ErrClosed is returned by Enqueue when the queue is
+	// closed and does not accept new jobs.
+	ErrClosed = errors.New("queue is closed")
+
+	// ErrNotFound is returned when no job matches
+	// the given ID.
+	ErrNotFound = errors.New("job not found")
+)
+
+// State describes a state of a job.
+type State string
+
+const (
+	Queued  State = "queued"
+	Running State = "running"
+)
+
+// Job describes a single terraformer request.
+type Job struct {
+	ContentID string
+	TraceID   string
+	Provider  string
+	Method    string
+
+	// OnPosition, if non-nil, is called each time the position
+	// of the queued job changes. The position is 1-based.
+	OnPosition func(position int)
+
+	created  time.Time
+	started  time.Time
+	position int
+	start    chan struct{}
+	cancel   chan struct{}
+	once     sync.Once
+}
+
+func (j *Job) stop() {
+	j.once.Do(func() { close(j.cancel) })
+}
+
+// Status describes a job in the queue.
+type Status struct {
+	ContentID string    `json:"contentId"`
+	TraceID   string    `json:"traceId,omitempty"`
+	Provider  string    `json:"provider,omitempty"`
+	Method    string    `json:"method"`
+	State     State     `json:"state"`
+	Position  int       `json:"position,omitempty"` // 1-based, set for queued jobs
+	Created   time.Time `json:"created"`
+	Started   time.Time `json:"started,omitempty"`
+}
+
+// Queue schedules jobs in the FIFO order.
+//
+// A job is started when no other job operates on the same content
+// and the number of running jobs of the provider is below its limit.
+//
+// The zero value for Queue uses DefaultConcurrency for every provider.
+type Queue struct {
+	// Concurrency is a default limit of running jobs per provider.
+	//
+	// If zero, DefaultConcurrency is used.
+	Concurrency int
+
+	// Limits overwrites Concurrency for the given providers.
+	Limits map[string]int
+
+	mu      sync.Mutex
+	pending []*Job
+	running map[string]*Job // maps content ID to a running job
+	count   map[string]int  // maps provider to a number of running jobs
+	closed  bool
+}
+
+// Enqueue adds the job to the queue and blocks until
+// the job is started.
+//
+// If the job was canceled before it started, ErrCanceled
+// is returned. If the queue is closed, ErrClosed is returned.
+//
+// The caller is responsible for calling Done once the job is finished.
+func (q *Queue) Enqueue(job *Job) error {
+	q.mu.Lock()
+
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+
+	job.created = time.Now().UTC()
+	job.start = make(chan struct{})
+	job.cancel = make(chan struct{})
+
+	q.pending = append(q.pending, job)
+	q.schedule()
+	q.mu.Unlock()
+
+	select {
+	case <-job.start:
+		return nil
+	case <-job.cancel:
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// The job may have been started while it was
+	// being canceled - in such case run it anyway.
+	select {
+	case <-job.start:
+		return nil
+	default:
+	}
+
+	q.remove(job)
+	q.schedule()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	return ErrCanceled
+}
+
+// Done marks the started job as finished.
+func (q *Queue) Done(job *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.running[job.ContentID] != job {
+		return
+	}
+
+	delete(q.running, job.ContentID)
+
+	if q.count[job.Provider]--; q.count[job.Provider] <= 0 {
+		delete(q.count, job.Provider)
+	}
+
+	q.schedule()
+}
+
+// Cancel cancels queued jobs of the given content. If traceID
+// is not empty, only the job with matching trace ID is canceled.
+//
+// If the content has a matching running job, it is returned,
+// so the caller can interrupt it.
+func (q *Queue) Cancel(contentID, traceID string) (running *Job, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	found := false
+
+	for _, job := range q.pending {
+		if job.ContentID == contentID && (traceID == "" || job.TraceID == traceID) {
+			job.stop()
+			found = true
+		}
+	}
+
+	if job, ok := q.running[contentID]; ok && (traceID == "" || job.TraceID == traceID) {
+		return job, nil
+	}
+
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	return nil, nil
+}
+
+// Status gives status of the running and queued jobs. If contentID
+// is not empty, only the jobs of the given content are returned.
+func (q *Queue) Status(contentID string) []*Status {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var status []*Status
+
+	for _, job := range q.running {
+		if contentID == "" || job.ContentID == contentID {
+			status = append(status, newStatus(job, Running))
+		}
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Started.Before(status[j].Started)
+	})
+
+	for _, job := range q.pending {
+		if contentID == "" || job.ContentID == contentID {
+			status = append(status, newStatus(job, Queued))
+		}
+	}
+
+	return status
+}
+
+// Close stops accepting new jobs and cancels the queued ones.
+// Running jobs are not affected.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+
+	for _, job := range q.pending {
+		job.stop()
+	}
+}
+
+// schedule starts the jobs, which are allowed to run and
+// reports new positions of the remaining ones.
+//
+// It must be called with q.mu held.
+func (q *Queue) schedule() {
+	if q.running == nil {
+		q.running = make(map[string]*Job)
+		q.count = make(map[string]int)
+	}
+
+	pending := q.pending[:0]
+
+	for _, job := range q.pending {
+		if _, ok := q.running[job.ContentID]; ok || q.count[job.Provider] >= q.limit(job.Provider) {
+			pending = append(pending, job)
+			continue
+		}
+
+		// A canceled job is waiting to be removed by Enqueue.
+		select {
+		case <-job.cancel:
+			pending = append(pending, job)
+			continue
+		default:
+		}
+
+		job.started = time.Now().UTC()
+		q.running[job.ContentID] = job
+		q.count[job.Provider]++
+		close(job.start)
+	}
+
+	q.pending = pending
+
+	for i, job := range q.pending {
+		if job.position != i+1 {
+			job.position = i + 1
+
+			if job.OnPosition != nil {
+				go job.OnPosition(job.position)
+			}
+		}
+	}
+}
+
+func (q *Queue) remove(job *Job) {
+	for i, j := range q.pending {
+		if j == job {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *Queue) limit(provider string) int {
+	if n, ok := q.Limits[provider]; ok && n > 0 {
+		return n
+	}
+
+	if q.Concurrency > 0 {
+		return q.Concurrency
+	}
+
+	return DefaultConcurrency
+}
+
+func newStatus(job *Job, state State) *Status {
+	s := &Status{
+		ContentID: job.ContentID,
+		TraceID:   job.TraceID,
+		Provider:  job.Provider,
+		Method:    job.Method,
+		State:     state,
+		Created:   job.created,
+		Started:   job.started,
+	}
+
+	if state == Queued {
+		s.Position = job.position
+	}
+
+	return s
+}
+
+// ParseLimits parses per-provider limits from the
+// "provider=limit,..." format, e.g. "aws=8,google=2".
+func ParseLimits(s string) (map[string]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	limits := make(map[string]int)
+
+	for _, kv := range strings.Split(s, ",") {
+		i := strings.IndexRune(kv, '=')
+		if i == -1 {
+			return nil, fmt.Errorf("invalid limit %q: missing '='", kv)
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(kv[i+1:]))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid limit %q: value must be a positive integer", kv)
+		}
+
+		limits[strings.TrimSpace(kv[:i])] = n
+	}
+
+	return limits, nil
+}
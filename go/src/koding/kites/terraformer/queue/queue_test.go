@@ -0,0 +1,157 @@
+package queue_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"koding/kites/terraformer/queue"
+)
+
+func enqueue(q *queue.Queue, job *queue.Job) <-chan error {
+	ch := make(chan error, 1)
+	go func() { ch <- q.Enqueue(job) }()
+	return ch
+}
+
+func wait(t *testing.T, ch <-chan error, want error) {
+	select {
+	case err := <-ch:
+		if err != want {
+			t.Fatalf("got %v, want %v", err, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the job")
+	}
+}
+
+func blocked(t *testing.T, ch <-chan error) {
+	select {
+	case err := <-ch:
+		t.Fatalf("expected job to be queued, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func states(status []*queue.Status) []string {
+	var s []string
+	for _, st := range status {
+		s = append(s, st.ContentID+":"+string(st.State))
+	}
+	return s
+}
+
+func TestQueue(t *testing.T) {
+	q := &queue.Queue{
+		Concurrency: 2,
+		Limits:      map[string]int{"vagrant": 1},
+	}
+
+	a := &queue.Job{ContentID: "a", Provider: "aws"}
+	b := &queue.Job{ContentID: "b", Provider: "aws"}
+	c := &queue.Job{ContentID: "c", Provider: "aws"}
+	a2 := &queue.Job{ContentID: "a", Provider: "aws"}
+	v1 := &queue.Job{ContentID: "v1", Provider: "vagrant"}
+	v2 := &queue.Job{ContentID: "v2", Provider: "vagrant"}
+
+	wait(t, enqueue(q, a), nil)
+	wait(t, enqueue(q, b), nil)
+	wait(t, enqueue(q, v1), nil)
+
+	// The aws limit is reached.
+	cc := enqueue(q, c)
+	blocked(t, cc)
+
+	// The vagrant limit is reached.
+	cv2 := enqueue(q, v2)
+	blocked(t, cv2)
+
+	want := []string{"c:queued", "v2:queued"}
+	if got := states(q.Status("")[3:]); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	q.Done(b)
+	wait(t, cc, nil)
+
+	// The content is already being operated on.
+	ca2 := enqueue(q, a2)
+	blocked(t, ca2)
+
+	if st := q.Status("a"); len(st) != 2 || st[1].State != queue.Queued || st[1].Position != 2 {
+		t.Fatalf("unexpected status of a: %v", states(st))
+	}
+
+	q.Done(a)
+	wait(t, ca2, nil)
+
+	q.Done(v1)
+	wait(t, cv2, nil)
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	q := &queue.Queue{Concurrency: 1}
+
+	a := &queue.Job{ContentID: "a", TraceID: "1"}
+	b := &queue.Job{ContentID: "b", TraceID: "2"}
+
+	wait(t, enqueue(q, a), nil)
+
+	cb := enqueue(q, b)
+	blocked(t, cb)
+
+	if _, err := q.Cancel("c", ""); err != queue.ErrNotFound {
+		t.Fatalf("got %v, want %v", err, queue.ErrNotFound)
+	}
+
+	running, err := q.Cancel("b", "2")
+	if err != nil || running != nil {
+		t.Fatalf("got %v, %v; want nil, nil", running, err)
+	}
+
+	wait(t, cb, queue.ErrCanceled)
+
+	if running, err := q.Cancel("a", ""); err != nil || running != a {
+		t.Fatalf("got %v, %v; want %v, nil", running, err, a)
+	}
+
+	cb = enqueue(q, &queue.Job{ContentID: "b"})
+	blocked(t, cb)
+
+	q.Close()
+
+	wait(t, cb, queue.ErrClosed)
+	wait(t, enqueue(q, &queue.Job{ContentID: "c"}), queue.ErrClosed)
+
+	q.Done(a)
+
+	if st := q.Status(""); len(st) != 0 {
+		t.Fatalf("got %v, want empty queue", states(st))
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	cases := map[string]struct {
+		s    string
+		want map[string]int
+		ok   bool
+	}{
+		"empty":    {"", nil, true},
+		"valid":    {"aws=8, google=2", map[string]int{"aws": 8, "google": 2}, true},
+		"no value": {"aws", nil, false},
+		"zero":     {"aws=0", nil, false},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := queue.ParseLimits(cas.s)
+			if (err == nil) != cas.ok {
+				t.Fatalf("got %v, want ok=%t", err, cas.ok)
+			}
+
+			if cas.ok && !reflect.DeepEqual(got, cas.want) {
+				t.Fatalf("got %v, want %v", got, cas.want)
+			}
+		})
+	}
+}
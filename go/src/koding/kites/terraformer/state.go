@@ -0,0 +1,269 @@
+package terraformer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"koding/kites/terraformer/storage"
+
+	"github.com/koding/kite"
+)
+
+// LockRequest is a request value of state.lock, state.unlock
+// and state.forceUnlock kite methods.
+type LockRequest struct {
+	ContentID string
+	TraceID   string
+
+	// LockID is required by state.unlock.
+	LockID string
+
+	// TTL is a lease time of a lock acquired with state.lock.
+	// If zero, Config.LockTTL is used.
+	TTL time.Duration
+}
+
+// RevisionsRequest is a request value of state.revisions
+// and state.rollback kite methods.
+type RevisionsRequest struct {
+	ContentID string
+	TraceID   string
+
+	// LockID is an ID of a lock acquired with state.lock.
+	LockID string
+
+	// Revision is required by state.rollback.
+	Revision int
+}
+
+// StateLock provides a kite call for locking a state.
+//
+// It responds with *storage.Lock, which ID is used to release
+// the lock and to run operations on the locked state.
+func (t *Terraformer) StateLock(r *kite.Request) (interface{}, error) {
+	var args LockRequest
+	if err := r.Args.One().Unmarshal(&args); err != nil {
+		return nil, err
+	}
+
+	if args.ContentID == "" {
+		return nil, errors.New("contentID is not set")
+	}
+
+	return t.Locker.Lock(args.ContentID, owner(args.TraceID, r), t.lockTTL(args.TTL))
+}
+
+// StateUnlock provides a kite call for releasing a lock
+// acquired with state.lock.
+func (t *Terraformer) StateUnlock(r *kite.Request) (interface{}, error) {
+	var args LockRequest
+	if err := r.Args.One().Unmarshal(&args); err != nil {
+		return nil, err
+	}
+
+	if args.ContentID == "" || args.LockID == "" {
+		return nil, errors.New("contentID and lockID are required")
+	}
+
+	if err := t.Locker.Unlock(args.ContentID, args.LockID); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// StateForceUnlock provides a kite call for releasing a lock
+// regardless of its owner.
+//
+// It is meant to be used when a lock holder crashed before
+// releasing it and waiting for the lease to expire is
+// not desired. The current state is recorded as a new
+// revision, if it does not match the most recent one.
+func (t *Terraformer) StateForceUnlock(r *kite.Request) (interface{}, error) {
+	var args LockRequest
+	if err := r.Args.One().Unmarshal(&args); err != nil {
+		return nil, err
+	}
+
+	if args.ContentID == "" {
+		return nil, errors.New("contentID is not set")
+	}
+
+	lock, err := t.Locker.Info(args.ContentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.Locker.ForceUnlock(args.ContentID); err != nil {
+		return nil, err
+	}
+
+	if lock != nil {
+		t.Log.Warning("%s: lock %s of %q was forcefully released by %q", args.ContentID, lock.ID, lock.Owner, owner(args.TraceID, r))
+	}
+
+	// The lock holder might have crashed after writing the state,
+	// but before recording its revision. Accept the current state,
+	// as otherwise it would fail verification until rolled back.
+	c, err := t.Context.Get(args.ContentID, args.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	if _, err := c.Rebaseline(); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// StateRevisions provides a kite call for listing revisions
+// of a state.
+//
+// It responds with []*storage.Revision.
+func (t *Terraformer) StateRevisions(r *kite.Request) (interface{}, error) {
+	var args RevisionsRequest
+	if err := r.Args.One().Unmarshal(&args); err != nil {
+		return nil, err
+	}
+
+	if args.ContentID == "" {
+		return nil, errors.New("contentID is not set")
+	}
+
+	// The revisions are read directly from the storage, so they
+	// can be listed while the state is being operated on.
+	revs := &storage.Revisions{
+		Storage: t.Storage,
+	}
+
+	return revs.List(args.ContentID)
+}
+
+// StateRollback provides a kite call for rolling back
+// a state to a prior revision.
+//
+// It responds with the rolled back *terraform.State.
+func (t *Terraformer) StateRollback(r *kite.Request) (interface{}, error) {
+	var args RevisionsRequest
+	if err := r.Args.One().Unmarshal(&args); err != nil {
+		return nil, err
+	}
+
+	if args.Revision == 0 {
+		return nil, errors.New("revision is not set")
+	}
+
+	unlock, err := t.lock(&TerraformRequest{
+		ContentID: args.ContentID,
+		TraceID:   args.TraceID,
+		LockID:    args.LockID,
+	}, r.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	c, err := t.Context.Get(args.ContentID, args.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	return c.Rollback(args.Revision)
+}
+
+// lock locks the state for the duration of an operation.
+//
+// If the request carries a lock ID, the method ensures the
+// state is locked with it instead, and does not release it.
+//
+// In both cases the lease of the lock is refreshed until
+// the returned unlock func is called.
+func (t *Terraformer) lock(args *TerraformRequest, method string) (unlock func(), err error) {
+	if args.ContentID == "" {
+		return nil, errors.New("contentID is not set")
+	}
+
+	ttl := t.lockTTL(0)
+
+	if args.LockID != "" {
+		lock, err := t.Locker.Info(args.ContentID)
+		if err != nil {
+			return nil, err
+		}
+
+		if lock == nil || lock.ID != args.LockID {
+			return nil, storage.ErrNotLocked
+		}
+
+		// The lock is held by the requester, keep its lease
+		// for the time of the operation.
+		if err := t.Locker.Refresh(lock.ContentID, lock.ID, ttl); err != nil {
+			return nil, err
+		}
+
+		return t.keepLock(lock, ttl), nil
+	}
+
+	lock, err := t.Locker.Lock(args.ContentID, fmt.Sprintf("%s (%s)", args.TraceID, method), ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := t.keepLock(lock, ttl)
+
+	return func() {
+		stop()
+
+		if err := t.Locker.Unlock(lock.ContentID, lock.ID); err != nil {
+			t.Log.Error("%s: failed to release lock %s: %s", lock.ContentID, lock.ID, err)
+		}
+	}, nil
+}
+
+// keepLock refreshes the lease of the lock until the returned
+// func is called.
+func (t *Terraformer) keepLock(lock *storage.Lock, ttl time.Duration) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := t.Locker.Refresh(lock.ContentID, lock.ID, ttl); err != nil {
+					t.Log.Error("%s: failed to refresh lock %s: %s", lock.ContentID, lock.ID, err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func (t *Terraformer) lockTTL(ttl time.Duration) time.Duration {
+	if ttl != 0 {
+		return ttl
+	}
+
+	if t.Config != nil && t.Config.LockTTL != 0 {
+		return t.Config.LockTTL
+	}
+
+	return storage.DefaultLockTTL
+}
+
+func owner(traceID string, r *kite.Request) string {
+	if traceID != "" {
+		return traceID
+	}
+
+	return r.Username
+}
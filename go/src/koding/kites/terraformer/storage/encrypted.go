@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"koding/kites/common/keyring"
+
+	"github.com/koding/logging"
+)
+
+// encryptedMagic prefixes objects written by Encrypted storage,
+// so they can be told apart from unencrypted ones.
+var encryptedMagic = []byte("kdenc:1\n")
+
+// DataKey is a key used to encrypt a single object.
+type DataKey struct {
+	KeyID     string // ID of the key encryption key
+	Plaintext []byte // used to encrypt the object, never stored
+	Wrapped   []byte // Plaintext encrypted with the key encryption key
+}
+
+// KeyService provides data keys for envelope encryption,
+// in a similar manner to a KMS.
+type KeyService interface {
+	// GenerateDataKey gives new data key wrapped
+	// with the primary key encryption key.
+	GenerateDataKey() (*DataKey, error)
+
+	// Decrypt unwraps the data key, which was wrapped
+	// with the given key encryption key.
+	Decrypt(keyID string, wrapped []byte) ([]byte, error)
+
+	// PrimaryKeyID gives ID of the key encryption key,
+	// which is used to wrap new data keys.
+	PrimaryKeyID() string
+}
+
+var _ KeyService = (*LocalKeys)(nil)
+
+// LocalKeys is a KeyService, which keeps key encryption keys
+// in memory. It is a local stand-in for a KMS.
+type LocalKeys struct {
+	Keyring *keyring.Keyring
+}
+
+// GenerateDataKey implements the KeyService interface.
+func (lk *LocalKeys) GenerateDataKey() (*DataKey, error) {
+	key, wrapped, err := lk.Keyring.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataKey{
+		KeyID:     lk.Keyring.Primary,
+		Plaintext: key,
+		Wrapped:   wrapped,
+	}, nil
+}
+
+// Decrypt implements the KeyService interface.
+func (lk *LocalKeys) Decrypt(keyID string, wrapped []byte) ([]byte, error) {
+	return lk.Keyring.Unwrap(keyID, wrapped)
+}
+
+// PrimaryKeyID implements the KeyService interface.
+func (lk *LocalKeys) PrimaryKeyID() string {
+	return lk.Keyring.Primary
+}
+
+// envelope is an encrypted object.
+type envelope struct {
+	KeyID string `json:"keyId"`
+	Key   []byte `json:"key"`  // data key wrapped with KEK
+	Data  []byte `json:"data"` // object encrypted with data key
+}
+
+var _ Interface = (*Encrypted)(nil)
+
+// Encrypted is a storage, which transparently encrypts
+// objects written to the underlying storage.
+//
+// Objects are read back in plaintext. Unencrypted objects,
+// written before encryption was enabled, and objects
+// encrypted with a key other than the primary one, are
+// re-encrypted with the primary key when they are read.
+type Encrypted struct {
+	storage Interface
+	keys    KeyService
+	log     logging.Logger
+}
+
+// NewEncrypted creates a new storage, which encrypts
+// objects of s with the given keys.
+func NewEncrypted(s Interface, keys KeyService, log logging.Logger) *Encrypted {
+	return &Encrypted{
+		storage: s,
+		keys:    keys,
+		log:     log,
+	}
+}
+
+// BasePath returns base path of the underlying storage.
+func (e *Encrypted) BasePath() (string, error) {
+	return e.storage.BasePath()
+}
+
+// Write encrypts the file and writes it to the underlying storage.
+func (e *Encrypted) Write(path string, file io.Reader) error {
+	p, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	return e.write(path, p)
+}
+
+// Read reads the file from the underlying storage and decrypts it.
+func (e *Encrypted) Read(path string) (io.Reader, error) {
+	r, err := e.storage.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := ioutil.ReadAll(r)
+	closeReader(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p, err = e.open(path, p); err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(p), nil
+}
+
+// Remove removes the file from the underlying storage.
+func (e *Encrypted) Remove(path string) error {
+	return e.storage.Remove(path)
+}
+
+// Clone clones the files to the target storage, decrypting them.
+func (e *Encrypted) Clone(path string, target Interface) error {
+	return e.storage.Clone(path, &decrypter{
+		Interface: target,
+		e:         e,
+	})
+}
+
+func (e *Encrypted) write(path string, p []byte) error {
+	dk, err := e.keys.GenerateDataKey()
+	if err != nil {
+		return err
+	}
+
+	data, err := keyring.Seal(dk.Plaintext, p)
+	if err != nil {
+		return err
+	}
+
+	env, err := json.Marshal(&envelope{
+		KeyID: dk.KeyID,
+		Key:   dk.Wrapped,
+		Data:  data,
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.storage.Write(path, io.MultiReader(bytes.NewReader(encryptedMagic), bytes.NewReader(env)))
+}
+
+// open decrypts the object read from the given path.
+//
+// If the object is not encrypted with the primary key,
+// it is re-encrypted.
+func (e *Encrypted) open(path string, p []byte) ([]byte, error) {
+	if !bytes.HasPrefix(p, encryptedMagic) {
+		e.reencrypt(path, p, "unencrypted")
+		return p, nil
+	}
+
+	var env envelope
+
+	if err := json.Unmarshal(p[len(encryptedMagic):], &env); err != nil {
+		return nil, fmt.Errorf("%q: unable to read encrypted object: %s", path, err)
+	}
+
+	key, err := e.keys.Decrypt(env.KeyID, env.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%q: unable to decrypt data key: %s", path, err)
+	}
+
+	if p, err = keyring.Open(key, env.Data); err != nil {
+		return nil, fmt.Errorf("%q: unable to decrypt: %s", path, err)
+	}
+
+	if env.KeyID != e.keys.PrimaryKeyID() {
+		e.reencrypt(path, p, "encrypted with "+env.KeyID+" key")
+	}
+
+	return p, nil
+}
+
+// reencrypt writes the object encrypted with the primary key.
+//
+// Failing to do so is not fatal, as the object is going
+// to be re-encrypted on next read.
+func (e *Encrypted) reencrypt(path string, p []byte, reason string) {
+	if err := e.write(path, p); err != nil {
+		e.log.Warning("%q: unable to re-encrypt object (%s): %s", path, reason, err)
+		return
+	}
+
+	e.log.Debug("%q: re-encrypted object (%s) with %s key", path, reason, e.keys.PrimaryKeyID())
+}
+
+// decrypter is used by Encrypted.Clone to decrypt
+// files written to the target storage.
+type decrypter struct {
+	Interface
+	e *Encrypted
+}
+
+func (d *decrypter) Write(path string, file io.Reader) error {
+	p, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	if p, err = d.e.open(path, p); err != nil {
+		return err
+	}
+
+	return d.Interface.Write(path, bytes.NewReader(p))
+}
@@ -0,0 +1,139 @@
+package storage_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"koding/kites/common/keyring"
+	"koding/kites/terraformer/storage"
+
+	"github.com/koding/logging"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func localKeys(t *testing.T, s string) *storage.LocalKeys {
+	kr, err := keyring.Parse(s)
+	if err != nil {
+		t.Fatalf("Parse()=%s", err)
+	}
+
+	return &storage.LocalKeys{Keyring: kr}
+}
+
+func readAll(t *testing.T, s storage.Interface, path string) string {
+	r, err := s.Read(path)
+	if err != nil {
+		t.Fatalf("Read(%q)=%s", path, err)
+	}
+
+	p, err := ioutil.ReadAll(r)
+	if c, ok := r.(io.Closer); ok {
+		c.Close()
+	}
+
+	if err != nil {
+		t.Fatalf("ReadAll(%q)=%s", path, err)
+	}
+
+	return string(p)
+}
+
+func TestEncrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraformer-encrypted")
+	if err != nil {
+		t.Fatalf("TempDir()=%s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	log := logging.NewLogger("test")
+
+	remote, err := storage.NewFile(filepath.Join(dir, "remote"), log)
+	if err != nil {
+		t.Fatalf("NewFile()=%s", err)
+	}
+
+	local, err := storage.NewFile(filepath.Join(dir, "local"), log)
+	if err != nil {
+		t.Fatalf("NewFile()=%s", err)
+	}
+
+	const state = `{"version":3,"secret":"private key"}`
+
+	raw := func(path string) []byte {
+		p, err := ioutil.ReadFile(filepath.Join(dir, "remote", path))
+		if err != nil {
+			t.Fatalf("ReadFile(%q)=%s", path, err)
+		}
+		return p
+	}
+
+	// Objects written before encryption was enabled.
+	if err := remote.Write("content/legacy.tfstate", bytes.NewReader([]byte(state))); err != nil {
+		t.Fatalf("Write()=%s", err)
+	}
+
+	old := localKeys(t, "old:"+key(1))
+
+	enc := storage.NewEncrypted(remote, old, log)
+
+	if err := enc.Write("content/state.tfstate", bytes.NewReader([]byte(state))); err != nil {
+		t.Fatalf("Write()=%s", err)
+	}
+
+	if p := raw("content/state.tfstate"); bytes.Contains(p, []byte("private key")) {
+		t.Fatalf("state is stored in plaintext: %s", p)
+	}
+
+	if got := readAll(t, enc, "content/state.tfstate"); got != state {
+		t.Fatalf("got %s, want %s", got, state)
+	}
+
+	// Unencrypted objects are readable and get encrypted.
+	if got := readAll(t, enc, "content/legacy.tfstate"); got != state {
+		t.Fatalf("got %s, want %s", got, state)
+	}
+
+	if p := raw("content/legacy.tfstate"); bytes.Contains(p, []byte("private key")) {
+		t.Fatalf("legacy state was not re-encrypted: %s", p)
+	}
+
+	// Rotate keys - objects encrypted with the old key are readable
+	// and are re-encrypted with the new one.
+	rotated := localKeys(t, "new:"+key(2)+",old:"+key(1))
+
+	enc = storage.NewEncrypted(remote, rotated, log)
+
+	if err := enc.Clone("content", local); err != nil {
+		t.Fatalf("Clone()=%s", err)
+	}
+
+	for _, path := range []string{"content/state.tfstate", "content/legacy.tfstate"} {
+		if got := readAll(t, local, path); got != state {
+			t.Fatalf("%s: got %s, want %s", path, got, state)
+		}
+	}
+
+	// The old key is no longer needed.
+	current := localKeys(t, "new:"+key(2))
+
+	enc = storage.NewEncrypted(remote, current, log)
+
+	if got := readAll(t, enc, "content/state.tfstate"); got != state {
+		t.Fatalf("got %s, want %s", got, state)
+	}
+
+	// Reading with unknown key fails.
+	enc = storage.NewEncrypted(remote, old, log)
+
+	if _, err := enc.Read("content/state.tfstate"); err == nil {
+		t.Fatal("expected Read() to fail with unknown key")
+	}
+}
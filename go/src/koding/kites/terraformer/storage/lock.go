@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultLockTTL is a default lease time of a state lock.
+const DefaultLockTTL = 10 * time.Minute
+
+// ErrNotLocked is returned when the state is not locked
+// with the given lock ID.
+var ErrNotLocked = errors.New("state is not locked with the given lock ID")
+
+// Lock describes a lease on a state of a single content.
+type Lock struct {
+	ID        string    `json:"id" bson:"lockId"`
+	ContentID string    `json:"contentId" bson:"_id"`
+	Owner     string    `json:"owner,omitempty" bson:"owner,omitempty"`
+	Created   time.Time `json:"created" bson:"created"`
+	Expires   time.Time `json:"expires" bson:"expires"`
+}
+
+// NewLock creates new lock value for the given content.
+//
+// If ttl is 0, DefaultLockTTL is used.
+func NewLock(contentID, owner string, ttl time.Duration) *Lock {
+	if ttl == 0 {
+		ttl = DefaultLockTTL
+	}
+
+	now := time.Now().UTC()
+
+	return &Lock{
+		ID:        newLockID(),
+		ContentID: contentID,
+		Owner:     owner,
+		Created:   now,
+		Expires:   now.Add(ttl),
+	}
+}
+
+// Expired tells whether the lease is expired.
+func (l *Lock) Expired() bool {
+	return l.Expires.Before(time.Now())
+}
+
+// LockedError is returned when the state is already locked.
+type LockedError struct {
+	Lock *Lock
+}
+
+// Error implements the built-in error interface.
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("state of %q is locked by %q since %s (lock ID %s, expires %s)",
+		e.Lock.ContentID, e.Lock.Owner, e.Lock.Created.Format(time.RFC3339),
+		e.Lock.ID, e.Lock.Expires.Format(time.RFC3339))
+}
+
+// Locker provides lease-based locking of terraform states.
+type Locker interface {
+	// Lock acquires a lock for the given content. If the
+	// content is already locked, *LockedError is returned.
+	Lock(contentID, owner string, ttl time.Duration) (*Lock, error)
+
+	// Refresh extends the lease of the lock.
+	Refresh(contentID, lockID string, ttl time.Duration) error
+
+	// Unlock releases the lock. If the content is not locked
+	// with the given lock ID, ErrNotLocked is returned.
+	Unlock(contentID, lockID string) error
+
+	// ForceUnlock releases the lock regardless of its owner.
+	ForceUnlock(contentID string) error
+
+	// Info gives the current lock of the content or nil,
+	// if the content is not locked.
+	Info(contentID string) (*Lock, error)
+}
+
+var _ Locker = (*FileLocker)(nil)
+
+// FileLocker provides state locking, which keeps locks
+// as files in a local directory.
+//
+// The locks are not shared between hosts, thus FileLocker
+// must not be used when more than one terraformer operates
+// on the same remote storage - use MongoLocker instead.
+type FileLocker struct {
+	dir string
+}
+
+// NewFileLocker creates a new locker for the given directory.
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileLocker{
+		dir: dir,
+	}, nil
+}
+
+// Lock implements the Locker interface.
+func (fl *FileLocker) Lock(contentID, owner string, ttl time.Duration) (*Lock, error) {
+	file, unguard, err := fl.guard(contentID)
+	if err != nil {
+		return nil, err
+	}
+	defer unguard()
+
+	old, err := fl.read(file)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	case !old.Expired():
+		return nil, &LockedError{Lock: old}
+	}
+
+	// The content is either not locked or the lease
+	// has expired - take the lock.
+	lock := NewLock(contentID, owner, ttl)
+
+	if err := fl.write(file, lock); err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// Refresh implements the Locker interface.
+func (fl *FileLocker) Refresh(contentID, lockID string, ttl time.Duration) error {
+	file, unguard, err := fl.guard(contentID)
+	if err != nil {
+		return err
+	}
+	defer unguard()
+
+	lock, err := fl.held(file, lockID)
+	if err != nil {
+		return err
+	}
+
+	if ttl == 0 {
+		ttl = DefaultLockTTL
+	}
+
+	lock.Expires = time.Now().UTC().Add(ttl)
+
+	return fl.write(file, lock)
+}
+
+// Unlock implements the Locker interface.
+func (fl *FileLocker) Unlock(contentID, lockID string) error {
+	file, unguard, err := fl.guard(contentID)
+	if err != nil {
+		return err
+	}
+	defer unguard()
+
+	if _, err := fl.held(file, lockID); err != nil {
+		return err
+	}
+
+	return os.Remove(file)
+}
+
+// ForceUnlock implements the Locker interface.
+func (fl *FileLocker) ForceUnlock(contentID string) error {
+	file, unguard, err := fl.guard(contentID)
+	if err != nil {
+		return err
+	}
+	defer unguard()
+
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Info implements the Locker interface.
+func (fl *FileLocker) Info(contentID string) (*Lock, error) {
+	file, err := fl.file(contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := fl.read(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lock.Expired() {
+		return nil, nil
+	}
+
+	return lock, nil
+}
+
+// guard takes an exclusive flock on the guard file of the content,
+// which serializes lock operations of all terraformers on the host.
+// The flock is released by the returned func or when the process
+// exits, thus a crashed locker does not leave the content guarded.
+func (fl *FileLocker) guard(contentID string) (file string, unguard func(), err error) {
+	file, err = fl.file(contentID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	f, err := os.OpenFile(file+".guard", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return "", nil, err
+	}
+
+	return file, func() { f.Close() }, nil
+}
+
+func (fl *FileLocker) held(file, lockID string) (*Lock, error) {
+	lock, err := fl.read(file)
+	if os.IsNotExist(err) {
+		return nil, ErrNotLocked
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lock.ID != lockID {
+		return nil, ErrNotLocked
+	}
+
+	return lock, nil
+}
+
+func (fl *FileLocker) read(file string) (*Lock, error) {
+	p, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+
+	if err := json.Unmarshal(p, &lock); err != nil {
+		return nil, fmt.Errorf("unable to read lock %q: %s", file, err)
+	}
+
+	return &lock, nil
+}
+
+// write replaces the lock file atomically, so readers
+// never see a partially written lock.
+func (fl *FileLocker) write(file string, lock *Lock) error {
+	p, err := json.Marshal(lock)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(fl.dir, ".lock")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(p)
+	err = nonil(err, f.Sync(), f.Close())
+
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return nil
+}
+
+func (fl *FileLocker) file(contentID string) (string, error) {
+	if contentID == "" || strings.ContainsAny(contentID, `/\`) || strings.HasPrefix(contentID, ".") {
+		return "", fmt.Errorf("invalid content ID: %q", contentID)
+	}
+
+	return filepath.Join(fl.dir, contentID+".lock"), nil
+}
+
+func newLockID() string {
+	p := make([]byte, 16)
+
+	if _, err := rand.Read(p); err != nil {
+		panic("unable to read random bytes: " + err.Error())
+	}
+
+	return hex.EncodeToString(p)
+}
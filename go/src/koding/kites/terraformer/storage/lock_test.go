@@ -0,0 +1,195 @@
+package storage_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"koding/kites/terraformer/storage"
+
+	"github.com/koding/logging"
+)
+
+func TestFileLocker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraformer-lock")
+	if err != nil {
+		t.Fatalf("TempDir()=%s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fl, err := storage.NewFileLocker(dir)
+	if err != nil {
+		t.Fatalf("NewFileLocker()=%s", err)
+	}
+
+	lock, err := fl.Lock("content", "alice", time.Minute)
+	if err != nil {
+		t.Fatalf("Lock()=%s", err)
+	}
+
+	if _, err := fl.Lock("content", "bob", time.Minute); err == nil {
+		t.Fatal("expected Lock() to fail for locked content")
+	} else if le, ok := err.(*storage.LockedError); !ok || le.Lock.ID != lock.ID {
+		t.Fatalf("got %#v, want *storage.LockedError for %s", err, lock.ID)
+	}
+
+	if err := fl.Refresh("content", lock.ID, time.Minute); err != nil {
+		t.Fatalf("Refresh()=%s", err)
+	}
+
+	if err := fl.Unlock("content", "invalid"); err != storage.ErrNotLocked {
+		t.Fatalf("got %v, want %v", err, storage.ErrNotLocked)
+	}
+
+	if err := fl.Unlock("content", lock.ID); err != nil {
+		t.Fatalf("Unlock()=%s", err)
+	}
+
+	if info, err := fl.Info("content"); err != nil || info != nil {
+		t.Fatalf("got %+v, %v; want nil, nil", info, err)
+	}
+
+	// An expired lock is stolen.
+	if _, err := fl.Lock("content", "alice", -time.Minute); err != nil {
+		t.Fatalf("Lock()=%s", err)
+	}
+
+	lock, err = fl.Lock("content", "bob", time.Minute)
+	if err != nil {
+		t.Fatalf("Lock()=%s", err)
+	}
+
+	if info, err := fl.Info("content"); err != nil || info == nil || info.Owner != "bob" {
+		t.Fatalf("got %+v, %v; want lock of bob", info, err)
+	}
+
+	if err := fl.ForceUnlock("content"); err != nil {
+		t.Fatalf("ForceUnlock()=%s", err)
+	}
+
+	if err := fl.Refresh("content", lock.ID, time.Minute); err != storage.ErrNotLocked {
+		t.Fatalf("got %v, want %v", err, storage.ErrNotLocked)
+	}
+}
+
+func TestFileLockerSteal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraformer-lock")
+	if err != nil {
+		t.Fatalf("TempDir()=%s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fl, err := storage.NewFileLocker(dir)
+	if err != nil {
+		t.Fatalf("NewFileLocker()=%s", err)
+	}
+
+	if _, err := fl.Lock("content", "alice", -time.Minute); err != nil {
+		t.Fatalf("Lock()=%s", err)
+	}
+
+	const n = 16
+
+	var wg sync.WaitGroup
+	locks := make(chan *storage.Lock, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			lock, err := fl.Lock("content", fmt.Sprintf("locker-%d", i), time.Minute)
+			if err == nil {
+				locks <- lock
+			} else if _, ok := err.(*storage.LockedError); !ok {
+				t.Errorf("Lock()=%s", err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(locks)
+
+	// Only one of concurrent lockers steals the expired lock.
+	if len(locks) != 1 {
+		t.Fatalf("got %d lock holders, want 1", len(locks))
+	}
+
+	lock := <-locks
+
+	if info, err := fl.Info("content"); err != nil || info == nil || info.ID != lock.ID {
+		t.Fatalf("got %+v, %v; want lock %s", info, err, lock.ID)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraformer-revisions")
+	if err != nil {
+		t.Fatalf("TempDir()=%s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := storage.NewFile(dir, logging.NewLogger("test"))
+	if err != nil {
+		t.Fatalf("NewFile()=%s", err)
+	}
+
+	revs := &storage.Revisions{
+		Storage: f,
+		Max:     2,
+	}
+
+	states := []string{`{"serial":1}`, `{"serial":2}`, `{"serial":2}`, `{"serial":3}`}
+
+	for _, state := range states {
+		if _, err := revs.Add("content", "trace", []byte(state)); err != nil {
+			t.Fatalf("Add()=%s", err)
+		}
+	}
+
+	list, err := revs.List("content")
+	if err != nil {
+		t.Fatalf("List()=%s", err)
+	}
+
+	if len(list) != 2 || list[0].Revision != 2 || list[1].Revision != 3 {
+		t.Fatalf("got %+v, want revisions 2 and 3", list)
+	}
+
+	if _, err := revs.Read("content", 1); err == nil {
+		t.Fatal("expected Read() to fail for pruned revision")
+	}
+
+	state, err := revs.Read("content", 2)
+	if err != nil {
+		t.Fatalf("Read()=%s", err)
+	}
+
+	if string(state) != `{"serial":2}` {
+		t.Fatalf("got %s, want %s", state, `{"serial":2}`)
+	}
+
+	if err := revs.Verify("content", []byte(`{"serial":3}`)); err != nil {
+		t.Fatalf("Verify()=%s", err)
+	}
+
+	if err := revs.Verify("content", []byte(`{"serial":4}`)); err == nil {
+		t.Fatal("expected Verify() to fail for modified state")
+	}
+
+	// Tampered revision fails the checksum.
+	if err := ioutil.WriteFile(filepath.Join(dir, "revisions", "content", "3.tfstate"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("WriteFile()=%s", err)
+	}
+
+	if _, err := revs.Read("content", 3); err == nil {
+		t.Fatal("expected Read() to fail for tampered revision")
+	} else if _, ok := err.(*storage.ChecksumMismatchError); !ok {
+		t.Fatalf("got %T, want *storage.ChecksumMismatchError", err)
+	}
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"time"
+
+	"koding/db/mongodb"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// LocksColl is a MongoDB collection, which holds state locks.
+const LocksColl = "jTerraformerLocks"
+
+var _ Locker = (*MongoLocker)(nil)
+
+// MongoLocker provides state locking, which keeps locks in
+// MongoDB, so they can be shared by multiple terraformers.
+type MongoLocker struct {
+	db *mongodb.MongoDB
+}
+
+// NewMongoLocker creates a new locker for the given database.
+func NewMongoLocker(db *mongodb.MongoDB) *MongoLocker {
+	return &MongoLocker{
+		db: db,
+	}
+}
+
+// Lock implements the Locker interface.
+func (ml *MongoLocker) Lock(contentID, owner string, ttl time.Duration) (*Lock, error) {
+	lock := NewLock(contentID, owner, ttl)
+
+	err := ml.db.Run(LocksColl, func(c *mgo.Collection) error {
+		err := c.Insert(lock)
+		if !mgo.IsDup(err) {
+			return err
+		}
+
+		// The content is already locked - steal the lock
+		// if its lease has expired.
+		sel := bson.M{
+			"_id":     contentID,
+			"expires": bson.M{"$lt": lock.Created},
+		}
+
+		err = c.Update(sel, lock)
+		if err != mgo.ErrNotFound {
+			return err
+		}
+
+		var old Lock
+
+		if err := c.FindId(contentID).One(&old); err != nil {
+			return err
+		}
+
+		return &LockedError{Lock: &old}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// Refresh implements the Locker interface.
+func (ml *MongoLocker) Refresh(contentID, lockID string, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = DefaultLockTTL
+	}
+
+	return ml.run(func(c *mgo.Collection) error {
+		return c.Update(
+			bson.M{"_id": contentID, "lockId": lockID},
+			bson.M{"$set": bson.M{"expires": time.Now().UTC().Add(ttl)}},
+		)
+	})
+}
+
+// Unlock implements the Locker interface.
+func (ml *MongoLocker) Unlock(contentID, lockID string) error {
+	return ml.run(func(c *mgo.Collection) error {
+		return c.Remove(bson.M{"_id": contentID, "lockId": lockID})
+	})
+}
+
+// ForceUnlock implements the Locker interface.
+func (ml *MongoLocker) ForceUnlock(contentID string) error {
+	err := ml.db.Run(LocksColl, func(c *mgo.Collection) error {
+		return c.RemoveId(contentID)
+	})
+
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// Info implements the Locker interface.
+func (ml *MongoLocker) Info(contentID string) (*Lock, error) {
+	var lock Lock
+
+	err := ml.db.Run(LocksColl, func(c *mgo.Collection) error {
+		return c.FindId(contentID).One(&lock)
+	})
+
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lock.Expired() {
+		return nil, nil
+	}
+
+	return &lock, nil
+}
+
+func (ml *MongoLocker) run(fn func(*mgo.Collection) error) error {
+	err := ml.db.Run(LocksColl, fn)
+
+	if err == mgo.ErrNotFound {
+		return ErrNotLocked
+	}
+
+	return err
+}
@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// DefaultMaxRevisions is a default number of state revisions
+// that are kept for a single content.
+const DefaultMaxRevisions = 20
+
+// Revision describes a single revision of a terraform state.
+type Revision struct {
+	Revision int       `json:"revision"`
+	Checksum string    `json:"checksum"` // hex-encoded SHA-256 of the state
+	TraceID  string    `json:"traceId,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// ChecksumMismatchError is returned when a state does not match
+// the checksum of its revision.
+type ChecksumMismatchError struct {
+	ContentID string
+	Revision  *Revision
+	Checksum  string
+}
+
+// Error implements the built-in error interface.
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("state of %q does not match revision %d: checksum is %s, expected %s",
+		e.ContentID, e.Revision.Revision, e.Checksum, e.Revision.Checksum)
+}
+
+// Revisions keeps checksummed revisions of terraform states
+// in a storage, under <prefix>/<contentID> path.
+type Revisions struct {
+	Storage Interface
+	Prefix  string // "revisions" by default
+	Max     int    // DefaultMaxRevisions by default
+}
+
+// List gives revisions of the given content, the most
+// recent one being the last.
+func (r *Revisions) List(contentID string) ([]*Revision, error) {
+	rd, err := r.Storage.Read(r.path(contentID, "index.json"))
+	if IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer closeReader(rd)
+
+	var revs []*Revision
+
+	if err := json.NewDecoder(rd).Decode(&revs); err != nil {
+		return nil, fmt.Errorf("unable to read revisions of %q: %s", contentID, err)
+	}
+
+	return revs, nil
+}
+
+// Add stores the state as a new revision of the content.
+//
+// If the state does not differ from the most recent
+// revision, the most recent revision is returned.
+func (r *Revisions) Add(contentID, traceID string, state []byte) (*Revision, error) {
+	revs, err := r.List(contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := Checksum(state)
+
+	if len(revs) != 0 && revs[len(revs)-1].Checksum == sum {
+		return revs[len(revs)-1], nil
+	}
+
+	rev := &Revision{
+		Revision: 1,
+		Checksum: sum,
+		TraceID:  traceID,
+		Created:  time.Now().UTC(),
+	}
+
+	if len(revs) != 0 {
+		rev.Revision = revs[len(revs)-1].Revision + 1
+	}
+
+	if err := r.Storage.Write(r.statePath(contentID, rev.Revision), bytes.NewReader(state)); err != nil {
+		return nil, err
+	}
+
+	revs = append(revs, rev)
+
+	if n := len(revs) - r.max(); n > 0 {
+		for _, old := range revs[:n] {
+			if err := r.Storage.Remove(r.statePath(contentID, old.Revision)); err != nil {
+				return nil, err
+			}
+		}
+
+		revs = revs[n:]
+	}
+
+	p, err := json.Marshal(revs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Storage.Write(r.path(contentID, "index.json"), bytes.NewReader(p)); err != nil {
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+// Read gives the state of the given revision, ensuring its
+// checksum matches.
+func (r *Revisions) Read(contentID string, revision int) ([]byte, error) {
+	revs, err := r.List(contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rev *Revision
+
+	for _, rv := range revs {
+		if rv.Revision == revision {
+			rev = rv
+			break
+		}
+	}
+
+	if rev == nil {
+		return nil, fmt.Errorf("revision %d of %q not found", revision, contentID)
+	}
+
+	state, err := ReadAll(r.Storage, r.statePath(contentID, revision))
+	if err != nil {
+		return nil, err
+	}
+
+	if sum := Checksum(state); sum != rev.Checksum {
+		return nil, &ChecksumMismatchError{
+			ContentID: contentID,
+			Revision:  rev,
+			Checksum:  sum,
+		}
+	}
+
+	return state, nil
+}
+
+// Verify ensures the state matches the most recent revision
+// of the content. Contents with no revisions are not verified.
+func (r *Revisions) Verify(contentID string, state []byte) error {
+	revs, err := r.List(contentID)
+	if err != nil {
+		return err
+	}
+
+	if len(revs) == 0 {
+		return nil
+	}
+
+	rev := revs[len(revs)-1]
+
+	if sum := Checksum(state); sum != rev.Checksum {
+		return &ChecksumMismatchError{
+			ContentID: contentID,
+			Revision:  rev,
+			Checksum:  sum,
+		}
+	}
+
+	return nil
+}
+
+func (r *Revisions) path(contentID, file string) string {
+	prefix := r.Prefix
+	if prefix == "" {
+		prefix = "revisions"
+	}
+
+	return path.Join(prefix, contentID, file)
+}
+
+func (r *Revisions) statePath(contentID string, revision int) string {
+	return r.path(contentID, strconv.Itoa(revision)+".tfstate")
+}
+
+func (r *Revisions) max() int {
+	if r.Max != 0 {
+		return r.Max
+	}
+
+	return DefaultMaxRevisions
+}
+
+// Checksum gives hex-encoded SHA-256 checksum of the given state.
+func Checksum(state []byte) string {
+	sum := sha256.Sum256(state)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsNotExist tells whether the error, returned by a storage,
+// describes a missing file.
+func IsNotExist(err error) bool {
+	if os.IsNotExist(err) {
+		return true
+	}
+
+	if e, ok := err.(awserr.Error); ok && e.Code() == "NoSuchKey" {
+		return true
+	}
+
+	return false
+}
+
+// ReadAll reads the whole file from the storage.
+func ReadAll(s Interface, path string) ([]byte, error) {
+	r, err := s.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer closeReader(r)
+
+	return ioutil.ReadAll(r)
+}
+
+func closeReader(r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		c.Close()
+	}
+}
@@ -13,13 +13,17 @@ import (
 	"sync"
 	"syscall"
 
+	"koding/db/mongodb"
 	"koding/kites/common"
+	"koding/kites/common/keyring"
 	"koding/kites/terraformer/kodingcontext"
+	"koding/kites/terraformer/queue"
 	"koding/kites/terraformer/storage"
 
 	dogstatsd "github.com/DataDog/datadog-go/statsd"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/koding/kite"
+	"github.com/koding/kite/dnode"
 	"github.com/koding/logging"
 )
 
@@ -48,6 +52,16 @@ type Terraformer struct {
 	// Store app runtime config
 	Config *Config
 
+	// Locker protects states from concurrent modifications
+	Locker storage.Locker
+
+	// Storage is a remote storage, which keeps states,
+	// their revisions and terraform logs
+	Storage storage.Interface
+
+	// Queue limits the number of concurrently running requests
+	Queue *queue.Queue
+
 	closeChan chan struct{} // To signal when terraformer is closing
 
 	closing bool
@@ -60,6 +74,18 @@ type TerraformRequest struct {
 	Variables map[string]interface{}
 	ContentID string
 	TraceID   string
+
+	// Provider is used to limit the number of concurrently
+	// running requests per provider.
+	Provider string
+
+	// LockID is an ID of a lock acquired with state.lock. If empty,
+	// the state is locked for the duration of the request.
+	LockID string
+
+	// OnOutput, if valid, is called with each line of
+	// terraform output.
+	OnOutput dnode.Function
 }
 
 // New creates a new terraformer
@@ -95,17 +121,54 @@ func New(conf *Config, log logging.Logger) (*Terraformer, error) {
 		rs = local
 	}
 
+	if conf.EncryptionKeys != "" {
+		kr, err := keyring.Parse(conf.EncryptionKeys)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading encryption keys: %s", err)
+		}
+
+		log.Info("encrypting remote store with %s key", kr.Primary)
+
+		rs = storage.NewEncrypted(rs, &storage.LocalKeys{Keyring: kr}, log)
+	}
+
 	c, err := kodingcontext.New(ls, rs, log, conf.Debug)
 	if err != nil {
 		return nil, err
 	}
 
+	var locker storage.Locker
+	if conf.MongoURL != "" {
+		locker = storage.NewMongoLocker(mongodb.NewMongoDB(conf.MongoURL))
+	} else {
+		if conf.AWS.Key != "" && conf.AWS.Secret != "" && conf.AWS.Bucket != "" {
+			log.Warning("state locks are local to this host, set MongoURL when running more than one terraformer")
+		}
+
+		lockPath := filepath.Join(filepath.Dir(conf.LocalStorePath), filepath.Base(conf.LocalStorePath)+".locks")
+
+		fl, err := storage.NewFileLocker(lockPath)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating locker: %s", err)
+		}
+
+		locker = fl
+	}
+
+	limits, err := queue.ParseLimits(conf.ProviderConcurrency)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading provider concurrency: %s", err)
+	}
+
 	t := &Terraformer{
 		Log:       log,
 		Metrics:   common.MustInitMetrics(Name),
 		Debug:     conf.Debug,
 		Context:   c,
 		Config:    conf,
+		Locker:    locker,
+		Storage:   rs,
+		Queue:     &queue.Queue{Concurrency: conf.Concurrency, Limits: limits},
 		closeChan: make(chan struct{}),
 	}
 
@@ -125,6 +188,12 @@ func (t *Terraformer) Close() error {
 	t.closing = true
 	t.rwmu.Unlock()
 
+	// reject queued requests, running ones are
+	// waited for by the context shutdown
+	if t.Queue != nil {
+		t.Queue.Close()
+	}
+
 	var err error
 	if t.Context != nil {
 		err = t.Context.Shutdown()
@@ -169,12 +238,26 @@ func (t *Terraformer) Plan(r *kite.Request) (interface{}, error) {
 		return nil, err
 	}
 
+	done, err := t.enqueue(&args, r.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	unlock, err := t.lock(&args, r.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	c, err := t.Context.Get(args.ContentID, args.TraceID)
 	if err != nil {
 		return nil, err
 	}
 	defer c.Close()
 
+	t.stream(c, &args)
+
 	// set variables if sent
 	c.Variables = args.Variables
 
@@ -194,18 +277,65 @@ func (t *Terraformer) Destroy(r *kite.Request) (interface{}, error) {
 	return t.apply(r, destroy)
 }
 
+// Refresh provides a kite call for refresh operation
+func (t *Terraformer) Refresh(r *kite.Request) (interface{}, error) {
+	args := TerraformRequest{}
+	if err := r.Args.One().Unmarshal(&args); err != nil {
+		return nil, err
+	}
+
+	done, err := t.enqueue(&args, r.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	unlock, err := t.lock(&args, r.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	c, err := t.Context.Get(args.ContentID, args.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	t.stream(c, &args)
+
+	// set variables if sent
+	c.Variables = args.Variables
+
+	return c.Refresh()
+}
+
 func (t *Terraformer) apply(r *kite.Request, destroy bool) (*terraform.State, error) {
 	args := TerraformRequest{}
 	if err := r.Args.One().Unmarshal(&args); err != nil {
 		return nil, err
 	}
 
+	done, err := t.enqueue(&args, r.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	unlock, err := t.lock(&args, r.Method)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	c, err := t.Context.Get(args.ContentID, args.TraceID)
 	if err != nil {
 		return nil, err
 	}
 	defer c.Close()
 
+	t.stream(c, &args)
+
 	// set variables if sent
 	c.Variables = args.Variables
 
@@ -0,0 +1,78 @@
+// Package kubernetes provides a terraform provider for Kubernetes
+// deployments, which are not supported by the upstream provider.
+package kubernetes
+
+import (
+	"koding/kites/kloud/api/kubernetesapi"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Provider returns a terraform.ResourceProvider.
+func Provider() terraform.ResourceProvider {
+	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"host": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "URL of the Kubernetes API server.",
+			},
+			"insecure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Skips verification of the server's certificate.",
+			},
+			"cluster_ca_certificate": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "PEM-encoded root certificate of the cluster.",
+			},
+			"client_certificate": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "PEM-encoded client certificate.",
+			},
+			"client_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "PEM-encoded client certificate key.",
+			},
+			"token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Bearer token used to authenticate.",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Username used for basic authentication.",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Password used for basic authentication.",
+			},
+		},
+
+		ResourcesMap: map[string]*schema.Resource{
+			// kubernetes_deployment creates an apps/v1 deployment
+			"kubernetes_deployment": resourceKubernetesDeployment(),
+		},
+
+		ConfigureFunc: providerConfigure,
+	}
+}
+
+func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+	return kubernetesapi.NewClient(&kubernetesapi.Config{
+		Server:   d.Get("host").(string),
+		Insecure: d.Get("insecure").(bool),
+		CA:       d.Get("cluster_ca_certificate").(string),
+		Cert:     d.Get("client_certificate").(string),
+		Key:      d.Get("client_key").(string),
+		Token:    d.Get("token").(string),
+		Username: d.Get("username").(string),
+		Password: d.Get("password").(string),
+	})
+}
@@ -0,0 +1,346 @@
+package kubernetes
+
+import (
+	"errors"
+	"strings"
+
+	"koding/kites/kloud/api/kubernetesapi"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceKubernetesDeployment() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceDeploymentCreate,
+		Read:   resourceDeploymentRead,
+		Update: resourceDeploymentUpdate,
+		Delete: resourceDeploymentDelete,
+
+		Schema: map[string]*schema.Schema{
+			"metadata": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "Name of the deployment.",
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Default:     "default",
+							Description: "Namespace of the deployment.",
+						},
+						"labels": labelsSchema("Labels of the deployment."),
+					},
+				},
+			},
+			"spec": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						// Replicas are read neither by Read nor Update, as
+						// deployments are scaled by kloud on start and stop.
+						"replicas": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     1,
+							Description: "Number of pods created on apply.",
+						},
+						"selector": {
+							Type:     schema.TypeList,
+							Required: true,
+							MaxItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"match_labels": labelsSchema("Labels of pods managed by the deployment."),
+								},
+							},
+						},
+						"template": {
+							Type:     schema.TypeList,
+							Required: true,
+							MaxItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"metadata": {
+										Type:     schema.TypeList,
+										Optional: true,
+										MaxItems: 1,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"labels": labelsSchema("Labels of the pod."),
+											},
+										},
+									},
+									"spec": {
+										Type:     schema.TypeList,
+										Required: true,
+										MaxItems: 1,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"container": containerSchema(),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func labelsSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeMap,
+		Optional:    true,
+		Description: description,
+	}
+}
+
+func containerSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Required: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "Name of the container.",
+				},
+				"image": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "Image of the container.",
+				},
+				"command": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "Entrypoint of the container.",
+				},
+				"args": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "Arguments of the entrypoint.",
+				},
+				"env": {
+					Type:     schema.TypeList,
+					Optional: true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"name": {
+								Type:     schema.TypeString,
+								Required: true,
+							},
+							"value": {
+								Type:     schema.TypeString,
+								Optional: true,
+							},
+						},
+					},
+				},
+				"port": {
+					Type:     schema.TypeList,
+					Optional: true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"container_port": {
+								Type:     schema.TypeInt,
+								Required: true,
+							},
+							"protocol": {
+								Type:     schema.TypeString,
+								Optional: true,
+								Default:  "TCP",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceDeploymentCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*kubernetesapi.Client)
+	deployment := expandDeployment(d)
+	md := deployment["metadata"].(map[string]interface{})
+
+	namespace, name := md["namespace"].(string), md["name"].(string)
+
+	if err := client.CreateDeployment(namespace, deployment); err != nil {
+		return err
+	}
+
+	// The ID format is namespace/name, kloud relies on it
+	// to identify deployments of machines.
+	d.SetId(namespace + "/" + name)
+
+	return nil
+}
+
+func resourceDeploymentRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*kubernetesapi.Client)
+
+	namespace, name, err := parseID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Deployment(namespace, name)
+	if err == kubernetesapi.ErrNotFound {
+		d.SetId("")
+		return nil
+	}
+
+	return err
+}
+
+func resourceDeploymentUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*kubernetesapi.Client)
+
+	namespace, name, err := parseID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	current, err := client.Deployment(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	deployment := expandDeployment(d)
+
+	// Keep the deployment scaled as it was, otherwise
+	// updating a stopped machine would start it.
+	if current.Spec.Replicas != nil {
+		deployment["spec"].(map[string]interface{})["replicas"] = *current.Spec.Replicas
+	}
+
+	return client.ReplaceDeployment(namespace, name, deployment)
+}
+
+func resourceDeploymentDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*kubernetesapi.Client)
+
+	namespace, name, err := parseID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	err = client.DeleteDeployment(namespace, name)
+	if err == kubernetesapi.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func parseID(id string) (namespace, name string, err error) {
+	i := strings.IndexRune(id, '/')
+	if i == -1 {
+		return "", "", errors.New("invalid deployment ID: " + id)
+	}
+
+	return id[:i], id[i+1:], nil
+}
+
+// expandDeployment builds an apps/v1 Deployment object
+// from the resource configuration.
+func expandDeployment(d *schema.ResourceData) map[string]interface{} {
+	md := block(d.Get("metadata"))
+	spec := block(d.Get("spec"))
+	template := block(spec["template"])
+	podSpec := block(template["spec"])
+
+	var containers []interface{}
+
+	for _, v := range list(podSpec["container"]) {
+		containers = append(containers, expandContainer(v.(map[string]interface{})))
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      md["name"],
+			"namespace": md["namespace"],
+			"labels":    md["labels"],
+		},
+		"spec": map[string]interface{}{
+			"replicas": spec["replicas"],
+			"selector": map[string]interface{}{
+				"matchLabels": block(spec["selector"])["match_labels"],
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": block(template["metadata"])["labels"],
+				},
+				"spec": map[string]interface{}{
+					"containers": containers,
+				},
+			},
+		},
+	}
+}
+
+func expandContainer(c map[string]interface{}) map[string]interface{} {
+	var env, ports []interface{}
+
+	for _, v := range list(c["env"]) {
+		e := v.(map[string]interface{})
+
+		env = append(env, map[string]interface{}{
+			"name":  e["name"],
+			"value": e["value"],
+		})
+	}
+
+	for _, v := range list(c["port"]) {
+		p := v.(map[string]interface{})
+
+		ports = append(ports, map[string]interface{}{
+			"containerPort": p["container_port"],
+			"protocol":      p["protocol"],
+		})
+	}
+
+	return map[string]interface{}{
+		"name":    c["name"],
+		"image":   c["image"],
+		"command": list(c["command"]),
+		"args":    list(c["args"]),
+		"env":     env,
+		"ports":   ports,
+	}
+}
+
+// block gives the first element of a block with MaxItems: 1,
+// or an empty one if it was not configured.
+func block(v interface{}) map[string]interface{} {
+	if l := list(v); len(l) != 0 {
+		if m, ok := l[0].(map[string]interface{}); ok {
+			return m
+		}
+	}
+
+	return make(map[string]interface{})
+}
+
+func list(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
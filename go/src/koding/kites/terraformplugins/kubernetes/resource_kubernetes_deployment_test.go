@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestExpandDeployment(t *testing.T) {
+	raw := map[string]interface{}{
+		"metadata": []interface{}{map[string]interface{}{
+			"name":   "koding-vm",
+			"labels": map[string]interface{}{"app": "koding"},
+		}},
+		"spec": []interface{}{map[string]interface{}{
+			"selector": []interface{}{map[string]interface{}{
+				"match_labels": map[string]interface{}{"app": "koding"},
+			}},
+			"template": []interface{}{map[string]interface{}{
+				"spec": []interface{}{map[string]interface{}{
+					"container": []interface{}{map[string]interface{}{
+						"name":    "app",
+						"image":   "nginx:1.13",
+						"command": []interface{}{"nginx"},
+						"env": []interface{}{map[string]interface{}{
+							"name":  "FOO",
+							"value": "bar",
+						}},
+						"port": []interface{}{map[string]interface{}{
+							"container_port": 80,
+						}},
+					}},
+				}},
+			}},
+		}},
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceKubernetesDeployment().Schema, raw)
+
+	p, err := json.Marshal(expandDeployment(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = `{"apiVersion":"apps/v1","kind":"Deployment",` +
+		`"metadata":{"labels":{"app":"koding"},"name":"koding-vm","namespace":"default"},` +
+		`"spec":{"replicas":1,"selector":{"matchLabels":{"app":"koding"}},` +
+		`"template":{"metadata":{"labels":null},"spec":{"containers":[{"args":[],"command":["nginx"],` +
+		`"env":[{"name":"FOO","value":"bar"}],"image":"nginx:1.13","name":"app",` +
+		`"ports":[{"containerPort":80,"protocol":"TCP"}]}]}}}}`
+
+	if got := string(p); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseID(t *testing.T) {
+	namespace, name, err := parseID("dev/koding-vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if namespace != "dev" || name != "koding-vm" {
+		t.Fatalf("got %q/%q, want dev/koding-vm", namespace, name)
+	}
+
+	if _, _, err := parseID("koding-vm"); err == nil {
+		t.Fatal("expected error for ID without namespace")
+	}
+}
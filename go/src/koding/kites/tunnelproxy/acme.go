@@ -0,0 +1,212 @@
+package tunnelproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/acme"
+)
+
+// ACMEChallengePath is a path prefix under which HTTP-01
+// challenge responses are served.
+const ACMEChallengePath = "/.well-known/acme-challenge/"
+
+// Solver fulfills ACME challenges of a single type.
+type Solver interface {
+	// Type gives the challenge type, e.g. "http-01" or "dns-01".
+	Type() string
+
+	// Present makes the challenge response available to the CA.
+	//
+	// For HTTP-01 challenges the response is the key authorization,
+	// for DNS-01 it is a value of the TXT record.
+	Present(ctx context.Context, domain, token, response string) error
+
+	// CleanUp removes the challenge response.
+	CleanUp(ctx context.Context, domain, token string) error
+}
+
+// Issuer issues certificates for domains.
+type Issuer interface {
+	// Issue issues a certificate for the given CSR, after proving
+	// control over the domain. The returned certificate chain is
+	// DER-encoded, with the leaf certificate first.
+	Issue(ctx context.Context, domain string, csr []byte) ([][]byte, error)
+}
+
+var _ Solver = (*HTTPSolver)(nil)
+
+// HTTPSolver is a Solver for HTTP-01 challenges. It serves
+// the responses under ACMEChallengePath.
+type HTTPSolver struct {
+	mu        sync.Mutex
+	responses map[string]string // maps domain+token to a response
+}
+
+// Type implements the Solver interface.
+func (*HTTPSolver) Type() string {
+	return "http-01"
+}
+
+// Present implements the Solver interface.
+func (s *HTTPSolver) Present(_ context.Context, domain, token, response string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.responses == nil {
+		s.responses = make(map[string]string)
+	}
+
+	s.responses[domain+"/"+token] = response
+
+	return nil
+}
+
+// CleanUp implements the Solver interface.
+func (s *HTTPSolver) CleanUp(_ context.Context, domain, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.responses, domain+"/"+token)
+
+	return nil
+}
+
+// ServeHTTP serves challenge responses.
+func (s *HTTPSolver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, ACMEChallengePath)
+
+	s.mu.Lock()
+	response, ok := s.responses[host(r.Host)+"/"+token]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(response))
+}
+
+var _ Issuer = (*ACMEIssuer)(nil)
+
+// ACMEIssuer issues certificates with an ACME CA, like Let's Encrypt.
+type ACMEIssuer struct {
+	// Client is used to talk to the CA. Its Key is the account key.
+	Client *acme.Client
+
+	// Solver fulfills the challenges.
+	Solver Solver
+
+	// Email is a contact address of the account.
+	Email string
+
+	mu         sync.Mutex
+	registered bool
+}
+
+// Issue implements the Issuer interface.
+func (ai *ACMEIssuer) Issue(ctx context.Context, domain string, csr []byte) ([][]byte, error) {
+	if err := ai.register(ctx); err != nil {
+		return nil, fmt.Errorf("unable to register ACME account: %s", err)
+	}
+
+	if err := ai.authorize(ctx, domain); err != nil {
+		return nil, fmt.Errorf("unable to authorize %q: %s", domain, err)
+	}
+
+	der, _, err := ai.Client.CreateCert(ctx, csr, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to issue certificate for %q: %s", domain, err)
+	}
+
+	return der, nil
+}
+
+// register registers the account with the CA. Only successful
+// registration is remembered, failed one is retried on next call.
+func (ai *ACMEIssuer) register(ctx context.Context) error {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	if ai.registered {
+		return nil
+	}
+
+	a := &acme.Account{}
+
+	if ai.Email != "" {
+		a.Contact = []string{"mailto:" + ai.Email}
+	}
+
+	_, err := ai.Client.Register(ctx, a, acme.AcceptTOS)
+
+	// The account is already registered.
+	if e, ok := err.(*acme.Error); ok && e.StatusCode == http.StatusConflict {
+		err = nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ai.registered = true
+
+	return nil
+}
+
+func (ai *ACMEIssuer) authorize(ctx context.Context, domain string) error {
+	authz, err := ai.Client.Authorize(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var chal *acme.Challenge
+
+	for _, c := range authz.Challenges {
+		if c.Type == ai.Solver.Type() {
+			chal = c
+			break
+		}
+	}
+
+	if chal == nil {
+		return fmt.Errorf("CA does not offer %s challenge", ai.Solver.Type())
+	}
+
+	var response string
+
+	switch chal.Type {
+	case "http-01":
+		response, err = ai.Client.HTTP01ChallengeResponse(chal.Token)
+	case "dns-01":
+		response, err = ai.Client.DNS01ChallengeRecord(chal.Token)
+	default:
+		err = errors.New("unsupported challenge type: " + chal.Type)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := ai.Solver.Present(ctx, domain, chal.Token, response); err != nil {
+		return err
+	}
+	defer ai.Solver.CleanUp(ctx, domain, chal.Token)
+
+	if _, err := ai.Client.Accept(ctx, chal); err != nil {
+		return err
+	}
+
+	_, err = ai.Client.WaitAuthorization(ctx, authz.URI)
+	return err
+}
@@ -0,0 +1,332 @@
+package tunnelproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koding/logging"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// defaultRenewBefore is a time before certificate expiration,
+// when the certificate is renewed.
+const defaultRenewBefore = 30 * 24 * time.Hour
+
+var (
+	// certTimeout is a timeout for obtaining a certificate.
+	certTimeout = 5 * time.Minute
+
+	// renewRetry is a time after which failed renewal is retried.
+	renewRetry = time.Hour
+)
+
+// CertManager obtains TLS certificates for custom domains of tunnels
+// and serves them by SNI.
+//
+// Certificates are cached in memory and in the Cache storage,
+// and are renewed in the background before they expire.
+type CertManager struct {
+	// Issuer issues the certificates.
+	Issuer Issuer
+
+	// Cache stores the certificates and their keys.
+	Cache autocert.Cache
+
+	// HostPolicy, when non-nil, tells whether a certificate
+	// can be obtained for the given domain.
+	HostPolicy func(domain string) error
+
+	// RenewBefore is a time before expiration when certificate
+	// is renewed.
+	//
+	// If zero, 30 days is used.
+	RenewBefore time.Duration
+
+	// Log is used for logging.
+	//
+	// If nil, default logger is used.
+	Log logging.Logger
+
+	mu      sync.Mutex
+	certs   map[string]*tls.Certificate
+	pending map[string]*certCall
+	failed  map[string]time.Time // maps domain to time of last failure
+}
+
+type certCall struct {
+	done chan struct{}
+	cert *tls.Certificate
+	err  error
+}
+
+// GetCertificate gives a certificate for the server name requested
+// by the client. It is meant to be used as tls.Config.GetCertificate.
+func (m *CertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := strings.TrimSuffix(strings.ToLower(hello.ServerName), ".")
+
+	if name == "" {
+		return nil, errors.New("missing server name")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), certTimeout)
+	defer cancel()
+
+	return m.Cert(ctx, name)
+}
+
+// Cert gives a certificate for the given domain.
+//
+// If the certificate is not cached, it is issued.
+func (m *CertManager) Cert(ctx context.Context, domain string) (*tls.Certificate, error) {
+	m.mu.Lock()
+
+	if cert, ok := m.certs[domain]; ok {
+		if m.expiring(cert) && time.Since(m.failed[domain]) > renewRetry {
+			m.obtain(domain, true)
+		}
+
+		m.mu.Unlock()
+		return cert, nil
+	}
+
+	call := m.obtain(domain, false)
+
+	m.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.cert, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Forget removes the certificate from the memory cache, e.g.
+// when the domain is no longer served. The certificate is
+// left in the Cache storage.
+func (m *CertManager) Forget(domain string) {
+	m.mu.Lock()
+	delete(m.certs, domain)
+	m.mu.Unlock()
+}
+
+// obtain starts obtaining the certificate, unless it is already
+// in progress. When renew is true, the Cache is not used.
+//
+// It must be called with m.mu held.
+func (m *CertManager) obtain(domain string, renew bool) *certCall {
+	if call, ok := m.pending[domain]; ok {
+		return call
+	}
+
+	if m.pending == nil {
+		m.pending = make(map[string]*certCall)
+		m.certs = make(map[string]*tls.Certificate)
+		m.failed = make(map[string]time.Time)
+	}
+
+	call := &certCall{
+		done: make(chan struct{}),
+	}
+
+	m.pending[domain] = call
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), certTimeout)
+		defer cancel()
+
+		call.cert, call.err = m.cert(ctx, domain, renew)
+
+		m.mu.Lock()
+		delete(m.pending, domain)
+		if call.err == nil {
+			m.certs[domain] = call.cert
+			delete(m.failed, domain)
+		} else {
+			m.failed[domain] = time.Now()
+		}
+		m.mu.Unlock()
+
+		if call.err != nil {
+			m.log().Error("%s: unable to obtain certificate: %s", domain, call.err)
+		}
+
+		close(call.done)
+	}()
+
+	return call
+}
+
+func (m *CertManager) cert(ctx context.Context, domain string, renew bool) (*tls.Certificate, error) {
+	if m.HostPolicy != nil {
+		if err := m.HostPolicy(domain); err != nil {
+			return nil, err
+		}
+	}
+
+	if !renew {
+		cert, err := m.cacheGet(ctx, domain)
+		if err == nil && !m.expiring(cert) {
+			return cert, nil
+		}
+
+		if err != nil && err != autocert.ErrCacheMiss {
+			m.log().Warning("%s: unable to read cached certificate: %s", domain, err)
+		}
+	}
+
+	m.log().Info("%s: issuing certificate", domain)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domain},
+		DNSNames: []string{domain},
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := m.Issuer.Issue(ctx, domain, csr)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := newCert(domain, der, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.cachePut(ctx, domain, cert); err != nil {
+		m.log().Warning("%s: unable to cache certificate: %s", domain, err)
+	}
+
+	return cert, nil
+}
+
+func (m *CertManager) expiring(cert *tls.Certificate) bool {
+	renewBefore := m.RenewBefore
+	if renewBefore == 0 {
+		renewBefore = defaultRenewBefore
+	}
+
+	return time.Now().Add(renewBefore).After(cert.Leaf.NotAfter)
+}
+
+// cacheGet reads the certificate from the cache, which is
+// stored as PEM-encoded private key followed by the chain.
+func (m *CertManager) cacheGet(ctx context.Context, domain string) (*tls.Certificate, error) {
+	p, err := m.Cache.Get(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	block, rest := pem.Decode(p)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, errors.New("no private key found")
+	}
+
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var der [][]byte
+
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		der = append(der, block.Bytes)
+	}
+
+	return newCert(domain, der, key)
+}
+
+func (m *CertManager) cachePut(ctx context.Context, domain string, cert *tls.Certificate) error {
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	if err := pem.Encode(&buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}); err != nil {
+		return err
+	}
+
+	for _, der := range cert.Certificate {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+			return err
+		}
+	}
+
+	return m.Cache.Put(ctx, domain, buf.Bytes())
+}
+
+func (m *CertManager) log() logging.Logger {
+	if m.Log != nil {
+		return m.Log
+	}
+
+	return defaultLog
+}
+
+var defaultLog = logging.NewCustom("certs", false)
+
+// newCert builds a certificate out of the given chain, verifying
+// the leaf is valid for the domain and matches the key.
+func newCert(domain string, der [][]byte, key crypto.Signer) (*tls.Certificate, error) {
+	if len(der) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := leaf.VerifyHostname(domain); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("certificate for %q is not valid now", domain)
+	}
+
+	pub, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("unexpected certificate public key")
+	}
+
+	priv, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok || pub.X.Cmp(priv.X) != 0 || pub.Y.Cmp(priv.Y) != 0 {
+		return nil, errors.New("certificate does not match the private key")
+	}
+
+	return &tls.Certificate{
+		Certificate: der,
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
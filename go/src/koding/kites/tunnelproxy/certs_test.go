@@ -0,0 +1,127 @@
+package tunnelproxy_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"koding/kites/tunnelproxy"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+type countingIssuer struct {
+	tunnelproxy.Issuer
+	n int32
+}
+
+func (ci *countingIssuer) Issue(ctx context.Context, domain string, csr []byte) ([][]byte, error) {
+	atomic.AddInt32(&ci.n, 1)
+	return ci.Issuer.Issue(ctx, domain, csr)
+}
+
+func (ci *countingIssuer) count() int {
+	return int(atomic.LoadInt32(&ci.n))
+}
+
+func TestCertManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tunnelproxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	solver := &tunnelproxy.HTTPSolver{}
+
+	srv := httptest.NewServer(solver)
+	defer srv.Close()
+
+	// Resolves each domain to the test server.
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+			},
+		},
+	}
+
+	local := &tunnelproxy.LocalIssuer{
+		Solver: solver,
+		Client: client,
+	}
+
+	ca, err := local.CACert()
+	if err != nil {
+		t.Fatalf("CACert()=%s", err)
+	}
+
+	issuer := &countingIssuer{Issuer: local}
+
+	manager := func(renewBefore time.Duration) *tunnelproxy.CertManager {
+		return &tunnelproxy.CertManager{
+			Issuer: issuer,
+			Cache:  autocert.DirCache(dir),
+			HostPolicy: func(domain string) error {
+				if domain != "example.com" {
+					return errors.New("not allowed")
+				}
+				return nil
+			},
+			RenewBefore: renewBefore,
+		}
+	}
+
+	m := manager(0)
+
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "Example.com."})
+	if err != nil {
+		t.Fatalf("GetCertificate()=%s", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{DNSName: "example.com", Roots: roots}); err != nil {
+		t.Fatalf("Verify()=%s", err)
+	}
+
+	if n := issuer.count(); n != 1 {
+		t.Fatalf("got %d issued certificates, want 1", n)
+	}
+
+	// The certificate is cached in memory.
+	if c, err := m.Cert(context.Background(), "example.com"); err != nil || c != cert {
+		t.Fatalf("got %v, %v; want cached certificate", c, err)
+	}
+
+	// The certificate is cached in the storage.
+	if _, err := manager(0).Cert(context.Background(), "example.com"); err != nil {
+		t.Fatalf("Cert()=%s", err)
+	}
+
+	if n := issuer.count(); n != 1 {
+		t.Fatalf("got %d issued certificates, want 1", n)
+	}
+
+	// The cached certificate is about to expire.
+	if _, err := manager(100*24*time.Hour).Cert(context.Background(), "example.com"); err != nil {
+		t.Fatalf("Cert()=%s", err)
+	}
+
+	if n := issuer.count(); n != 2 {
+		t.Fatalf("got %d issued certificates, want 2", n)
+	}
+
+	if _, err := m.Cert(context.Background(), "example.org"); err == nil {
+		t.Fatal("expected certificate for example.org to be denied")
+	}
+}
@@ -1,6 +1,7 @@
 package tunnelproxy
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
@@ -15,6 +16,7 @@ import (
 	"github.com/koding/kite/config"
 	"github.com/koding/logging"
 	"github.com/koding/tunnel"
+	"github.com/koding/tunnel/proto"
 )
 
 const (
@@ -75,6 +77,18 @@ type ClientOptions struct {
 	// Services is sent with RegisterRequest.
 	Services map[string]*Tunnel
 
+	// Domains are custom domains sent with RegisterRequest.
+	Domains []string
+
+	// Recorder, when non-nil, records HTTP requests received
+	// by services with Inspect set.
+	Recorder *Recorder
+
+	// InspectKite makes Recorder also record HTTP requests received
+	// on the virtual host of the tunnel. They are not recorded by
+	// default, as they are kite requests, which carry kite keys.
+	InspectKite bool
+
 	// PublicIP of the client.
 	PublicIP string
 
@@ -122,10 +136,10 @@ type Client struct {
 
 	stateChanges chan *tunnel.ClientStateChange
 	regserv      chan map[string]*Tunnel
-	services     Services       // maps service name to a service
-	routes       map[int]string // maps tcp tunnel remote port to local addr
-	ident        string         // TODO: use c.connected when tryRegister is moved to eventloop
-	mu           sync.Mutex     // protects ident, services and routes
+	services     Services         // maps service name to a service
+	routes       map[int]*Service // maps tunnel remote port to a service
+	ident        string           // TODO: use c.connected when tryRegister is moved to eventloop
+	mu           sync.Mutex       // protects ident, services and routes
 }
 
 // NewClient gives new, unstarted tunnel client for the given options.
@@ -152,7 +166,7 @@ func NewClient(opts *ClientOptions) (*Client, error) {
 		stateChanges:  make(chan *tunnel.ClientStateChange, 128),
 		regserv:       make(chan map[string]*Tunnel, 1),
 		services:      make(Services),
-		routes:        make(map[int]string),
+		routes:        make(map[int]*Service),
 	}
 
 	// If VirtualHost was configured, try to connect to it first.
@@ -164,11 +178,23 @@ func NewClient(opts *ClientOptions) (*Client, error) {
 		}
 	}
 
+	proxy := tunnel.ProxyFuncs{
+		TCP: c.proxy,
+	}
+
+	if c.opts.LocalAddr != "" {
+		proxy.HTTP = (&tunnel.HTTPProxy{LocalAddr: c.opts.LocalAddr}).Proxy
+		proxy.WS = proxy.HTTP
+
+		if c.opts.Recorder != nil && c.opts.InspectKite {
+			proxy.HTTP = c.proxyHTTP
+		}
+	}
+
 	cfg := &tunnel.ClientConfig{
 		FetchIdentifier: c.fetchIdent,
 		FetchServerAddr: c.fetchServerAddr,
-		FetchLocalAddr:  c.fetchLocalAddr,
-		LocalAddr:       c.opts.LocalAddr,
+		Proxy:           tunnel.Proxy(proxy),
 		Debug:           c.opts.Debug,
 		Log:             c.opts.Log.New("transport"),
 		StateChanges:    c.stateChanges,
@@ -216,7 +242,14 @@ func (c *Client) RestoreServices(services Services) error {
 			return fmt.Errorf("invalid local address for %q service: %s", name, err)
 		}
 
-		t := &Tunnel{}
+		if err := validProtocol(srv.Protocol); err != nil {
+			return fmt.Errorf("invalid %q service: %s", name, err)
+		}
+
+		t := &Tunnel{
+			Protocol: srv.Protocol,
+			Policy:   srv.Policy,
+		}
 
 		if _, remotePort, err := splitHostPort(srv.RemoteAddr); err == nil && remotePort != 0 {
 			t.Port = remotePort
@@ -241,6 +274,9 @@ func (c *Client) RestoreServices(services Services) error {
 			Name:          name,
 			LocalAddr:     srv.LocalAddr,
 			ForwardedPort: srv.ForwardedPort,
+			Protocol:      srv.Protocol,
+			Policy:        srv.Policy,
+			Inspect:       srv.Inspect,
 		}
 	}
 	c.mu.Unlock()
@@ -256,16 +292,29 @@ func (c *Client) RegisterService(srvc *Service) error {
 		return fmt.Errorf("invalid local address for %q service: %s", srvc.Name, err)
 	}
 
+	if err := validProtocol(srvc.Protocol); err != nil {
+		return fmt.Errorf("invalid %q service: %s", srvc.Name, err)
+	}
+
+	if srvc.Inspect && srvc.Protocol == "udp" {
+		return fmt.Errorf("invalid %q service: unable to inspect HTTP traffic of udp service", srvc.Name)
+	}
+
 	c.mu.Lock()
 	c.services[srvc.Name] = &Service{
 		Name:          srvc.Name,
 		LocalAddr:     srvc.LocalAddr,
 		ForwardedPort: srvc.ForwardedPort,
+		Protocol:      srvc.Protocol,
+		Policy:        srvc.Policy,
+		Inspect:       srvc.Inspect,
 	}
 	c.mu.Unlock()
 
 	t := &Tunnel{
-		Name: srvc.Name,
+		Name:     srvc.Name,
+		Protocol: srvc.Protocol,
+		Policy:   srvc.Policy,
 	}
 
 	if srvc.ForwardedPort != 0 {
@@ -328,8 +377,10 @@ func (c *Client) eventloop() {
 					_, remotePort, _ := splitHostPort(srv.RemoteAddr)
 
 					pending[name] = &Tunnel{
-						Port:    remotePort,
-						Restore: true,
+						Port:     remotePort,
+						Restore:  true,
+						Protocol: srv.Protocol,
+						Policy:   srv.Policy,
 					}
 				}
 
@@ -345,7 +396,7 @@ func (c *Client) eventloop() {
 				// all TCP tunnels got invalidated, clear routes until
 				// we connect again and restore them
 				c.mu.Lock()
-				c.routes = make(map[int]string)
+				c.routes = make(map[int]*Service)
 				c.mu.Unlock()
 
 				handlePending = nil
@@ -419,7 +470,7 @@ func (c *Client) eventloop() {
 				}
 
 				c.services[name].RemoteAddr = net.JoinHostPort(resp.VirtualHost, strconv.Itoa(srv.Port))
-				c.routes[srv.Port] = c.services[name].LocalAddr
+				c.routes[srv.Port] = c.services[name]
 				delete(pending, name)
 			}
 			c.mu.Unlock()
@@ -492,6 +543,7 @@ func (c *Client) tryRegister() error {
 	req := &RegisterRequest{
 		TunnelName: c.opts.TunnelName,
 		Services:   c.opts.Services,
+		Domains:    c.opts.Domains,
 	}
 
 	kiteResp, err := client.TellWithTimeout("register", c.opts.timeout(), req)
@@ -525,23 +577,76 @@ func (c *Client) fetchServerAddr() (string, error) {
 	return c.connected.ServerAddr, nil
 }
 
-func (c *Client) fetchLocalAddr(port int) (string, error) {
+// proxy forwards port-routed connections to the local server
+// of the service, which owns the port.
+func (c *Client) proxy(remote net.Conn, msg *proto.ControlMessage) {
 	c.mu.Lock()
-	addr, ok := c.routes[port]
+	srv, ok := c.routes[msg.LocalPort]
 	c.mu.Unlock()
 
 	if !ok {
-		return "", fmt.Errorf("no service route found for %d port", port)
+		c.opts.Log.Warning("no service route found for %d port", msg.LocalPort)
+		remote.Close()
+		return
+	}
+
+	log := c.opts.Log.New(srv.Name)
+
+	switch {
+	case srv.Protocol == "udp":
+		proxyUDP(remote, srv.LocalAddr, udpIdleTimeout, log)
+	case srv.Inspect && c.opts.Recorder != nil:
+		c.opts.Recorder.proxy(remote, srv.Name, srv.LocalAddr, log)
+	default:
+		p := &tunnel.TCPProxy{
+			LocalAddr: srv.LocalAddr,
+			Log:       log,
+		}
+
+		p.Proxy(remote, msg)
+	}
+}
+
+// proxyHTTP forwards HTTP requests received on the virtual host
+// to the local server, recording them.
+func (c *Client) proxyHTTP(remote net.Conn, _ *proto.ControlMessage) {
+	c.opts.Recorder.proxy(remote, "kite", c.opts.LocalAddr, c.opts.Log.New("kite"))
+}
+
+// Replay sends a recorded HTTP request with the given ID once again
+// to the local server of the service, which received it.
+func (c *Client) Replay(id int64) (*Exchange, error) {
+	if c.opts.Recorder == nil {
+		return nil, errors.New("recording HTTP requests is disabled")
+	}
+
+	ex, ok := c.opts.Recorder.Exchange(id)
+	if !ok {
+		return nil, fmt.Errorf("request %d not found", id)
+	}
+
+	localAddr := c.opts.LocalAddr
+
+	if ex.Service != "kite" {
+		c.mu.Lock()
+		srv, ok := c.services[ex.Service]
+		c.mu.Unlock()
+
+		if !ok {
+			return nil, fmt.Errorf("service %q not found", ex.Service)
+		}
+
+		localAddr = srv.LocalAddr
 	}
 
-	return addr, nil
+	return c.opts.Recorder.Replay(id, localAddr)
 }
 
 func (c *Client) connect() (*kite.Client, error) {
 	client := c.kite.NewClient(c.tunnelKiteURL)
 	client.Auth = &kite.Auth{
 		Type: "kiteKey",
-		Key:  c.opts.Kite.Config.KiteKey,
+		Key:  c.opts.Kite.KiteKey(),
 	}
 
 	if err := client.DialTimeout(c.opts.timeout()); err != nil {
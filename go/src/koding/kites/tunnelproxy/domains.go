@@ -0,0 +1,214 @@
+package tunnelproxy
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// accountKeyName is a cache key of the ACME account key.
+const accountKeyName = "acme_account.key"
+
+// lookupCNAME is used to verify custom domains point to tunnels.
+var lookupCNAME = net.LookupCNAME
+
+func (s *Server) initCerts() error {
+	cache := s.opts.CertCache
+
+	if cache == nil {
+		if s.opts.CertCacheDir == "" {
+			return errors.New("certCacheDir is required to serve custom domains")
+		}
+
+		cache = autocert.DirCache(s.opts.CertCacheDir)
+	}
+
+	if s.opts.Solver == nil {
+		s.opts.Solver = &HTTPSolver{}
+	}
+
+	issuer := s.opts.Issuer
+
+	if issuer == nil {
+		key, err := accountKey(cache)
+		if err != nil {
+			return fmt.Errorf("unable to read ACME account key: %s", err)
+		}
+
+		dir := s.opts.ACMEDirectory
+		if dir == "" {
+			dir = acme.LetsEncryptURL
+		}
+
+		issuer = &ACMEIssuer{
+			Client: &acme.Client{
+				Key:          key,
+				DirectoryURL: dir,
+			},
+			Solver: s.opts.Solver,
+			Email:  s.opts.ACMEEmail,
+		}
+	}
+
+	s.Certs = &CertManager{
+		Issuer:     issuer,
+		Cache:      cache,
+		HostPolicy: s.hostPolicy,
+		Log:        s.opts.Log.New("certs"),
+	}
+
+	return nil
+}
+
+// hostPolicy allows certificates only for registered custom domains.
+func (s *Server) hostPolicy(domain string) error {
+	s.mu.Lock()
+	_, ok := s.domains[domain]
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%q is not a registered custom domain", domain)
+	}
+
+	return nil
+}
+
+// addDomains routes the custom domains to the tunnel of the given client.
+//
+// Domains, which are not a CNAME of the virtual host or are owned
+// by other tunnels, are skipped.
+func (s *Server) addDomains(ident, vhost string, domains []string) []string {
+	if len(domains) == 0 {
+		return nil
+	}
+
+	if s.Certs == nil {
+		s.opts.Log.Warning("%s: ignoring custom domains %v, as they are disabled", ident, domains)
+		return nil
+	}
+
+	var added []string
+
+	for _, domain := range domains {
+		domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+
+		if err := s.verifyDomain(domain, host(vhost)); err != nil {
+			s.opts.Log.Warning("%s: unable to add %q domain: %s", ident, domain, err)
+			continue
+		}
+
+		s.mu.Lock()
+		owner, ok := s.domains[domain]
+		if !ok {
+			s.domains[domain] = ident
+			s.idents[domain] = ident
+		}
+		s.mu.Unlock()
+
+		if ok && owner != ident {
+			s.opts.Log.Warning("%s: unable to add %q domain: already used by %s", ident, domain, owner)
+			continue
+		}
+
+		s.opts.Log.Debug("%s: adding domain=%q", ident, domain)
+
+		s.Server.AddHost(domain, ident)
+		added = append(added, domain)
+	}
+
+	return added
+}
+
+func (s *Server) verifyDomain(domain, vhost string) error {
+	if domain == "" || net.ParseIP(domain) != nil {
+		return errors.New("invalid domain")
+	}
+
+	if domain == vhost || strings.HasSuffix(domain, "."+host(s.opts.BaseVirtualHost)) {
+		return errors.New("not a custom domain")
+	}
+
+	cname, err := lookupCNAME(domain)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSuffix(strings.ToLower(cname), ".") != vhost {
+		return fmt.Errorf("domain is a CNAME of %q, expected %q", cname, vhost)
+	}
+
+	return nil
+}
+
+// serveTLS serves the handler on HTTPSPort, using certificates
+// issued for custom domains.
+func (s *Server) serveTLS(h http.Handler) error {
+	l, err := net.Listen("tcp", net.JoinHostPort(s.privateIP, strconv.Itoa(s.opts.HTTPSPort)))
+	if err != nil {
+		return err
+	}
+
+	cfg := &tls.Config{
+		GetCertificate: s.Certs.GetCertificate,
+	}
+
+	go func() {
+		if err := serveNoHTTP2(tls.NewListener(l, cfg), h); err != nil {
+			s.opts.Log.Error("serving custom domains failed: %s", err)
+		}
+	}()
+
+	return nil
+}
+
+// accountKey reads the ACME account key from the cache, generating
+// a new one if it does not exist.
+func accountKey(cache autocert.Cache) (crypto.Signer, error) {
+	ctx := context.Background()
+
+	p, err := cache.Get(ctx, accountKeyName)
+	if err == nil {
+		block, _ := pem.Decode(p)
+		if block == nil {
+			return nil, errors.New("invalid account key")
+		}
+
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	if err != autocert.ErrCacheMiss {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	p = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	if err := cache.Put(ctx, accountKeyName, p); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,466 @@
+package tunnelproxy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koding/logging"
+)
+
+var (
+	defaultRecordSize  = 50
+	defaultMaxBodySize = 64 * 1024
+)
+
+// redacted replaces values of credential headers in recorded
+// exchanges, so they are never kept in memory nor exposed.
+const redacted = "[redacted]"
+
+// credentialHeaders are headers, which values are redacted
+// in recorded exchanges.
+var credentialHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	TokenHeader,
+}
+
+// Exchange is a recorded HTTP request/response pair.
+type Exchange struct {
+	ID       int64             `json:"id"`
+	Service  string            `json:"service"`
+	Time     time.Time         `json:"time"`
+	Duration time.Duration     `json:"duration"`
+	Request  *RecordedRequest  `json:"request"`
+	Response *RecordedResponse `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Replay   int64             `json:"replay,omitempty"` // ID of the replayed exchange
+}
+
+// RecordedRequest is a captured HTTP request.
+type RecordedRequest struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"` // request URI, e.g. "/hook?id=1"
+	Proto      string      `json:"proto"`
+	Host       string      `json:"host"`
+	RemoteAddr string      `json:"remoteAddr,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	Truncated  bool        `json:"truncated,omitempty"` // whether Body was cut at MaxBodySize
+}
+
+// RecordedResponse is a captured HTTP response.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Proto      string      `json:"proto"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	Truncated  bool        `json:"truncated,omitempty"` // whether Body was cut at MaxBodySize
+}
+
+// Recorder keeps recent HTTP exchanges of each service in a bounded
+// ring buffer, so they can be inspected and replayed.
+//
+// Credential headers, like Authorization or Cookie, are redacted
+// in the recorded exchanges, thus replayed requests are sent
+// without them.
+type Recorder struct {
+	// Size is a number of exchanges kept per service.
+	//
+	// If zero, 50 is used.
+	Size int
+
+	// MaxBodySize is a maximum number of bytes recorded for each
+	// request and response body. Bodies are forwarded unmodified,
+	// regardless of the limit.
+	//
+	// If zero, 64KiB is used.
+	MaxBodySize int
+
+	once      sync.Once
+	transport *http.Transport
+
+	mu    sync.Mutex
+	id    int64
+	rings map[string]*ring // maps service name to its exchanges
+}
+
+// Exchanges gives recorded exchanges of the given service, newest first.
+//
+// If service is empty, exchanges of all services are returned.
+func (rec *Recorder) Exchanges(service string) []*Exchange {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	var all []*Exchange
+
+	for name, r := range rec.rings {
+		if service == "" || service == name {
+			all = append(all, r.list()...)
+		}
+	}
+
+	sort.Sort(byID(all))
+
+	return all
+}
+
+// Exchange gives recorded exchange with the given ID.
+func (rec *Recorder) Exchange(id int64) (*Exchange, bool) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	for _, r := range rec.rings {
+		for _, ex := range r.items {
+			if ex.ID == id {
+				return ex, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// Replay sends the request of the exchange with the given ID once
+// again to the local server. The replayed exchange is recorded
+// and returned.
+func (rec *Recorder) Replay(id int64, localAddr string) (*Exchange, error) {
+	orig, ok := rec.Exchange(id)
+	if !ok {
+		return nil, fmt.Errorf("exchange %d not found", id)
+	}
+
+	if orig.Request.Truncated {
+		return nil, fmt.Errorf("unable to replay exchange %d: request body was truncated", id)
+	}
+
+	req, err := http.NewRequest(orig.Request.Method, "http://"+localAddr+orig.Request.URL, bytes.NewReader(orig.Request.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = cloneHeader(orig.Request.Header)
+	req.Host = orig.Request.Host
+
+	for _, key := range credentialHeaders {
+		req.Header.Del(key)
+	}
+	req.RemoteAddr = orig.Request.RemoteAddr
+
+	ex, resp, err := rec.roundTrip(req, orig.Service, localAddr)
+	if err == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	ex.Replay = id
+	rec.add(ex, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ex, nil
+}
+
+// proxy serves HTTP requests read from the remote connection
+// with the local server, recording each exchange.
+func (rec *Recorder) proxy(remote net.Conn, service, localAddr string, log logging.Logger) {
+	defer remote.Close()
+
+	br := bufio.NewReader(remote)
+
+	for {
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			if err != io.EOF {
+				log.Debug("unable to read request: %s", err)
+			}
+			return
+		}
+
+		ex, resp, err := rec.roundTrip(req, service, localAddr)
+		if err != nil {
+			rec.add(ex, err)
+			log.Debug("unable to proxy request to %s: %s", localAddr, err)
+
+			sendError(remote, req)
+			return
+		}
+
+		err = resp.Write(remote)
+		resp.Body.Close()
+
+		rec.add(ex, err)
+
+		if err != nil {
+			log.Debug("unable to write response: %s", err)
+			return
+		}
+
+		if req.Close || resp.Close {
+			return
+		}
+	}
+}
+
+// roundTrip sends the request to the local server. The returned
+// exchange is complete once the response body is read and closed.
+func (rec *Recorder) roundTrip(req *http.Request, service, localAddr string) (*Exchange, *http.Response, error) {
+	rec.once.Do(rec.init)
+
+	reqBody := rec.newBody()
+
+	ex := &Exchange{
+		Service: service,
+		Time:    time.Now(),
+		Request: &RecordedRequest{
+			Method:     req.Method,
+			URL:        req.URL.RequestURI(),
+			Proto:      req.Proto,
+			Host:       req.Host,
+			RemoteAddr: req.Header.Get("X-Forwarded-For"),
+			Header:     redactHeader(req.Header),
+		},
+	}
+
+	if ex.Request.RemoteAddr == "" {
+		ex.Request.RemoteAddr = req.RemoteAddr
+	}
+
+	if req.Body != nil {
+		req.Body = &teeBody{ReadCloser: req.Body, body: reqBody}
+	}
+
+	req.RequestURI = ""
+	req.URL.Scheme = "http"
+	req.URL.Host = localAddr
+
+	resp, err := rec.transport.RoundTrip(req)
+
+	ex.Duration = time.Since(ex.Time)
+	ex.Request.Body, ex.Request.Truncated = reqBody.bytes()
+
+	if err != nil {
+		return ex, nil, err
+	}
+
+	respBody := rec.newBody()
+
+	ex.Response = &RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Proto:      resp.Proto,
+		Header:     redactHeader(resp.Header),
+	}
+
+	resp.Body = &teeBody{
+		ReadCloser: resp.Body,
+		body:       respBody,
+		done: func() {
+			ex.Response.Body, ex.Response.Truncated = respBody.bytes()
+			ex.Request.Body, ex.Request.Truncated = reqBody.bytes()
+		},
+	}
+
+	return ex, resp, nil
+}
+
+func (rec *Recorder) add(ex *Exchange, err error) {
+	if err != nil {
+		ex.Error = err.Error()
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.rings == nil {
+		rec.rings = make(map[string]*ring)
+	}
+
+	r, ok := rec.rings[ex.Service]
+	if !ok {
+		r = &ring{size: rec.size()}
+		rec.rings[ex.Service] = r
+	}
+
+	rec.id++
+	ex.ID = rec.id
+
+	r.add(ex)
+}
+
+func (rec *Recorder) init() {
+	rec.transport = &http.Transport{
+		Proxy:               nil, // always talk to the local server directly
+		DisableCompression:  true,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+func (rec *Recorder) newBody() *body {
+	if rec.MaxBodySize > 0 {
+		return &body{max: rec.MaxBodySize}
+	}
+
+	return &body{max: defaultMaxBodySize}
+}
+
+func (rec *Recorder) size() int {
+	if rec.Size > 0 {
+		return rec.Size
+	}
+
+	return defaultRecordSize
+}
+
+// ring is a fixed-size buffer, which overwrites the oldest
+// exchange when full.
+type ring struct {
+	size  int
+	next  int
+	items []*Exchange
+}
+
+func (r *ring) add(ex *Exchange) {
+	if len(r.items) < r.size {
+		r.items = append(r.items, ex)
+	} else {
+		r.items[r.next] = ex
+	}
+
+	r.next = (r.next + 1) % r.size
+}
+
+func (r *ring) list() []*Exchange {
+	items := make([]*Exchange, len(r.items))
+	copy(items, r.items)
+	return items
+}
+
+// body captures at most max bytes written to it; it is safe
+// for concurrent use, as the request body may be still read
+// by the transport while the response is already handled.
+type body struct {
+	mu        sync.Mutex
+	max       int
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *body) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(p)
+
+	if rest := b.max - b.buf.Len(); n > rest {
+		p = p[:rest]
+		b.truncated = true
+	}
+
+	b.buf.Write(p)
+
+	return n, nil
+}
+
+func (b *body) bytes() ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.buf.Len() == 0 {
+		return nil, b.truncated
+	}
+
+	return append([]byte(nil), b.buf.Bytes()...), b.truncated
+}
+
+// teeBody records everything read from the body; done, when non-nil,
+// is called once the body is closed.
+type teeBody struct {
+	io.ReadCloser
+	body *body
+	done func()
+	once sync.Once
+}
+
+func (tb *teeBody) Read(p []byte) (int, error) {
+	n, err := tb.ReadCloser.Read(p)
+	if n > 0 {
+		tb.body.Write(p[:n])
+	}
+	return n, err
+}
+
+func (tb *teeBody) Close() error {
+	err := tb.ReadCloser.Close()
+
+	if tb.done != nil {
+		tb.once.Do(tb.done)
+	}
+
+	return err
+}
+
+// sendError replies with 503 status, like tunnel.HTTPProxy does
+// when the local server is not reachable.
+func sendError(remote net.Conn, req *http.Request) error {
+	const msg = "no local server"
+
+	resp := &http.Response{
+		StatusCode:    http.StatusServiceUnavailable,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Request:       req,
+		Header:        make(http.Header),
+		Body:          ioutil.NopCloser(strings.NewReader(msg)),
+		ContentLength: int64(len(msg)),
+		Close:         true,
+	}
+
+	return resp.Write(remote)
+}
+
+func cloneHeader(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+
+	h2 := make(http.Header, len(h))
+
+	for k, v := range h {
+		h2[k] = append([]string(nil), v...)
+	}
+
+	return h2
+}
+
+// redactHeader gives a copy of the header with values
+// of credential headers redacted.
+func redactHeader(h http.Header) http.Header {
+	h = cloneHeader(h)
+
+	for _, key := range credentialHeaders {
+		if _, ok := h[key]; ok {
+			h[key] = []string{redacted}
+		}
+	}
+
+	return h
+}
+
+type byID []*Exchange
+
+func (p byID) Len() int           { return len(p) }
+func (p byID) Less(i, j int) bool { return p[i].ID > p[j].ID }
+func (p byID) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
@@ -0,0 +1,162 @@
+package tunnelproxy
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/koding/logging"
+)
+
+func TestRecorder(t *testing.T) {
+	var hits int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		p, _ := ioutil.ReadAll(r.Body)
+
+		w.Header().Set("X-Hook", r.URL.Query().Get("id"))
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		w.Write([]byte("got " + string(p)))
+	}))
+	defer srv.Close()
+
+	rec := &Recorder{Size: 2, MaxBodySize: 8}
+	log := logging.NewCustom("recorder", testing.Verbose())
+	addr := srv.Listener.Addr().String()
+
+	// Simulates a stream of a tunnel connection.
+	remote, local := net.Pipe()
+	done := make(chan struct{})
+
+	go func() {
+		rec.proxy(local, "web", addr, log)
+		close(done)
+	}()
+
+	br := bufio.NewReader(remote)
+
+	for _, id := range []string{"1", "2", "3"} {
+		req, err := http.NewRequest("POST", "http://example.com/hook?id="+id, strings.NewReader("payload "+id))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set(TokenHeader, "secret")
+
+		if err := req.Write(remote); err != nil {
+			t.Fatalf("Write()=%s", err)
+		}
+
+		resp, err := http.ReadResponse(br, req)
+		if err != nil {
+			t.Fatalf("ReadResponse()=%s", err)
+		}
+
+		p, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "got payload " + id; string(p) != want {
+			t.Fatalf("got %q, want %q", p, want)
+		}
+	}
+
+	// Waits until the last exchange is recorded.
+	remote.Close()
+	<-done
+
+	exs := rec.Exchanges("web")
+
+	if len(exs) != 2 {
+		t.Fatalf("got %d exchanges, want 2", len(exs))
+	}
+
+	// The oldest exchange was overwritten.
+	if exs[0].ID != 3 || exs[1].ID != 2 {
+		t.Fatalf("got exchanges %d, %d; want 3, 2", exs[0].ID, exs[1].ID)
+	}
+
+	ex := exs[0]
+
+	if ex.Request.URL != "/hook?id=3" || ex.Request.Host != "example.com" {
+		t.Fatalf("got %s %s, want example.com /hook?id=3", ex.Request.Host, ex.Request.URL)
+	}
+
+	if string(ex.Request.Body) != "payload " || !ex.Request.Truncated {
+		t.Fatalf("got request body %q (truncated=%t), want truncated %q", ex.Request.Body, ex.Request.Truncated, "payload ")
+	}
+
+	// Credentials are forwarded, but not recorded.
+	if ex.Response.StatusCode != 200 || ex.Response.Header.Get("X-Hook") != "3" ||
+		ex.Response.Header.Get("X-Authorization") != "Bearer secret" {
+		t.Fatalf("got response %+v", ex.Response)
+	}
+
+	for _, key := range []string{"Authorization", TokenHeader} {
+		if got := ex.Request.Header.Get(key); got != redacted {
+			t.Fatalf("got %s header %q, want %q", key, got, redacted)
+		}
+	}
+
+	if _, err := rec.Replay(3, addr); err == nil {
+		t.Fatal("expected replay of truncated request to fail")
+	}
+
+	if n := len(rec.Exchanges("other")); n != 0 {
+		t.Fatalf("got %d exchanges, want 0", n)
+	}
+
+	// Replays a request, which body was recorded completely.
+	rec.MaxBodySize = 0
+
+	remote, local = net.Pipe()
+	done = make(chan struct{})
+
+	go func() {
+		rec.proxy(local, "web", addr, log)
+		close(done)
+	}()
+
+	req, err := http.NewRequest("GET", "http://example.com/status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Close = true
+
+	if err := req.Write(remote); err != nil {
+		t.Fatalf("Write()=%s", err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(remote), req)
+	if err != nil {
+		t.Fatalf("ReadResponse()=%s", err)
+	}
+	resp.Body.Close()
+
+	remote.Close()
+	<-done
+
+	replayed, err := rec.Replay(4, addr)
+	if err != nil {
+		t.Fatalf("Replay()=%s", err)
+	}
+
+	if replayed.Replay != 4 || replayed.ID != 5 || string(replayed.Response.Body) != "got " {
+		t.Fatalf("got replayed exchange %+v", replayed)
+	}
+
+	if n := atomic.LoadInt32(&hits); n != 5 {
+		t.Fatalf("got %d requests, want 5", n)
+	}
+}
@@ -0,0 +1,193 @@
+package tunnelproxy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Issuer = (*LocalIssuer)(nil)
+
+// LocalIssuer is an Issuer, which signs certificates with a local,
+// self-signed CA.
+//
+// Like Pebble, it validates HTTP-01 challenges before issuing
+// a certificate, which makes it a stand-in for an ACME CA
+// in tests and development environments.
+type LocalIssuer struct {
+	// Solver fulfills the challenges, it must be of http-01 type.
+	Solver Solver
+
+	// Client is used to fetch challenge responses.
+	//
+	// If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// Validity is a validity period of issued certificates.
+	//
+	// If zero, 90 days is used.
+	Validity time.Duration
+
+	once   sync.Once
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+	err    error
+}
+
+// CACert gives the certificate of the local CA.
+func (li *LocalIssuer) CACert() (*x509.Certificate, error) {
+	li.once.Do(li.init)
+
+	return li.caCert, li.err
+}
+
+// Issue implements the Issuer interface.
+func (li *LocalIssuer) Issue(ctx context.Context, domain string, csrDER []byte) ([][]byte, error) {
+	if _, err := li.CACert(); err != nil {
+		return nil, err
+	}
+
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	if csr.Subject.CommonName != domain {
+		return nil, fmt.Errorf("CSR is not for %q domain", domain)
+	}
+
+	if err := li.validate(ctx, domain); err != nil {
+		return nil, fmt.Errorf("unable to validate %q: %s", domain, err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(li.validity()),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, li.caCert, csr.PublicKey, li.caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return [][]byte{der, li.caCert.Raw}, nil
+}
+
+// validate checks the domain serves a response to the challenge.
+func (li *LocalIssuer) validate(ctx context.Context, domain string) error {
+	if li.Solver.Type() != "http-01" {
+		return errors.New("unsupported challenge type: " + li.Solver.Type())
+	}
+
+	p := make([]byte, 32)
+
+	if _, err := io.ReadFull(rand.Reader, p); err != nil {
+		return err
+	}
+
+	token := hex.EncodeToString(p[:16])
+	response := token + "." + hex.EncodeToString(p[16:])
+
+	if err := li.Solver.Present(ctx, domain, token, response); err != nil {
+		return err
+	}
+	defer li.Solver.CleanUp(ctx, domain, token)
+
+	req, err := http.NewRequest("GET", "http://"+domain+ACMEChallengePath+token, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := li.client().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	if strings.TrimSpace(string(body)) != response {
+		return errors.New("invalid challenge response")
+	}
+
+	return nil
+}
+
+func (li *LocalIssuer) init() {
+	li.caKey, li.err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if li.err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Koding Tunnel Local CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &li.caKey.PublicKey, li.caKey)
+	if err != nil {
+		li.err = err
+		return
+	}
+
+	li.caCert, li.err = x509.ParseCertificate(der)
+}
+
+func (li *LocalIssuer) client() *http.Client {
+	if li.Client != nil {
+		return li.Client
+	}
+
+	return http.DefaultClient
+}
+
+func (li *LocalIssuer) validity() time.Duration {
+	if li.Validity != 0 {
+		return li.Validity
+	}
+
+	return 90 * 24 * time.Hour
+}
@@ -0,0 +1,211 @@
+package tunnelproxy
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// TokenHeader is a header, which carries a shared token
+// required by Policy.Token.
+const TokenHeader = "X-Tunnel-Token"
+
+var (
+	errForbidden    = errors.New("access denied")
+	errUnauthorized = errors.New("unauthorized")
+)
+
+// BasicAuth describes HTTP basic auth credentials.
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Policy describes who is allowed to access a tunneled service.
+//
+// All of the non-empty rules must be satisfied. A nil or empty
+// policy makes the service publicly reachable.
+//
+// Port-routed services do not speak HTTP, thus only the AllowedIPs
+// rule can be enforced for them. The other rules apply to HTTP
+// requests received on the virtual host of the tunnel and are
+// configured with a policy of the "kite" service.
+type Policy struct {
+	// BasicAuth requires HTTP basic auth with the given credentials.
+	BasicAuth *BasicAuth `json:"basicAuth,omitempty"`
+
+	// Token requires the TokenHeader header to be set to the given value.
+	Token string `json:"token,omitempty"`
+
+	// AllowedIPs restricts access to the given IP addresses
+	// or networks in CIDR notation.
+	AllowedIPs []string `json:"allowedIPs,omitempty"`
+
+	// KodingSession requires the request to carry a kite token
+	// or kite key of the tunnel owner, signed by kontrol:
+	//
+	//   Authorization: Bearer <token>
+	//
+	KodingSession bool `json:"kodingSession,omitempty"`
+}
+
+// IsHTTP tells whether the policy has rules, which can be
+// only enforced for HTTP requests.
+func (p *Policy) IsHTTP() bool {
+	return p != nil && (p.BasicAuth != nil || p.Token != "" || p.KodingSession)
+}
+
+// Valid validates the policy.
+func (p *Policy) Valid() error {
+	if p == nil {
+		return nil
+	}
+
+	if p.BasicAuth != nil && p.KodingSession {
+		return errors.New("basicAuth and kodingSession both use Authorization header")
+	}
+
+	if p.BasicAuth != nil && p.BasicAuth.Username == "" {
+		return errors.New("basicAuth username is empty")
+	}
+
+	for _, s := range p.AllowedIPs {
+		if _, err := parseIPNet(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// allowIP tells whether the given ip is allowed by the policy.
+func (p *Policy) allowIP(ip string) bool {
+	if p == nil || len(p.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(parseIP(ip))
+	if addr == nil {
+		return false
+	}
+
+	for _, s := range p.AllowedIPs {
+		if n, err := parseIPNet(s); err == nil && n.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authenticator verifies a kite token or kite key and
+// gives the username it was issued for.
+type authenticator func(token string) (username string, err error)
+
+// allow checks whether the request is allowed by the policy.
+//
+// The owner is a username of the tunnel owner, which is
+// required by KodingSession rule.
+func (p *Policy) allow(r *http.Request, owner string, auth authenticator) error {
+	if p == nil {
+		return nil
+	}
+
+	// The IP is taken from the connection only, as forwarding
+	// headers can be set by the client.
+	if !p.allowIP(r.RemoteAddr) {
+		return errForbidden
+	}
+
+	if p.BasicAuth != nil {
+		user, pass, ok := r.BasicAuth()
+		if !ok || !equal(user, p.BasicAuth.Username) || !equal(pass, p.BasicAuth.Password) {
+			return errUnauthorized
+		}
+	}
+
+	if p.Token != "" && !equal(r.Header.Get(TokenHeader), p.Token) {
+		return errUnauthorized
+	}
+
+	if p.KodingSession {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || auth == nil {
+			return errUnauthorized
+		}
+
+		username, err := auth(token)
+		if err != nil || username != owner {
+			return errUnauthorized
+		}
+	}
+
+	return nil
+}
+
+func equal(s, t string) bool {
+	return subtle.ConstantTimeCompare([]byte(s), []byte(t)) == 1
+}
+
+func parseIPNet(s string) (*net.IPNet, error) {
+	if strings.ContainsRune(s, '/') {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed IP %q: %s", s, err)
+		}
+
+		return n, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid allowed IP %q", s)
+	}
+
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// policyListener enforces AllowedIPs rule of a policy
+// for port-routed services.
+type policyListener struct {
+	net.Listener
+
+	mu     sync.Mutex
+	policy *Policy
+}
+
+// Accept waits for a connection, which is allowed by the policy.
+// Other connections are closed.
+func (l *policyListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		l.mu.Lock()
+		policy := l.policy
+		l.mu.Unlock()
+
+		if policy.allowIP(conn.RemoteAddr().String()) {
+			return conn, nil
+		}
+
+		conn.Close()
+	}
+}
+
+func (l *policyListener) setPolicy(policy *Policy) {
+	l.mu.Lock()
+	l.policy = policy
+	l.mu.Unlock()
+}
@@ -0,0 +1,124 @@
+package tunnelproxy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	auth := func(token string) (string, error) {
+		if token == "valid" {
+			return "rafal", nil
+		}
+		return "", errors.New("invalid token")
+	}
+
+	req := func(remote string, header ...string) *http.Request {
+		r, err := http.NewRequest("GET", "http://tunnel.koding.me/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r.RemoteAddr = remote
+
+		for i := 0; i+1 < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+
+		return r
+	}
+
+	basic := req("10.0.0.1:1234")
+	basic.SetBasicAuth("user", "pass")
+
+	badBasic := req("10.0.0.1:1234")
+	badBasic.SetBasicAuth("user", "bad")
+
+	cases := map[string]struct {
+		policy *Policy
+		req    *http.Request
+		want   error
+	}{
+		"nil policy": {
+			nil,
+			req("1.2.3.4:1234"),
+			nil,
+		},
+		"allowed ip": {
+			&Policy{AllowedIPs: []string{"1.2.3.4", "10.0.0.0/8"}},
+			req("10.1.2.3:1234"),
+			nil,
+		},
+		"forbidden ip": {
+			&Policy{AllowedIPs: []string{"1.2.3.4", "10.0.0.0/8"}},
+			req("11.1.2.3:1234", "X-Forwarded-For", "1.2.3.4"),
+			errForbidden,
+		},
+		"basic auth": {
+			&Policy{BasicAuth: &BasicAuth{Username: "user", Password: "pass"}},
+			basic,
+			nil,
+		},
+		"bad basic auth": {
+			&Policy{BasicAuth: &BasicAuth{Username: "user", Password: "pass"}},
+			badBasic,
+			errUnauthorized,
+		},
+		"token": {
+			&Policy{Token: "secret"},
+			req("1.2.3.4:1234", TokenHeader, "secret"),
+			nil,
+		},
+		"missing token": {
+			&Policy{Token: "secret"},
+			req("1.2.3.4:1234"),
+			errUnauthorized,
+		},
+		"koding session": {
+			&Policy{KodingSession: true},
+			req("1.2.3.4:1234", "Authorization", "Bearer valid"),
+			nil,
+		},
+		"invalid koding session": {
+			&Policy{KodingSession: true},
+			req("1.2.3.4:1234", "Authorization", "Bearer invalid"),
+			errUnauthorized,
+		},
+		"all rules": {
+			&Policy{Token: "secret", AllowedIPs: []string{"1.2.3.4"}, KodingSession: true},
+			req("1.2.3.4:1234", TokenHeader, "secret", "Authorization", "Bearer valid"),
+			nil,
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := cas.policy.allow(cas.req, "rafal", auth); err != cas.want {
+				t.Fatalf("got %v, want %v", err, cas.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Valid(t *testing.T) {
+	cases := map[string]struct {
+		policy *Policy
+		ok     bool
+	}{
+		"nil":          {nil, true},
+		"ips":          {&Policy{AllowedIPs: []string{"1.2.3.4", "::1", "10.0.0.0/8"}}, true},
+		"invalid ip":   {&Policy{AllowedIPs: []string{"1.2.3"}}, false},
+		"invalid cidr": {&Policy{AllowedIPs: []string{"10.0.0.0/33"}}, false},
+		"no username":  {&Policy{BasicAuth: &BasicAuth{Password: "pass"}}, false},
+		"conflict":     {&Policy{BasicAuth: &BasicAuth{Username: "user"}, KodingSession: true}, false},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := cas.policy.Valid(); (err == nil) != cas.ok {
+				t.Fatalf("got %v, want ok=%t", err, cas.ok)
+			}
+		})
+	}
+}
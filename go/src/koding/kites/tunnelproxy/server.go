@@ -26,11 +26,14 @@ import (
 
 	dogstatsd "github.com/DataDog/datadog-go/statsd"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
 	"github.com/hashicorp/go-multierror"
 	"github.com/koding/kite"
+	"github.com/koding/kite/kitekey"
 	"github.com/koding/logging"
 	"github.com/koding/tunnel"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 type ServerOptions struct {
@@ -40,6 +43,14 @@ type ServerOptions struct {
 	AccessKey       string `json:"accessKey"`
 	SecretKey       string `json:"secretKey"`
 
+	// DNS backend config; Route53 is used by default.
+	DNSBackend      string `json:"dnsBackend,omitempty"`      // "route53", "rfc2136" or "cloudflare"
+	DNSServer       string `json:"dnsServer,omitempty"`       // rfc2136 name server address
+	DNSKeyName      string `json:"dnsKeyName,omitempty"`      // rfc2136 TSIG key name
+	DNSKeySecret    string `json:"dnsKeySecret,omitempty"`    // rfc2136 TSIG key secret, base64-encoded
+	DNSKeyAlgorithm string `json:"dnsKeyAlgorithm,omitempty"` // rfc2136 TSIG algorithm, hmac-sha256 when empty
+	CloudflareToken string `json:"cloudflareToken,omitempty"`
+
 	// Server kite config.
 	Port        int    `json:"port" required:"true"`
 	Region      string `json:"region" required:"true"`
@@ -54,8 +65,38 @@ type ServerOptions struct {
 	Test         bool   `json:"test,omitempty"`
 	NoCNAME      bool   `json:"noCNAME,omitempty"`
 
+	// Custom domains.
+	HTTPSPort     int    `json:"httpsPort,omitempty"`     // serves custom domains over TLS when non-zero
+	ACMEDirectory string `json:"acmeDirectory,omitempty"` // Let's Encrypt when empty
+	ACMEEmail     string `json:"acmeEmail,omitempty"`
+	CertCacheDir  string `json:"certCacheDir,omitempty"`
+
 	Log     logging.Logger    `json:"-"`
 	Metrics *dogstatsd.Client `json:"-"`
+
+	// Solver fulfills ACME challenges for custom domains.
+	// If nil, HTTP-01 challenges are served by the server.
+	Solver Solver `json:"-"`
+
+	// Issuer issues certificates for custom domains.
+	// If nil, certificates are issued with ACME.
+	Issuer Issuer `json:"-"`
+
+	// CertCache overwrites storage of the issued certificates,
+	// which is CertCacheDir by default.
+	CertCache autocert.Cache `json:"-"`
+}
+
+// hasDNS tells whether the server is configured to manage DNS records.
+// The Route53 backend requires AWS credentials, other backends
+// are validated when creating the client.
+func (opts *ServerOptions) hasDNS() bool {
+	switch strings.ToLower(opts.DNSBackend) {
+	case "", "route53":
+		return opts.AccessKey != "" && opts.SecretKey != ""
+	default:
+		return true
+	}
 }
 
 func (opts *ServerOptions) registerURL() string {
@@ -78,15 +119,18 @@ func (opts *ServerOptions) kontrolURL() string {
 // of the tunneling sessions for the clients.
 type Server struct {
 	Server *tunnel.Server
-	DNS    *dnsclient.Route53
+	DNS    dnsclient.RecordClient
+	Certs  *CertManager // nil when custom domains are disabled
 
 	opts      *ServerOptions
 	record    *dnsclient.Record
 	callbacks *callbacks
 	privateIP string
+	auth      authenticator // verifies kite tokens for KodingSession policies
 
-	mu       sync.Mutex        // protects idents, services and tunnels
+	mu       sync.Mutex        // protects idents, domains, services and tunnels
 	idents   map[string]string // maps vhost to ident
+	domains  map[string]string // maps custom domain to ident
 	services map[int]net.Listener
 	tunnels  *Tunnels
 
@@ -145,19 +189,27 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
-	if optsCopy.NoCNAME && (optsCopy.AccessKey == "" || optsCopy.SecretKey == "") {
-		return nil, errors.New("no valid Route53 configuration found")
+	if optsCopy.NoCNAME && !optsCopy.hasDNS() {
+		return nil, errors.New("no valid DNS configuration found")
 	}
 
-	var dns *dnsclient.Route53
-	if optsCopy.AccessKey != "" && optsCopy.SecretKey != "" {
+	var dns dnsclient.RecordClient
+	if optsCopy.hasDNS() {
 		dnsOpts := &dnsclient.Options{
-			Creds:      credentials.NewStaticCredentials(optsCopy.AccessKey, optsCopy.SecretKey, ""),
-			HostedZone: optsCopy.HostedZone,
-			Log:        optsCopy.Log,
-			Debug:      optsCopy.Debug,
+			HostedZone:    optsCopy.HostedZone,
+			Log:           optsCopy.Log,
+			Debug:         optsCopy.Debug,
+			Backend:       optsCopy.DNSBackend,
+			Server:        optsCopy.DNSServer,
+			TSIGName:      optsCopy.DNSKeyName,
+			TSIGSecret:    optsCopy.DNSKeySecret,
+			TSIGAlgorithm: optsCopy.DNSKeyAlgorithm,
+			APIToken:      optsCopy.CloudflareToken,
+		}
+		if optsCopy.AccessKey != "" && optsCopy.SecretKey != "" {
+			dnsOpts.Creds = credentials.NewStaticCredentials(optsCopy.AccessKey, optsCopy.SecretKey, "")
 		}
-		dns, err = dnsclient.NewRoute53Client(dnsOpts)
+		dns, err = dnsclient.New(dnsOpts)
 		if err != nil {
 			return nil, err
 		}
@@ -168,7 +220,7 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 	// In other words if tunnel.example.com resolves to the tunnelserver, then
 	// *.tunnel.example.com must also resolve to the very same tunnelserver instance.
 	//
-	// If there are not route53 credentials passed, we assume the DNS records are
+	// If there is no DNS configuration passed, we assume the DNS records are
 	// taken care externally.
 	if !optsCopy.NoCNAME && dns != nil {
 		id, err := instanceID()
@@ -227,10 +279,17 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 		privateIP: "0.0.0.0",
 		record:    dnsclient.ParseRecord("", optsCopy.ServerAddr),
 		idents:    make(map[string]string),
+		domains:   make(map[string]string),
 		services:  make(map[int]net.Listener),
 		tunnels:   newTunnels(),
 	}
 
+	if optsCopy.HTTPSPort != 0 {
+		if err := s.initCerts(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Do not bind to private address for testing.
 	if !s.opts.Test {
 		if ip, err := privateIP(); err == nil {
@@ -265,13 +324,18 @@ type RegisterRequest struct {
 	// known services upon start, instead of issuing separate
 	// RegisterServices call.
 	Services map[string]*Tunnel `json:"services,omitempty"` // maps publicIP to local address
+
+	// Domains are custom domains, which are routed to the tunnel.
+	// Each domain is required to be a CNAME of the virtual host.
+	Domains []string `json:"domains,omitempty"`
 }
 
 // RegisterResult represents response value for register method.
 type RegisterResult struct {
-	VirtualHost string `json:"virtualHost"`
-	Ident       string `json:"identifier"`
-	ServerAddr  string `json:"serverAddr"`
+	VirtualHost string   `json:"virtualHost"`
+	Ident       string   `json:"identifier"`
+	ServerAddr  string   `json:"serverAddr"`
+	Domains     []string `json:"domains,omitempty"` // custom domains, which were added
 }
 
 type RegisterServicesRequest struct {
@@ -325,13 +389,13 @@ func (res *RegisterServicesResult) Err() (err error) {
 	return err
 }
 
-func (s *Server) addClient(ident, name, vhost string, services map[string]*Tunnel) {
+func (s *Server) addClient(ident, name, vhost, owner string, services map[string]*Tunnel) {
 	s.opts.Log.Debug("%s: adding vhost=%s", ident, vhost)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.tunnels.addClient(ident, name, vhost)
+	s.tunnels.addClient(ident, name, vhost, owner)
 	s.idents[vhost] = ident
 
 	var err error
@@ -376,6 +440,19 @@ func (s *Server) delClient(ident, vhost string) {
 		l.Close() // TODO(rjeczalik): add 10m grace period for client reconnections
 	}
 
+	for domain, domainIdent := range s.domains {
+		if domainIdent != ident {
+			continue
+		}
+
+		s.opts.Log.Debug("%s: deleting domain=%q", ident, domain)
+
+		s.Server.DeleteHost(domain)
+		s.Certs.Forget(domain)
+		delete(s.idents, domain)
+		delete(s.domains, domain)
+	}
+
 	s.tunnels.delClient(ident)
 	delete(s.idents, vhost)
 }
@@ -386,12 +463,34 @@ func (s *Server) addClientService(ident string, tun *Tunnel) error {
 
 	s.opts.Log.Debug("adding %q client: %+v", ident, tun)
 
+	if err := validProtocol(tun.Protocol); err != nil {
+		return err
+	}
+
+	if err := tun.Policy.Valid(); err != nil {
+		return fmt.Errorf("invalid policy: %s", err)
+	}
+
+	if tun.Policy.IsHTTP() {
+		return errors.New("only allowedIPs policy is supported for port-routed services")
+	}
+
 	err := s.tunnels.addClientService(ident, tun)
 	if err == errAlreadyExists {
 		// Tunnel already exists - try to update its port number to
 		// requested number or returned already owned one.
 		existingTun := s.tunnels.tunnel(ident, tun.Name)
 
+		if tun.protocol() != existingTun.protocol() {
+			return fmt.Errorf("%q service is already registered with %s protocol", tun.Name, existingTun.protocol())
+		}
+
+		existingTun.Policy = tun.Policy
+
+		if l, ok := s.services[existingTun.Port].(*policyListener); ok {
+			l.setPolicy(tun.Policy)
+		}
+
 		if tun.Port == existingTun.Port {
 			// Tunnel already exists and has requested port.
 			return nil
@@ -404,7 +503,7 @@ func (s *Server) addClientService(ident string, tun *Tunnel) error {
 			return nil
 		}
 
-		l, err := s.listen("tcp", s.addr(tun.Port))
+		l, err := s.listen(tun.protocol(), s.addr(tun.Port))
 		if err != nil {
 			s.opts.Log.Debug("%s: failed to upgrade port %d -> %d for %q", existingTun.Port, tun.Port, ident)
 
@@ -415,6 +514,8 @@ func (s *Server) addClientService(ident string, tun *Tunnel) error {
 			return nil
 		}
 
+		l = &policyListener{Listener: l, policy: tun.Policy}
+
 		// We managed to update tunnel's port number to requested value.
 		// Replace the listeners.
 		existingL, ok := s.services[existingTun.Port]
@@ -437,19 +538,22 @@ func (s *Server) addClientService(ident string, tun *Tunnel) error {
 		return err
 	}
 
-	l, err := s.listen("tcp", s.addr(tun.Port))
+	l, err := s.listen(tun.protocol(), s.addr(tun.Port))
 	if err != nil && tun.Port != 0 {
 		s.opts.Log.Debug("failed to bind to requested port %d, binding to random one: %s", tun.Port, err)
 
-		l, err = s.listen("tcp", s.addr(0))
+		l, err = s.listen(tun.protocol(), s.addr(0))
 	}
 	if err != nil {
+		s.tunnels.delClientService(ident, tun.Name)
+
 		return fmt.Errorf("failed to open tunnel: %s", err)
 	}
 
+	tun.Port = port(l.Addr().String())
+	l = &policyListener{Listener: l, policy: tun.Policy}
 	s.services[tun.Port] = l
 	s.Server.AddAddr(l, nil, ident)
-	tun.Port = port(l.Addr().String())
 
 	return nil
 }
@@ -476,7 +580,8 @@ func (s *Server) addr(port int) string {
 	return net.JoinHostPort(s.privateIP, strconv.Itoa(port))
 }
 
-// RegisterServices creates a port-routed TCP tunnel for each requested service.
+// RegisterServices creates a port-routed TCP or UDP tunnel for each
+// requested service.
 func (s *Server) RegisterServices(r *kite.Request) (interface{}, error) {
 	resp, err := s.registerServices(r)
 	if err != nil {
@@ -595,6 +700,18 @@ func (s *Server) register(r *kite.Request) (interface{}, error) {
 
 	s.opts.Log.Debug("received register request: %s", TunnelsByName(toList(req.Services, vhost)))
 
+	for name, tun := range req.Services {
+		if err := tun.Policy.Valid(); err != nil {
+			return nil, fmt.Errorf("invalid policy for %q service: %s", name, err)
+		}
+
+		// HTTP rules are enforced for requests received on the
+		// virtual host only, which are routed to the kite service.
+		if name != "kite" && tun.Policy.IsHTTP() {
+			return nil, fmt.Errorf("invalid policy for %q service: only allowedIPs policy is supported", name)
+		}
+	}
+
 	// By default all addresses are routed by default with wildcard
 	// CNAME. If it is disabled explicitly, we're inserting A records
 	// for each tunnel instead.
@@ -610,7 +727,9 @@ func (s *Server) register(r *kite.Request) (interface{}, error) {
 		Ident:       utils.RandString(32),
 	}
 
-	s.addClient(res.Ident, req.TunnelName, res.VirtualHost, req.Services)
+	s.addClient(res.Ident, req.TunnelName, res.VirtualHost, req.Username, req.Services)
+
+	res.Domains = s.addDomains(res.Ident, res.VirtualHost, req.Domains)
 
 	s.Server.OnDisconnect(res.Ident, func() error {
 		s.delClient(res.Ident, res.VirtualHost)
@@ -638,14 +757,29 @@ func (s *Server) upsert(vhost string) error {
 	return s.DNS.UpsertRecord(&rec)
 }
 
+// listen listens on the given address. If the address has no port,
+// the first available one from the TCP range is used.
+//
+// TCP and UDP tunnels share the range, so a port is never
+// used by more than one service.
+//
+// It must be called with s.mu held.
 func (s *Server) listen(network, addr string) (net.Listener, error) {
 	host, port, err := splitHostPort(addr)
-	if err != nil || port != 0 {
-		return net.Listen(network, addr)
+	if err != nil {
+		return listenNetwork(network, addr)
+	}
+
+	if port != 0 {
+		if _, ok := s.services[port]; ok {
+			return nil, fmt.Errorf("port %d is already in use", port)
+		}
+
+		return listenNetwork(network, addr)
 	}
 
 	if s.opts.TCPRangeTo == 0 && s.opts.TCPRangeFrom == 0 {
-		return net.Listen(network, addr)
+		return listenNetwork(network, addr)
 	}
 
 	from := s.opts.TCPRangeFrom
@@ -666,9 +800,14 @@ func (s *Server) listen(network, addr string) (net.Listener, error) {
 			port = from
 		}
 
+		if _, ok := s.services[port]; ok {
+			port++
+			continue
+		}
+
 		addr := net.JoinHostPort(host, strconv.Itoa(port))
 
-		l, err := net.Listen(network, addr)
+		l, err := listenNetwork(network, addr)
 		if err == nil {
 			s.lastMu.Lock()
 			s.last = port
@@ -685,6 +824,19 @@ func (s *Server) listen(network, addr string) (net.Listener, error) {
 	return nil, errors.New("unable to find available port")
 }
 
+func listenNetwork(network, addr string) (net.Listener, error) {
+	if network == "udp" {
+		l, err := listenUDP(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return l, nil
+	}
+
+	return net.Listen(network, addr)
+}
+
 func isLocal(tun *Tunnel, r *http.Request) bool {
 	if tun.LocalAddr == "" {
 		return false
@@ -693,22 +845,28 @@ func isLocal(tun *Tunnel, r *http.Request) bool {
 	return ok
 }
 
-// TODO(rjeczalik): make it possible for services to register custom
-// protocol type.
-func (s *Server) discover(service string, r *http.Request) ([]*Endpoint, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	ident, ok := s.idents[r.Host]
+// ident gives identifier of a client, which owns the given virtual host.
+//
+// It must be called with s.mu held.
+func (s *Server) ident(vhost string) (string, bool) {
+	ident, ok := s.idents[vhost]
 	if !ok {
-		host, port, err := net.SplitHostPort(r.Host)
+		host, port, err := net.SplitHostPort(vhost)
 		if err == nil && (port == "80" || port == "443") {
 			ident, ok = s.idents[host]
 		}
+	}
 
-		if !ok {
-			return nil, errors.New("virtual host not registered: " + r.Host)
-		}
+	return ident, ok
+}
+
+func (s *Server) discover(service string, r *http.Request) ([]*Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ident, ok := s.ident(r.Host)
+	if !ok {
+		return nil, errors.New("virtual host not registered: " + r.Host)
 	}
 
 	if service == "kite" || service == "kites" {
@@ -726,13 +884,13 @@ func (s *Server) discover(service string, r *http.Request) ([]*Endpoint, error)
 		s.opts.Log.Debug("%s: found local route for %s: %s", ident, tun.PublicIP, tun.LocalAddr)
 
 		return []*Endpoint{
-			tun.localEndpoint("tcp"),
-			tun.remoteEndpoint("tcp"),
+			tun.localEndpoint(tun.protocol()),
+			tun.remoteEndpoint(tun.protocol()),
 		}, nil
 
 	}
 
-	return []*Endpoint{tun.remoteEndpoint("tcp")}, nil
+	return []*Endpoint{tun.remoteEndpoint(tun.protocol())}, nil
 }
 
 func (s *Server) discoverKite(ident string, r *http.Request) ([]*Endpoint, error) {
@@ -800,10 +958,76 @@ func (s *Server) serverHandler() http.HandlerFunc {
 
 		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/klient")
 
+		if h, ok := s.opts.Solver.(http.Handler); ok && strings.HasPrefix(r.URL.Path, ACMEChallengePath) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if err := s.authorize(r); err != nil {
+			s.opts.Log.Debug("%s: access to %s denied: %s", r.RemoteAddr, r.Host, err)
+
+			if err == errForbidden {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+host(r.Host)+`"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
 		s.Server.ServeHTTP(w, r)
 	}
 }
 
+// authorize checks whether the request is allowed by the policy
+// of the virtual host, which is the policy of the "kite" service.
+func (s *Server) authorize(r *http.Request) error {
+	s.mu.Lock()
+	ident, ok := s.ident(r.Host)
+	var policy *Policy
+	var owner string
+	if ok {
+		if tun := s.tunnels.tunnel(ident, "kite"); tun != nil {
+			policy = tun.Policy
+		}
+		if tun := s.tunnels.tunnel(ident, ""); tun != nil {
+			owner = tun.owner
+		}
+	}
+	s.mu.Unlock()
+
+	// Requests for unknown virtual hosts are rejected by tunnel.Server.
+	if !ok {
+		return nil
+	}
+
+	return policy.allow(r, owner, s.auth)
+}
+
+// kiteAuthenticator verifies kite keys and kite tokens
+// signed by kontrol.
+func kiteAuthenticator(k *kite.Kite) authenticator {
+	return func(token string) (string, error) {
+		if username, err := k.AuthenticateSimpleKiteKey(token); err == nil {
+			return username, nil
+		}
+
+		var claims kitekey.KiteClaims
+
+		t, err := jwt.ParseWithClaims(token, &claims, k.RSAKey)
+		if err != nil {
+			return "", err
+		}
+
+		if !t.Valid || claims.Subject == "" {
+			return "", errors.New("invalid token")
+		}
+
+		return claims.Subject, nil
+	}
+}
+
 // NewServerKite creates a server kite for the given server.
 func NewServerKite(s *Server, name, version string) (*kite.Kite, error) {
 	cfg, err := konfig.ReadKiteConfig(s.opts.Debug)
@@ -834,6 +1058,10 @@ func NewServerKite(s *Server, name, version string) (*kite.Kite, error) {
 
 	k.Log = s.opts.Log
 
+	if !s.opts.Test {
+		s.auth = kiteAuthenticator(k)
+	}
+
 	k.HandleFunc("register", metrics.WrapKiteHandler(s.opts.Metrics, "register", s.Register))
 	k.HandleFunc("registerServices", metrics.WrapKiteHandler(s.opts.Metrics, "registerServices", s.RegisterServices))
 	k.HandleHTTPFunc("/healthCheck", artifact.HealthCheckHandler(name))
@@ -854,6 +1082,12 @@ func NewServerKite(s *Server, name, version string) (*kite.Kite, error) {
 		return nil, fmt.Errorf("error registering to Kontrol: %s", err)
 	}
 
+	if s.Certs != nil {
+		if err := s.serveTLS(k); err != nil {
+			return nil, fmt.Errorf("error serving custom domains: %s", err)
+		}
+	}
+
 	return k, nil
 }
 
@@ -21,6 +21,11 @@ type Service struct {
 	LocalAddr     string `json:"localAddr"`
 	RemoteAddr    string `json:"remoteAddr"`              // tunnel.Port
 	ForwardedPort int    `json:"forwardedPort,omitempty"` // tunnel.LocalAddr
+	Protocol      string `json:"protocol,omitempty"`      // "tcp" (default) or "udp"
+
+	Policy *Policy `json:"policy,omitempty"` // access control
+
+	Inspect bool `json:"inspect,omitempty"` // whether to record HTTP traffic
 }
 
 type Services map[string]*Service
@@ -46,10 +51,16 @@ type Tunnel struct {
 	VirtualHost string `json:"virtualHost,omitempty"`
 	Error       string `json:"error,omitempty"`
 	Restore     bool   `json:"restore,omitempty"`
+	Protocol    string `json:"protocol,omitempty"` // "tcp" (default) or "udp"
+
+	// Access control.
+	Policy *Policy `json:"policy,omitempty"`
 
 	// Local routing.
 	PublicIP  string `json:"publicIP,omitempty"`
 	LocalAddr string `json:"localAddr,omitempty"` // either local port or forwarded one (related to package TODO)
+
+	owner string // username of the tunnel owner, set for virtual host tunnel
 }
 
 func (t *Tunnel) String() string {
@@ -68,6 +79,13 @@ func (t *Tunnel) Err() error {
 	return nil
 }
 
+func (t *Tunnel) protocol() string {
+	if t.Protocol != "" {
+		return t.Protocol
+	}
+	return "tcp"
+}
+
 func (t *Tunnel) remoteEndpoint(proto string) *Endpoint {
 	e := &Endpoint{
 		Addr:     t.VirtualHost,
@@ -115,6 +133,17 @@ func (t TunnelsByName) String() string {
 
 var errAlreadyExists = errors.New("already exists")
 
+// validProtocol checks whether the given transport protocol
+// can be used for a service tunnel.
+func validProtocol(proto string) error {
+	switch proto {
+	case "", "tcp", "udp":
+		return nil
+	default:
+		return fmt.Errorf("unsupported protocol %q", proto)
+	}
+}
+
 // Tunnels
 type Tunnels struct {
 	m map[string]map[string]*Tunnel // maps ident to service name to service desc
@@ -126,7 +155,7 @@ func newTunnels() *Tunnels {
 	}
 }
 
-func (t *Tunnels) addClient(ident, name, vhost string) {
+func (t *Tunnels) addClient(ident, name, vhost, owner string) {
 	client, ok := t.m[ident]
 
 	if !ok {
@@ -137,6 +166,7 @@ func (t *Tunnels) addClient(ident, name, vhost string) {
 	client[""] = &Tunnel{
 		Name:        name,
 		VirtualHost: vhost,
+		owner:       owner,
 	}
 }
 
@@ -0,0 +1,361 @@
+package tunnelproxy
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/koding/logging"
+)
+
+// UDP datagrams are tunneled over stream connections. Each datagram
+// is sent as a frame, which is prefixed with the datagram length
+// encoded as a 2-byte big-endian integer.
+
+// maxDatagramSize is a maximum size of a tunneled datagram.
+const maxDatagramSize = 1<<16 - 1
+
+// udpIdleTimeout is a time after which inactive UDP session is closed.
+var udpIdleTimeout = 2 * time.Minute
+
+var errClosed = errors.New("use of closed connection")
+
+func writeFrame(w io.Writer, p []byte) error {
+	if len(p) > maxDatagramSize {
+		return errors.New("datagram too large")
+	}
+
+	frame := make([]byte, 2+len(p))
+	binary.BigEndian.PutUint16(frame, uint16(len(p)))
+	copy(frame[2:], p)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+// readFrame reads single frame from r into buf, which must be
+// large enough to hold a datagram of maxDatagramSize.
+func readFrame(r io.Reader, buf []byte) ([]byte, error) {
+	if _, err := io.ReadFull(r, buf[:2]); err != nil {
+		return nil, err
+	}
+
+	n := int(binary.BigEndian.Uint16(buf))
+
+	if _, err := io.ReadFull(r, buf[:n]); err != nil {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// activity tracks time of the last activity of a UDP session.
+type activity int64
+
+func (a *activity) touch() {
+	atomic.StoreInt64((*int64)(a), time.Now().UnixNano())
+}
+
+func (a *activity) idle(timeout time.Duration) bool {
+	return time.Since(time.Unix(0, atomic.LoadInt64((*int64)(a)))) >= timeout
+}
+
+// udpListener adapts net.PacketConn to net.Listener, so UDP services
+// can be port-routed by tunnel.Server like TCP ones.
+//
+// Datagrams received from each remote address are grouped into
+// a session, which is accepted as a single connection. Reading
+// from the connection gives framed datagrams, writing to it
+// expects framed datagrams, which are sent back to the remote.
+type udpListener struct {
+	pc   net.PacketConn
+	idle time.Duration
+
+	conns chan *udpConn
+	done  chan struct{}
+	once  sync.Once
+
+	mu sync.Mutex
+	m  map[string]*udpConn // maps remote address to a session
+}
+
+var _ net.Listener = (*udpListener)(nil)
+
+func listenUDP(addr string) (*udpListener, error) {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return newUDPListener(pc, udpIdleTimeout), nil
+}
+
+func newUDPListener(pc net.PacketConn, idle time.Duration) *udpListener {
+	l := &udpListener{
+		pc:    pc,
+		idle:  idle,
+		conns: make(chan *udpConn),
+		done:  make(chan struct{}),
+		m:     make(map[string]*udpConn),
+	}
+
+	go l.serve()
+
+	return l
+}
+
+// Accept waits for a new UDP session.
+func (l *udpListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, errClosed
+	}
+}
+
+// Close stops listening and closes all the sessions.
+func (l *udpListener) Close() (err error) {
+	l.once.Do(func() {
+		close(l.done)
+		err = l.pc.Close()
+	})
+
+	return err
+}
+
+// Addr gives the local address of the listener.
+func (l *udpListener) Addr() net.Addr {
+	return l.pc.LocalAddr()
+}
+
+func (l *udpListener) serve() {
+	defer l.Close()
+
+	buf := make([]byte, maxDatagramSize)
+
+	for {
+		n, addr, err := l.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		c, ok := l.session(addr)
+
+		frame := make([]byte, 2+n)
+		binary.BigEndian.PutUint16(frame, uint16(n))
+		copy(frame[2:], buf[:n])
+
+		select {
+		case c.in <- frame:
+		default:
+			// The session does not keep up, drop the datagram
+			// like an overflowing socket buffer would.
+		}
+
+		if !ok {
+			select {
+			case l.conns <- c:
+			case <-l.done:
+				return
+			}
+		}
+	}
+}
+
+// session gives a session for the given remote address. It reports
+// false if the session was created.
+func (l *udpListener) session(addr net.Addr) (*udpConn, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c, ok := l.m[addr.String()]; ok {
+		return c, true
+	}
+
+	c := &udpConn{
+		l:    l,
+		addr: addr,
+		in:   make(chan []byte, 64),
+		done: make(chan struct{}),
+	}
+	c.last.touch()
+
+	l.m[addr.String()] = c
+
+	return c, false
+}
+
+func (l *udpListener) remove(c *udpConn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.m[c.addr.String()] == c {
+		delete(l.m, c.addr.String())
+	}
+}
+
+// udpConn is a UDP session accepted by udpListener.
+type udpConn struct {
+	l    *udpListener
+	addr net.Addr
+	in   chan []byte
+	last activity
+
+	rbuf []byte // pending part of a frame being read
+	wbuf []byte // pending part of a frame being written
+
+	done chan struct{}
+	once sync.Once
+}
+
+var _ net.Conn = (*udpConn)(nil)
+
+// Read reads framed datagrams received from the remote.
+//
+// It returns io.EOF once the session is closed, which happens
+// after it was idle for the listener's idle timeout.
+func (c *udpConn) Read(p []byte) (int, error) {
+	if len(c.rbuf) == 0 {
+		t := time.NewTimer(c.l.idle)
+		defer t.Stop()
+
+		for len(c.rbuf) == 0 {
+			select {
+			case c.rbuf = <-c.in:
+				c.last.touch()
+			case <-c.done:
+				return 0, io.EOF
+			case <-c.l.done:
+				return 0, io.EOF
+			case <-t.C:
+				if c.last.idle(c.l.idle) {
+					c.Close()
+					return 0, io.EOF
+				}
+
+				t.Reset(c.l.idle)
+			}
+		}
+	}
+
+	n := copy(p, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+
+	return n, nil
+}
+
+// Write sends datagrams read from the framed p to the remote.
+func (c *udpConn) Write(p []byte) (int, error) {
+	select {
+	case <-c.done:
+		return 0, errClosed
+	default:
+	}
+
+	c.last.touch()
+	c.wbuf = append(c.wbuf, p...)
+
+	for len(c.wbuf) >= 2 {
+		n := 2 + int(binary.BigEndian.Uint16(c.wbuf))
+		if len(c.wbuf) < n {
+			break
+		}
+
+		if _, err := c.l.pc.WriteTo(c.wbuf[2:n], c.addr); err != nil {
+			return 0, err
+		}
+
+		c.wbuf = c.wbuf[n:]
+	}
+
+	return len(p), nil
+}
+
+// Close closes the session. A datagram received afterwards
+// from the same remote starts a new session.
+func (c *udpConn) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+		c.l.remove(c)
+	})
+
+	return nil
+}
+
+func (c *udpConn) LocalAddr() net.Addr  { return c.l.pc.LocalAddr() }
+func (c *udpConn) RemoteAddr() net.Addr { return c.addr }
+
+// Deadlines are not supported, idle sessions are closed instead.
+func (c *udpConn) SetDeadline(time.Time) error      { return nil }
+func (c *udpConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *udpConn) SetWriteDeadline(time.Time) error { return nil }
+
+// proxyUDP forwards framed datagrams between the remote end of
+// the tunnel and the local UDP server.
+func proxyUDP(remote net.Conn, localAddr string, idle time.Duration, log logging.Logger) {
+	defer remote.Close()
+
+	log.Debug("dialing local server: %q", localAddr)
+
+	local, err := net.Dial("udp", localAddr)
+	if err != nil {
+		log.Error("dialing local server %q failed: %s", localAddr, err)
+		return
+	}
+	defer local.Close()
+
+	var last activity
+	last.touch()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer local.Close() // unblocks local read
+
+		buf := make([]byte, maxDatagramSize)
+
+		for {
+			p, err := readFrame(remote, buf)
+			if err != nil {
+				return
+			}
+
+			last.touch()
+
+			if _, err := local.Write(p); err != nil {
+				log.Debug("writing to local server %q failed: %s", localAddr, err)
+			}
+		}
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+
+	for {
+		local.SetReadDeadline(time.Now().Add(idle))
+
+		n, err := local.Read(buf)
+		if e, ok := err.(net.Error); ok && e.Timeout() && !last.idle(idle) {
+			continue
+		}
+
+		if err != nil {
+			log.Debug("reading from local server %q: %s", localAddr, err)
+			break
+		}
+
+		last.touch()
+
+		if err := writeFrame(remote, buf[:n]); err != nil {
+			break
+		}
+	}
+
+	remote.Close() // unblocks remote read
+	<-done
+}
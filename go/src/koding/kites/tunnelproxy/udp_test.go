@@ -0,0 +1,139 @@
+package tunnelproxy
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/koding/logging"
+)
+
+func echoUDP(t *testing.T) net.PacketConn {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			pc.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	return pc
+}
+
+func TestUDPTunnel(t *testing.T) {
+	echo := echoUDP(t)
+	defer echo.Close()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := newUDPListener(pc, time.Second)
+	defer l.Close()
+
+	log := logging.NewCustom("udp", testing.Verbose())
+
+	// Simulates tunnel.Server and tunnel.Client connected
+	// with a stream.
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			server, client := net.Pipe()
+
+			go proxyUDP(client, echo.LocalAddr().String(), time.Second, log)
+			go io.Copy(server, conn)
+			go io.Copy(conn, server)
+		}
+	}()
+
+	conn, err := net.Dial("udp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 64)
+
+	for _, msg := range []string{"ping", "", "pong"} {
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatalf("Write()=%s", err)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("Read()=%s", err)
+		}
+
+		if got := string(buf[:n]); got != msg {
+			t.Fatalf("got %q, want %q", got, msg)
+		}
+	}
+}
+
+func TestUDPListener_Idle(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := newUDPListener(pc, 50*time.Millisecond)
+	defer l.Close()
+
+	conn, err := net.Dial("udp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write()=%s", err)
+	}
+
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Accept()=%s", err)
+	}
+
+	buf := make([]byte, 64)
+
+	p, err := readFrame(c, buf)
+	if err != nil {
+		t.Fatalf("readFrame()=%s", err)
+	}
+
+	if string(p) != "ping" {
+		t.Fatalf("got %q, want %q", p, "ping")
+	}
+
+	if _, err := c.Read(buf); err != io.EOF {
+		t.Fatalf("got %v, want %v", err, io.EOF)
+	}
+
+	// A datagram received after the session was closed
+	// starts a new one.
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write()=%s", err)
+	}
+
+	if c2, err := l.Accept(); err != nil || c2 == c {
+		t.Fatalf("got %v, %v; want new session", c2, err)
+	}
+}
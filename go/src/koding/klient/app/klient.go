@@ -2,8 +2,10 @@ package app
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
 	"net/url"
@@ -145,8 +147,12 @@ type KlientConfig struct {
 
 	VagrantHome string
 
-	TunnelName    string
-	TunnelKiteURL string
+	TunnelName       string
+	TunnelKiteURL    string
+	TunnelPolicies   string // path to JSON file with access policies
+	TunnelDomains    string // comma-separated custom domains
+	TunnelRecord     int    // number of recorded HTTP requests per service
+	TunnelRecordKite bool   // whether to record requests received on the virtual host
 
 	NoTunnel bool
 	NoProxy  bool
@@ -516,6 +522,9 @@ func (k *Klient) RegisterMethods() {
 
 	// Tunnel
 	k.handleFunc("tunnel.info", k.tunnel.Info)
+	k.handleFunc("tunnel.register", k.tunnel.Register)
+	k.handleFunc("tunnel.requests", k.tunnel.Requests)
+	k.handleFunc("tunnel.replay", k.tunnel.Replay)
 
 	// Log
 	k.handleFunc("log.upload", k.uploader.Upload)
@@ -666,12 +675,31 @@ func (k *Klient) tunnelOptions() (*tunnel.Options, error) {
 		Debug:         k.config.Debug,
 		Kite:          k.kite,
 		NoProxy:       k.config.NoProxy,
+		Record:        k.config.TunnelRecord,
+		RecordKite:    k.config.TunnelRecordKite,
 	}
 
 	if k.config.Port != 0 {
 		opts.LocalAddr = net.JoinHostPort("127.0.0.1", strconv.Itoa(k.config.Port))
 	}
 
+	for _, domain := range strings.Split(k.config.TunnelDomains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			opts.Domains = append(opts.Domains, domain)
+		}
+	}
+
+	if k.config.TunnelPolicies != "" {
+		p, err := ioutil.ReadFile(k.config.TunnelPolicies)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(p, &opts.Policies); err != nil {
+			return nil, fmt.Errorf("invalid tunnel policies %q: %s", k.config.TunnelPolicies, err)
+		}
+	}
+
 	return opts, nil
 }
 
@@ -52,11 +52,15 @@ var (
 	flagVagrantHome = f.String("vagrant-home", "", "Change Vagrant home path")
 
 	// Tunnel flags
-	flagTunnelName    = f.String("tunnel-name", "", "Enable tunneling by setting non-empty tunnel name")
-	flagTunnelKiteURL = f.String("tunnel-kite-url", "", "Change default tunnel server kite URL")
-	flagNoTunnel      = f.Bool("no-tunnel", defaultNoTunnel(), "Force tunnel connection off")
-	flagNoProxy       = f.Bool("no-proxy", false, "Force TLS proxy for tunneled connection off")
-	flagAutoupdate    = f.Bool("autoupdate", false, "Force turn automatic updates on")
+	flagTunnelName       = f.String("tunnel-name", "", "Enable tunneling by setting non-empty tunnel name")
+	flagTunnelKiteURL    = f.String("tunnel-kite-url", "", "Change default tunnel server kite URL")
+	flagTunnelPolicy     = f.String("tunnel-policies", "", "JSON file with access policies for tunneled services")
+	flagTunnelDomains    = f.String("tunnel-domains", "", "Comma-separated custom domains, which are CNAMEs of the tunnel")
+	flagTunnelRecord     = f.Int("tunnel-record", 0, "Number of recent HTTP requests recorded per tunneled service")
+	flagTunnelRecordKite = f.Bool("tunnel-record-kite", false, "Record also requests received on the tunnel virtual host")
+	flagNoTunnel         = f.Bool("no-tunnel", defaultNoTunnel(), "Force tunnel connection off")
+	flagNoProxy          = f.Bool("no-proxy", false, "Force TLS proxy for tunneled connection off")
+	flagAutoupdate       = f.Bool("autoupdate", false, "Force turn automatic updates on")
 
 	// Upload log flags
 	flagLogBucketRegion   = f.String("log-bucket-region", "", "Change bucket region to upload logs")
@@ -141,6 +145,10 @@ func realMain() int {
 		VagrantHome:       vagrantHome,
 		TunnelName:        *flagTunnelName,
 		TunnelKiteURL:     *flagTunnelKiteURL,
+		TunnelPolicies:    *flagTunnelPolicy,
+		TunnelDomains:     *flagTunnelDomains,
+		TunnelRecord:      *flagTunnelRecord,
+		TunnelRecordKite:  *flagTunnelRecordKite,
 		NoTunnel:          *flagNoTunnel,
 		NoProxy:           *flagNoProxy,
 		Autoupdate:        *flagAutoupdate,
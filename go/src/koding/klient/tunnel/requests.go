@@ -0,0 +1,74 @@
+package tunnel
+
+import (
+	"errors"
+
+	"github.com/koding/kite"
+)
+
+// RequestsRequest is a request value of tunnel.requests kite method.
+type RequestsRequest struct {
+	// Service limits the requests to the given service. Requests
+	// received on the virtual host belong to the "kite" service,
+	// they are recorded only when Options.RecordKite is true.
+	//
+	// If empty, requests of all services are returned.
+	Service string `json:"service,omitempty"`
+
+	// Limit is a maximum number of returned requests.
+	//
+	// If zero, all recorded requests are returned.
+	Limit int `json:"limit,omitempty"`
+}
+
+// ReplayRequest is a request value of tunnel.replay kite method.
+type ReplayRequest struct {
+	ID int64 `json:"id"` // ID of the recorded request
+}
+
+// Requests gives recently recorded HTTP requests and responses,
+// newest first.
+//
+// Requests are recorded only when Options.Record is non-zero.
+func (t *Tunnel) Requests(r *kite.Request) (interface{}, error) {
+	var req RequestsRequest
+
+	if r.Args != nil {
+		if err := r.Args.One().Unmarshal(&req); err != nil {
+			return nil, errors.New("invalid request: " + err.Error())
+		}
+	}
+
+	if t.recorder == nil {
+		return nil, errors.New("recording HTTP requests is disabled")
+	}
+
+	exs := t.recorder.Exchanges(req.Service)
+
+	if req.Limit > 0 && len(exs) > req.Limit {
+		exs = exs[:req.Limit]
+	}
+
+	return exs, nil
+}
+
+// Replay sends the recorded HTTP request once again to the local
+// server of the service, which received it. The replayed request
+// is recorded as well.
+func (t *Tunnel) Replay(r *kite.Request) (interface{}, error) {
+	var req ReplayRequest
+
+	if r.Args == nil {
+		return nil, errors.New("invalid request")
+	}
+
+	if err := r.Args.One().Unmarshal(&req); err != nil {
+		return nil, errors.New("invalid request: " + err.Error())
+	}
+
+	if t.client == nil {
+		return nil, errors.New("tunnel is not running")
+	}
+
+	return t.client.Replay(req.ID)
+}
@@ -0,0 +1,108 @@
+package tunnel
+
+import (
+	"errors"
+	"strings"
+
+	"koding/kites/tunnelproxy"
+
+	"github.com/koding/kite"
+)
+
+// RegisterRequest is a request value of tunnel.register kite method.
+type RegisterRequest struct {
+	// Name of the service, e.g. "postgres".
+	Name string `json:"name"`
+
+	// LocalAddr is an address of the local server,
+	// e.g. "127.0.0.1:5432".
+	LocalAddr string `json:"localAddr"`
+
+	// Protocol is either "tcp" (default) or "udp".
+	Protocol string `json:"protocol,omitempty"`
+
+	// Policy restricts access to the service. If nil,
+	// a policy from Options.Policies is used.
+	Policy *tunnelproxy.Policy `json:"policy,omitempty"`
+
+	// Inspect records HTTP requests received by the service,
+	// when recording is enabled. See tunnel.requests.
+	Inspect bool `json:"inspect,omitempty"`
+}
+
+// Register exposes a local TCP or UDP server with a port-routed
+// tunnel. The tunnel server allocates a public port for the service,
+// which is reported by tunnel.info once the service is registered.
+//
+// The service is persisted and restored on reconnects.
+func (t *Tunnel) Register(r *kite.Request) (interface{}, error) {
+	var req RegisterRequest
+
+	if r.Args == nil {
+		return nil, errors.New("invalid request")
+	}
+
+	if err := r.Args.One().Unmarshal(&req); err != nil {
+		return nil, errors.New("invalid request: " + err.Error())
+	}
+
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+
+	if name == "" {
+		return nil, errors.New("service name is empty")
+	}
+
+	if name == "kite" || name == "kites" {
+		return nil, errors.New(name + " is a reserved name for internal services")
+	}
+
+	if t.client == nil {
+		return nil, errors.New("tunnel is not running")
+	}
+
+	_, localPort, err := splitHostPort(req.LocalAddr)
+	if err != nil {
+		return nil, errors.New("invalid local address: " + err.Error())
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	srv := &tunnelproxy.Service{
+		Name:      name,
+		LocalAddr: req.LocalAddr,
+		Protocol:  req.Protocol,
+		Policy:    req.Policy,
+		Inspect:   req.Inspect,
+	}
+
+	if srv.Policy == nil {
+		srv.Policy = t.opts.Policies[name]
+	}
+
+	if err := srv.Policy.Valid(); err != nil {
+		return nil, errors.New("invalid policy: " + err.Error())
+	}
+
+	// if we are a host managed kite, the local server is accessible
+	// directly, otherwise restoreServices looks up forwarded port
+	if !t.isVagrant {
+		srv.ForwardedPort = localPort
+	}
+
+	if err := t.client.RegisterService(srv); err != nil {
+		return nil, err
+	}
+
+	if t.services == nil {
+		t.services = make(tunnelproxy.Services)
+	}
+
+	t.services[name] = srv
+
+	if err := t.db.SetServices(t.services); err != nil {
+		t.opts.Log.Warning("tunnel: unable to update services: %s", err)
+	}
+
+	return srv, nil
+}
@@ -31,6 +31,7 @@ type Tunnel struct {
 	ports       []*vagrant.ForwardedPort
 	opts        *Options
 	services    tunnelproxy.Services
+	domains     []string // custom domains added by tunnel server
 	registerURL *url.URL
 
 	// Used to wait for first successful tunnel server registration.
@@ -40,7 +41,8 @@ type Tunnel struct {
 	stateChanges chan *tunnel.ClientStateChange
 	isVagrant    bool
 
-	proxy *tlsproxy.Proxy
+	proxy    *tlsproxy.Proxy
+	recorder *tunnelproxy.Recorder // nil when recording is disabled
 }
 
 type Options struct {
@@ -55,6 +57,16 @@ type Options struct {
 	LastAddr      string `json:"lastAddr,omitempty"`
 	LastReachable bool   `json:"lastReachable,omitempty"`
 
+	// Policies maps service name to its access policy. The policy
+	// of the "kite" service applies to HTTP requests received
+	// on the virtual host of the tunnel. Policies of other services
+	// can have allowedIPs rules only, as they are not HTTP-aware.
+	Policies map[string]*tunnelproxy.Policy `json:"policies,omitempty"`
+
+	// Domains are custom domains routed to the tunnel. Each of them
+	// must be a CNAME of the tunnel's virtual host.
+	Domains []string `json:"domains,omitempty"`
+
 	DB           *bolt.DB                       `json:"-"`
 	Log          kite.Logger                    `json:"-"`
 	Kite         *kite.Kite                     `json:"-"`
@@ -62,6 +74,16 @@ type Options struct {
 
 	Debug   bool `json:"-"`
 	NoProxy bool `json:"-"`
+
+	// Record is a number of recent HTTP requests recorded per service,
+	// for services registered with Inspect.
+	//
+	// If zero, requests are not recorded.
+	Record int `json:"-"`
+
+	// RecordKite enables recording requests received on the
+	// virtual host of the tunnel, as the "kite" service.
+	RecordKite bool `json:"-"`
 }
 
 // updateEmpty overwrites each zero-value field of opts with defaults (merge-in).
@@ -110,10 +132,26 @@ func (opts *Options) updateEmpty(defaults *Options) {
 		opts.Debug = defaults.Debug
 	}
 
+	if opts.Record == 0 {
+		opts.Record = defaults.Record
+	}
+
+	if !opts.RecordKite {
+		opts.RecordKite = defaults.RecordKite
+	}
+
 	if opts.PublicIP == nil {
 		opts.PublicIP = defaults.PublicIP
 	}
 
+	if opts.Policies == nil {
+		opts.Policies = defaults.Policies
+	}
+
+	if len(opts.Domains) == 0 {
+		opts.Domains = defaults.Domains
+	}
+
 	// set defaults
 	if opts.Timeout == 0 {
 		opts.Timeout = 1 * time.Minute
@@ -164,6 +202,9 @@ func (t *Tunnel) clientOptions() *tunnelproxy.ClientOptions {
 		LastVirtualHost:    t.opts.VirtualHost,
 		LocalAddr:          t.opts.LocalAddr,
 		Services:           t.buildServices(),
+		Domains:            t.opts.Domains,
+		Recorder:           t.recorder,
+		InspectKite:        t.opts.RecordKite,
 		Kite:               t.opts.Kite,
 		Timeout:            t.opts.Timeout,
 		OnRegister:         t.updateOptions,
@@ -194,6 +235,7 @@ func (t *Tunnel) updateOptions(reg *tunnelproxy.RegisterResult) {
 	t.mu.Lock()
 	t.opts.VirtualHost = reg.VirtualHost
 	t.opts.TunnelName = guessTunnelName(reg.VirtualHost)
+	t.domains = reg.Domains
 
 	// if we're a vagrant vm, update forwarded ports
 	if t.isVagrant {
@@ -315,6 +357,12 @@ func (t *Tunnel) restoreServices() {
 		}
 	}
 
+	for name, s := range services {
+		if p, ok := t.opts.Policies[name]; ok {
+			s.Policy = p
+		}
+	}
+
 	t.opts.Log.Debug("going to restore services: %s (with forwarded ports)", services)
 
 	// TODO(rjeczalik): add vagrant.forwardPort to host klient and call
@@ -371,6 +419,10 @@ func (t *Tunnel) BuildOptions(opts *Options, registerURL *url.URL) error {
 		}
 	}
 
+	if t.opts.Record > 0 {
+		t.recorder = &tunnelproxy.Recorder{Size: t.opts.Record}
+	}
+
 	clientOpts := t.clientOptions()
 
 	if t.opts.LastReachable && !t.isVagrant {
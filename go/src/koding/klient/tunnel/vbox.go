@@ -53,6 +53,13 @@ type InfoResponse struct {
 	IsVagrant   bool   `json:"isVagrant"` // whether we NATed behind Vagrant network
 
 	Ports map[string]*Port `json:"ports,omitempty"`
+
+	// Services are port-routed TCP and UDP tunnels. Services
+	// which are not registered yet have empty RemoteAddr.
+	Services tunnelproxy.Services `json:"services,omitempty"`
+
+	// Domains are custom domains routed to the tunnel.
+	Domains []string `json:"domains,omitempty"`
 }
 
 const (
@@ -90,6 +97,7 @@ func (t *Tunnel) Info(r *kite.Request) (interface{}, error) {
 	info.VirtualHost = t.opts.VirtualHost
 	info.PublicIP = t.opts.PublicIP.String()
 	info.IsVagrant = t.isVagrant
+	info.Domains = t.domains
 
 	// Build tunnel information.
 	info.Ports = map[string]*Port{
@@ -107,6 +115,15 @@ func (t *Tunnel) Info(r *kite.Request) (interface{}, error) {
 		info.Ports["kite"].Remote = port
 	}
 
+	if len(t.services) != 0 {
+		info.Services = make(tunnelproxy.Services, len(t.services))
+
+		for name, srv := range t.services {
+			srvCopy := *srv
+			info.Services[name] = &srvCopy
+		}
+	}
+
 	return info, nil
 }
 
@@ -152,11 +169,13 @@ func (t *Tunnel) kiteServices(kiteAddr, kitesAddr string) map[string]*tunnelprox
 			Name:      "kite",
 			PublicIP:  t.opts.PublicIP.String(),
 			LocalAddr: kiteAddr,
+			Policy:    t.opts.Policies["kite"],
 		},
 		"kites": {
 			Name:      "kites",
 			PublicIP:  t.opts.PublicIP.String(),
 			LocalAddr: kitesAddr,
+			Policy:    t.opts.Policies["kites"],
 		},
 	}
 }
@@ -281,7 +300,7 @@ func (t *Tunnel) dialHostKite(addr string) (*kite.Client, error) {
 	client := t.opts.Kite.NewClient(hostKiteURL.String())
 	client.Auth = &kite.Auth{
 		Type: "kiteKey",
-		Key:  t.opts.Kite.Config.KiteKey,
+		Key:  t.opts.Kite.KiteKey(),
 	}
 	client.Reconnect = true
 
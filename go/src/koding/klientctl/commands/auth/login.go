@@ -13,11 +13,10 @@ import (
 )
 
 type loginOptions struct {
-	token      string
-	baseURL    string
-	team       string
-	jsonOutput bool
-	force      bool
+	token   string
+	baseURL string
+	team    string
+	force   bool
 }
 
 // NewLoginCommand creates a command that allows to log into Koding account.
@@ -35,12 +34,13 @@ func NewLoginCommand(c *cli.CLI) *cobra.Command {
 	flags.StringVar(&opts.token, "token", "", "temporary authorization token")
 	flags.StringVar(&opts.baseURL, "baseurl", config.Konfig.Endpoints.Koding.Public.String(), "service login endpoint")
 	flags.StringVar(&opts.team, "team", "kd.io", "team to login")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 	flags.BoolVarP(&opts.force, "force", "f", false, "force new session")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.NoArgs, // No custom arguments are accepted.
+		cli.CompleteFlag("team", cli.CompleteTeams), // Complete team names.
 	)(c, cmd)
 
 	return cmd
@@ -76,8 +76,8 @@ func loginCommand(c *cli.CLI, opts *loginOptions) cli.CobraFuncE {
 			return fmt.Errorf("error logging into your Koding account: %v", err)
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), resp)
+		if c.Format() != cli.OutputTable {
+			c.Print(resp)
 			return nil
 		}
 
@@ -10,9 +10,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-type showOptions struct {
-	jsonOutput bool
-}
+type showOptions struct{}
 
 // NewShowCommand creates a command that displays current session details.
 func NewShowCommand(c *cli.CLI) *cobra.Command {
@@ -26,7 +24,7 @@ func NewShowCommand(c *cli.CLI) *cobra.Command {
 
 	// Flags.
 	flags := cmd.Flags()
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -40,8 +38,8 @@ func showCommand(c *cli.CLI, opts *showOptions) cli.CobraFuncE {
 	return func(cmd *cobra.Command, args []string) error {
 		info := auth.Used()
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), info)
+		if c.Format() != cli.OutputTable {
+			c.Print(info)
 		} else {
 			printInfo(c, info)
 		}
@@ -92,22 +92,17 @@ __kd_mount_completion()
 
 __custom_func() {
     case ${last_command} in
-        kd_machine_ssh | kd_ssh | kd_machine_config_show | kd_machine_start | kd_machine_stop)
-            __kd_remote_machines
-            ;;
         kd_machine_exec | kd_exec)
             __kd_exec_completion
             ;;
         kd_machine_cp | kd_cp)
             __kd_cp_completion
             ;;
-        kd_machine_umount | kd_machine_unmount | kd_unmount | kd_umount | kd_machine_mount_inspect | kd_sync | kd_sync_pause | kd_sync_resume | kd_machine_mount_sync_pause | kd_machine_mount_sync_resume)
-            __kd_existing_mounts -e
-            ;;
         kd_mount | kd_machine_mount)
             __kd_mount_completion
             ;;
         *)
+            __kd_custom_args
             ;;
     esac
 }
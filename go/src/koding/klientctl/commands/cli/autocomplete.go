@@ -13,9 +13,25 @@ import (
 	"github.com/spf13/cobra"
 )
 
-const autocompletionFilePath = "/usr/local/etc/bash_completion.d/kd"
+// autocompletionFilePaths stores default locations of completion
+// scripts for each of the supported shells.
+var autocompletionFilePaths = map[string]string{
+	"bash": "/usr/local/etc/bash_completion.d/kd",
+	"zsh":  "/usr/local/share/zsh/site-functions/_kd",
+	"fish": filepath.Join(os.Getenv("HOME"), ".config", "fish", "completions", "kd.fish"),
+}
 
-type autocompleteOptions struct{}
+// autocompletionGenerators stores completion script generators
+// for each of the supported shells.
+var autocompletionGenerators = map[string]func(*cobra.Command, io.Writer) error{
+	"bash": genBashCompletion,
+	"zsh":  genZshCompletion,
+	"fish": genFishCompletion,
+}
+
+type autocompleteOptions struct {
+	shell string
+}
 
 // NewAutocompleteCommand creates a command that allows generates file
 // completion for root command and all its subcommands.
@@ -28,6 +44,10 @@ func NewAutocompleteCommand(c *CLI) *cobra.Command {
 		RunE:  autocompleteCommand(c, opts),
 	}
 
+	// Flags.
+	flags := cmd.Flags()
+	flags.StringVar(&opts.shell, "shell", "bash", "shell type (bash, zsh or fish)")
+
 	// Middlewares.
 	MultiCobraCmdMiddleware(
 		MaxArgs(1), // Only file path is allowed.
@@ -38,21 +58,26 @@ func NewAutocompleteCommand(c *CLI) *cobra.Command {
 
 func autocompleteCommand(c *CLI, opts *autocompleteOptions) CobraFuncE {
 	return func(cmd *cobra.Command, args []string) error {
+		gen, ok := autocompletionGenerators[opts.shell]
+		if !ok {
+			return fmt.Errorf("unsupported shell %q", opts.shell)
+		}
+
 		rootCmd := cmd.Root()
 		if len(args) == 0 {
-			return genBashCompletion(rootCmd, autocompletionFilePath)
+			return genCompletionFile(rootCmd, gen, autocompletionFilePaths[opts.shell])
 		}
 
 		if args[0] == "-" {
-			return rootCmd.GenBashCompletion(c.Out())
+			return gen(rootCmd, c.Out())
 		}
 
-		return genBashCompletion(rootCmd, args[0])
+		return genCompletionFile(rootCmd, gen, args[0])
 	}
 }
 
-// GenBashCompletion generates bash completion for a given command.
-func genBashCompletion(cmd *cobra.Command, filename string) error {
+// genCompletionFile generates shell completion for a given command.
+func genCompletionFile(cmd *cobra.Command, gen func(*cobra.Command, io.Writer) error, filename string) error {
 	if runtime.GOOS == "windows" {
 		return nil
 	}
@@ -61,9 +86,15 @@ func genBashCompletion(cmd *cobra.Command, filename string) error {
 		return err
 	}
 
+	// Generate a new auto completion script.
+	buf := bytes.Buffer{}
+	if err := gen(cmd, &buf); err != nil {
+		return err
+	}
+
 	switch _, err := os.Stat(filename); {
 	case os.IsNotExist(err):
-		return cmd.GenBashCompletionFile(filename)
+		return ioutil.WriteFile(filename, buf.Bytes(), 0644)
 	case err != nil:
 		return err
 	}
@@ -80,12 +111,6 @@ func genBashCompletion(cmd *cobra.Command, filename string) error {
 	}
 	f.Close()
 
-	// Generate a new auto completion script.
-	buf := bytes.Buffer{}
-	if err := cmd.GenBashCompletion(&buf); err != nil {
-		return err
-	}
-
 	generatedMD5, err := md5sum(bytes.NewReader(buf.Bytes()))
 	if err != nil {
 		return err
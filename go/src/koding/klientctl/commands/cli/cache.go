@@ -33,7 +33,7 @@ func WithInitializedCache(cli *CLI, rootCmd *cobra.Command) {
 		if err != nil {
 			cli.Log().Error("Cannot open config cache: %v", err)
 
-			return NewError(3, fmt.Errorf(
+			return NewError(ExitCache, fmt.Errorf(
 				"Error opening configuration cache: %s\n\n%s", err, cacheLockedSuggestion,
 			))
 		}
@@ -84,9 +84,10 @@ type CLI struct {
 
 	m *metrics.Metrics // usage metrics.
 
-	debug bool
-	log   logging.Logger
-	mds   map[string][]func() string // debug info about middlewares.
+	debug  bool
+	format string // requested output format.
+	log    logging.Logger
+	mds    map[string][]func() string // debug info about middlewares.
 }
 
 // NewCLI creates a new CLI client.
@@ -0,0 +1,296 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CompletionAnnotation is a cobra command annotation key that stores a kind
+// of resources that command arguments can be completed with.
+const CompletionAnnotation = "command-completion-annotation"
+
+// Completion kinds, which denote resources that can be dynamically completed
+// by shell completion scripts.
+const (
+	CompleteMachines    = "machines"
+	CompleteMounts      = "mounts"
+	CompleteStacks      = "stacks"
+	CompleteTemplates   = "templates"
+	CompleteCredentials = "credentials"
+	CompleteTeams       = "teams"
+)
+
+// completions maps completion kinds to hidden kd commands that print
+// space-separated resource identifiers. All of them read either kd cache
+// or machine group cache of klient, thus they are fast and do not require
+// network connectivity.
+var completions = map[string]string{
+	CompleteMachines:    "kd machine identifiers",
+	CompleteMounts:      "kd machine mount identifiers --base-path=false",
+	CompleteStacks:      "kd stack identifiers",
+	CompleteTemplates:   "kd template identifiers",
+	CompleteCredentials: "kd credential identifiers",
+	CompleteTeams:       "kd team identifiers",
+}
+
+const bashCompleteFunc = "__kd_complete"
+
+// CompleteArgs annotates the command, so its arguments are completed with
+// resources of the given kind.
+func CompleteArgs(kind string) CobraCmdMiddleware {
+	return func(_ *CLI, cmd *cobra.Command) {
+		if _, ok := completions[kind]; !ok {
+			panic("unknown completion kind: " + kind)
+		}
+
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+
+		cmd.Annotations[CompletionAnnotation] = kind
+	}
+}
+
+// CompleteFlag annotates the named command flag, so its values are completed
+// with resources of the given kind.
+func CompleteFlag(name, kind string) CobraCmdMiddleware {
+	return func(_ *CLI, cmd *cobra.Command) {
+		if _, ok := completions[kind]; !ok {
+			panic("unknown completion kind: " + kind)
+		}
+
+		if err := cmd.MarkFlagCustom(name, bashCompleteFunc+" "+kind); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// flagCompletion gives completion kind of the given flag.
+func flagCompletion(f *pflag.Flag) string {
+	for _, value := range f.Annotations[cobra.BashCompCustom] {
+		if strings.HasPrefix(value, bashCompleteFunc+" ") {
+			return strings.TrimPrefix(value, bashCompleteFunc+" ")
+		}
+	}
+
+	return ""
+}
+
+func completionKinds() []string {
+	kinds := make([]string, 0, len(completions))
+
+	for kind := range completions {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// walkCommands calls fn for cmd and all its available subcommands.
+func walkCommands(cmd *cobra.Command, fn func(*cobra.Command)) {
+	fn(cmd)
+
+	for _, subcmd := range cmd.Commands() {
+		if !subcmd.IsAvailableCommand() {
+			continue
+		}
+
+		walkCommands(subcmd, fn)
+	}
+}
+
+// bashCompletionFunc generates bash functions that complete annotated
+// commands and flags. Arguments of annotated commands are completed by
+// the __kd_custom_args function, which is expected to be called by
+// __custom_func as a fallback.
+func bashCompletionFunc(root *cobra.Command) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("\n# This function adds resources of the given kind to COMREPLY.\n")
+	buf.WriteString("# Usage: " + bashCompleteFunc + " KIND\n")
+	buf.WriteString(bashCompleteFunc + "()\n{\n    local kd_output\n\n    case $1 in\n")
+
+	for _, kind := range completionKinds() {
+		fmt.Fprintf(&buf, "        %s)\n            kd_output=$(%s 2>/dev/null)\n            ;;\n", kind, completions[kind])
+	}
+
+	buf.WriteString("        *)\n            return\n            ;;\n    esac\n\n")
+	buf.WriteString("    COMPREPLY=( \"${COMPREPLY[@]}\" $( compgen -W \"${kd_output}\" -- \"$cur\" ) )\n}\n")
+
+	cmds := make(map[string][]string)
+
+	walkCommands(root, func(cmd *cobra.Command) {
+		kind, ok := cmd.Annotations[CompletionAnnotation]
+		if !ok {
+			return
+		}
+
+		name := strings.Replace(cmd.CommandPath(), " ", "_", -1)
+		cmds[kind] = append(cmds[kind], name)
+
+		for _, alias := range cmd.Aliases {
+			cmds[kind] = append(cmds[kind], strings.TrimSuffix(name, cmd.Name())+alias)
+		}
+	})
+
+	buf.WriteString("\n# This function completes arguments of annotated commands.\n")
+	buf.WriteString("__kd_custom_args()\n{\n    case ${last_command} in\n")
+
+	for _, kind := range completionKinds() {
+		if len(cmds[kind]) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&buf, "        %s)\n            %s %s\n            ;;\n", strings.Join(cmds[kind], " | "), bashCompleteFunc, kind)
+	}
+
+	buf.WriteString("        *)\n            ;;\n    esac\n}\n")
+
+	return buf.String()
+}
+
+// genBashCompletion writes bash completion script for the given command.
+func genBashCompletion(root *cobra.Command, w io.Writer) error {
+	fn := root.BashCompletionFunction
+	defer func() { root.BashCompletionFunction = fn }()
+
+	root.BashCompletionFunction = bashCompletionFunc(root) + fn
+
+	return root.GenBashCompletion(w)
+}
+
+// zshPreamble emulates bash-completion functions used by the bash script,
+// which is going to be evaluated with zsh's bashcompinit.
+const zshPreamble = `#compdef kd
+
+autoload -U +X compinit && compinit
+autoload -U +X bashcompinit && bashcompinit
+
+__kd_get_comp_words_by_ref()
+{
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[${COMP_CWORD}-1]}"
+    words=("${COMP_WORDS[@]}")
+    cword=("${COMP_CWORD[@]}")
+}
+
+__kd_filedir()
+{
+    if [[ "$1" = "-d" ]]; then
+        COMPREPLY=( "${COMPREPLY[@]}" $( compgen -d -- "$cur" ) )
+    else
+        COMPREPLY=( "${COMPREPLY[@]}" $( compgen -f -- "$cur" ) )
+    fi
+}
+
+__kd_ltrim_colon_completions()
+{
+    :
+}
+
+`
+
+var zshReplacer = strings.NewReplacer(
+	"_get_comp_words_by_ref", "__kd_get_comp_words_by_ref",
+	"__ltrim_colon_completions", "__kd_ltrim_colon_completions",
+	"_filedir", "__kd_filedir",
+	"declare -F", "whence -w",
+)
+
+// genZshCompletion writes zsh completion script for the given command.
+//
+// The script reuses bash completion by the means of bashcompinit.
+func genZshCompletion(root *cobra.Command, w io.Writer) error {
+	var buf bytes.Buffer
+
+	if err := genBashCompletion(root, &buf); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, zshPreamble); err != nil {
+		return err
+	}
+
+	_, err := zshReplacer.WriteString(w, buf.String())
+	return err
+}
+
+const fishPreamble = `# fish completion for kd
+
+# This function tests whether the command line, excluding flags,
+# consists exactly of the given words.
+function __kd_using_command
+    set -l words (commandline -opc | string match -v -- '-*')
+    test "$words" = "$argv"
+end
+
+# This function prints resources of the given kind.
+function __kd_complete
+    switch $argv[1]
+`
+
+// genFishCompletion writes fish completion script for the given command.
+func genFishCompletion(root *cobra.Command, w io.Writer) error {
+	var buf bytes.Buffer
+
+	buf.WriteString(fishPreamble)
+
+	for _, kind := range completionKinds() {
+		fmt.Fprintf(&buf, "        case %s\n            %s 2>/dev/null | string split ' '\n", kind, completions[kind])
+	}
+
+	buf.WriteString("    end\nend\n\n")
+
+	name := root.Name()
+
+	walkCommands(root, func(cmd *cobra.Command) {
+		cond := fishQuote("__kd_using_command " + cmd.CommandPath())
+
+		for _, subcmd := range cmd.Commands() {
+			if !subcmd.IsAvailableCommand() {
+				continue
+			}
+
+			fmt.Fprintf(&buf, "complete -c %s -f -n %s -a %s -d %s\n", name, cond,
+				fishQuote(subcmd.Name()), fishQuote(subcmd.Short))
+		}
+
+		if kind, ok := cmd.Annotations[CompletionAnnotation]; ok {
+			fmt.Fprintf(&buf, "complete -c %s -f -n %s -a %s\n", name, cond,
+				fishQuote("(__kd_complete "+kind+")"))
+		}
+
+		cmd.NonInheritedFlags().VisitAll(func(f *pflag.Flag) {
+			if f.Hidden {
+				return
+			}
+
+			fmt.Fprintf(&buf, "complete -c %s -n %s -l %s", name, cond, f.Name)
+
+			if f.Shorthand != "" {
+				fmt.Fprintf(&buf, " -s %s", f.Shorthand)
+			}
+
+			if kind := flagCompletion(f); kind != "" {
+				fmt.Fprintf(&buf, " -x -a %s", fishQuote("(__kd_complete "+kind+")"))
+			}
+
+			fmt.Fprintf(&buf, " -d %s\n", fishQuote(f.Usage))
+		})
+	})
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1) + "'"
+}
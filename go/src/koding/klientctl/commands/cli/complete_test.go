@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newCompletionTestCommand() *cobra.Command {
+	root := &cobra.Command{Use: "kd"}
+
+	ssh := &cobra.Command{Use: "ssh", Aliases: []string{"s"}, Short: "SSH into the machine", Run: func(*cobra.Command, []string) {}}
+	list := &cobra.Command{Use: "list", Short: "List stacks", Run: func(*cobra.Command, []string) {}}
+	list.Flags().String("team", "", "limit to team's stacks")
+
+	CompleteArgs(CompleteMachines)(nil, ssh)
+	CompleteFlag("team", CompleteTeams)(nil, list)
+
+	root.AddCommand(ssh, list)
+
+	return root
+}
+
+func TestBashCompletionFunc(t *testing.T) {
+	root := newCompletionTestCommand()
+
+	var buf bytes.Buffer
+
+	if err := genBashCompletion(root, &buf); err != nil {
+		t.Fatalf("genBashCompletion()=%s", err)
+	}
+
+	script := buf.String()
+
+	want := []string{
+		"kd_ssh | kd_s)\n            __kd_complete machines",
+		"kd machine identifiers 2>/dev/null",
+		`flags_completion+=("__kd_complete teams")`,
+	}
+
+	for _, s := range want {
+		if !strings.Contains(script, s) {
+			t.Errorf("bash completion does not contain %q", s)
+		}
+	}
+
+	if root.BashCompletionFunction != "" {
+		t.Errorf("root command was modified: %q", root.BashCompletionFunction)
+	}
+}
+
+func TestFishCompletion(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := genFishCompletion(newCompletionTestCommand(), &buf); err != nil {
+		t.Fatalf("genFishCompletion()=%s", err)
+	}
+
+	script := buf.String()
+
+	want := []string{
+		`complete -c kd -f -n '__kd_using_command kd' -a 'ssh' -d 'SSH into the machine'`,
+		`complete -c kd -f -n '__kd_using_command kd ssh' -a '(__kd_complete machines)'`,
+		`complete -c kd -n '__kd_using_command kd list' -l team -x -a '(__kd_complete teams)' -d 'limit to team\'s stacks'`,
+	}
+
+	for _, s := range want {
+		if !strings.Contains(script, s) {
+			t.Errorf("fish completion does not contain %q:\n%s", s, script)
+		}
+	}
+}
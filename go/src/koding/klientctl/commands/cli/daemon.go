@@ -30,10 +30,10 @@ func DaemonRequired(cli *CLI, rootCmd *cobra.Command) {
 			)
 		}
 
-		return fmt.Errorf(
+		return NewError(ExitOffline, fmt.Errorf(
 			"%q requires the deamon to be installed.%s",
 			cmd.CommandPath(),
 			installHelp,
-		)
+		))
 	}
 }
@@ -1,5 +1,31 @@
 package cli
 
+import (
+	"net"
+
+	"koding/db/models"
+	"koding/klientctl/endpoint/remoteapi"
+	"koding/klientctl/endpoint/stack"
+
+	"github.com/koding/kite"
+)
+
+// Exit codes of kd commands. The values are part of the kd interface
+// and are not going to change.
+//
+// Commands that run processes remotely, like exec, may exit with
+// any code returned by the remote process.
+const (
+	ExitSuccess  = 0 // command finished successfully
+	ExitFailure  = 1 // unspecified failure
+	ExitUsage    = 2 // invalid arguments or flags
+	ExitCache    = 3 // kd cache is not accessible
+	ExitNotFound = 4 // requested resource was not found
+	ExitAuth     = 5 // user is not authenticated or not authorized
+	ExitOffline  = 6 // remote endpoint or klient daemon is unreachable
+	ExitRemote   = 7 // remote endpoint failed to process the request
+)
+
 // Error carries application error along with requested exit code.
 type Error struct {
 	E        error
@@ -24,15 +50,47 @@ func (e *Error) Error() string {
 }
 
 // ExitCodeFromError gets exit code from provided error. If error is nil, the
-// exit code will be 0. For errors other than Error type, 1 will be returned.
+// exit code will be 0. For errors other than Error type, the exit code is
+// derived from the type of the error, defaulting to ExitFailure.
 func ExitCodeFromError(err error) int {
 	if err == nil {
-		return 0
+		return ExitSuccess
+	}
+
+	if err == remoteapi.ErrNotFound {
+		return ExitNotFound
+	}
+
+	switch e := err.(type) {
+	case *Error:
+		return e.ExitCode
+	case *stack.KloudError:
+		return ExitCodeFromError(e.Err)
+	case *kite.Error:
+		return kiteExitCode(e)
+	case *models.NotFoundError:
+		return ExitNotFound
+	case net.Error:
+		return ExitOffline
 	}
 
-	if ee, ok := err.(*Error); ok {
-		return ee.ExitCode
+	return ExitFailure
+}
+
+func kiteExitCode(err *kite.Error) int {
+	switch err.Type {
+	case "authenticationError", "kiteKey", "token":
+		return ExitAuth
+	case "timeout", "sendError", "disconnect":
+		return ExitOffline
+	case "kloudError":
+		switch err.CodeVal {
+		case "416", "420", "500":
+			return ExitAuth
+		case "106", "200", "401":
+			return ExitNotFound
+		}
 	}
 
-	return 1
+	return ExitRemote
 }
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"koding/db/models"
+	"koding/klientctl/endpoint/remoteapi"
+	"koding/klientctl/endpoint/stack"
+
+	"github.com/koding/kite"
+	"github.com/spf13/cobra"
+)
+
+func TestExitCodeFromError(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		code int
+	}{
+		"nil":             {nil, ExitSuccess},
+		"cli error":       {NewError(42, errors.New("exit")), 42},
+		"plain error":     {errors.New("something bad happened"), ExitFailure},
+		"not found model": {&models.NotFoundError{Resource: "jMachine", Err: errors.New("oops")}, ExitNotFound},
+		"not found":       {remoteapi.ErrNotFound, ExitNotFound},
+		"not found text":  {errors.New("resource was not found"), ExitFailure},
+		"auth kite":       {&kite.Error{Type: "authenticationError", Message: "invalid token"}, ExitAuth},
+		"auth kloud":      {&kite.Error{Type: "kloudError", CodeVal: "416", Message: "Not Authorized"}, ExitAuth},
+		"offline kite":    {&kite.Error{Type: "timeout", Message: "no response"}, ExitOffline},
+		"offline kloud":   {&stack.KloudError{Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, ExitOffline},
+		"auth kloud call": {&stack.KloudError{Err: &kite.Error{Type: "kloudError", CodeVal: "420", Message: "Not Authorized"}}, ExitAuth},
+		"remote kite":     {&kite.Error{Type: "kloudError", CodeVal: "417", Message: "Internal server error"}, ExitRemote},
+	}
+
+	for name, cas := range cases {
+		cas := cas
+		t.Run(name, func(t *testing.T) {
+			if code := ExitCodeFromError(cas.err); code != cas.code {
+				t.Fatalf("got %d, want %d", code, cas.code)
+			}
+		})
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	newCmd := func(json bool) *cobra.Command {
+		cmd := &cobra.Command{Use: "list"}
+		if json {
+			cmd.Flags().Bool("json", false, "")
+		}
+		return cmd
+	}
+
+	cases := map[string]struct {
+		cmd    *cobra.Command
+		format string
+		json   bool
+		want   string
+		ok     bool
+	}{
+		"table":             {newCmd(false), OutputTable, false, OutputTable, true},
+		"yaml":              {newCmd(true), OutputYAML, false, OutputYAML, true},
+		"legacy json":       {newCmd(true), OutputTable, true, OutputJSON, true},
+		"json conflict":     {newCmd(true), OutputYAML, true, "", false},
+		"unknown format":    {newCmd(true), "xml", false, "", false},
+		"no structured out": {newCmd(false), OutputYAML, false, OutputYAML, true},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			if cas.json {
+				if err := cas.cmd.Flags().Set("json", "true"); err != nil {
+					t.Fatalf("Set()=%s", err)
+				}
+			}
+
+			var c CLI
+
+			err := c.setFormat(cas.cmd, cas.format)
+			if (err == nil) != cas.ok {
+				t.Fatalf("got %v, want ok=%t", err, cas.ok)
+			}
+
+			if cas.ok && c.Format() != cas.want {
+				t.Fatalf("got %q, want %q", c.Format(), cas.want)
+			}
+		})
+	}
+}
+
+func TestWithOutputFormat(t *testing.T) {
+	var out, errOut bytes.Buffer
+
+	c := &CLI{
+		out: &out,
+		err: &errOut,
+		mds: make(map[string][]func() string),
+	}
+
+	cmd := &cobra.Command{
+		Use: "use",
+		RunE: func(*cobra.Command, []string) error {
+			fmt.Fprintln(c.Out(), "Switched to koding.com.")
+			return nil
+		},
+	}
+
+	WithOutputFormat(c, cmd)
+
+	cmd.SetArgs([]string{"--output", "json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute()=%s", err)
+	}
+
+	var got CommandOutput
+
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal()=%s", err)
+	}
+
+	want := CommandOutput{
+		Command: "use",
+		Message: "Switched to koding.com.",
+	}
+
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if s := errOut.String(); s != "Switched to koding.com.\n" {
+		t.Fatalf("got %q, want message on the error stream", s)
+	}
+}
+
+func TestPrintYAML(t *testing.T) {
+	v := struct {
+		ID    string `json:"id"`
+		Title string `json:"title,omitempty"`
+		Count int    `json:"count"`
+	}{
+		ID:    "123",
+		Count: 2,
+	}
+
+	var buf bytes.Buffer
+
+	if err := PrintYAML(&buf, v); err != nil {
+		t.Fatalf("PrintYAML()=%s", err)
+	}
+
+	want := "count: 2\nid: \"123\"\n"
+
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
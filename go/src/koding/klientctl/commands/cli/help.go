@@ -18,7 +18,7 @@ func HelpForNoFlags(cli *CLI, rootCmd *cobra.Command) {
 			// Break command execution.
 			cmd.SilenceErrors = true
 			cmd.SilenceUsage = true
-			return NewError(0, errors.New("help called"))
+			return NewError(ExitSuccess, errors.New("help called"))
 		}
 
 		if tail != nil {
@@ -14,7 +14,7 @@ func NoArgs(cli *CLI, rootCmd *cobra.Command) {
 	tail := rootCmd.PreRunE
 	rootCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 		if err := noArgs(cmd, args); err != nil {
-			return err
+			return NewError(ExitUsage, err)
 		}
 
 		if tail != nil {
@@ -43,7 +43,7 @@ func ExactArgs(n int) CobraCmdMiddleware {
 		tail := rootCmd.PreRunE
 		rootCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 			if err := exactArgs(n, cmd, args); err != nil {
-				return err
+				return NewError(ExitUsage, err)
 			}
 
 			if tail != nil {
@@ -70,7 +70,7 @@ func MaxArgs(max int) CobraCmdMiddleware {
 		tail := rootCmd.PreRunE
 		rootCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 			if err := maxArgs(max, cmd, args); err != nil {
-				return err
+				return NewError(ExitUsage, err)
 			}
 
 			if tail != nil {
@@ -97,7 +97,7 @@ func MinArgs(min int) CobraCmdMiddleware {
 		tail := rootCmd.PreRunE
 		rootCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 			if err := minArgs(min, cmd, args); err != nil {
-				return err
+				return NewError(ExitUsage, err)
 			}
 
 			if tail != nil {
@@ -124,7 +124,7 @@ func RangeArgs(min, max int) CobraCmdMiddleware {
 		tail := rootCmd.PreRunE
 		rootCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 			if err := rangeArgs(min, max, cmd, args); err != nil {
-				return err
+				return NewError(ExitUsage, err)
 			}
 
 			if tail != nil {
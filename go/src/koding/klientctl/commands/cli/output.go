@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Output formats supported by kd commands.
+//
+// When JSON or YAML format is requested, commands write
+// their results to the output stream using the same schema
+// for both formats, which is defined by the json tags
+// of the result types. Human-readable messages are
+// written to the error stream.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// OutputFlag is a name of the flag that selects
+// output format of kd commands.
+const OutputFlag = "output"
+
+// StructuredOutputAnnotation is a cobra command annotation key, which
+// marks commands that print their results with CLI.Print, but have
+// no legacy --json flag.
+const StructuredOutputAnnotation = "structured-output-annotation"
+
+// ErrorOutput is written to the error stream when a command
+// failed and JSON or YAML output format was requested.
+//
+// Example JSON output:
+//
+//	{
+//	  "error": {
+//	    "code": 4,
+//	    "message": "resource was not found"
+//	  }
+//	}
+type ErrorOutput struct {
+	Error struct {
+		Code    int    `json:"code"`    // exit code of the command
+		Message string `json:"message"` // error message
+	} `json:"error"`
+}
+
+// CommandOutput is written to the output stream when a command, which
+// has no structured output, succeeded and JSON or YAML output format
+// was requested. Messages printed by the command are written to
+// the error stream instead.
+//
+// Example JSON output:
+//
+//	{
+//	  "command": "kd config use",
+//	  "message": "Switched to https://koding.com."
+//	}
+type CommandOutput struct {
+	Command string `json:"command"`           // command path
+	Message string `json:"message,omitempty"` // messages printed by the command
+}
+
+// WithOutputFormat adds output flag to the command and configures
+// the command according to the requested format. Legacy --json
+// flag is equivalent to --output=json.
+//
+// Commands which support structured output define the --json
+// flag, or StructuredOutputAnnotation, and print their results
+// with CLI.Print when Format is other than OutputTable. Other
+// commands write CommandOutput when they succeed.
+//
+// Commands which define their own output flag, e.g. for
+// a file name, are not affected.
+func WithOutputFormat(cli *CLI, rootCmd *cobra.Command) {
+	if rootCmd.Flags().Lookup(OutputFlag) != nil {
+		return
+	}
+
+	cli.registerMiddleware("with_output_format", rootCmd)
+	tail := rootCmd.RunE
+	if tail == nil {
+		panic("cannot insert middleware into empty function")
+	}
+
+	format := rootCmd.Flags().String(OutputFlag, OutputTable, "output format (table, json or yaml)")
+
+	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := cli.setFormat(cmd, *format); err != nil {
+			return NewError(ExitUsage, err)
+		}
+
+		var buf *bytes.Buffer
+		out := cli.out
+
+		if cli.format != OutputTable && !hasStructuredOutput(cmd) {
+			buf = new(bytes.Buffer)
+			cli.out = io.MultiWriter(cli.Err(), buf)
+		}
+
+		err := tail(cmd, args)
+
+		cli.out = out
+
+		if err == nil && buf != nil {
+			cli.Print(&CommandOutput{
+				Command: cmd.CommandPath(),
+				Message: strings.TrimSpace(buf.String()),
+			})
+		}
+
+		if err != nil && cli.format != OutputTable {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			var out ErrorOutput
+			out.Error.Code = ExitCodeFromError(err)
+			out.Error.Message = err.Error()
+
+			cli.print(cli.Err(), &out)
+		}
+
+		return err
+	}
+}
+
+func (c *CLI) setFormat(cmd *cobra.Command, format string) error {
+	switch format {
+	case OutputTable, OutputJSON, OutputYAML:
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+
+	if f := cmd.Flags().Lookup("json"); f != nil && f.Changed && f.Value.String() == "true" {
+		if format != OutputTable && format != OutputJSON {
+			return fmt.Errorf("--json flag conflicts with --%s=%s", OutputFlag, format)
+		}
+
+		format = OutputJSON
+	}
+
+	c.format = format
+
+	return nil
+}
+
+func hasStructuredOutput(cmd *cobra.Command) bool {
+	if cmd.Flags().Lookup("json") != nil {
+		return true
+	}
+
+	_, ok := cmd.Annotations[StructuredOutputAnnotation]
+	return ok
+}
+
+// Format gives the output format requested by user.
+func (c *CLI) Format() string {
+	if c.format == "" {
+		return OutputTable
+	}
+
+	return c.format
+}
+
+// Print writes v to the output stream in the requested format.
+//
+// Since tables are rendered by the commands themselves,
+// v is encoded with JSON if table format was requested.
+func (c *CLI) Print(v interface{}) {
+	c.print(c.Out(), v)
+}
+
+func (c *CLI) print(w io.Writer, v interface{}) {
+	if c.Format() != OutputYAML {
+		PrintJSON(w, v)
+		return
+	}
+
+	if err := PrintYAML(w, v); err != nil {
+		c.Log().Error("Unable to encode YAML output: %s", err)
+	}
+}
+
+// PrintYAML converts provided object to YAML and writes it to w.
+//
+// The object is encoded with JSON first, so the resulting
+// document has the same schema as the JSON one.
+func PrintYAML(w io.Writer, v interface{}) error {
+	p, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+
+	if err := json.Unmarshal(p, &doc); err != nil {
+		return err
+	}
+
+	p, err = yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(p)
+	return err
+}
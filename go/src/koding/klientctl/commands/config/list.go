@@ -11,9 +11,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-type listOptions struct {
-	jsonOutput bool
-}
+type listOptions struct{}
 
 // NewListCommand creates a command that shows all available configurations.
 func NewListCommand(c *cli.CLI) *cobra.Command {
@@ -28,7 +26,7 @@ func NewListCommand(c *cli.CLI) *cobra.Command {
 
 	// Flags.
 	flags := cmd.Flags()
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -42,8 +40,8 @@ func listCommand(c *cli.CLI, opts *listOptions) cli.CobraFuncE {
 	return func(cmd *cobra.Command, args []string) error {
 		konfigs := configstore.List()
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), konfigs)
+		if c.Format() != cli.OutputTable {
+			c.Print(konfigs)
 			return nil
 		}
 
@@ -14,8 +14,7 @@ import (
 )
 
 type showOptions struct {
-	defaults   bool
-	jsonOutput bool
+	defaults bool
 }
 
 // NewShowCommand creates a command that displays configurations.
@@ -31,7 +30,7 @@ func NewShowCommand(c *cli.CLI) *cobra.Command {
 	// Flags.
 	flags := cmd.Flags()
 	flags.BoolVar(&opts.defaults, "defaults", false, "include default configuration")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -68,8 +67,8 @@ func showCommand(c *cli.CLI, opts *showOptions) cli.CobraFuncE {
 			}
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), used)
+		if c.Format() != cli.OutputTable {
+			c.Print(used)
 			return nil
 		}
 
@@ -19,6 +19,7 @@ func NewCommand(c *cli.CLI) *cobra.Command {
 	cmd.AddCommand(
 		NewCreateCommand(c),
 		NewDescribeCommand(c),
+		NewIdentifiersCommand(c),
 		NewInitCommand(c),
 		NewListCommand(c),
 		NewUseCommand(c),
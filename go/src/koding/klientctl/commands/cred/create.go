@@ -17,11 +17,10 @@ import (
 )
 
 type createOptions struct {
-	provider   string
-	file       string
-	team       string
-	title      string
-	jsonOutput bool
+	provider string
+	file     string
+	team     string
+	title    string
 }
 
 // NewCreateCommand creates a command that can be used to create new stack
@@ -41,12 +40,13 @@ func NewCreateCommand(c *cli.CLI) *cobra.Command {
 	flags.StringVarP(&opts.file, "file", "f", "", "read from file")
 	flags.StringVar(&opts.team, "team", "", "owner of the credential")
 	flags.StringVar(&opts.title, "title", "", "credential title")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.DaemonRequired, // Deamon service is required.
 		cli.NoArgs,         // No custom arguments are accepted.
+		cli.CompleteFlag("team", cli.CompleteTeams), // Complete team names.
 	)(c, cmd)
 
 	return cmd
@@ -60,7 +60,7 @@ func createCommand(c *cli.CLI, opts *createOptions) cli.CobraFuncE {
 			Title:    opts.title,
 		}
 
-		if err := Create(c, opts.file, createOpts, opts.jsonOutput); err != nil {
+		if err := Create(c, opts.file, createOpts, c.Format() != cli.OutputTable); err != nil {
 			return err
 		}
 
@@ -101,7 +101,7 @@ func Create(c *cli.CLI, file string, opts *credential.CreateOptions, js bool) er
 	}
 
 	if js {
-		cli.PrintJSON(c.Out(), cred)
+		c.Print(cred)
 		return nil
 	}
 
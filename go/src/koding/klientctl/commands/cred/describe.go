@@ -12,8 +12,7 @@ import (
 )
 
 type describeOptions struct {
-	provider   string
-	jsonOutput bool
+	provider string
 }
 
 // NewDescribeCommand creates a command that describes credential documents.
@@ -29,7 +28,7 @@ func NewDescribeCommand(c *cli.CLI) *cobra.Command {
 	// Flags.
 	flags := cmd.Flags()
 	flags.StringVarP(&opts.provider, "provider", "p", "", "credential provider")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -56,8 +55,8 @@ func describeCommand(c *cli.CLI, opts *describeOptions) cli.CobraFuncE {
 			descs = stack.Descriptions{opts.provider: desc}
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), descs.Slice())
+		if c.Format() != cli.OutputTable {
+			c.Print(descs.Slice())
 			return nil
 		}
 
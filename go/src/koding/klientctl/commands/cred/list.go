@@ -12,9 +12,8 @@ import (
 )
 
 type listOptions struct {
-	provider   string
-	team       string
-	jsonOutput bool
+	provider string
+	team     string
 }
 
 // NewListCommand creates a command that displays imported stack credentials.
@@ -32,12 +31,13 @@ func NewListCommand(c *cli.CLI) *cobra.Command {
 	flags := cmd.Flags()
 	flags.StringVarP(&opts.provider, "provider", "p", "", "credential provider")
 	flags.StringVar(&opts.team, "team", "", "owner of the credential")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.DaemonRequired, // Deamon service is required.
 		cli.NoArgs,         // No custom arguments are accepted.
+		cli.CompleteFlag("team", cli.CompleteTeams), // Complete team names.
 	)(c, cmd)
 
 	return cmd
@@ -59,8 +59,8 @@ func listCommand(c *cli.CLI, opts *listOptions) cli.CobraFuncE {
 			return fmt.Errorf("you have no matching credentials attached to your Koding account")
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), creds)
+		if c.Format() != cli.OutputTable {
+			c.Print(creds)
 			return nil
 		}
 
@@ -19,6 +19,7 @@ import (
 	"koding/klientctl/commands/status"
 	"koding/klientctl/commands/team"
 	"koding/klientctl/commands/template"
+	"koding/klientctl/commands/tunnel"
 	"koding/klientctl/commands/version"
 
 	"github.com/spf13/cobra"
@@ -63,11 +64,13 @@ func NewKdCommand(c *cli.CLI) *cobra.Command {
 		cli.Alias(sync.NewCommand(c), "kd machine mount"),
 		team.NewCommand(c),
 		template.NewCommand(c),
+		tunnel.NewCommand(c),
 		version.NewCommand(c),
 	)
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
+		cli.ApplyForAll(cli.WithOutputFormat),     // Handle output format for all commands.
 		cli.ApplyForAll(cli.WithMetrics),          // Collect metrics for all commands.
 		cli.ApplyForAll(cli.CloseOnExitCtlCli),    // Run ctlcli.Close for all commands.
 		cli.ApplyForAll(cli.WithLoggedInfo),       // Log invocation and errors for all commands.
@@ -23,6 +23,10 @@ func NewUploadCommand(c *cli.CLI) *cobra.Command {
 		RunE:  uploadCommand(c, opts),
 	}
 
+	// Flags.
+	flags := cmd.Flags()
+	flags.Bool("json", false, "output in JSON format")
+
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.ExactArgs(1), // One argument is accepted.
@@ -53,6 +57,11 @@ func uploadCommand(c *cli.CLI, opts *uploadOptions) cli.CobraFuncE {
 			return err
 		}
 
+		if c.Format() != cli.OutputTable {
+			c.Print(f)
+			return nil
+		}
+
 		fmt.Fprintln(c.Out(), f.URL)
 		return nil
 	}
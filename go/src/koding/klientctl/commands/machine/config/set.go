@@ -16,13 +16,24 @@ func NewSetCommand(c *cli.CLI) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "set <machine-id> <key> <value>",
 		Short: "Set configuration value",
-		RunE:  setCommand(c, opts),
+		Long: `Set configuration value of a machine. Supported keys are:
+
+  alwaysOn   true or false
+  schedule   start/stop schedule in the following format:
+
+             start=<cron>;stop=<cron>;timezone=<tz>
+
+             where <cron> is a 5-field crontab spec, e.g.
+             "start=0 8 * * 1-5;stop=0 20 * * 1-5;timezone=Europe/Berlin".
+             The "off" value removes machine schedule.`,
+		RunE: setCommand(c, opts),
 	}
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.ExactArgs(3),   // Three arguments are accepted.
+		cli.DaemonRequired,                     // Deamon service is required.
+		cli.ExactArgs(3),                       // Three arguments are accepted.
+		cli.CompleteArgs(cli.CompleteMachines), // Complete machine identifiers.
 	)(c, cmd)
 
 	return cmd
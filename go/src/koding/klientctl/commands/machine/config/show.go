@@ -11,9 +11,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-type showOptions struct {
-	jsonOutput bool
-}
+type showOptions struct{}
 
 // NewShowCommand creates a command that displays remote machine configuration.
 func NewShowCommand(c *cli.CLI) *cobra.Command {
@@ -27,12 +25,13 @@ func NewShowCommand(c *cli.CLI) *cobra.Command {
 
 	// Flags.
 	flags := cmd.Flags()
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.NoArgs,         // No custom arguments are accepted.
+		cli.DaemonRequired,                     // Deamon service is required.
+		cli.NoArgs,                             // No custom arguments are accepted.
+		cli.CompleteArgs(cli.CompleteMachines), // Complete machine identifiers.
 	)(c, cmd)
 
 	return cmd
@@ -48,8 +47,8 @@ func showCommand(c *cli.CLI, opts *showOptions) cli.CobraFuncE {
 			return err
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), conf)
+		if c.Format() != cli.OutputTable {
+			c.Print(conf)
 		} else {
 			printKeyVal(c, conf)
 		}
@@ -11,10 +11,9 @@ import (
 )
 
 type identifiersOptions struct {
-	ids        bool
-	aliases    bool
-	ips        bool
-	jsonOutput bool
+	ids     bool
+	aliases bool
+	ips     bool
 }
 
 // NewIdentifiersCommand creates a command that displays identifiers of all
@@ -34,7 +33,7 @@ func NewIdentifiersCommand(c *cli.CLI) *cobra.Command {
 	flags.BoolVar(&opts.ids, "id", true, "machine IDs")
 	flags.BoolVar(&opts.aliases, "alias", true, "machine aliases")
 	flags.BoolVar(&opts.ips, "ip", true, "machine IP addresses")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -58,8 +57,8 @@ func identifiersCommand(c *cli.CLI, opts *identifiersOptions) cli.CobraFuncE {
 			return err
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), identifiers)
+		if c.Format() != cli.OutputTable {
+			c.Print(identifiers)
 			return nil
 		}
 
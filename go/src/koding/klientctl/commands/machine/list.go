@@ -13,9 +13,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-type listOptions struct {
-	jsonOutput bool
-}
+type listOptions struct{}
 
 // NewListCommand creates a command that displays remote machines which belong
 // to the user or that can be accessed by their.
@@ -32,7 +30,7 @@ func NewListCommand(c *cli.CLI) *cobra.Command {
 
 	// Flags.
 	flags := cmd.Flags()
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -61,8 +59,8 @@ func listCommand(c *cli.CLI, opts *listOptions) cli.CobraFuncE {
 			}
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), infos)
+		if c.Format() != cli.OutputTable {
+			c.Print(infos)
 			return nil
 		}
 
@@ -11,9 +11,8 @@ import (
 )
 
 type identifiersOptions struct {
-	mountIds   bool
-	basePaths  bool
-	jsonOutput bool
+	mountIds  bool
+	basePaths bool
 }
 
 // NewIdentifiersCommand creates a command that displays identifiers of all
@@ -32,7 +31,7 @@ func NewIdentifiersCommand(c *cli.CLI) *cobra.Command {
 	flags := cmd.Flags()
 	flags.BoolVar(&opts.mountIds, "mount-id", true, "mount IDs")
 	flags.BoolVar(&opts.basePaths, "base-path", true, "mount base paths")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -55,8 +54,8 @@ func identifiersCommand(c *cli.CLI, opts *identifiersOptions) cli.CobraFuncE {
 			return err
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), identifiers)
+		if c.Format() != cli.OutputTable {
+			c.Print(identifiers)
 			return nil
 		}
 
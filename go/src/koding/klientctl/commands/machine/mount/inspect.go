@@ -22,6 +22,9 @@ func NewInspectCommand(c *cli.CLI) *cobra.Command {
 		Short:  "Show mount debug information",
 		RunE:   inspectCommand(c, opts),
 		Hidden: true,
+		Annotations: map[string]string{
+			cli.StructuredOutputAnnotation: "true",
+		},
 	}
 
 	// Flags.
@@ -32,8 +35,9 @@ func NewInspectCommand(c *cli.CLI) *cobra.Command {
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.ExactArgs(1),   // One argument is required.
+		cli.DaemonRequired,                   // Deamon service is required.
+		cli.ExactArgs(1),                     // One argument is required.
+		cli.CompleteArgs(cli.CompleteMounts), // Complete mount identifiers.
 	)(c, cmd)
 
 	return cmd
@@ -59,7 +63,7 @@ func inspectCommand(c *cli.CLI, opts *inspectOptions) cli.CobraFuncE {
 			return err
 		}
 
-		cli.PrintJSON(c.Out(), records)
+		c.Print(records)
 		return nil
 	}
 }
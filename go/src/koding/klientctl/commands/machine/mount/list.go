@@ -15,8 +15,7 @@ import (
 )
 
 type listOptions struct {
-	filter     string
-	jsonOutput bool
+	filter string
 }
 
 // NewListCommand creates a command that displays available mounts.
@@ -33,7 +32,7 @@ func NewListCommand(c *cli.CLI) *cobra.Command {
 	// Flags.
 	flags := cmd.Flags()
 	flags.StringVar(&opts.filter, "filter", "", "limit to specific mount")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -55,8 +54,8 @@ func listCommand(c *cli.CLI, opts *listOptions) cli.CobraFuncE {
 			return err
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), mounts)
+		if c.Format() != cli.OutputTable {
+			c.Print(mounts)
 			return nil
 		}
 
@@ -43,8 +43,9 @@ current working directory.
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.MaxArgs(1),     // At most one argument is accepted.
+		cli.DaemonRequired,                   // Deamon service is required.
+		cli.MaxArgs(1),                       // At most one argument is accepted.
+		cli.CompleteArgs(cli.CompleteMounts), // Complete mount identifiers.
 	)(c, cmd)
 
 	return cmd
@@ -20,8 +20,9 @@ func NewPauseCommand(c *cli.CLI) *cobra.Command {
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.MaxArgs(1),     // At most one argument is accepted.
+		cli.DaemonRequired,                   // Deamon service is required.
+		cli.MaxArgs(1),                       // At most one argument is accepted.
+		cli.CompleteArgs(cli.CompleteMounts), // Complete mount identifiers.
 	)(c, cmd)
 
 	return cmd
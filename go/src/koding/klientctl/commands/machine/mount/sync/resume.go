@@ -21,8 +21,9 @@ func NewResumeCommand(c *cli.CLI) *cobra.Command {
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.MaxArgs(1),     // At most one argument is accepted.
+		cli.DaemonRequired,                   // Deamon service is required.
+		cli.MaxArgs(1),                       // At most one argument is accepted.
+		cli.CompleteArgs(cli.CompleteMounts), // Complete mount identifiers.
 	)(c, cmd)
 
 	return cmd
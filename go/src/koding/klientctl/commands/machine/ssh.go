@@ -28,8 +28,9 @@ func NewSSHCommand(c *cli.CLI) *cobra.Command {
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.ExactArgs(1),   // One argument must be provided.
+		cli.DaemonRequired,                     // Deamon service is required.
+		cli.ExactArgs(1),                       // One argument must be provided.
+		cli.CompleteArgs(cli.CompleteMachines), // Complete machine identifiers.
 	)(c, cmd)
 
 	return cmd
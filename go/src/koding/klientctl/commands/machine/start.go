@@ -9,9 +9,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-type startOptions struct {
-	jsonOutput bool
-}
+type startOptions struct{}
 
 // NewStartCommand creates a command that can start a remote machine.
 func NewStartCommand(c *cli.CLI) *cobra.Command {
@@ -25,12 +23,13 @@ func NewStartCommand(c *cli.CLI) *cobra.Command {
 
 	// Flags.
 	flags := cmd.Flags()
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.ExactArgs(1),   // One argument is required.
+		cli.DaemonRequired,                     // Deamon service is required.
+		cli.ExactArgs(1),                       // One argument is required.
+		cli.CompleteArgs(cli.CompleteMachines), // Complete machine identifiers.
 	)(c, cmd)
 
 	return cmd
@@ -51,8 +50,8 @@ func startCommand(c *cli.CLI, opts *startOptions) cli.CobraFuncE {
 				err = e.Error
 			}
 
-			if opts.jsonOutput {
-				cli.PrintJSON(c.Out(), e)
+			if c.Format() != cli.OutputTable {
+				c.Print(e)
 			} else {
 				fmt.Fprintf(c.Out(), "[%d%%] %s\n", e.Event.Percentage, e.Event.Message)
 			}
@@ -32,7 +32,8 @@ func NewUmountCommand(c *cli.CLI) *cobra.Command {
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
+		cli.DaemonRequired,                   // Deamon service is required.
+		cli.CompleteArgs(cli.CompleteMounts), // Complete mount identifiers.
 	)(c, cmd)
 
 	return cmd
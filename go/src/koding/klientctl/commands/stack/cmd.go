@@ -17,7 +17,9 @@ func NewCommand(c *cli.CLI) *cobra.Command {
 	// Subcommands.
 	cmd.AddCommand(
 		NewCreateCommand(c),
+		NewIdentifiersCommand(c),
 		NewListCommand(c),
+		NewPlanCommand(c),
 	)
 
 	// Middlewares.
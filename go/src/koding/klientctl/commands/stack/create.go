@@ -1,9 +1,13 @@
 package stack
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strings"
+
 	"koding/klientctl/commands/cli"
 	"koding/klientctl/endpoint/kloud"
 	"koding/klientctl/endpoint/stack"
@@ -12,11 +16,13 @@ import (
 )
 
 type createOptions struct {
-	team       string
-	title      string
-	file       string
-	creds      []string
-	jsonOutput bool
+	team    string
+	title   string
+	file    string
+	creds   []string
+	vars    []string
+	varFile string
+	logs    bool
 }
 
 // NewCreateCommand creates a command that can create stacks.
@@ -35,12 +41,17 @@ func NewCreateCommand(c *cli.CLI) *cobra.Command {
 	flags.StringVar(&opts.title, "title", "", "stack title")
 	flags.StringVarP(&opts.file, "file", "f", "", "read stack template from a file")
 	flags.StringSliceVarP(&opts.creds, "credential", "c", nil, "stack credentials")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.StringArrayVar(&opts.vars, "var", nil, "set a user variable (key=value)")
+	flags.StringVar(&opts.varFile, "var-file", "", "read user variables from a JSON file")
+	flags.Bool("json", false, "output in JSON format")
+	flags.BoolVar(&opts.logs, "logs", false, "stream provisioning logs while the stack is building")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.DaemonRequired, // Deamon service is required.
 		cli.NoArgs,         // No custom arguments are accepted.
+		cli.CompleteFlag("team", cli.CompleteTeams),             // Complete team names.
+		cli.CompleteFlag("credential", cli.CompleteCredentials), // Complete credential identifiers.
 	)(c, cmd)
 
 	return cmd
@@ -64,6 +75,11 @@ func createCommand(c *cli.CLI, opts *createOptions) cli.CobraFuncE {
 			return errors.New("error reading template file: " + err.Error())
 		}
 
+		vars, err := readVariables(opts.varFile, opts.vars, os.Environ())
+		if err != nil {
+			return err
+		}
+
 		fmt.Fprintln(c.Err(), "Creating stack... ")
 
 		createOpts := &stack.CreateOptions{
@@ -71,6 +87,7 @@ func createCommand(c *cli.CLI, opts *createOptions) cli.CobraFuncE {
 			Title:       opts.title,
 			Credentials: opts.creds,
 			Template:    p,
+			Variables:   vars,
 		}
 
 		resp, err := stack.Create(createOpts)
@@ -78,21 +95,98 @@ func createCommand(c *cli.CLI, opts *createOptions) cli.CobraFuncE {
 			return errors.New("error creating stack: " + err.Error())
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), resp)
+		if c.Format() != cli.OutputTable {
+			c.Print(resp)
 			return nil
 		}
 
 		fmt.Fprintf(c.Err(), "\nCreatad %q stack with %s ID.\nWaiting for the stack to finish building...\n\n", resp.Title, resp.StackID)
 
-		for e := range kloud.Wait(resp.EventID) {
+		wait, last := kloud.Wait, -1
+		if opts.logs {
+			wait = kloud.Tail
+		}
+
+		for e := range wait(resp.EventID) {
+			for _, line := range e.Output {
+				fmt.Fprintln(c.Err(), line)
+			}
+
 			if e.Error != nil {
 				return fmt.Errorf("building %q stack failed: %s", resp.Title, e.Error)
 			}
 
-			fmt.Fprintf(c.Out(), "[%d%%] %s\n", e.Event.Percentage, e.Event.Message)
+			if e.Event.Percentage > last {
+				last = e.Event.Percentage
+				fmt.Fprintf(c.Out(), "[%d%%] %s\n", e.Event.Percentage, e.Event.Message)
+			}
 		}
 
 		return nil
 	}
 }
+
+// envVarPrefix is a prefix of environment variables,
+// which are used to provide values for user variables.
+const envVarPrefix = "KD_VAR_"
+
+// readVariables builds user variables out of the given sources,
+// in the increasing order of precedence:
+//
+//   - variables file, a JSON object
+//   - environment variables prefixed with KD_VAR_
+//   - key=value pairs
+func readVariables(file string, pairs, environ []string) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	if file != "" {
+		p, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.New("error reading variables file: " + err.Error())
+		}
+
+		var v map[string]interface{}
+
+		if err := json.Unmarshal(p, &v); err != nil {
+			return nil, errors.New("error reading variables file: " + err.Error())
+		}
+
+		for key, value := range v {
+			if s, ok := value.(string); ok {
+				vars[key] = s
+			} else {
+				vars[key] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	for _, env := range environ {
+		if !strings.HasPrefix(env, envVarPrefix) {
+			continue
+		}
+
+		if key, value, ok := splitPair(env[len(envVarPrefix):]); ok {
+			vars[key] = value
+		}
+	}
+
+	for _, pair := range pairs {
+		key, value, ok := splitPair(pair)
+		if !ok {
+			return nil, fmt.Errorf("invalid variable %q, expected key=value format", pair)
+		}
+
+		vars[key] = value
+	}
+
+	return vars, nil
+}
+
+func splitPair(s string) (key, value string, ok bool) {
+	i := strings.IndexRune(s, '=')
+	if i < 1 {
+		return "", "", false
+	}
+
+	return s[:i], s[i+1:], true
+}
@@ -12,8 +12,7 @@ import (
 )
 
 type listOptions struct {
-	team       string
-	jsonOutput bool
+	team string
 }
 
 // NewListCommand creates a command that can list stacks.
@@ -30,12 +29,13 @@ func NewListCommand(c *cli.CLI) *cobra.Command {
 	// Flags.
 	flags := cmd.Flags()
 	flags.StringVar(&opts.team, "team", "", "limit to team's stacks")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.DaemonRequired, // Deamon service is required.s
 		cli.NoArgs,         // No custom arguments are accepted.
+		cli.CompleteFlag("team", cli.CompleteTeams), // Complete team names.
 	)(c, cmd)
 
 	return cmd
@@ -56,8 +56,8 @@ func listCommand(c *cli.CLI, opts *listOptions) cli.CobraFuncE {
 			return err
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), stacks)
+		if c.Format() != cli.OutputTable {
+			c.Print(stacks)
 			return nil
 		}
 
@@ -0,0 +1,109 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"koding/kites/kloud/stack"
+	"koding/klientctl/commands/cli"
+	kdstack "koding/klientctl/endpoint/stack"
+
+	"github.com/spf13/cobra"
+)
+
+type planOptions struct {
+	team     string
+	provider string
+	vars     []string
+	varFile  string
+}
+
+// NewPlanCommand creates a command that shows machines of a stack
+// template together with their estimated cost.
+func NewPlanCommand(c *cli.CLI) *cobra.Command {
+	opts := &planOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "plan <template-id>",
+		Short: "Show machines and estimated cost of a stack",
+		Long: "Show machines, which are going to be built for a stack template,\n" +
+			"together with estimated hourly and monthly cost of running them.\n\n" +
+			"Machines, which prices are not known, have no cost estimated.",
+		RunE: planCommand(c, opts),
+	}
+
+	// Flags.
+	flags := cmd.Flags()
+	flags.StringVar(&opts.team, "team", "", "owner of the stack")
+	flags.StringVar(&opts.provider, "provider", "", "stack provider")
+	flags.StringArrayVar(&opts.vars, "var", nil, "set a user variable (key=value)")
+	flags.StringVar(&opts.varFile, "var-file", "", "read user variables from a JSON file")
+	flags.Bool("json", false, "output in JSON format")
+
+	// Middlewares.
+	cli.MultiCobraCmdMiddleware(
+		cli.DaemonRequired,                          // Deamon service is required.
+		cli.ExactArgs(1),                            // Template ID is required.
+		cli.CompleteArgs(cli.CompleteTemplates),     // Complete template IDs.
+		cli.CompleteFlag("team", cli.CompleteTeams), // Complete team names.
+	)(c, cmd)
+
+	return cmd
+}
+
+func planCommand(c *cli.CLI, opts *planOptions) cli.CobraFuncE {
+	return func(cmd *cobra.Command, args []string) error {
+		vars, err := readVariables(opts.varFile, opts.vars, os.Environ())
+		if err != nil {
+			return err
+		}
+
+		resp, err := kdstack.Plan(&kdstack.PlanOptions{
+			TemplateID: args[0],
+			Team:       opts.team,
+			Provider:   opts.provider,
+			Variables:  vars,
+		})
+		if err != nil {
+			return err
+		}
+
+		if c.Format() != cli.OutputTable {
+			c.Print(resp)
+			return nil
+		}
+
+		printPlan(c, resp)
+		return nil
+	}
+}
+
+func printPlan(c *cli.CLI, resp *kdstack.PlanResponse) {
+	w := tabwriter.NewWriter(c.Out(), 2, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "LABEL\tPROVIDER\tHOURLY\tMONTHLY")
+
+	for _, m := range resp.Machines {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Label, m.Provider, hourly(m.Cost), monthly(m.Cost))
+	}
+
+	if resp.Cost != nil {
+		fmt.Fprintf(w, "TOTAL\t\t%s\t%s\n", hourly(resp.Cost), monthly(resp.Cost))
+	}
+}
+
+func hourly(cost *stack.Cost) string {
+	if cost == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f %s", cost.Hourly, cost.Currency)
+}
+
+func monthly(cost *stack.Cost) string {
+	if cost == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f %s", cost.Monthly, cost.Currency)
+}
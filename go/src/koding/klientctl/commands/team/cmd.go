@@ -16,6 +16,7 @@ func NewCommand(c *cli.CLI) *cobra.Command {
 
 	// Subcommands.
 	cmd.AddCommand(
+		NewIdentifiersCommand(c),
 		NewListCommand(c),
 		NewShowCommand(c),
 		NewUseCommand(c),
@@ -12,8 +12,7 @@ import (
 )
 
 type listOptions struct {
-	slug       string
-	jsonOutput bool
+	slug string
 }
 
 // NewListCommand creates a command that lists user's teams.
@@ -30,7 +29,7 @@ func NewListCommand(c *cli.CLI) *cobra.Command {
 	// Flags.
 	flags := cmd.Flags()
 	flags.StringVar(&opts.slug, "slug", "", "limit to team with given slug")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -62,8 +61,8 @@ func listCommand(c *cli.CLI, opts *listOptions) cli.CobraFuncE {
 			return nil
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), teams)
+		if c.Format() != cli.OutputTable {
+			c.Print(teams)
 			return nil
 		}
 
@@ -9,9 +9,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-type showOptions struct {
-	jsonOutput bool
-}
+type showOptions struct{}
 
 // NewShowCommand creates a command that displays currently used team.
 func NewShowCommand(c *cli.CLI) *cobra.Command {
@@ -25,7 +23,7 @@ func NewShowCommand(c *cli.CLI) *cobra.Command {
 
 	// Flags.
 	flags := cmd.Flags()
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -45,8 +43,8 @@ func showCommand(c *cli.CLI, opts *showOptions) cli.CobraFuncE {
 			return err
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), t)
+		if c.Format() != cli.OutputTable {
+			c.Print(t)
 		} else {
 			fmt.Fprintln(c.Err(), "You are currently logged in to the following team:", t.Name)
 		}
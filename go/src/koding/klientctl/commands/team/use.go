@@ -23,8 +23,9 @@ func NewUseCommand(c *cli.CLI) *cobra.Command {
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
-		cli.DaemonRequired, // Deamon service is required.
-		cli.ExactArgs(1),   // One argument is accepted.
+		cli.DaemonRequired,                  // Deamon service is required.
+		cli.ExactArgs(1),                    // One argument is accepted.
+		cli.CompleteArgs(cli.CompleteTeams), // Complete team names.
 	)(c, cmd)
 
 	return cmd
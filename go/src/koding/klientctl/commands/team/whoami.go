@@ -12,9 +12,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-type whoAmIOptions struct {
-	jsonOutput bool
-}
+type whoAmIOptions struct{}
 
 // NewWhoAmICommand creates a command that displays authentication details.
 func NewWhoAmICommand(c *cli.CLI) *cobra.Command {
@@ -28,7 +26,7 @@ func NewWhoAmICommand(c *cli.CLI) *cobra.Command {
 
 	// Flags.
 	flags := cmd.Flags()
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -46,8 +44,8 @@ func whoAmICommand(c *cli.CLI, opts *whoAmIOptions) cli.CobraFuncE {
 			return err
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), resp)
+		if c.Format() != cli.OutputTable {
+			c.Print(resp)
 			return nil
 		}
 
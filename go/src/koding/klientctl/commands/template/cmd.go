@@ -17,9 +17,13 @@ func NewCommand(c *cli.CLI) *cobra.Command {
 	// Subcommands.
 	cmd.AddCommand(
 		NewDeleteCommand(c),
+		NewDiffCommand(c),
+		NewHistoryCommand(c),
+		NewIdentifiersCommand(c),
 		NewInitCommand(c),
 		NewListCommand(c),
 		NewShowCommand(c),
+		NewValidateCommand(c),
 	)
 
 	// Middlewares.
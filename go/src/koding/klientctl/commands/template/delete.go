@@ -31,11 +31,13 @@ func NewDeleteCommand(c *cli.CLI) *cobra.Command {
 	flags.StringVarP(&opts.template, "template", "t", "", "limit to template name")
 	flags.StringVar(&opts.id, "id", "", "limit to template id")
 	flags.BoolVar(&opts.force, "force", false, "confirm all questions")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.DaemonRequired, // Deamon service is required.
 		cli.NoArgs,         // No custom arguments are accepted.
+		cli.CompleteFlag("id", cli.CompleteTemplates), // Complete template IDs.
 	)(c, cmd)
 
 	return cmd
@@ -80,6 +82,11 @@ func deleteCommand(c *cli.CLI, opts *deleteOptions) cli.CobraFuncE {
 			return err
 		}
 
+		if c.Format() != cli.OutputTable {
+			c.Print(map[string]string{"id": f.ID})
+			return nil
+		}
+
 		fmt.Fprintf(c.Out(), "Stack template with %q ID deleted successfully.\n", f.ID)
 
 		return nil
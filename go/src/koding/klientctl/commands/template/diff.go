@@ -0,0 +1,86 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+
+	"koding/klientctl/commands/cli"
+	kdstack "koding/klientctl/endpoint/stack"
+
+	"github.com/spf13/cobra"
+)
+
+type diffOptions struct{}
+
+// NewDiffCommand creates a command that shows differences between
+// stack template revisions.
+func NewDiffCommand(c *cli.CLI) *cobra.Command {
+	opts := &diffOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "diff <template-id> [<rev1> [<rev2>]]",
+		Short: "Show changes between stack template revisions",
+		Long: "Show changes of resources between stack template revisions.\n\n" +
+			"If no revision is given, the latest revision is compared with its\n" +
+			"predecessor. If only rev1 is given, it is compared with the latest one.",
+		RunE: diffCommand(c, opts),
+	}
+
+	// Flags.
+	flags := cmd.Flags()
+	flags.Bool("json", false, "output in JSON format")
+
+	// Middlewares.
+	cli.MultiCobraCmdMiddleware(
+		cli.DaemonRequired,                      // Deamon service is required.
+		cli.RangeArgs(1, 3),                     // Template ID and optional revisions.
+		cli.CompleteArgs(cli.CompleteTemplates), // Complete template IDs.
+	)(c, cmd)
+
+	return cmd
+}
+
+func diffCommand(c *cli.CLI, opts *diffOptions) cli.CobraFuncE {
+	return func(cmd *cobra.Command, args []string) error {
+		diffOpts := &kdstack.TemplateDiffOptions{
+			TemplateID: args[0],
+		}
+
+		var err error
+
+		if len(args) > 1 {
+			if diffOpts.From, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid revision %q: %s", args[1], err)
+			}
+		}
+
+		if len(args) > 2 {
+			if diffOpts.To, err = strconv.Atoi(args[2]); err != nil {
+				return fmt.Errorf("invalid revision %q: %s", args[2], err)
+			}
+		}
+
+		resp, err := kdstack.TemplateDiff(diffOpts)
+		if err != nil {
+			return err
+		}
+
+		if c.Format() != cli.OutputTable {
+			c.Print(resp)
+			return nil
+		}
+
+		fmt.Fprintf(c.Out(), "Changes in resources between revision %d and %d:\n\n", resp.From, resp.To)
+
+		if len(resp.Changes) == 0 {
+			fmt.Fprintln(c.Out(), "No changes.")
+			return nil
+		}
+
+		for _, change := range resp.Changes {
+			fmt.Fprintln(c.Out(), change)
+		}
+
+		return nil
+	}
+}
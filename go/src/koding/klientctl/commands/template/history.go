@@ -0,0 +1,69 @@
+package template
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"koding/kites/kloud/stack"
+	"koding/klientctl/commands/cli"
+	kdstack "koding/klientctl/endpoint/stack"
+
+	"github.com/spf13/cobra"
+)
+
+type historyOptions struct{}
+
+// NewHistoryCommand creates a command that displays revision history
+// of a stack template.
+func NewHistoryCommand(c *cli.CLI) *cobra.Command {
+	opts := &historyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "history <template-id>",
+		Short: "Show stack template revisions",
+		RunE:  historyCommand(c, opts),
+	}
+
+	// Flags.
+	flags := cmd.Flags()
+	flags.Bool("json", false, "output in JSON format")
+
+	// Middlewares.
+	cli.MultiCobraCmdMiddleware(
+		cli.DaemonRequired,                      // Deamon service is required.
+		cli.ExactArgs(1),                        // Template ID is required.
+		cli.CompleteArgs(cli.CompleteTemplates), // Complete template IDs.
+	)(c, cmd)
+
+	return cmd
+}
+
+func historyCommand(c *cli.CLI, opts *historyOptions) cli.CobraFuncE {
+	return func(cmd *cobra.Command, args []string) error {
+		revs, err := kdstack.TemplateHistory(args[0])
+		if err != nil {
+			return err
+		}
+
+		if c.Format() != cli.OutputTable {
+			c.Print(revs)
+			return nil
+		}
+
+		printRevisions(c, revs)
+
+		return nil
+	}
+}
+
+func printRevisions(c *cli.CLI, revs []*stack.TemplateRevision) {
+	w := tabwriter.NewWriter(c.Out(), 2, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "REVISION\tCREATED\tSUM")
+
+	for _, rev := range revs {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", rev.Revision, rev.CreatedAt.Format(time.RFC3339), rev.Sum)
+	}
+}
@@ -0,0 +1,51 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"koding/klientctl/commands/cli"
+	"koding/klientctl/endpoint/remoteapi"
+
+	"github.com/spf13/cobra"
+)
+
+type identifiersOptions struct{}
+
+// NewIdentifiersCommand creates a command that displays identifiers of all
+// cached templates.
+func NewIdentifiersCommand(c *cli.CLI) *cobra.Command {
+	opts := &identifiersOptions{}
+
+	cmd := &cobra.Command{
+		Use:    "identifiers",
+		Short:  "Display template IDs",
+		RunE:   identifiersCommand(c, opts),
+		Hidden: true,
+	}
+
+	// Flags.
+	flags := cmd.Flags()
+	flags.Bool("json", false, "output in JSON format")
+
+	// Middlewares.
+	cli.MultiCobraCmdMiddleware(
+		cli.NoArgs, // No custom arguments are accepted.
+	)(c, cmd)
+
+	return cmd
+}
+
+func identifiersCommand(c *cli.CLI, opts *identifiersOptions) cli.CobraFuncE {
+	return func(cmd *cobra.Command, args []string) error {
+		identifiers := remoteapi.TemplateIDs()
+
+		if c.Format() != cli.OutputTable {
+			c.Print(identifiers)
+			return nil
+		}
+
+		fmt.Fprintf(c.Out(), "%s\n", strings.Join(identifiers, " "))
+		return nil
+	}
+}
@@ -13,9 +13,8 @@ import (
 )
 
 type listOptions struct {
-	template   string
-	team       string
-	jsonOutput bool
+	template string
+	team     string
 }
 
 // NewListCommand creates a command that displays stack templates.
@@ -33,12 +32,13 @@ func NewListCommand(c *cli.CLI) *cobra.Command {
 	flags := cmd.Flags()
 	flags.StringVarP(&opts.template, "template", "t", "", "limit to template name")
 	flags.StringVar(&opts.team, "team", "", "limit to given team")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.DaemonRequired, // Deamon service is required.
 		cli.NoArgs,         // No custom arguments are accepted.
+		cli.CompleteFlag("team", cli.CompleteTeams), // Complete team names.
 	)(c, cmd)
 
 	return cmd
@@ -60,8 +60,8 @@ func listCommand(c *cli.CLI, opts *listOptions) cli.CobraFuncE {
 			return err
 		}
 
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), tmpls)
+		if c.Format() != cli.OutputTable {
+			c.Print(tmpls)
 			return nil
 		}
 
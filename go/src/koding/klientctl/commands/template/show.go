@@ -15,9 +15,8 @@ import (
 )
 
 type showOptions struct {
-	id         string
-	hclOutput  bool
-	jsonOutput bool
+	id        string
+	hclOutput bool
 }
 
 // NewShowCommand creates a command that shows details of a given stack template.
@@ -34,12 +33,13 @@ func NewShowCommand(c *cli.CLI) *cobra.Command {
 	flags := cmd.Flags()
 	flags.StringVar(&opts.id, "id", "", "limit to template id")
 	flags.BoolVar(&opts.hclOutput, "hcl", false, "output in HCL format")
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
 		cli.DaemonRequired, // Deamon service is required.
 		cli.MaxArgs(1),     // No more than 1 arg.
+		cli.CompleteFlag("id", cli.CompleteTemplates), // Complete template IDs.
 	)(c, cmd)
 
 	return cmd
@@ -76,8 +76,8 @@ func showCommand(c *cli.CLI, opts *showOptions) cli.CobraFuncE {
 		}
 
 		switch {
-		case opts.jsonOutput:
-			cli.PrintJSON(c.Out(), v)
+		case c.Format() != cli.OutputTable:
+			c.Print(v)
 		case opts.hclOutput:
 			tree, err := hcl.Parse(tmpl.Template.Content)
 			if err != nil {
@@ -0,0 +1,90 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"koding/klientctl/commands/cli"
+	"koding/klientctl/config"
+	"koding/klientctl/endpoint/stack"
+
+	"github.com/spf13/cobra"
+)
+
+type validateOptions struct {
+	file string
+}
+
+// NewValidateCommand creates a command that validates stack template files.
+func NewValidateCommand(c *cli.CLI) *cobra.Command {
+	opts := &validateOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "validate",
+		Aliases: []string{"lint"},
+		Short:   "Validate a stack template file",
+		RunE:    validateCommand(c, opts),
+	}
+
+	// Flags.
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.file, "file", "f", config.Konfig.Template.File, "read stack template from a file")
+	flags.Bool("json", false, "output in JSON format")
+
+	// Middlewares.
+	cli.MultiCobraCmdMiddleware(
+		cli.NoArgs, // No custom arguments are accepted.
+	)(c, cmd)
+
+	return cmd
+}
+
+func validateCommand(c *cli.CLI, opts *validateOptions) cli.CobraFuncE {
+	return func(cmd *cobra.Command, args []string) error {
+		var p []byte
+		var err error
+
+		switch opts.file {
+		case "":
+			return errors.New("no template file was provided")
+		case "-":
+			p, err = ioutil.ReadAll(c.In())
+		default:
+			p, err = ioutil.ReadFile(opts.file)
+		}
+
+		if err != nil {
+			return errors.New("error reading template file: " + err.Error())
+		}
+
+		problems, err := stack.Validate(&stack.ValidateOptions{
+			Template: p,
+		})
+		if err != nil {
+			return err
+		}
+
+		if c.Format() != cli.OutputTable {
+			c.Print(problems)
+		} else {
+			for _, p := range problems {
+				if p.Line != 0 {
+					fmt.Fprintf(c.Out(), "%s:%d: %s\n", opts.file, p.Line, p)
+				} else {
+					fmt.Fprintf(c.Out(), "%s: %s\n", opts.file, p)
+				}
+			}
+		}
+
+		if n := problems.Errors(); n != 0 {
+			return fmt.Errorf("template %q is not valid: found %d error(s)", opts.file, n)
+		}
+
+		if c.Format() == cli.OutputTable {
+			fmt.Fprintf(c.Out(), "Template %q is valid.\n", opts.file)
+		}
+
+		return nil
+	}
+}
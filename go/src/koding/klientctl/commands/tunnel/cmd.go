@@ -0,0 +1,29 @@
+package tunnel
+
+import (
+	"koding/klientctl/commands/cli"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCommand creates a command that inspects HTTP traffic of tunneled services.
+func NewCommand(c *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tunnel",
+		Short: "Inspect HTTP requests received by tunneled services",
+		RunE:  cli.PrintHelp(c.Err()),
+	}
+
+	// Subcommands.
+	cmd.AddCommand(
+		NewReplayCommand(c),
+		NewRequestsCommand(c),
+	)
+
+	// Middlewares.
+	cli.MultiCobraCmdMiddleware(
+		cli.NoArgs, // No custom arguments are accepted.
+	)(c, cmd)
+
+	return cmd
+}
@@ -0,0 +1,68 @@
+package tunnel
+
+import (
+	"fmt"
+	"strconv"
+
+	"koding/klientctl/commands/cli"
+	"koding/klientctl/endpoint/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+type replayOptions struct{}
+
+// NewReplayCommand creates a command that sends a recorded HTTP request
+// once again to the local service.
+func NewReplayCommand(c *cli.CLI) *cobra.Command {
+	opts := &replayOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "replay <request-id>",
+		Short: "Replay recorded HTTP request",
+		Long: `This command sends a recorded HTTP request once again to the local server
+of the tunneled service, which received it, and displays the response.
+
+Request IDs are displayed by "kd tunnel requests" command.`,
+		RunE: replayCommand(c, opts),
+	}
+
+	// Flags.
+	flags := cmd.Flags()
+	flags.Bool("json", false, "output in JSON format")
+
+	// Middlewares.
+	cli.MultiCobraCmdMiddleware(
+		cli.DaemonRequired, // Deamon service is required.
+		cli.ExactArgs(1),   // One argument is required.
+	)(c, cmd)
+
+	return cmd
+}
+
+func replayCommand(c *cli.CLI, opts *replayOptions) cli.CobraFuncE {
+	return func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid request ID %q: %s", args[0], err)
+		}
+
+		ex, err := tunnel.Replay(id)
+		if err != nil {
+			return err
+		}
+
+		if c.Format() != cli.OutputTable {
+			c.Print(ex)
+			return nil
+		}
+
+		fmt.Fprintf(c.Out(), "Replayed request %d as %d: %s %s -> %s\n\n", id, ex.ID, ex.Request.Method, ex.Request.URL, status(ex))
+
+		if ex.Response != nil && len(ex.Response.Body) != 0 {
+			fmt.Fprintf(c.Out(), "%s\n", ex.Response.Body)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package tunnel
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"koding/kites/tunnelproxy"
+	"koding/klientctl/commands/cli"
+	"koding/klientctl/endpoint/machine"
+	"koding/klientctl/endpoint/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+type requestsOptions struct {
+	service string
+	limit   int
+}
+
+// NewRequestsCommand creates a command that displays HTTP requests recently
+// received by tunneled services.
+func NewRequestsCommand(c *cli.CLI) *cobra.Command {
+	opts := &requestsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "requests",
+		Short: "List recorded HTTP requests",
+		Long: `This command displays HTTP requests recently received by tunneled services.
+
+Requests are recorded only when klient runs with -tunnel-record flag. Requests
+received on the tunnel virtual host belong to the "kite" service.`,
+		RunE: requestsCommand(c, opts),
+	}
+
+	// Flags.
+	flags := cmd.Flags()
+	flags.StringVar(&opts.service, "service", "", "limit to requests of the service")
+	flags.IntVar(&opts.limit, "limit", 0, "maximum number of requests")
+	flags.Bool("json", false, "output in JSON format")
+
+	// Middlewares.
+	cli.MultiCobraCmdMiddleware(
+		cli.DaemonRequired, // Deamon service is required.
+		cli.NoArgs,         // No custom arguments are accepted.
+	)(c, cmd)
+
+	return cmd
+}
+
+func requestsCommand(c *cli.CLI, opts *requestsOptions) cli.CobraFuncE {
+	return func(cmd *cobra.Command, args []string) error {
+		requestsOpts := &tunnel.RequestsOptions{
+			Service: opts.service,
+			Limit:   opts.limit,
+		}
+
+		exs, err := tunnel.Requests(requestsOpts)
+		if err != nil {
+			return err
+		}
+
+		if c.Format() != cli.OutputTable {
+			c.Print(exs)
+			return nil
+		}
+
+		tabRequestsFormatter(c.Out(), exs)
+		return nil
+	}
+}
+
+func tabRequestsFormatter(w io.Writer, exs []*tunnelproxy.Exchange) {
+	now := time.Now()
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "ID\tSERVICE\tMETHOD\tHOST\tURL\tSTATUS\tDURATION\tAGE\n")
+	for _, ex := range exs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			ex.ID,
+			ex.Service,
+			ex.Request.Method,
+			ex.Request.Host,
+			ex.Request.URL,
+			status(ex),
+			ex.Duration.Round(time.Millisecond),
+			machine.ShortDuration(ex.Time, now),
+		)
+	}
+	tw.Flush()
+}
+
+func status(ex *tunnelproxy.Exchange) string {
+	if ex.Response != nil {
+		return fmt.Sprintf("%d", ex.Response.StatusCode)
+	}
+
+	return "error: " + ex.Error
+}
@@ -10,7 +10,6 @@ import (
 )
 
 type options struct {
-	jsonOutput bool
 }
 
 // NewCommand creates a command that displays current version of this application.
@@ -27,7 +26,7 @@ with machine's kite query ID.`,
 
 	// Flags.
 	flags := cmd.Flags()
-	flags.BoolVar(&opts.jsonOutput, "json", false, "output in JSON format")
+	flags.Bool("json", false, "output in JSON format")
 
 	// Middlewares.
 	cli.MultiCobraCmdMiddleware(
@@ -51,8 +50,8 @@ func command(c *cli.CLI, opts *options) cli.CobraFuncE {
 		}
 
 		v.Latest, _ = config.LatestKDVersionNum()
-		if opts.jsonOutput {
-			cli.PrintJSON(c.Out(), v)
+		if c.Format() != cli.OutputTable {
+			c.Print(v)
 			return nil
 		}
 
@@ -1,8 +1,10 @@
 package credential
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 
 	"koding/kites/kloud/stack"
@@ -10,6 +12,8 @@ import (
 	"koding/klientctl/endpoint/kloud"
 )
 
+//go:generate go run gendescs.go -o descriptions.go
+
 var DefaultClient = &Client{}
 
 func init() {
@@ -67,6 +71,12 @@ func (c *Client) List(opts *ListOptions) (stack.Credentials, error) {
 		return nil, err
 	}
 
+	// Team credentials are listed together with private
+	// ones, thus only unscoped listing is complete.
+	if req.Team == "" && len(req.Template) == 0 {
+		c.prune(req.Provider, resp.Credentials)
+	}
+
 	c.cache(resp.Credentials)
 
 	return resp.Credentials, nil
@@ -165,12 +175,55 @@ func (c *Client) Describe() (stack.Descriptions, error) {
 	return c.describe, nil
 }
 
+// Descriptions gives cached provider descriptions. If the cache
+// is empty, descriptions built into kd are returned.
+//
+// It does not make any calls to kloud, thus it is
+// suitable for offline use.
+func (c *Client) Descriptions() stack.Descriptions {
+	c.init()
+
+	if len(c.describe) != 0 {
+		return c.describe
+	}
+
+	var descs stack.Descriptions
+
+	if err := json.Unmarshal(builtinDescriptions, &descs); err != nil {
+		panic("credential: invalid builtin descriptions: " + err.Error())
+	}
+
+	return descs
+}
+
+// Identifiers gives sorted identifiers of cached credentials.
+//
+// It does not make any calls to kloud, thus it is
+// suitable for shell completion.
+func (c *Client) Identifiers() []string {
+	c.init()
+
+	var identifiers []string
+
+	for _, creds := range c.cached {
+		for _, cred := range creds {
+			identifiers = append(identifiers, cred.Identifier)
+		}
+	}
+
+	sort.Strings(identifiers)
+
+	return identifiers
+}
+
 func (c *Client) Close() (err error) {
-	if len(c.cached) != 0 {
+	// Empty credentials are written as well, so
+	// removed ones are not completed anymore.
+	if c.cached != nil {
 		err = c.kloud().Cache().ReadWrite().SetValue("credential", c.cached)
 	}
 
-	if len(c.used) != 0 {
+	if c.used != nil {
 		err = nonil(err, c.kloud().Cache().ReadWrite().SetValue("credential.used", c.used))
 	}
 
@@ -181,6 +234,42 @@ func (c *Client) Close() (err error) {
 	return err
 }
 
+// prune removes cached credentials of the given provider, or of
+// all providers if it's empty, which are missing from the listed ones.
+func (c *Client) prune(provider string, listed stack.Credentials) {
+	c.init()
+
+	for p, creds := range c.cached {
+		if provider != "" && p != provider {
+			continue
+		}
+
+		var kept []stack.CredentialItem
+
+		for _, cred := range creds {
+			if _, ok := listed.Find(cred.Identifier); ok {
+				kept = append(kept, cred)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(c.cached, p)
+		} else {
+			c.cached[p] = kept
+		}
+	}
+
+	for p, identifier := range c.used {
+		if provider != "" && p != provider {
+			continue
+		}
+
+		if _, ok := listed.Find(identifier); !ok {
+			delete(c.used, p)
+		}
+	}
+}
+
 func (c *Client) cache(credentials stack.Credentials) {
 	c.init()
 
@@ -245,6 +334,8 @@ func nonil(err ...error) error {
 func List(opts *ListOptions) (stack.Credentials, error)         { return DefaultClient.List(opts) }
 func Create(opts *CreateOptions) (*stack.CredentialItem, error) { return DefaultClient.Create(opts) }
 func Describe() (stack.Descriptions, error)                     { return DefaultClient.Describe() }
+func Descriptions() stack.Descriptions                          { return DefaultClient.Descriptions() }
+func Identifiers() []string                                     { return DefaultClient.Identifiers() }
 func Use(identifier string) error                               { return DefaultClient.Use(identifier) }
 func Used() map[string]string                                   { return DefaultClient.Used() }
 func Provider(identifier string) (string, error)                { return DefaultClient.Provider(identifier) }
@@ -0,0 +1,72 @@
+// +build ignore
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"koding/kites/kloud/stack/provider"
+
+	_ "koding/kites/kloud/provider/aws"
+	_ "koding/kites/kloud/provider/azure"
+	_ "koding/kites/kloud/provider/do"
+	_ "koding/kites/kloud/provider/docker"
+	_ "koding/kites/kloud/provider/google"
+	_ "koding/kites/kloud/provider/kubernetes"
+	_ "koding/kites/kloud/provider/marathon"
+	_ "koding/kites/kloud/provider/openstack"
+	_ "koding/kites/kloud/provider/softlayer"
+	_ "koding/kites/kloud/provider/vagrant"
+)
+
+var output = flag.String("o", "-", "")
+
+var t = template.Must(template.New("").Parse(`// Code generated by gendescs.go; DO NOT EDIT.
+
+package credential
+
+// builtinDescriptions are descriptions of providers supported by kloud,
+// which are used when no descriptions were cached yet.
+var builtinDescriptions = []byte(` + "`{{.}}`" + `)
+`))
+
+func main() {
+	flag.Parse()
+
+	p, err := json.MarshalIndent(provider.Desc(), "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if bytes.ContainsRune(p, '`') {
+		log.Fatal("descriptions contain a backtick")
+	}
+
+	var buf bytes.Buffer
+
+	if err := t.Execute(&buf, strings.TrimSpace(string(p))); err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(src)
+		return
+	}
+
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
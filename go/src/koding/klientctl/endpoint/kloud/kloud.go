@@ -96,9 +96,24 @@ func (c *Client) Close() error {
 // The returned channel will be closed as soon as the operation
 // finishes or error occurs.
 func (c *Client) Wait(event string) <-chan *stack.EventResponse {
+	return c.wait(event, false)
+}
+
+// Tail works like Wait, additionally receiving the output
+// of the operation, e.g. terraform logs.
+//
+// Events carrying new output lines are sent to the
+// returned channel even if the percentage has not changed.
+func (c *Client) Tail(event string) <-chan *stack.EventResponse {
+	return c.wait(event, true)
+}
+
+func (c *Client) wait(event string, output bool) <-chan *stack.EventResponse {
 	ch := make(chan *stack.EventResponse, 1)
 
-	var arg stack.EventArg
+	arg := stack.EventArg{
+		Output: output,
+	}
 
 	if i := strings.IndexRune(event, '-'); i != -1 {
 		arg.Type = event[:i]
@@ -122,11 +137,11 @@ func (c *Client) Wait(event string) <-chan *stack.EventResponse {
 		last := -1
 		defer close(ch)
 
-		id := stack.EventArgs{arg}
-
 		for {
 			var events []stack.EventResponse
 
+			id := stack.EventArgs{arg}
+
 			if err := c.Call("event", id, &events); err != nil {
 				ch <- &stack.EventResponse{
 					EventId: arg.EventId,
@@ -150,8 +165,9 @@ func (c *Client) Wait(event string) <-chan *stack.EventResponse {
 					continue
 				}
 
-				if e.Event.Percentage > last {
+				if e.Event.Percentage > last || len(e.Output) != 0 {
 					last = e.Event.Percentage
+					arg.Offset += len(e.Output)
 					event = &e
 					break
 				}
@@ -425,3 +441,11 @@ func Call(method string, arg, reply interface{}) error {
 func Wait(event string) <-chan *stack.EventResponse {
 	return DefaultClient.Wait(event)
 }
+
+// Tail works like Wait, additionally receiving the output
+// of the operation.
+//
+// The function forwards the call to the DefaultClient.
+func Tail(event string) <-chan *stack.EventResponse {
+	return DefaultClient.Tail(event)
+}
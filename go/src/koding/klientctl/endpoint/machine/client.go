@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"koding/kites/config"
+	"koding/kites/kloud/schedule"
 	"koding/kites/kloud/stack"
 	"koding/klient/machine"
 	"koding/klient/machine/machinegroup"
@@ -15,6 +16,7 @@ import (
 	"koding/klientctl/ctlcli"
 	"koding/klientctl/endpoint/kloud"
 	koding "koding/klientctl/endpoint/remoteapi"
+	"koding/klientctl/endpoint/team"
 	"koding/klientctl/stream"
 	"koding/remoteapi/models"
 
@@ -213,8 +215,10 @@ func (c *Client) Set(options *SetOptions) error {
 	switch options.Key {
 	case "alwaysOn":
 		return c.setAlwaysOn(id, options.Value)
+	case "schedule":
+		return c.setSchedule(id, options.Value)
 	default:
-		return fmt.Errorf(`unsupported %q key; supported ones: "alwaysOn"`, options.Value)
+		return fmt.Errorf(`unsupported %q key; supported ones: "alwaysOn", "schedule"`, options.Key)
 	}
 }
 
@@ -232,6 +236,23 @@ func (c *Client) setAlwaysOn(id machine.ID, key string) error {
 	return c.koding().UpdateMachineAlwaysOn(m, on)
 }
 
+func (c *Client) setSchedule(id machine.ID, value string) error {
+	sched, err := schedule.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	// Team is used to authorize admins, when scheduling
+	// machines of other team members.
+	req := &stack.ScheduleRequest{
+		MachineID: string(id),
+		GroupName: team.Used().Name,
+		Schedule:  sched,
+	}
+
+	return c.kloud().Call("machine.schedule", req, &stack.ScheduleResponse{})
+}
+
 func (c *Client) machineCall(id machine.ID, method string) (string, error) {
 	m, err := c.machine(id)
 	if err != nil {
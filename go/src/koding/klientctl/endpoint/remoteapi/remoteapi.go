@@ -2,6 +2,7 @@ package remoteapi
 
 import (
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -45,10 +46,54 @@ type Client struct {
 	Team   *team.Client   // if nil, team.DefaultClient is used
 	Client *client.Koding // if nil, new client is created (with kloud as auth provider)
 
-	once     sync.Once // for c.init()
-	api      *remoteapi.Client
-	c        *client.Koding
-	accounts map[string]*models.JAccount
+	once      sync.Once // for c.init()
+	api       *remoteapi.Client
+	c         *client.Koding
+	accounts  map[string]*models.JAccount
+	stacks    entries // maps stack ID to its title
+	templates entries // maps template ID to its slug
+}
+
+// entry describes a stack or a template, which is
+// cached for shell completion.
+type entry struct {
+	Name     string `json:"name"` // stack title or template slug
+	Team     string `json:"team,omitempty"`
+	OriginID string `json:"originId,omitempty"`
+}
+
+// entries maps resource ID to its cached value.
+type entries map[string]*entry
+
+// refresh updates the entries with the ones listed
+// using the given filter.
+//
+// Cached entries, which match the filter but are not
+// listed, are stale and thus removed. If the filter
+// looks up a single resource or uses fields, which
+// are not cached, no entries are removed.
+func (e entries) refresh(f *Filter, listed entries) {
+	if f == nil || (f.ID == "" && f.Provider == "") {
+		for id, cached := range e {
+			if _, ok := listed[id]; !ok && f.matches(cached) {
+				delete(e, id)
+			}
+		}
+	}
+
+	for id, cached := range listed {
+		e[id] = cached
+	}
+}
+
+// sortedIDs gives sorted IDs of the entries.
+func (e entries) sortedIDs() []string {
+	ids := make([]string, 0, len(e))
+	for id := range e {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
 }
 
 // New creates new remoteapi client for the given user.
@@ -63,6 +108,14 @@ func (c *Client) Close() (err error) {
 	if len(c.accounts) != 0 {
 		err = c.kloud().Cache().ReadWrite().SetValue("jAccounts", c.accounts)
 	}
+	// Empty stacks and templates are written as well,
+	// so stale entries do not outlive their removal.
+	if c.stacks != nil {
+		err = nonil(err, c.kloud().Cache().ReadWrite().SetValue("jComputeStacks", c.stacks))
+	}
+	if c.templates != nil {
+		err = nonil(err, c.kloud().Cache().ReadWrite().SetValue("jStackTemplates", c.templates))
+	}
 	return err
 }
 
@@ -137,11 +190,22 @@ func (c *Client) init() {
 
 func (c *Client) initClient() {
 	c.accounts = make(map[string]*models.JAccount)
+	c.stacks = make(entries)
+	c.templates = make(entries)
 
 	// Ignoring read error, if it's non-nil then empty cache is going to
 	// be used instead.
 	_ = c.kloud().Cache().ReadOnly().GetValue("jAccounts", &c.accounts)
 
+	// Stacks and templates cached by older kd versions have
+	// a different format, in which case they are dropped.
+	if err := c.kloud().Cache().ReadOnly().GetValue("jComputeStacks", &c.stacks); err != nil || c.stacks == nil {
+		c.stacks = make(entries)
+	}
+	if err := c.kloud().Cache().ReadOnly().GetValue("jStackTemplates", &c.templates); err != nil || c.templates == nil {
+		c.templates = make(entries)
+	}
+
 	if c.Client == nil {
 		c.api = &remoteapi.Client{
 			Transport: endpoint.Transport(c.kloud(), c.auth()),
@@ -190,6 +254,22 @@ func (c *Client) timeout() time.Duration {
 	return DefaultTimeout
 }
 
+func nonil(err ...error) error {
+	for _, e := range err {
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 func AccountByUsername(username string) (*models.JAccount, error) {
 	return DefaultClient.AccountByUsername(username)
 }
@@ -0,0 +1,50 @@
+package remoteapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntriesRefresh(t *testing.T) {
+	cases := map[string]struct {
+		filter *Filter
+		listed entries
+		want   []string
+	}{
+		"all entries": {
+			nil,
+			entries{"3": {Name: "c", Team: "foo"}},
+			[]string{"3"},
+		},
+		"team entries": {
+			&Filter{Team: "foo"},
+			entries{"3": {Name: "c", Team: "foo"}},
+			[]string{"2", "3"},
+		},
+		"no team entries": {
+			&Filter{Team: "bar"},
+			entries{},
+			[]string{"1"},
+		},
+		"single entry": {
+			&Filter{ID: "4"},
+			entries{"4": {Name: "d", Team: "bar"}},
+			[]string{"1", "2", "4"},
+		},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := entries{
+				"1": {Name: "a", Team: "foo"},
+				"2": {Name: "b", Team: "bar"},
+			}
+
+			e.refresh(cas.filter, cas.listed)
+
+			if got := e.sortedIDs(); !reflect.DeepEqual(got, cas.want) {
+				t.Fatalf("got %v, want %v", got, cas.want)
+			}
+		})
+	}
+}
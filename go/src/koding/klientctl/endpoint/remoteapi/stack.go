@@ -34,6 +34,18 @@ func (c *Client) ListStacks(f *Filter) ([]*models.JComputeStack, error) {
 		return nil, err
 	}
 
+	listed := make(entries, len(stacks))
+
+	for _, stack := range stacks {
+		listed[stack.ID] = &entry{
+			Name:     str(stack.Title),
+			Team:     str(stack.Group),
+			OriginID: str(stack.OriginID),
+		}
+	}
+
+	c.stacks.refresh(f, listed)
+
 	if len(stacks) == 0 {
 		return nil, ErrNotFound
 	}
@@ -41,9 +53,28 @@ func (c *Client) ListStacks(f *Filter) ([]*models.JComputeStack, error) {
 	return stacks, nil
 }
 
+// StackIDs gives sorted IDs of stacks, which were
+// cached by previous ListStacks calls.
+//
+// It does not make any calls to remote.api, thus
+// it is suitable for shell completion.
+func (c *Client) StackIDs() []string {
+	c.init()
+
+	return c.stacks.sortedIDs()
+}
+
 // ListStacks gives all stacks, filtered by the given f filter.
 //
 // The functions uses DefaultClient.
 func ListStacks(f *Filter) ([]*models.JComputeStack, error) {
 	return DefaultClient.ListStacks(f)
 }
+
+// StackIDs gives sorted IDs of stacks, which were
+// cached by previous ListStacks calls.
+//
+// The functions uses DefaultClient.
+func StackIDs() []string {
+	return DefaultClient.StackIDs()
+}
@@ -57,6 +57,18 @@ func (c *Client) ListTemplates(f *Filter) ([]*models.JStackTemplate, error) {
 		return nil, err
 	}
 
+	listed := make(entries, len(templates))
+
+	for _, tmpl := range templates {
+		listed[tmpl.ID] = &entry{
+			Name:     str(tmpl.Slug),
+			Team:     str(tmpl.Group),
+			OriginID: str(tmpl.OriginID),
+		}
+	}
+
+	c.templates.refresh(f, listed)
+
 	if len(templates) == 0 {
 		return nil, ErrNotFound
 	}
@@ -64,6 +76,17 @@ func (c *Client) ListTemplates(f *Filter) ([]*models.JStackTemplate, error) {
 	return templates, nil
 }
 
+// TemplateIDs gives sorted IDs of templates, which were
+// cached by previous ListTemplates calls.
+//
+// It does not make any calls to remote.api, thus
+// it is suitable for shell completion.
+func (c *Client) TemplateIDs() []string {
+	c.init()
+
+	return c.templates.sortedIDs()
+}
+
 // DeleteTemplate deletes a template given by the id.
 func (c *Client) DeleteTemplate(id string) error {
 	c.init()
@@ -79,7 +102,13 @@ func (c *Client) DeleteTemplate(id string) error {
 		return err
 	}
 
-	return remoteapi.Unmarshal(&resp.Payload.DefaultResponse, nil)
+	if err := remoteapi.Unmarshal(&resp.Payload.DefaultResponse, nil); err != nil {
+		return err
+	}
+
+	delete(c.templates, id)
+
+	return nil
 }
 
 // SampleTemplate returns a content of a sample stack template
@@ -119,6 +148,19 @@ func (c *Client) SampleTemplate(provider string) (string, map[string]interface{}
 	return v.JSON, v.Defaults.UserInputs, nil
 }
 
+// matches tells whether the given cached entry matches the filter.
+//
+// A nil filter matches all entries.
+func (f *Filter) matches(e *entry) bool {
+	if f == nil {
+		return true
+	}
+
+	return (f.Team == "" || f.Team == e.Team) &&
+		(f.OriginID == "" || f.OriginID == e.OriginID) &&
+		(f.Slug == "" || f.Slug == e.Name)
+}
+
 func (c *Client) buildFilter(f *Filter) error {
 	if f.Slug != "" {
 		fields := strings.Split(f.Slug, "/")
@@ -161,6 +203,14 @@ func ListTemplates(f *Filter) ([]*models.JStackTemplate, error) {
 	return DefaultClient.ListTemplates(f)
 }
 
+// TemplateIDs gives sorted IDs of templates, which were
+// cached by previous ListTemplates calls.
+//
+// The functions uses DefaultClient.
+func TemplateIDs() []string {
+	return DefaultClient.TemplateIDs()
+}
+
 // DeleteTemplate deletes a template given by the id.
 //
 // The functions uses DefaultClient.
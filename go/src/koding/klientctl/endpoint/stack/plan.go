@@ -0,0 +1,105 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+
+	"koding/kites/kloud/stack"
+	"koding/klientctl/endpoint/remoteapi"
+	"koding/klientctl/endpoint/team"
+)
+
+// PlanOptions are used to plan machines of a stack template.
+type PlanOptions struct {
+	TemplateID string
+	Team       string
+
+	// Provider is used to route the request in kloud. If empty,
+	// the first provider of the template is used.
+	Provider string
+
+	// Variables holds values for user variables.
+	Variables map[string]string
+}
+
+// Valid implements the stack.Validator interface.
+func (opts *PlanOptions) Valid() error {
+	if opts == nil {
+		return errors.New("stack: arguments are missing")
+	}
+
+	if opts.TemplateID == "" {
+		return errors.New("stack: template ID is missing")
+	}
+
+	return nil
+}
+
+// PlanResponse describes machines, which are going to be built
+// for a stack template, and their estimated cost.
+type PlanResponse struct {
+	Machines []*stack.Machine `json:"machines"`
+	Cost     *stack.Cost      `json:"cost,omitempty"`
+}
+
+// Plan gives machines of the given stack template and their
+// estimated costs.
+func (c *Client) Plan(opts *PlanOptions) (*PlanResponse, error) {
+	if err := opts.Valid(); err != nil {
+		return nil, err
+	}
+
+	req := &stack.PlanRequest{
+		Provider:        opts.Provider,
+		StackTemplateID: opts.TemplateID,
+		GroupName:       opts.Team,
+		Variables:       UserVariables(opts.Variables),
+	}
+
+	if req.GroupName == "" {
+		req.GroupName = team.Used().Name
+	}
+
+	if req.Provider == "" {
+		p, err := c.templateProvider(opts.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Provider = p
+	}
+
+	var resp PlanResponse
+
+	if err := c.kloud().Call("plan", req, &resp); err != nil {
+		return nil, &KloudError{Err: err}
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) templateProvider(templateID string) (string, error) {
+	templates, err := remoteapi.ListTemplates(&remoteapi.Filter{ID: templateID})
+	if err != nil {
+		return "", fmt.Errorf("stack: unable to read template %q: %s", templateID, err)
+	}
+
+	if templates[0].Template == nil {
+		return "", fmt.Errorf("stack: template %q has no content", templateID)
+	}
+
+	providers, err := stack.ReadProviders([]byte(templates[0].Template.Content))
+	if err != nil {
+		return "", fmt.Errorf("stack: unable to read providers: %s", err)
+	}
+
+	if len(providers) == 0 {
+		return "", errors.New("stack: unable to read providers")
+	}
+
+	return providers[0], nil
+}
+
+func Plan(opts *PlanOptions) (*PlanResponse, error) {
+	return DefaultClient.Plan(opts)
+}
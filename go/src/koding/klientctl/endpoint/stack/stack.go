@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"koding/kites/kloud/stack"
 	kloudstack "koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
 	"koding/kites/kloud/utils/object"
 	"koding/klientctl/endpoint/credential"
 	"koding/klientctl/endpoint/kloud"
@@ -17,11 +19,18 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
+const userInput = "userInput"
+
 type CreateOptions struct {
 	Team        string
 	Title       string
 	Credentials []string
 	Template    []byte
+
+	// Variables holds values for user variables. If a variable
+	// name is not prefixed with "userInput_", the prefix
+	// is added.
+	Variables map[string]string
 }
 
 func (opts *CreateOptions) Valid() error {
@@ -38,6 +47,16 @@ func (opts *CreateOptions) Valid() error {
 
 var DefaultClient = &Client{}
 
+// KloudError is returned when a call to kloud fails.
+type KloudError struct {
+	Err error // kite or transport error
+}
+
+// Error implements the built-in error interface.
+func (e *KloudError) Error() string {
+	return "stack: unable to communicate with Kloud: " + e.Err.Error()
+}
+
 type Client struct {
 	Kloud      *kloud.Client
 	Credential *credential.Client
@@ -67,6 +86,7 @@ func (c *Client) Create(opts *CreateOptions) (*stack.ImportResponse, error) {
 		Team:        opts.Team,
 		Title:       opts.Title,
 		Credentials: make(map[string][]string),
+		Variables:   UserVariables(opts.Variables),
 	}
 
 	if req.Team == "" {
@@ -99,13 +119,45 @@ func (c *Client) Create(opts *CreateOptions) (*stack.ImportResponse, error) {
 		req.Credentials[provider] = []string{identifier}
 	}
 
+	// If user variables are provided via a credential, they
+	// are going to be validated by kloud.
+	if _, ok := req.Credentials[userInput]; !ok {
+		if err := c.checkVariables(data, req.Variables); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := c.kloud().Call("import", req, &resp); err != nil {
-		return nil, fmt.Errorf("stack: unable to communicate with Kloud: %s", err)
+		return nil, &KloudError{Err: err}
 	}
 
 	return &resp, nil
 }
 
+func (c *Client) checkVariables(data []byte, vars map[string]string) error {
+	t, err := provider.ParseTemplate(string(data), kloud.DefaultLog)
+	if err != nil {
+		return fmt.Errorf("stack: unable to read template: %s", err)
+	}
+
+	if len(vars) != 0 {
+		if err := t.InjectVariables("", vars); err != nil {
+			return fmt.Errorf("stack: unable to inject variables: %s", err)
+		}
+	}
+
+	missing, err := t.MissingVariables(userInput)
+	if err != nil {
+		return fmt.Errorf("stack: unable to read variables: %s", err)
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("stack: %s", &provider.MissingVariablesError{Names: missing})
+	}
+
+	return nil
+}
+
 func (c *Client) kloud() *kloud.Client {
 	if c.Kloud != nil {
 		return c.Kloud
@@ -146,6 +198,26 @@ func (c *Client) jsonReencode(data []byte) ([]byte, error) {
 	return nil, errors.New("unknown encoding")
 }
 
+// UserVariables gives a copy of vars with each
+// name prefixed with "userInput_", if needed.
+func UserVariables(vars map[string]string) map[string]string {
+	if len(vars) == 0 {
+		return nil
+	}
+
+	userVars := make(map[string]string, len(vars))
+
+	for k, v := range vars {
+		if !strings.HasPrefix(k, userInput+"_") {
+			k = userInput + "_" + k
+		}
+
+		userVars[k] = v
+	}
+
+	return userVars
+}
+
 func Create(opts *CreateOptions) (*stack.ImportResponse, error) {
 	return DefaultClient.Create(opts)
 }
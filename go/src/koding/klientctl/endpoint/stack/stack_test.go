@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"koding/kites/kloud/utils/object"
+	"koding/klientctl/endpoint/stack"
 	"koding/klientctl/endpoint/stack/stackfixture"
 
 	"github.com/hashicorp/hcl"
@@ -108,6 +109,26 @@ func TestFixYAML(t *testing.T) {
 	}
 }
 
+func TestUserVariables(t *testing.T) {
+	vars := map[string]string{
+		"count":         "2",
+		"userInput_foo": "bar",
+	}
+
+	want := map[string]string{
+		"userInput_count": "2",
+		"userInput_foo":   "bar",
+	}
+
+	if got := stack.UserVariables(vars); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if got := stack.UserVariables(nil); got != nil {
+		t.Fatalf("got %v, want nil", got)
+	}
+}
+
 func mustJSON(v interface{}) []byte {
 	p, err := json.MarshalIndent(v, "", "\t")
 	if err != nil {
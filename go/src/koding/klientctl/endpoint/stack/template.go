@@ -0,0 +1,77 @@
+package stack
+
+import (
+	"errors"
+
+	"koding/kites/kloud/stack"
+)
+
+// TemplateDiffOptions are used to request a structural difference
+// between two revisions of a stack template.
+type TemplateDiffOptions struct {
+	TemplateID string
+	From       int // if 0, revision preceding To is used
+	To         int // if 0, the latest revision is used
+}
+
+// Valid implements the stack.Validator interface.
+func (opts *TemplateDiffOptions) Valid() error {
+	if opts == nil {
+		return errors.New("stack: arguments are missing")
+	}
+
+	if opts.TemplateID == "" {
+		return errors.New("stack: template ID is missing")
+	}
+
+	return nil
+}
+
+// TemplateHistory gives revision history of the given stack template.
+func (c *Client) TemplateHistory(templateID string) ([]*stack.TemplateRevision, error) {
+	if templateID == "" {
+		return nil, errors.New("stack: template ID is missing")
+	}
+
+	req := &stack.TemplateHistoryRequest{
+		TemplateID: templateID,
+	}
+
+	var resp stack.TemplateHistoryResponse
+
+	if err := c.kloud().Call("template.history", req, &resp); err != nil {
+		return nil, &KloudError{Err: err}
+	}
+
+	return resp.Revisions, nil
+}
+
+// TemplateDiff gives structural difference of resources between
+// two revisions of the given stack template.
+func (c *Client) TemplateDiff(opts *TemplateDiffOptions) (*stack.TemplateDiffResponse, error) {
+	if err := opts.Valid(); err != nil {
+		return nil, err
+	}
+
+	req := &stack.TemplateDiffRequest{
+		TemplateID: opts.TemplateID,
+		From:       opts.From,
+		To:         opts.To,
+	}
+
+	var resp stack.TemplateDiffResponse
+
+	if err := c.kloud().Call("template.diff", req, &resp); err != nil {
+		return nil, &KloudError{Err: err}
+	}
+
+	return &resp, nil
+}
+
+func TemplateHistory(templateID string) ([]*stack.TemplateRevision, error) {
+	return DefaultClient.TemplateHistory(templateID)
+}
+
+func TemplateDiff(opts *TemplateDiffOptions) (*stack.TemplateDiffResponse, error) {
+	return DefaultClient.TemplateDiff(opts)
+}
@@ -0,0 +1,204 @@
+package stack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"koding/kites/kloud/stack"
+	"koding/kites/kloud/stack/provider"
+	"koding/kites/kloud/utils/object"
+	"koding/klientctl/endpoint/kloud"
+
+	"github.com/hashicorp/hcl"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Problem describes a single issue found
+// in a template file during validation.
+type Problem struct {
+	Line    int    `json:"line,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+// String implements the fmt.Stringer interface.
+func (p *Problem) String() string {
+	var buf bytes.Buffer
+
+	if p.Warning {
+		buf.WriteString("warning: ")
+	} else {
+		buf.WriteString("error: ")
+	}
+
+	if p.Path != "" {
+		buf.WriteString(p.Path + ": ")
+	}
+
+	buf.WriteString(p.Message)
+
+	return buf.String()
+}
+
+// Problems represents a list of problems.
+type Problems []*Problem
+
+// Errors gives number of problems, which are not warnings.
+func (p Problems) Errors() (n int) {
+	for _, p := range p {
+		if !p.Warning {
+			n++
+		}
+	}
+	return n
+}
+
+// ValidateOptions are used to validate a template.
+type ValidateOptions struct {
+	// Template is a content of template
+	// file encoded in JSON, YAML or HCL.
+	Template []byte
+
+	// Descs are used to validate provider-specific
+	// parts of the template.
+	//
+	// If nil, cached descriptions are used, or the ones
+	// built into kd when the cache is empty.
+	Descs stack.Descriptions
+}
+
+// Validate validates the template. It does not make any calls
+// to remote services.
+//
+// If template is not parsable, Validate returns a single
+// parse problem. Non-nil error is returned only when
+// opts are invalid.
+func (c *Client) Validate(opts *ValidateOptions) (Problems, error) {
+	if opts == nil || len(opts.Template) == 0 {
+		return nil, fmt.Errorf("stack: template data is missing")
+	}
+
+	data, problem := c.jsonDecode(opts.Template)
+	if problem != nil {
+		return Problems{problem}, nil
+	}
+
+	t, err := provider.ParseTemplate(string(data), kloud.DefaultLog)
+	if err != nil {
+		return Problems{{Message: err.Error()}}, nil
+	}
+
+	descs := opts.Descs
+	if descs == nil {
+		descs = c.credential().Descriptions()
+	}
+
+	v := &provider.Validator{
+		Descs: descs,
+	}
+
+	var problems Problems
+
+	for _, e := range v.Validate(t) {
+		problems = append(problems, &Problem{
+			Line:    Line(opts.Template, e.Path, e.Token),
+			Path:    strings.Join(e.Path, "."),
+			Message: e.Err.Error(),
+			Warning: e.Warning,
+		})
+	}
+
+	return problems, nil
+}
+
+// jsonDecode works like jsonReencode, but it reports
+// parse problems with line numbers.
+func (c *Client) jsonDecode(data []byte) ([]byte, *Problem) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var v interface{}
+
+		if err := json.Unmarshal(data, &v); err != nil {
+			p := &Problem{
+				Message: "invalid JSON: " + err.Error(),
+			}
+
+			if e, ok := err.(*json.SyntaxError); ok {
+				p.Line = bytes.Count(data[:e.Offset], []byte("\n")) + 1
+			}
+
+			return nil, p
+		}
+
+		p, err := jsonMarshal(v)
+		if err != nil {
+			return nil, &Problem{Message: err.Error()}
+		}
+
+		return p, nil
+	}
+
+	var hclv interface{}
+
+	if err := hcl.Unmarshal(data, &hclv); err == nil {
+		object.FixHCL(hclv)
+
+		p, err := jsonMarshal(hclv)
+		if err != nil {
+			return nil, &Problem{Message: err.Error()}
+		}
+
+		return p, nil
+	}
+
+	var ymlv interface{}
+
+	if err := yaml.Unmarshal(data, &ymlv); err != nil {
+		return nil, &Problem{Message: "invalid YAML or HCL: " + err.Error()}
+	}
+
+	p, err := jsonMarshal(object.FixYAML(ymlv))
+	if err != nil {
+		return nil, &Problem{Message: err.Error()}
+	}
+
+	return p, nil
+}
+
+// Line gives a line number of the value under the given path
+// within the raw template content, looking up each path
+// element in order. If token is non-empty, it is looked
+// up after the last path element.
+//
+// The lookup is a best-effort one, as it is expected to
+// work with any of the supported template encodings.
+// If the value was not found, the line of the last
+// matched element is returned, or 0 if none matched.
+func Line(content []byte, path []string, token string) int {
+	lines := strings.Split(string(content), "\n")
+	line, found := 0, 0
+
+	keys := append(path[:len(path):len(path)], token)
+
+	for _, key := range keys {
+		if _, err := strconv.Atoi(key); err == nil || key == "" {
+			continue
+		}
+
+		for i := line; i < len(lines); i++ {
+			if strings.Contains(lines[i], key) {
+				line, found = i, i+1
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+func Validate(opts *ValidateOptions) (Problems, error) {
+	return DefaultClient.Validate(opts)
+}
@@ -0,0 +1,99 @@
+package stack_test
+
+import (
+	"testing"
+
+	"koding/kites/kloud/stack"
+	"koding/klientctl/endpoint/credential"
+	kdstack "koding/klientctl/endpoint/stack"
+	"koding/klientctl/endpoint/stack/stackfixture"
+)
+
+func TestLine(t *testing.T) {
+	path := []string{"resource", "aws_instance", "example-instance", "tags", "Name"}
+	token := "${var.koding_group_slug}"
+
+	cases := map[string]struct {
+		content []byte
+		want    int
+	}{
+		"hcl":  {stackfixture.StackHCL, 10},
+		"json": {stackfixture.StackJSON, 14},
+		"yaml": {stackfixture.StackYAML, 11},
+	}
+
+	for name, cas := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := kdstack.Line(cas.content, path, token); got != cas.want {
+				t.Fatalf("got %d, want %d", got, cas.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	descs := stack.Descriptions{
+		"aws": {
+			Credential: []stack.Value{{Name: "access_key"}, {Name: "secret_key"}},
+		},
+	}
+
+	c := &kdstack.Client{
+		Credential: &credential.Client{},
+	}
+
+	for _, content := range [][]byte{stackfixture.StackHCL, stackfixture.StackJSON, stackfixture.StackYAML} {
+		problems, err := c.Validate(&kdstack.ValidateOptions{
+			Template: content,
+			Descs:    descs,
+		})
+		if err != nil {
+			t.Fatalf("Validate()=%s", err)
+		}
+
+		if len(problems) != 0 {
+			t.Fatalf("got %d problems, want 0: %+v", len(problems), problems[0])
+		}
+	}
+
+	problems, err := c.Validate(&kdstack.ValidateOptions{
+		Template: []byte("{\n\t\"resource\": {\n\t\t\"aws_instance\": \n}"),
+		Descs:    descs,
+	})
+	if err != nil {
+		t.Fatalf("Validate()=%s", err)
+	}
+
+	if len(problems) != 1 || problems[0].Line != 4 {
+		t.Fatalf("got %+v, want single problem in line 4", problems)
+	}
+}
+
+func TestValidateOffline(t *testing.T) {
+	c := &kdstack.Client{
+		Credential: &credential.Client{},
+	}
+
+	// With no descriptions cached, the ones built into kd are used.
+	problems, err := c.Validate(&kdstack.ValidateOptions{
+		Template: stackfixture.StackJSON,
+	})
+	if err != nil {
+		t.Fatalf("Validate()=%s", err)
+	}
+
+	if len(problems) != 0 {
+		t.Fatalf("got %d problems, want 0: %+v", len(problems), problems[0])
+	}
+
+	problems, err = c.Validate(&kdstack.ValidateOptions{
+		Template: []byte(`{"provider": {"nosuchcloud": {}}, "resource": {"nosuchcloud_instance": {"vm": {}}}}`),
+	})
+	if err != nil {
+		t.Fatalf("Validate()=%s", err)
+	}
+
+	if problems.Errors() == 0 {
+		t.Fatal("expected unsupported provider to be reported")
+	}
+}
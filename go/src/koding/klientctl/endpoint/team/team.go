@@ -2,6 +2,7 @@ package team
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"koding/kites/kloud/stack"
@@ -35,8 +36,9 @@ func (t *Team) Valid() error {
 type Client struct {
 	Kloud *kloud.Client
 
-	once sync.Once // for c.init()
-	used Team
+	once  sync.Once // for c.init()
+	used  Team
+	names []string
 }
 
 func (c *Client) Use(team *Team) {
@@ -65,9 +67,46 @@ func (c *Client) List(opts *ListOptions) ([]*team.Team, error) {
 		return nil, err
 	}
 
+	if req.Slug == "" {
+		c.names = make([]string, 0, len(resp.Teams))
+
+		for _, t := range resp.Teams {
+			c.names = append(c.names, t.Name)
+		}
+	}
+
 	return resp.Teams, nil
 }
 
+// Names gives sorted names of teams, which were cached
+// by the last List call, including the currently used one.
+//
+// It does not make any calls to kloud, thus it is
+// suitable for shell completion.
+func (c *Client) Names() []string {
+	c.init()
+
+	names := make(map[string]struct{}, len(c.names)+1)
+
+	for _, name := range c.names {
+		names[name] = struct{}{}
+	}
+
+	if c.used.Valid() == nil {
+		names[c.used.Name] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(names))
+
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
+
 func (c *Client) Whoami() (*stack.WhoamiResponse, error) {
 	c.init()
 
@@ -85,6 +124,12 @@ func (c *Client) Close() (err error) {
 		err = c.kloud().Cache().ReadWrite().SetValue("team.used", &c.used)
 	}
 
+	// Empty names are written as well, so teams
+	// the user left are not completed anymore.
+	if c.names != nil {
+		err = nonil(err, c.kloud().Cache().ReadWrite().SetValue("team.names", c.names))
+	}
+
 	return err
 }
 
@@ -96,6 +141,17 @@ func (c *Client) readCache() {
 	// Ignoring read error, if it's non-nil then empty cache is going to
 	// be used instead.
 	_ = c.kloud().Cache().ReadOnly().GetValue("team.used", &c.used)
+	_ = c.kloud().Cache().ReadOnly().GetValue("team.names", &c.names)
+}
+
+func nonil(err ...error) error {
+	for _, e := range err {
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
 }
 
 func (c *Client) kloud() *kloud.Client {
@@ -109,3 +165,4 @@ func Use(team *Team)                               { DefaultClient.Use(team) }
 func Used() *Team                                  { return DefaultClient.Used() }
 func List(opts *ListOptions) ([]*team.Team, error) { return DefaultClient.List(opts) }
 func Whoami() (*stack.WhoamiResponse, error)       { return DefaultClient.Whoami() }
+func Names() []string                              { return DefaultClient.Names() }
@@ -0,0 +1,84 @@
+package tunnel
+
+import (
+	"koding/kites/config"
+	"koding/kites/tunnelproxy"
+	"koding/klient/tunnel"
+	konfig "koding/klientctl/config"
+	"koding/klientctl/endpoint/kloud"
+)
+
+// DefaultClient is a default client used by Requests and Replay functions.
+var DefaultClient = &Client{}
+
+// RequestsOptions are options available for `tunnel requests` command.
+type RequestsOptions struct {
+	Service string // Limit to requests of the given service.
+	Limit   int    // Maximum number of requests.
+}
+
+// Client inspects HTTP requests recorded by the tunnel of local klient.
+type Client struct {
+	Konfig *config.Konfig
+	Klient kloud.Transport
+
+	k kloud.Transport
+}
+
+// Requests gives recently recorded HTTP requests, newest first.
+func (c *Client) Requests(opts *RequestsOptions) ([]*tunnelproxy.Exchange, error) {
+	req := &tunnel.RequestsRequest{
+		Service: opts.Service,
+		Limit:   opts.Limit,
+	}
+
+	var resp []*tunnelproxy.Exchange
+
+	if err := c.klient().Call("tunnel.requests", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Replay sends the recorded HTTP request with the given ID once again
+// to the local service.
+func (c *Client) Replay(id int64) (*tunnelproxy.Exchange, error) {
+	req := &tunnel.ReplayRequest{
+		ID: id,
+	}
+
+	var resp tunnelproxy.Exchange
+
+	if err := c.klient().Call("tunnel.replay", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) konfig() *config.Konfig {
+	if c.Konfig != nil {
+		return c.Konfig
+	}
+	return konfig.Konfig
+}
+
+func (c *Client) klient() kloud.Transport {
+	if c.Klient != nil {
+		return c.Klient
+	}
+
+	if c.k == nil {
+		c.k = &kloud.KiteTransport{
+			ClientURL: c.konfig().Endpoints.Klient.Private.String(),
+		}
+	}
+
+	return c.k
+}
+
+func Requests(opts *RequestsOptions) ([]*tunnelproxy.Exchange, error) {
+	return DefaultClient.Requests(opts)
+}
+func Replay(id int64) (*tunnelproxy.Exchange, error) { return DefaultClient.Replay(id) }
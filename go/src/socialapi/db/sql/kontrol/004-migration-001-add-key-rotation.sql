@@ -0,0 +1,29 @@
+-- add expires_at column into key table, a key pair which expires_at is
+-- in the past gets deleted; it is set for previous key pairs on rotation
+DO $$
+  BEGIN
+    BEGIN
+      ALTER TABLE kite.key ADD COLUMN "expires_at" timestamp(6) WITH TIME ZONE;
+    EXCEPTION
+      WHEN duplicate_column THEN RAISE NOTICE 'expires_at column already exists';
+    END;
+  END;
+$$;
+
+--
+-- create revocation table for storing revoked kite keys
+--
+CREATE TABLE IF NOT EXISTS "kite"."revocation" (
+    id UUID NOT NULL DEFAULT uuid_generate_v4(),
+    kite_id UUID, -- revokes kite key of a single kite
+    username TEXT COLLATE "default", -- revokes kite keys of all kites of the user
+    reason TEXT NOT NULL DEFAULT '' COLLATE "default",
+    created_by TEXT NOT NULL COLLATE "default",
+    created_at timestamp(6) WITH TIME ZONE NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+
+    -- create constraints along with table creation
+    PRIMARY KEY ("id") NOT DEFERRABLE INITIALLY IMMEDIATE,
+    CONSTRAINT "revocation_kite_id_or_username_check" CHECK (kite_id IS NOT NULL OR username IS NOT NULL)
+) WITH (OIDS = FALSE);
+
+GRANT SELECT, INSERT ON "kite"."revocation" TO "kontrol"; -- revocations are permanent
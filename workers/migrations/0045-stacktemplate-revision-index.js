@@ -0,0 +1,19 @@
+
+var mongodb = require('mongodb');
+
+exports.up = function(db, next){
+  var index = {
+    "name": "templateId_revision",
+    "key": {
+      "templateId": 1,
+      "revision": 1
+    },
+    "ns": "koding.jStackTemplateRevisions",
+    "unique": true
+  }
+  db.ensureIndex("jStackTemplateRevisions", index.key, index, next);
+};
+
+exports.down = function(db, next){
+  next();
+};